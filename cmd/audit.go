@@ -0,0 +1,65 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+
+	"flight-itinerary-go/internal/audit"
+)
+
+const auditUsage = `Usage: flight-itinerary audit <command> [options]
+
+Commands:
+  verify [--dir DIR] [--anchor SEQ:HASH]     check the hash chain of the audit log
+
+The audit log directory is audit.dir (env AUDIT_LOG_DIR, default audit). An anchor is an event
+the chain must contain, such as the head the server logs at shutdown; it detects events cut from
+the end.
+`
+
+// runAudit inspects the audit log from the command line and returns the exit code: 0 when the
+// chain is intact, 1 when it is broken or cannot be read
+func runAudit(args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 || args[0] != "verify" {
+		fmt.Fprint(stderr, auditUsage)
+		return 2
+	}
+	appConfig, err := loadConfig()
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+	flags := flag.NewFlagSet("audit verify", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	dir := flags.String("dir", appConfig.Audit.Dir, "audit log directory")
+	anchor := flags.String("anchor", "", "event the chain must contain, as SEQ:HASH")
+	if err := flags.Parse(args[1:]); err != nil {
+		return 2
+	}
+	var anchors []audit.Anchor
+	if *anchor != "" {
+		parsed, err := audit.ParseAnchor(*anchor)
+		if err != nil {
+			fmt.Fprintln(stderr, err)
+			return 2
+		}
+		anchors = append(anchors, parsed)
+	}
+
+	report, err := audit.Verify(*dir, anchors...)
+	if err != nil {
+		fmt.Fprintf(stderr, "failed to verify audit log: %v\n", err)
+		return 1
+	}
+	fmt.Fprintf(stdout, "Checked %d events in %d files\n", report.Events, report.Files)
+	fmt.Fprintf(stdout, "Last event %d, head %s\n", report.Last, report.Head)
+	for _, problem := range report.Problems {
+		fmt.Fprintf(stdout, "TAMPERED %s\n", problem)
+	}
+	if !report.Valid() {
+		return 1
+	}
+	fmt.Fprintln(stdout, "Audit log intact")
+	return 0
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+
+	"gopkg.in/yaml.v3"
+
+	"flight-itinerary-go/internal/config"
+)
+
+const configUsage = `Usage: flight-itinerary config <command> [options]
+
+Commands:
+  print [--format text|json|yaml] [--config FILE] [--KEY VALUE ...]
+                                             print the effective settings and where they come from
+
+Settings merge defaults, the config file (--config or CONFIG_FILE), environment variables and
+flags, in increasing precedence. The server accepts the same flags, e.g. --server.address :8443.
+`
+
+// runConfig shows the configuration from the command line and returns the exit code: 0 when the
+// configuration is valid, 1 when it is not
+func runConfig(args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 || args[0] != "print" {
+		fmt.Fprint(stderr, configUsage)
+		return 2
+	}
+	loader := config.NewLoader(os.LookupEnv)
+	flags := flag.NewFlagSet("config print", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	format := flags.String("format", "text", "text, json or yaml")
+	loader.RegisterFlags(flags)
+	if err := flags.Parse(args[1:]); err != nil {
+		return 2
+	}
+
+	appConfig, sources, err := loader.Load()
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+	switch *format {
+	case "text":
+		if file := loader.File(); file != "" {
+			fmt.Fprintf(stdout, "Config file: %s\n\n", file)
+		}
+		writer := tabwriter.NewWriter(stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(writer, "KEY\tVALUE\tSOURCE\tENV")
+		for _, setting := range appConfig.Settings(sources) {
+			fmt.Fprintf(writer, "%s\t%s\t%s\t%s\n", setting.Key, orDash(setting.Value), setting.Source, setting.Env)
+		}
+		writer.Flush()
+	case "json", "yaml":
+		data, err := json.MarshalIndent(appConfig.Redacted(), "", "  ")
+		if err == nil && *format == "yaml" {
+			// Converting through JSON keeps the keys of the config file
+			var document map[string]interface{}
+			decoder := json.NewDecoder(bytes.NewReader(data))
+			decoder.UseNumber()
+			if err = decoder.Decode(&document); err == nil {
+				data, err = yaml.Marshal(integers(document))
+			}
+		}
+		if err != nil {
+			fmt.Fprintf(stderr, "failed to encode configuration: %v\n", err)
+			return 1
+		}
+		fmt.Fprintln(stdout, string(data))
+	default:
+		fmt.Fprintf(stderr, "unknown format %q; use text, json or yaml\n", *format)
+		return 2
+	}
+	return 0
+}
+
+// integers turns JSON numbers back into integers, which is all the settings hold
+func integers(value interface{}) interface{} {
+	switch typed := value.(type) {
+	case map[string]interface{}:
+		for key, item := range typed {
+			typed[key] = integers(item)
+		}
+	case json.Number:
+		if number, err := typed.Int64(); err == nil {
+			return number
+		}
+	}
+	return value
+}
+
+// loadConfig reads the settings of the keys and audit commands from the config file named by
+// CONFIG_FILE and environment variables
+func loadConfig() (config.Config, error) {
+	appConfig, _, err := config.NewLoader(os.LookupEnv).Load()
+	return appConfig, err
+}
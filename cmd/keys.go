@@ -0,0 +1,106 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"flight-itinerary-go/internal/auth"
+)
+
+const keysUsage = `Usage: flight-itinerary keys <command> [options]
+
+Commands:
+  create --name NAME --scopes SCOPE[,SCOPE] [--tier TIER] [--tenant TENANT]
+                                             create a key and print it once
+  list                                       list keys
+  revoke ID                                  revoke a key
+
+The key file is auth.api_keys_path (env API_KEYS_PATH, default api-keys.json).
+Scopes: %s
+`
+
+// runKeys manages API keys from the command line and returns the exit code
+func runKeys(args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		fmt.Fprintf(stderr, keysUsage, strings.Join(auth.Scopes, ", "))
+		return 2
+	}
+	appConfig, err := loadConfig()
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+	store, err := auth.NewKeyStore(appConfig.Auth.APIKeysPath)
+	if err != nil {
+		fmt.Fprintf(stderr, "failed to open key store: %v\n", err)
+		return 1
+	}
+
+	switch args[0] {
+	case "create":
+		flags := flag.NewFlagSet("keys create", flag.ContinueOnError)
+		flags.SetOutput(stderr)
+		name := flags.String("name", "", "description of the key holder")
+		scopes := flags.String("scopes", auth.ScopeReconstruct, "comma-separated scopes")
+		tier := flags.String("tier", "", "rate limit tier; empty for the default tier")
+		tenant := flags.String("tenant", "", "tenant the key is bound to; empty to choose per request")
+		if err := flags.Parse(args[1:]); err != nil {
+			return 2
+		}
+		if *name == "" {
+			fmt.Fprintln(stderr, "--name is required")
+			return 2
+		}
+		plaintext, key, err := store.Create(*name, strings.Split(*scopes, ","), *tier, *tenant)
+		if err != nil {
+			fmt.Fprintf(stderr, "failed to create key: %v\n", err)
+			return 1
+		}
+		fmt.Fprintf(stdout, "Created key %s with scopes %s\n", key.ID, strings.Join(key.Scopes, ","))
+		fmt.Fprintln(stdout, "Store it now, it cannot be shown again:")
+		fmt.Fprintln(stdout, plaintext)
+	case "list":
+		keys, err := store.List()
+		if err != nil {
+			fmt.Fprintf(stderr, "failed to list keys: %v\n", err)
+			return 1
+		}
+		writer := tabwriter.NewWriter(stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(writer, "ID\tNAME\tSCOPES\tTIER\tTENANT\tCREATED\tREVOKED")
+		for _, key := range keys {
+			revoked := "-"
+			if key.RevokedAt != nil {
+				revoked = key.RevokedAt.Format(time.RFC3339)
+			}
+			fmt.Fprintf(writer, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n", key.ID, key.Name, strings.Join(key.Scopes, ","),
+				orDash(key.Tier), orDash(key.Tenant), key.CreatedAt.Format(time.RFC3339), revoked)
+		}
+		writer.Flush()
+	case "revoke":
+		if len(args) != 2 {
+			fmt.Fprintln(stderr, "Usage: flight-itinerary keys revoke ID")
+			return 2
+		}
+		if err := store.Revoke(args[1]); err != nil {
+			fmt.Fprintf(stderr, "failed to revoke key: %v\n", err)
+			return 1
+		}
+		fmt.Fprintf(stdout, "Revoked key %s\n", args[1])
+	default:
+		fmt.Fprintf(stderr, keysUsage, strings.Join(auth.Scopes, ", "))
+		return 2
+	}
+	return 0
+}
+
+// orDash shows unset optional columns as a dash
+func orDash(value string) string {
+	if value == "" {
+		return "-"
+	}
+	return value
+}
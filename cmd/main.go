@@ -2,12 +2,35 @@ package main
 
 import (
 	"context"
+	"crypto/tls"
+	"flag"
+	"flight-itinerary-go/internal/airport"
+	"flight-itinerary-go/internal/analytics"
+	"flight-itinerary-go/internal/audit"
+	"flight-itinerary-go/internal/auth"
+	"flight-itinerary-go/internal/cache"
+	"flight-itinerary-go/internal/config"
+	"flight-itinerary-go/internal/gql"
+	"flight-itinerary-go/internal/ratelimit"
+	"flight-itinerary-go/internal/render"
+	"flight-itinerary-go/internal/rpc"
 	"flight-itinerary-go/internal/service"
+	"flight-itinerary-go/internal/storage"
+	"flight-itinerary-go/internal/tenant"
+	"flight-itinerary-go/internal/tlsconfig"
+	"fmt"
 	"github.com/labstack/gommon/log"
 	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/reflection"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
+	"path/filepath"
+	"strconv"
+	"strings"
 	"syscall"
 	"time"
 
@@ -22,52 +45,337 @@ import (
 )
 
 // @Summary Get health status
-// @Description Simple health status api
+// @Description Simple health status api. The server reports itself degraded once audit events could
+// @Description not be written, so monitoring raises an alert.
 // @Tags Health
 // @Produce json
 // @Success 200 {object} map[string]string
+// @Failure 503 {object} map[string]string
 // @Router /api/v1/health/status [get]
-func GetHealthStatus(ctx echo.Context) error {
-	return ctx.JSON(http.StatusOK, map[string]string{
-		"status":  "healthy",
-		"service": "flight-itinerary-go",
-	})
+func GetHealthStatus(auditLog *audit.Log) echo.HandlerFunc {
+	return func(ctx echo.Context) error {
+		if failures := auditLog.Failures(); failures > 0 {
+			return ctx.JSON(http.StatusServiceUnavailable, map[string]string{
+				"status":         "degraded",
+				"service":        "flight-itinerary-go",
+				"audit_failures": strconv.FormatUint(failures, 10),
+			})
+		}
+		return ctx.JSON(http.StatusOK, map[string]string{
+			"status":  "healthy",
+			"service": "flight-itinerary-go",
+		})
+	}
 }
 
 func main() {
-	logger := logger.NewLogger()
+	if len(os.Args) > 1 && os.Args[1] == "keys" {
+		os.Exit(runKeys(os.Args[2:], os.Stdout, os.Stderr))
+	}
+	if len(os.Args) > 1 && os.Args[1] == "audit" {
+		os.Exit(runAudit(os.Args[2:], os.Stdout, os.Stderr))
+	}
+	if len(os.Args) > 1 && os.Args[1] == "config" {
+		os.Exit(runConfig(os.Args[2:], os.Stdout, os.Stderr))
+	}
+
+	// Settings merge defaults, the config file, environment variables and flags; invalid settings
+	// stop the server before anything starts
+	loader := config.NewLoader(os.LookupEnv)
+	flags := flag.NewFlagSet("flight-itinerary", flag.ExitOnError)
+	loader.RegisterFlags(flags)
+	flags.Parse(os.Args[1:])
+	appConfig, sources, err := loader.Load()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
+	// Sensitive log fields are hashed, masked or dropped; log.redact adjusts the default policies
+	loggerOptions := appConfig.Log.Options()
+	logger := logger.NewLogger(loggerOptions)
 	defer logger.Sync()
-	logger.Info("Initializing...")
+	logger.Info("Initializing...", zap.String("config_file", loader.File()),
+		zap.Stringer("level", loggerOptions.Level), zap.Stringer("redaction", loggerOptions.Redaction.Policies))
+
+	// Callers authenticate with API keys managed by the keys command
+	keyStore, err := auth.NewKeyStore(appConfig.Auth.APIKeysPath)
+	if err != nil {
+		log.Fatal("API key store initialization failed", zap.Error(err))
+	}
+	if err := keyStore.Watch(logger); err != nil {
+		logger.Warn("API key file is not watched; key changes apply after a restart", zap.Error(err))
+	}
+	defer keyStore.Close()
+	var authenticator auth.Authenticator = keyStore
+
+	// With a JWKS file, JWTs issued by the gateway are accepted as well
+	if path := appConfig.Auth.JWKSPath; path != "" {
+		jwks, err := auth.NewJWKS(path, logger)
+		if err != nil {
+			log.Fatal("JWKS initialization failed", zap.Error(err))
+		}
+		defer jwks.Close()
+		jwtConfig := appConfig.Auth.JWT
+		tokenOptions := auth.TokenOptions{
+			Issuer:      jwtConfig.Issuer,
+			Audience:    jwtConfig.Audience,
+			Leeway:      time.Duration(jwtConfig.Leeway),
+			RolesClaim:  jwtConfig.RolesClaim,
+			TierClaim:   jwtConfig.TierClaim,
+			TenantClaim: jwtConfig.TenantClaim,
+		}
+		if rolesPath := jwtConfig.RolesPath; rolesPath != "" {
+			if tokenOptions.Roles, err = auth.LoadRoles(rolesPath); err != nil {
+				log.Fatal("JWT roles initialization failed", zap.Error(err))
+			}
+		}
+		authenticator = auth.NewCompositeAuthenticator(keyStore, auth.NewTokenAuthenticator(jwks, tokenOptions, logger))
+		logger.Info("Accepting JWT bearer tokens", zap.String("jwks", path))
+	}
+
+	// With a certificate, both servers use TLS; with a client CA bundle, clients may authenticate
+	// with certificates instead of keys or tokens
+	var tlsConfig *tls.Config
+	if certFile := appConfig.TLS.CertFile; certFile != "" {
+		tlsOptions := tlsconfig.Options{
+			CertFile:     certFile,
+			KeyFile:      appConfig.TLS.KeyFile,
+			ClientCAFile: appConfig.TLS.ClientCAFile,
+			ClientAuth:   appConfig.TLS.ClientAuth,
+			MinVersion:   appConfig.TLS.MinVersion,
+		}
+		var certificateReloader *tlsconfig.CertificateReloader
+		tlsConfig, certificateReloader, err = tlsconfig.NewConfig(tlsOptions, logger)
+		if err != nil {
+			log.Fatal("TLS initialization failed", zap.Error(err))
+		}
+		defer certificateReloader.Close()
+		if tlsConfig.ClientCAs != nil {
+			authenticator, err = auth.NewCertificateAuthenticator(authenticator, appConfig.TLS.ClientScopes)
+			if err != nil {
+				log.Fatal("Client certificate scopes are invalid", zap.Error(err))
+			}
+		}
+		logger.Info("TLS enabled", zap.String("cert_file", certFile),
+			zap.Bool("client_certificates", tlsConfig.ClientCAs != nil))
+	}
+	requestAuthenticator := customMiddleware.NewRequestAuthenticator(authenticator, logger)
+
+	// Every successful reconstruction is recorded for analytics
+	recorder := analytics.NewAnalytics(analytics.Options{
+		Anonymize: appConfig.Analytics.Anonymize,
+	})
+
+	// Repeated ticket sets are served from the result cache; hits skip reconstruction and analytics
+	resultCache := cache.NewCache(cache.Options{
+		MaxBytes: appConfig.Cache.MaxBytes,
+		TTL:      time.Duration(appConfig.Cache.TTL),
+	})
+
+	if _, err := airport.Embedded(); err != nil {
+		log.Fatal("Embedded airport data is invalid", zap.Error(err))
+	}
+
+	// The rate limit policy, tenant files and airports file are read at startup and on reload.
+	// Requests are limited per caller and route, and submitted tickets per caller and day.
+	referenceData, err := loadReferenceData(appConfig)
+	if err != nil {
+		log.Fatal("Reference data initialization failed", zap.Error(err))
+	}
+	airports := airport.NewReloadableDirectory(referenceData.directory())
+
+	// Each tenant gets its own itinerary store, cache namespace, rate limits, validation rules and
+	// airport overrides; requests naming no tenant are served by the default tenant
+	tenantDependencies := tenant.Dependencies{
+		ItineraryService: analytics.NewRecordingService(service.NewItineraryService(logger), recorder),
+		Cache:            resultCache,
+		Airports:         airports,
+		Policy:           referenceData.policy,
+		TicketUsage:      ratelimit.NewTicketUsage(),
+		Repository:       openRepository(appConfig.Storage.ItineraryPath, logger),
+		Logger:           logger,
+	}
+	tenants, err := tenant.NewRegistry(tenantDependencies, referenceData.tenants...)
+	if err != nil {
+		log.Fatal("Tenant initialization failed", zap.Error(err))
+	}
+	defer tenants.Close()
+	defaultTenant := tenants.Default()
+	logger.Info("Serving tenants", zap.Int("count", len(tenants.List())))
+
+	// Reconstructions and stored itinerary changes are recorded in a hash-chained audit log
+	auditLog, err := audit.NewLog(audit.Options{
+		Dir:      appConfig.Audit.Dir,
+		MaxBytes: appConfig.Audit.MaxBytes,
+		MaxAge:   time.Duration(appConfig.Audit.MaxAge),
+	})
+	if err != nil {
+		log.Fatal("Audit log initialization failed", zap.Error(err))
+	}
+	defer func() {
+		auditLog.Close()
+		logger.Info("Audit log closed; verify with audit verify --anchor", zap.Stringer("anchor", auditLog.Head()))
+	}()
 
-	// Initialize services
-	itineraryService := service.NewItineraryService(logger)
+	// Initialize services; the default tenant's service follows reloads
+	itineraryService := tenants.DefaultService()
+	tripService := defaultTenant.TripService
+	limiter := defaultTenant.Limiter
 
 	// Initialize handlers
 	itineraryHandler := handler.NewItineraryHandler(itineraryService, logger)
+	corsPolicy := customMiddleware.NewCORSPolicy(corsConfig(appConfig.CORS))
+	ticketCounter := customMiddleware.NewTicketCounter(limiter, logger)
+	sessionHandler := handler.NewSessionHandler(corsPolicy.AllowsOrigin, ticketCounter, logger)
+	tripHandler := handler.NewTripHandler(tripService, ticketCounter, logger)
+	analyticsHandler := handler.NewAnalyticsHandler(recorder, logger)
+	cacheHandler := handler.NewCacheHandler(resultCache)
+	tenantHandler := handler.NewTenantHandler(tenants, logger)
+	graphQLHandler := handler.NewGraphQLHandler(defaultTenant.Schema, gql.Limits{
+		MaxDepth:      appConfig.GraphQL.MaxDepth,
+		MaxComplexity: appConfig.GraphQL.MaxComplexity,
+	}, ticketCounter, logger)
 
 	itineraryRequestValidator := customMiddleware.NewItineraryValidator(logger)
+	identify := requestAuthenticator.Identify()
+	tenancy := customMiddleware.Tenancy(tenants, logger)
+	rateLimit := customMiddleware.RateLimit(limiter, logger)
+	ticketQuota := customMiddleware.TicketQuota(limiter, logger)
+	etag := customMiddleware.ETag()
+	audited := func(action string) echo.MiddlewareFunc {
+		return customMiddleware.Audit(auditLog, action, logger)
+	}
+	idempotency := customMiddleware.Idempotency(
+		customMiddleware.NewIdempotencyStore(time.Duration(appConfig.Idempotency.Retention)), logger)
+	itineraryRenderers := render.NewItineraryRegistry(airports)
+	graphRenderers := render.NewGraphRegistry()
 	echoServer := echo.New()
+	// Callers are identified by the connection address unless it belongs to a trusted proxy
+	echoServer.IPExtractor = ipExtractor(appConfig.Server.TrustedProxies)
 
 	//Global middleware
 	echoServer.Use(middleware.Recover())
-	echoServer.Use(middleware.CORS())
+	echoServer.Use(corsPolicy.Apply())
 	echoServer.Use(customMiddleware.LoggingMiddleware(logger))
 
 	// Routes
-	v1 := echoServer.Group("/api/v1")
+	v1 := echoServer.Group("/api/v1", identify, tenancy, rateLimit)
 	{
-		v1.GET("/health/status", GetHealthStatus)
+		v1.GET("/health/status", GetHealthStatus(auditLog))
 		v1.POST("/itinerary/reconstruct", itineraryHandler.ReconstructItinerary,
-			itineraryRequestValidator.Validate())
+			requestAuthenticator.Require(auth.ScopeReconstruct), audited(audit.ActionReconstruct),
+			customMiddleware.ItineraryContentNegotiation(itineraryRenderers, logger), etag,
+			itineraryRequestValidator.Validate(), ticketQuota)
+		v1.POST("/itinerary/graph", itineraryHandler.RenderGraph,
+			requestAuthenticator.Require(auth.ScopeReconstruct), audited(audit.ActionReconstruct),
+			customMiddleware.ContentNegotiation(graphRenderers, logger), etag,
+			itineraryRequestValidator.Validate(), ticketQuota)
+		v1.POST("/itinerary/map", itineraryHandler.RenderMap,
+			requestAuthenticator.Require(auth.ScopeReconstruct), audited(audit.ActionReconstruct), etag,
+			itineraryRequestValidator.Validate(), ticketQuota)
+		v1.GET("/itinerary/session", sessionHandler.Connect, requestAuthenticator.Require(auth.ScopeReconstruct),
+			audited(audit.ActionReconstruct))
+		v1.POST("/itineraries", tripHandler.Create, requestAuthenticator.Require(auth.ScopeWrite),
+			idempotency, audited(audit.ActionCreate), itineraryRequestValidator.Validate(), ticketQuota)
+		v1.GET("/itineraries", tripHandler.List, requestAuthenticator.Require(auth.ScopeRead), etag)
+		v1.GET("/itineraries/:id", tripHandler.Get, requestAuthenticator.Require(auth.ScopeRead))
+		v1.PUT("/itineraries/:id/tickets", tripHandler.UpdateTickets, requestAuthenticator.Require(auth.ScopeWrite),
+			idempotency, audited(audit.ActionUpdate), itineraryRequestValidator.Validate(), ticketQuota)
+		v1.PATCH("/itineraries/:id/tickets", tripHandler.PatchTickets, requestAuthenticator.Require(auth.ScopeWrite),
+			idempotency, audited(audit.ActionUpdate))
+		v1.GET("/itineraries/:id/versions", tripHandler.History, requestAuthenticator.Require(auth.ScopeRead), etag)
+		v1.POST("/itineraries/:id/versions/:version/revert", tripHandler.Revert,
+			requestAuthenticator.Require(auth.ScopeWrite), idempotency, audited(audit.ActionRevert))
+		v1.DELETE("/itineraries/:id", tripHandler.Delete, requestAuthenticator.Require(auth.ScopeWrite),
+			idempotency, audited(audit.ActionDelete))
+		v1.GET("/analytics/routes", analyticsHandler.TopRoutes, requestAuthenticator.Require(auth.ScopeAnalyticsRead), etag)
+		v1.GET("/analytics/hubs", analyticsHandler.TopHubs, requestAuthenticator.Require(auth.ScopeAnalyticsRead), etag)
+		v1.GET("/analytics/summary", analyticsHandler.Summary, requestAuthenticator.Require(auth.ScopeAnalyticsRead), etag)
+		v1.GET("/analytics/traffic", analyticsHandler.Traffic, requestAuthenticator.Require(auth.ScopeAnalyticsRead), etag)
+		v1.GET("/analytics/export", analyticsHandler.Export, requestAuthenticator.Require(auth.ScopeAnalyticsRead))
+		v1.GET("/cache/stats", cacheHandler.Stats, requestAuthenticator.Require(auth.ScopeAdmin))
+		v1.GET("/tenants", tenantHandler.List, requestAuthenticator.Require(auth.ScopeAdmin))
+		v1.GET("/tenants/:id", tenantHandler.Get, requestAuthenticator.Require(auth.ScopeAdmin))
 	}
+	echoServer.POST("/graphql", graphQLHandler.Query, identify, tenancy, rateLimit,
+		requestAuthenticator.Require(auth.ScopeReconstruct), audited(audit.ActionReconstruct))
 	echoServer.GET("/swagger/*", echoSwagger.WrapHandler)
 
+	// gRPC calls are served by their tenant like REST requests
+	grpcOptions := []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(rpc.LoggingUnaryInterceptor(logger),
+			rpc.AuthUnaryInterceptor(authenticator, auth.ScopeReconstruct, logger),
+			rpc.TenancyUnaryInterceptor(tenants, logger),
+			rpc.RateLimitUnaryInterceptor(limiter, logger),
+			rpc.AuditUnaryInterceptor(auditLog, logger)),
+		grpc.ChainStreamInterceptor(rpc.LoggingStreamInterceptor(logger),
+			rpc.AuthStreamInterceptor(authenticator, auth.ScopeReconstruct, logger),
+			rpc.TenancyStreamInterceptor(tenants, logger),
+			rpc.RateLimitStreamInterceptor(limiter, logger),
+			rpc.AuditStreamInterceptor(auditLog, logger)),
+	}
+	if tlsConfig != nil {
+		grpcOptions = append(grpcOptions, grpc.Creds(credentials.NewTLS(tlsConfig)))
+	}
+	grpcServer := grpc.NewServer(grpcOptions...)
+	rpc.NewItineraryServer(itineraryService, logger).Register(grpcServer)
+	reflection.Register(grpcServer)
+
 	// Graceful shutdown
 	go func() {
-		if err := echoServer.Start(":8080"); err != nil && err != http.ErrServerClosed {
+		var err error
+		if tlsConfig != nil {
+			// HTTP/2 is negotiated over TLS
+			echoServer.TLSServer.Addr = appConfig.Server.Address
+			echoServer.TLSServer.TLSConfig = tlsConfig
+			err = echoServer.StartServer(echoServer.TLSServer)
+		} else {
+			err = echoServer.Start(appConfig.Server.Address)
+		}
+		if err != nil && err != http.ErrServerClosed {
 			log.Fatal("Server startup failed", zap.Error(err))
 		}
 	}()
+	go func() {
+		listener, err := net.Listen("tcp", appConfig.Server.GRPCAddress)
+		if err != nil {
+			log.Fatal("gRPC listener startup failed", zap.Error(err))
+		}
+		logger.Info("gRPC server started", zap.String("address", listener.Addr().String()))
+		if err := grpcServer.Serve(listener); err != nil && err != grpc.ErrServerStopped {
+			log.Fatal("gRPC server startup failed", zap.Error(err))
+		}
+	}()
+
+	// Log level, CORS, rate limits, tenant rules and airports are reloaded on SIGHUP and, unless
+	// reload.watch is off, when their files change; connections are kept
+	configReloader := &reloader{
+		loader:       loader,
+		config:       appConfig,
+		sources:      sources,
+		data:         referenceData,
+		level:        loggerOptions.Level,
+		cors:         corsPolicy,
+		airports:     airports,
+		tenants:      tenants,
+		dependencies: tenantDependencies,
+		logger:       logger,
+	}
+	if appConfig.Reload.Watch {
+		stopWatching, err := configReloader.Watch()
+		if err != nil {
+			log.Fatal("Configuration watch initialization failed", zap.Error(err))
+		}
+		defer stopWatching()
+	}
+	hangup := make(chan os.Signal, 1)
+	signal.Notify(hangup, syscall.SIGHUP)
+	go func() {
+		for range hangup {
+			configReloader.Reload(triggerSignal)
+		}
+	}()
 
 	// Wait for interrupt signal
 	quit := make(chan os.Signal, 1)
@@ -76,12 +384,60 @@ func main() {
 
 	log.Info("Shutting down server...")
 
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(appConfig.Server.ShutdownTimeout))
 	defer cancel()
 
+	grpcStopped := make(chan struct{})
+	go func() {
+		grpcServer.GracefulStop()
+		close(grpcStopped)
+	}()
+
 	if err := echoServer.Shutdown(ctx); err != nil {
 		log.Fatal("Server forced to shutdown", zap.Error(err))
 	}
 
+	select {
+	case <-grpcStopped:
+	case <-ctx.Done():
+		grpcServer.Stop()
+	}
+
 	log.Info("Server exited")
 }
+
+// openRepository opens a tenant's itinerary store. Stores are kept in memory unless a database file
+// is configured; tenants other than the default one get a file next to it named after the tenant.
+func openRepository(storePath string, logger *zap.Logger) func(id string) (storage.Repository, error) {
+	return func(id string) (storage.Repository, error) {
+		path := storePath
+		if path == "" {
+			return storage.NewMemoryRepository(), nil
+		}
+		if id != tenant.DefaultID {
+			extension := filepath.Ext(path)
+			path = strings.TrimSuffix(path, extension) + "-" + id + extension
+		}
+		repository, err := storage.NewBoltRepository(path)
+		if err != nil {
+			return nil, err
+		}
+		logger.Info("Using file-backed itinerary store", zap.String("tenant", id), zap.String("path", path))
+		return repository, nil
+	}
+}
+
+// ipExtractor reads the client address from X-Forwarded-For only when the connection comes from
+// one of the trusted proxy ranges, so clients cannot choose the address they are limited by. The
+// ranges are valid once the configuration is loaded.
+func ipExtractor(trustedProxies []string) echo.IPExtractor {
+	if len(trustedProxies) == 0 {
+		return echo.ExtractIPDirect()
+	}
+	options := []echo.TrustOption{echo.TrustLoopback(false), echo.TrustLinkLocal(false), echo.TrustPrivateNet(false)}
+	for _, proxy := range trustedProxies {
+		_, network, _ := net.ParseCIDR(proxy)
+		options = append(options, echo.TrustIPRange(network))
+	}
+	return echo.ExtractIPFromXFFHeader(options...)
+}
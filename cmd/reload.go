@@ -0,0 +1,310 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/labstack/echo/v4/middleware"
+	"go.uber.org/zap"
+
+	"flight-itinerary-go/internal/airport"
+	"flight-itinerary-go/internal/config"
+	customMiddleware "flight-itinerary-go/internal/middleware"
+	"flight-itinerary-go/internal/ratelimit"
+	"flight-itinerary-go/internal/tenant"
+)
+
+// Reload triggers
+const (
+	triggerSignal = "SIGHUP"
+	triggerFile   = "file change"
+)
+
+// watchDelay lets an editor finish writing a file before it is read
+const watchDelay = 500 * time.Millisecond
+
+// referenceData is what the server reads from the files the settings name
+type referenceData struct {
+	policy   ratelimit.Policy
+	tenants  []tenant.Config
+	airports []airport.Airport
+}
+
+// loadReferenceData reads the rate limit policy, tenant files and airports file
+func loadReferenceData(appConfig config.Config) (referenceData, error) {
+	data := referenceData{policy: ratelimit.DefaultPolicy()}
+	var err error
+	if path := appConfig.RateLimits.Path; path != "" {
+		if data.policy, err = ratelimit.LoadPolicy(path); err != nil {
+			return referenceData{}, fmt.Errorf("rate limit policy: %w", err)
+		}
+	}
+	if dir := appConfig.Tenants.Dir; dir != "" {
+		if data.tenants, err = tenant.LoadConfigs(dir); err != nil {
+			return referenceData{}, fmt.Errorf("tenants: %w", err)
+		}
+	}
+	if path := appConfig.Airports.Path; path != "" {
+		if data.airports, err = airport.LoadAirports(path); err != nil {
+			return referenceData{}, fmt.Errorf("airports: %w", err)
+		}
+	}
+	return data, nil
+}
+
+// directory returns the built-in airports with the airports file applied over them
+func (data referenceData) directory() airport.Directory {
+	return airport.NewOverlayDirectory(airport.NewDirectory(), data.airports)
+}
+
+// corsConfig returns the CORS middleware settings
+func corsConfig(cors config.CORS) middleware.CORSConfig {
+	return middleware.CORSConfig{
+		AllowOrigins:     cors.AllowOrigins,
+		AllowMethods:     cors.AllowMethods,
+		AllowHeaders:     cors.AllowHeaders,
+		ExposeHeaders:    cors.ExposeHeaders,
+		AllowCredentials: cors.AllowCredentials,
+		MaxAge:           cors.MaxAge,
+	}
+}
+
+// reloader applies changed settings and reference data to the running server. Everything is read
+// and checked before anything is applied, so an invalid change leaves the server as it was.
+type reloader struct {
+	mutex        sync.Mutex
+	loader       *config.Loader
+	config       config.Config
+	sources      config.Sources
+	data         referenceData
+	level        zap.AtomicLevel
+	cors         *customMiddleware.CORSPolicy
+	airports     *airport.ReloadableDirectory
+	tenants      *tenant.Registry
+	dependencies tenant.Dependencies
+	// reloaded is signalled after every applied reload while watching, so the watch follows moved files
+	reloaded chan struct{}
+	logger   *zap.Logger
+}
+
+// Reload loads the configuration again and applies it all at once, logging what changed
+func (reloader *reloader) Reload(trigger string) error {
+	reloader.mutex.Lock()
+	defer reloader.mutex.Unlock()
+	logger := reloader.logger.With(zap.String("trigger", trigger))
+
+	next, sources, err := reloader.loader.Load()
+	if err != nil {
+		logger.Error("Configuration reload rejected, keeping current configuration", zap.Error(err))
+		return err
+	}
+	data, err := loadReferenceData(next)
+	if err != nil {
+		logger.Error("Configuration reload rejected, keeping current configuration", zap.Error(err))
+		return err
+	}
+	// Everything is built before anything is swapped in, so a reload applies completely or not at all
+	directory := data.directory()
+	corsRules := customMiddleware.NewCORSRules(corsConfig(next.CORS))
+	dependencies := reloader.dependencies
+	dependencies.Policy = data.policy
+	staged, err := reloader.tenants.Stage(dependencies, data.tenants...)
+	if err != nil {
+		logger.Error("Configuration reload rejected, keeping current configuration", zap.Error(err))
+		return err
+	}
+	reloader.airports.Set(directory)
+	staged.Apply()
+	reloader.level.SetLevel(next.Log.ZapLevel())
+	reloader.cors.Set(corsRules)
+
+	changes, restart := diffSettings(reloader.config.Settings(reloader.sources), next.Settings(sources))
+	changes = append(changes, reloader.data.diff(data)...)
+	reloader.config, reloader.sources, reloader.data = next, sources, data
+	if reloader.reloaded != nil {
+		select {
+		case reloader.reloaded <- struct{}{}:
+		default:
+		}
+	}
+	if len(restart) > 0 {
+		logger.Warn("Changed settings take effect after a restart", zap.Strings("settings", restart))
+	}
+	if len(changes) == 0 {
+		logger.Info("Configuration reloaded without changes")
+		return nil
+	}
+	logger.Info("Configuration reloaded", zap.Strings("changes", changes))
+	return nil
+}
+
+// diffSettings describes the changed reloadable settings and names the changed settings that
+// need a restart
+func diffSettings(previous, next []config.Setting) (changes []string, restart []string) {
+	values := make(map[string]config.Setting, len(previous))
+	for _, setting := range previous {
+		values[setting.Key] = setting
+	}
+	for _, setting := range next {
+		old := values[setting.Key]
+		if old.SameValue(setting) {
+			continue
+		}
+		switch {
+		case !setting.Reloadable:
+			restart = append(restart, setting.Key)
+		case setting.Secret:
+			// Secrets are compared by value but never printed
+			changes = append(changes, setting.Key+" changed")
+		default:
+			changes = append(changes, fmt.Sprintf("%s: %q -> %q", setting.Key, old.Value, setting.Value))
+		}
+	}
+	return changes, restart
+}
+
+// diff describes what changed in the files since the previous data
+func (data referenceData) diff(next referenceData) []string {
+	var changes []string
+	if !reflect.DeepEqual(data.policy, next.policy) {
+		changes = append(changes, "rate limit policy changed")
+	}
+	previous := make(map[string]tenant.Config, len(data.tenants))
+	for _, config := range data.tenants {
+		previous[config.ID] = config
+	}
+	for _, config := range next.tenants {
+		old, exists := previous[config.ID]
+		switch {
+		case !exists:
+			changes = append(changes, fmt.Sprintf("tenant %s added", config.ID))
+		case !reflect.DeepEqual(old, config):
+			changes = append(changes, fmt.Sprintf("tenant %s changed", config.ID))
+		}
+	}
+	if !reflect.DeepEqual(data.airports, next.airports) {
+		changes = append(changes, fmt.Sprintf("airports changed: %d -> %d in file", len(data.airports), len(next.airports)))
+	}
+	return changes
+}
+
+// Watch reloads when the config file, rate limit policy, tenant files or airports file change,
+// until the returned function is called. Directories are watched because editors and secret
+// managers replace files by renaming. After each reload, the watch moves to the paths now configured.
+func (reloader *reloader) Watch() (func() error, error) {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+	reloader.mutex.Lock()
+	watched := reloader.watchedPaths()
+	reloader.reloaded = make(chan struct{}, 1)
+	reloaded := reloader.reloaded
+	reloader.mutex.Unlock()
+	for _, dir := range watchedDirs(watched) {
+		if err := watcher.Add(dir); err != nil {
+			watcher.Close()
+			return nil, fmt.Errorf("failed to watch %s: %w", dir, err)
+		}
+	}
+
+	go func() {
+		var timer *time.Timer
+		for {
+			select {
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				if !event.Has(fsnotify.Write|fsnotify.Create|fsnotify.Rename|fsnotify.Remove) ||
+					!matchesWatched(watched, event.Name) {
+					continue
+				}
+				if timer != nil {
+					timer.Stop()
+				}
+				timer = time.AfterFunc(watchDelay, func() {
+					reloader.Reload(triggerFile)
+				})
+			case <-reloaded:
+				reloader.mutex.Lock()
+				next := reloader.watchedPaths()
+				reloader.mutex.Unlock()
+				reloader.rewatch(watcher, next)
+				watched = next
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+				reloader.logger.Error("Configuration watch failed", zap.Error(err))
+			}
+		}
+	}()
+	return watcher.Close, nil
+}
+
+// rewatch moves the watch to the directories of the paths, retrying directories that could not be
+// watched before
+func (reloader *reloader) rewatch(watcher *fsnotify.Watcher, paths map[string]bool) {
+	current, next := watcher.WatchList(), watchedDirs(paths)
+	for _, dir := range next {
+		if slices.Contains(current, dir) {
+			continue
+		}
+		if err := watcher.Add(dir); err != nil {
+			reloader.logger.Error("Failed to watch configuration directory", zap.String("dir", dir), zap.Error(err))
+			continue
+		}
+		reloader.logger.Info("Watching configuration directory", zap.String("dir", dir))
+	}
+	for _, dir := range current {
+		if !slices.Contains(next, dir) {
+			watcher.Remove(dir)
+		}
+	}
+}
+
+// watchedPaths returns the files to watch, and the tenant directory, whose JSON files are watched
+func (reloader *reloader) watchedPaths() (paths map[string]bool) {
+	paths = map[string]bool{}
+	for _, path := range []string{reloader.loader.File(), reloader.config.RateLimits.Path, reloader.config.Airports.Path} {
+		if path != "" {
+			paths[filepath.Clean(path)] = false
+		}
+	}
+	if dir := reloader.config.Tenants.Dir; dir != "" {
+		paths[filepath.Clean(dir)] = true
+	}
+	return paths
+}
+
+// watchedDirs returns the directories holding the watched paths
+func watchedDirs(paths map[string]bool) []string {
+	seen := map[string]bool{}
+	var dirs []string
+	for path, isDir := range paths {
+		dir := path
+		if !isDir {
+			dir = filepath.Dir(path)
+		}
+		if !seen[dir] {
+			seen[dir] = true
+			dirs = append(dirs, dir)
+		}
+	}
+	return dirs
+}
+
+// matchesWatched reports whether the file is a watched file or a tenant file
+func matchesWatched(paths map[string]bool, name string) bool {
+	name = filepath.Clean(name)
+	if isDir, exists := paths[name]; exists && !isDir {
+		return true
+	}
+	return paths[filepath.Dir(name)] && filepath.Ext(name) == ".json"
+}
@@ -15,6 +15,268 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
+        "/api/v1/analytics/export": {
+            "get": {
+                "description": "Exports every recorded leg as CSV. Trip IDs are empty when analytics are anonymized.",
+                "produces": [
+                    "text/csv"
+                ],
+                "tags": [
+                    "Analytics"
+                ],
+                "summary": "Export Legs",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Recorded at or after (RFC 3339 or YYYY-MM-DD)",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Recorded before (RFC 3339 or YYYY-MM-DD)",
+                        "name": "to",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "recorded_at,trip_id,leg,source,destination",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/errors.AppError"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/analytics/hubs": {
+            "get": {
+                "description": "Returns the airports most frequently used for layovers",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Analytics"
+                ],
+                "summary": "Top Hubs",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Recorded at or after (RFC 3339 or YYYY-MM-DD)",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Recorded before (RFC 3339 or YYYY-MM-DD)",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "maximum": 100,
+                        "type": "integer",
+                        "default": 10,
+                        "description": "Number of hubs",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/analytics.AirportCount"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/errors.AppError"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/analytics/routes": {
+            "get": {
+                "description": "Returns the most frequently flown legs across reconstructed itineraries",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Analytics"
+                ],
+                "summary": "Top Routes",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Recorded at or after (RFC 3339 or YYYY-MM-DD)",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Recorded before (RFC 3339 or YYYY-MM-DD)",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "maximum": 100,
+                        "type": "integer",
+                        "default": 10,
+                        "description": "Number of routes",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/analytics.RouteCount"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/errors.AppError"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/analytics/summary": {
+            "get": {
+                "description": "Returns the number of trips and legs and the average legs per trip",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Analytics"
+                ],
+                "summary": "Trip Summary",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Recorded at or after (RFC 3339 or YYYY-MM-DD)",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Recorded before (RFC 3339 or YYYY-MM-DD)",
+                        "name": "to",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/analytics.Summary"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/errors.AppError"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/analytics/traffic": {
+            "get": {
+                "description": "Returns departures and arrivals per airport, bucketed by hour or day",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Analytics"
+                ],
+                "summary": "Airport Traffic",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Only this airport",
+                        "name": "airport",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "hour",
+                            "day"
+                        ],
+                        "type": "string",
+                        "default": "day",
+                        "description": "Bucket size",
+                        "name": "interval",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Recorded at or after (RFC 3339 or YYYY-MM-DD)",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Recorded before (RFC 3339 or YYYY-MM-DD)",
+                        "name": "to",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/analytics.Traffic"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/errors.AppError"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/cache/stats": {
+            "get": {
+                "description": "Returns hits, misses, bypasses, evictions and the size of the reconstruction result cache",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Itinerary"
+                ],
+                "summary": "Result Cache Stats",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/cache.Stats"
+                        }
+                    }
+                }
+            }
+        },
         "/api/v1/health/status": {
             "get": {
                 "description": "Simple health status api",
@@ -38,9 +300,86 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/v1/itinerary/reconstruct": {
+        "/api/v1/itineraries": {
+            "get": {
+                "description": "Lists stored itineraries in creation order. Filters combine; date filters only match\nitineraries saved with a departure.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Stored Itineraries"
+                ],
+                "summary": "List Itineraries",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Airport anywhere on the route",
+                        "name": "via",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Origin airport",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Final destination airport",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Direct leg flown, e.g. JFK-LAX",
+                        "name": "leg",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Departure at or after (RFC 3339 or YYYY-MM-DD)",
+                        "name": "departing_after",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Departure before (RFC 3339 or YYYY-MM-DD)",
+                        "name": "departing_before",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 0,
+                        "description": "Number of itineraries to skip",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "maximum": 100,
+                        "type": "integer",
+                        "default": 20,
+                        "description": "Page size",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ItineraryPage"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/errors.AppError"
+                        }
+                    }
+                }
+            },
             "post": {
-                "description": "Reconstructs the travel itinerary from a list of source-destination pairs",
+                "description": "Reconstructs the itinerary from the tickets and stores both under a new ID",
                 "consumes": [
                     "application/json"
                 ],
@@ -48,9 +387,9 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Itinerary"
+                    "Stored Itineraries"
                 ],
-                "summary": "Reconstruct Itinerary",
+                "summary": "Save Itinerary",
                 "parameters": [
                     {
                         "description": "Array of ticket pairs",
@@ -66,18 +405,1114 @@ const docTemplate = `{
                                 }
                             }
                         }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Departure time (RFC 3339 or YYYY-MM-DD)",
+                        "name": "departure",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Replays the first response to retries with the same key",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/storage.Itinerary"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/errors.AppError"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/errors.AppError"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/itineraries/{id}": {
+            "get": {
+                "description": "Returns a stored itinerary by ID. The ETag changes with every version.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Stored Itineraries"
+                ],
+                "summary": "Get Itinerary",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Itinerary ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of the copy the client holds",
+                        "name": "If-None-Match",
+                        "in": "header"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/storage.Itinerary"
+                        }
+                    },
+                    "304": {
+                        "description": "Not Modified"
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/errors.AppError"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Deletes a stored itinerary",
+                "tags": [
+                    "Stored Itineraries"
+                ],
+                "summary": "Delete Itinerary",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Itinerary ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Replays the first response to retries with the same key",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of the version being changed",
+                        "name": "If-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/errors.AppError"
+                        }
+                    },
+                    "412": {
+                        "description": "Precondition Failed",
+                        "schema": {
+                            "$ref": "#/definitions/errors.AppError"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/errors.AppError"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/itineraries/{id}/tickets": {
+            "put": {
+                "description": "Replaces the tickets of a stored itinerary and reconstructs it",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Stored Itineraries"
+                ],
+                "summary": "Update Itinerary Tickets",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Itinerary ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Array of ticket pairs",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
                         "schema": {
                             "type": "array",
                             "items": {
-                                "type": "string"
+                                "type": "array",
+                                "items": {
+                                    "type": "string"
+                                }
                             }
                         }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Replays the first response to retries with the same key",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of the version being changed",
+                        "name": "If-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/storage.Itinerary"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/errors.AppError"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/errors.AppError"
+                        }
+                    },
+                    "412": {
+                        "description": "Precondition Failed",
+                        "schema": {
+                            "$ref": "#/definitions/errors.AppError"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/errors.AppError"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "description": "Applies ticket operations in order and reconstructs the itinerary. Operations are \"add\"\n(ticket), \"replace\" (ticket, with) and \"void\" (ticket); replace and void target an existing\nticket by its source and destination. Either all operations are saved as one new version or,\nwhen an operation is invalid or the result cannot be reconstructed, none is.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Stored Itineraries"
+                ],
+                "summary": "Edit Itinerary Tickets",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Itinerary ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Ticket operations",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/model.TicketOperation"
+                            }
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Replays the first response to retries with the same key",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of the version being changed",
+                        "name": "If-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/storage.Itinerary"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/errors.AppError"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/errors.AppError"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/errors.AppError"
+                        }
+                    },
+                    "412": {
+                        "description": "Precondition Failed",
+                        "schema": {
+                            "$ref": "#/definitions/errors.AppError"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/errors.AppError"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/itineraries/{id}/versions": {
+            "get": {
+                "description": "Returns every version of a stored itinerary, oldest first",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Stored Itineraries"
+                ],
+                "summary": "Itinerary History",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Itinerary ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/storage.Itinerary"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/errors.AppError"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/itineraries/{id}/versions/{version}/revert": {
+            "post": {
+                "description": "Saves the tickets of an earlier version as a new version; the history is never rewritten",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Stored Itineraries"
+                ],
+                "summary": "Revert Itinerary",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Itinerary ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Version to revert to",
+                        "name": "version",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Replays the first response to retries with the same key",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of the version being changed",
+                        "name": "If-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/storage.Itinerary"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/errors.AppError"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/errors.AppError"
+                        }
+                    },
+                    "412": {
+                        "description": "Precondition Failed",
+                        "schema": {
+                            "$ref": "#/definitions/errors.AppError"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/errors.AppError"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/itinerary/graph": {
+            "post": {
+                "description": "Renders the ticket graph built during reconstruction as Graphviz DOT or Mermaid, highlighting\nthe chosen path, duplicate tickets, cycles and disconnected fragments. Failed reconstructions\nare rendered too, with the failure reason as the graph title.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "text/vnd.graphviz",
+                    "text/vnd.mermaid"
+                ],
+                "tags": [
+                    "Itinerary"
+                ],
+                "summary": "Render Ticket Graph",
+                "parameters": [
+                    {
+                        "description": "Array of ticket pairs",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "type": "array",
+                                "items": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    {
+                        "enum": [
+                            "dot",
+                            "mermaid"
+                        ],
+                        "type": "string",
+                        "default": "dot",
+                        "description": "Graph format, overrides the Accept header",
+                        "name": "format",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "406": {
+                        "description": "Not Acceptable",
+                        "schema": {
+                            "$ref": "#/definitions/errors.AppError"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/itinerary/map": {
+            "post": {
+                "description": "Reconstructs the itinerary and returns it as an SVG world map with numbered great-circle legs\nand labelled airports, suitable for embedding in reports and emails",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "image/svg+xml"
+                ],
+                "tags": [
+                    "Itinerary"
+                ],
+                "summary": "Render Route Map",
+                "parameters": [
+                    {
+                        "description": "Array of ticket pairs",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "type": "array",
+                                "items": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    {
+                        "enum": [
+                            "robinson",
+                            "equirectangular"
+                        ],
+                        "type": "string",
+                        "default": "robinson",
+                        "description": "Map projection",
+                        "name": "projection",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/itinerary/reconstruct": {
+            "post": {
+                "description": "Reconstructs the travel itinerary from a list of source-destination pairs. Results are cached by\nticket set regardless of ticket order; the X-Cache response header reports HIT, MISS or BYPASS.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json",
+                    "application/x-ndjson",
+                    "text/csv",
+                    "text/plain",
+                    "text/markdown",
+                    "application/yaml",
+                    "application/msgpack",
+                    "application/geo+json",
+                    "application/vnd.google-earth.kml+xml",
+                    "image/svg+xml"
+                ],
+                "tags": [
+                    "Itinerary"
+                ],
+                "summary": "Reconstruct Itinerary",
+                "parameters": [
+                    {
+                        "description": "Array of ticket pairs",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "type": "array",
+                                "items": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    {
+                        "enum": [
+                            "json",
+                            "ndjson",
+                            "csv",
+                            "text",
+                            "markdown",
+                            "yaml",
+                            "msgpack",
+                            "geojson",
+                            "kml",
+                            "svg"
+                        ],
+                        "type": "string",
+                        "description": "Response format, overrides the Accept header",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "no-cache or no-store skips the result cache",
+                        "name": "Cache-Control",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "type": "string"
+                            }
+                        },
+                        "headers": {
+                            "X-Cache": {
+                                "type": "string",
+                                "description": "HIT, MISS or BYPASS"
+                            }
+                        }
+                    },
+                    "406": {
+                        "description": "Not Acceptable",
+                        "schema": {
+                            "$ref": "#/definitions/errors.AppError"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/itinerary/session": {
+            "get": {
+                "description": "Opens a WebSocket session for entering tickets one at a time. Clients send\n{\"action\": \"add\"|\"remove\"|\"reset\", \"ticket\": [\"JFK\", \"LAX\"]} and receive\n{\"type\": \"state\", \"state\": {...}} after every change, with the itinerary once the tickets\nconnect, or the current fragments and diagnostics otherwise. Invalid messages are answered\nwith {\"type\": \"error\", \"error\": {...}} and leave the session unchanged.",
+                "tags": [
+                    "Itinerary"
+                ],
+                "summary": "Incremental Ticket Session",
+                "responses": {
+                    "101": {
+                        "description": "Switching Protocols",
+                        "schema": {
+                            "$ref": "#/definitions/session.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/tenants": {
+            "get": {
+                "description": "Lists the tenants served by this instance with their rules, airport overrides, rate limits\nand number of stored itineraries",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Tenants"
+                ],
+                "summary": "List Tenants",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/handler.TenantSummary"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/tenants/{id}": {
+            "get": {
+                "description": "Returns a tenant's rules, airport overrides, rate limits and number of stored itineraries",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Tenants"
+                ],
+                "summary": "Get Tenant",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Tenant ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handler.TenantSummary"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/errors.AppError"
+                        }
+                    }
+                }
+            }
+        },
+        "/graphql": {
+            "post": {
+                "description": "Executes a GraphQL query. Clients select only the enrichments they need (airport names, leg\ndistances, layovers, emissions). Queries exceeding the depth or complexity limits are rejected\nbefore execution.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "GraphQL"
+                ],
+                "summary": "GraphQL Query",
+                "parameters": [
+                    {
+                        "description": "GraphQL query",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handler.GraphQLRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/errors.AppError"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "airport.Airport": {
+            "type": "object",
+            "properties": {
+                "city": {
+                    "type": "string"
+                },
+                "code": {
+                    "type": "string"
+                },
+                "country": {
+                    "type": "string"
+                },
+                "latitude": {
+                    "type": "number"
+                },
+                "longitude": {
+                    "type": "number"
+                },
+                "name": {
+                    "type": "string"
+                }
+            }
+        },
+        "analytics.AirportCount": {
+            "type": "object",
+            "properties": {
+                "airport": {
+                    "type": "string"
+                },
+                "count": {
+                    "type": "integer"
+                }
+            }
+        },
+        "analytics.RouteCount": {
+            "type": "object",
+            "properties": {
+                "count": {
+                    "type": "integer"
+                },
+                "destination": {
+                    "type": "string"
+                },
+                "source": {
+                    "type": "string"
+                }
+            }
+        },
+        "analytics.Summary": {
+            "type": "object",
+            "properties": {
+                "average_legs": {
+                    "type": "number"
+                },
+                "legs": {
+                    "type": "integer"
+                },
+                "trips": {
+                    "type": "integer"
+                }
+            }
+        },
+        "analytics.Traffic": {
+            "type": "object",
+            "properties": {
+                "airport": {
+                    "type": "string"
+                },
+                "arrivals": {
+                    "type": "integer"
+                },
+                "departures": {
+                    "type": "integer"
+                },
+                "start": {
+                    "type": "string"
+                }
+            }
+        },
+        "cache.Stats": {
+            "type": "object",
+            "properties": {
+                "bypasses": {
+                    "type": "integer"
+                },
+                "bytes": {
+                    "type": "integer"
+                },
+                "entries": {
+                    "type": "integer"
+                },
+                "evictions": {
+                    "type": "integer"
+                },
+                "hit_ratio": {
+                    "type": "number"
+                },
+                "hits": {
+                    "type": "integer"
+                },
+                "max_bytes": {
+                    "type": "integer"
+                },
+                "misses": {
+                    "type": "integer"
+                }
+            }
+        },
+        "errors.AppError": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "integer"
+                },
+                "message": {
+                    "type": "string"
+                },
+                "type": {
+                    "type": "string"
+                }
+            }
+        },
+        "handler.GraphQLRequest": {
+            "type": "object",
+            "properties": {
+                "operationName": {
+                    "type": "string"
+                },
+                "query": {
+                    "type": "string"
+                },
+                "variables": {
+                    "type": "object",
+                    "additionalProperties": true
+                }
+            }
+        },
+        "handler.ItineraryPage": {
+            "type": "object",
+            "properties": {
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/storage.Itinerary"
+                    }
+                },
+                "limit": {
+                    "type": "integer"
+                },
+                "offset": {
+                    "type": "integer"
+                },
+                "total": {
+                    "type": "integer"
+                }
+            }
+        },
+        "handler.TenantSummary": {
+            "type": "object",
+            "properties": {
+                "airports": {
+                    "description": "Airports are the tenant's overrides of the reference airports",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/airport.Airport"
+                    }
+                },
+                "id": {
+                    "type": "string"
+                },
+                "itineraries": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "rate_limits": {
+                    "description": "RateLimits is the policy in force for the tenant",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/ratelimit.Policy"
+                        }
+                    ]
+                },
+                "validation": {
+                    "$ref": "#/definitions/tenant.Validation"
+                }
+            }
+        },
+        "model.TicketOperation": {
+            "type": "object",
+            "properties": {
+                "op": {
+                    "type": "string"
+                },
+                "ticket": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "with": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "ratelimit.Limit": {
+            "type": "object",
+            "properties": {
+                "burst": {
+                    "type": "integer"
+                },
+                "rate": {
+                    "type": "number"
+                }
+            }
+        },
+        "ratelimit.Policy": {
+            "type": "object",
+            "properties": {
+                "default_tier": {
+                    "description": "DefaultTier applies to callers without a tier or with an unknown one",
+                    "type": "string"
+                },
+                "tiers": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "$ref": "#/definitions/ratelimit.Tier"
+                    }
+                }
+            }
+        },
+        "ratelimit.Tier": {
+            "type": "object",
+            "properties": {
+                "daily_tickets": {
+                    "description": "DailyTickets caps the tickets submitted per UTC day; zero means unlimited",
+                    "type": "integer"
+                },
+                "default": {
+                    "description": "Default applies to routes without their own limit",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/ratelimit.Limit"
+                        }
+                    ]
+                },
+                "routes": {
+                    "description": "Routes overrides the default by route, written as \"METHOD /path\" with the router's\nparameter syntax, e.g. \"GET /api/v1/itineraries/:id\"",
+                    "type": "object",
+                    "additionalProperties": {
+                        "$ref": "#/definitions/ratelimit.Limit"
+                    }
+                }
+            }
+        },
+        "session.Diagnostic": {
+            "type": "object",
+            "properties": {
+                "airport": {
+                    "type": "string"
+                },
+                "message": {
+                    "type": "string"
+                },
+                "ticket": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "type": {
+                    "type": "string"
+                }
+            }
+        },
+        "session.Response": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "$ref": "#/definitions/errors.AppError"
+                },
+                "state": {
+                    "$ref": "#/definitions/session.State"
+                },
+                "type": {
+                    "type": "string"
+                }
+            }
+        },
+        "session.State": {
+            "type": "object",
+            "properties": {
+                "complete": {
+                    "type": "boolean"
+                },
+                "diagnostics": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/session.Diagnostic"
+                    }
+                },
+                "fragments": {
+                    "description": "Fragments lists every chain of connecting tickets, ordered by departure airport",
+                    "type": "array",
+                    "items": {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        }
+                    }
+                },
+                "itinerary": {
+                    "description": "Itinerary is set once the tickets form a single connected route",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "tickets": {
+                    "type": "integer"
+                }
+            }
+        },
+        "storage.Itinerary": {
+            "type": "object",
+            "properties": {
+                "change": {
+                    "description": "Change describes the edit that produced this version",
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "departure": {
+                    "description": "Departure is optional; tickets carry no dates, so it is only known when provided",
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "route": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "tickets": {
+                    "type": "array",
+                    "items": {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        }
+                    }
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "version": {
+                    "description": "Version starts at 1 and increases with every change",
+                    "type": "integer"
+                }
+            }
+        },
+        "tenant.Validation": {
+            "type": "object",
+            "properties": {
+                "blocked_airports": {
+                    "description": "BlockedAirports are rejected as source or destination",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
                     }
+                },
+                "max_tickets": {
+                    "description": "MaxTickets caps the tickets per request; zero means unlimited",
+                    "type": "integer"
+                },
+                "require_known_airports": {
+                    "description": "RequireKnownAirports rejects airports missing from the tenant's airport directory",
+                    "type": "boolean"
                 }
             }
         }
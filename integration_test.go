@@ -2,18 +2,43 @@ package main_test
 
 import (
 	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
 	"encoding/json"
 	"flight-itinerary-go/internal/service"
+	"fmt"
 	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
 
+	"github.com/gorilla/websocket"
 	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
+	"flight-itinerary-go/internal/airport"
+	"flight-itinerary-go/internal/analytics"
+	"flight-itinerary-go/internal/audit"
+	"flight-itinerary-go/internal/auth"
+	"flight-itinerary-go/internal/cache"
+	"flight-itinerary-go/internal/enrichment"
+	"flight-itinerary-go/internal/gql"
 	"flight-itinerary-go/internal/handler"
+	appLogger "flight-itinerary-go/internal/logger"
 	customMiddleware "flight-itinerary-go/internal/middleware"
 	"flight-itinerary-go/internal/model"
+	"flight-itinerary-go/internal/ratelimit"
+	"flight-itinerary-go/internal/render"
+	"flight-itinerary-go/internal/session"
+	"flight-itinerary-go/internal/storage"
+	"flight-itinerary-go/internal/tenant"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
@@ -24,6 +49,13 @@ func TestIntegration(t *testing.T) {
 	RunSpecs(t, "Integration Suite")
 }
 
+// failingRecorder fails every audit write, like a full or unwritable disk
+type failingRecorder struct{}
+
+func (failingRecorder) Record(audit.Event) error {
+	return fmt.Errorf("disk full")
+}
+
 var _ = Describe("Integration Tests", func() {
 	var (
 		echoServer       *echo.Echo
@@ -35,13 +67,49 @@ var _ = Describe("Integration Tests", func() {
 	BeforeEach(func() {
 		logger = zap.NewExample()
 		echoServer = echo.New()
-		itineraryService = service.NewItineraryService(logger)
+		recorder := analytics.NewAnalytics(analytics.Options{})
+		resultCache := cache.NewCache(cache.Options{MaxBytes: 1 << 20, TTL: time.Minute})
+		itineraryService = cache.NewCachingService(
+			analytics.NewRecordingService(service.NewItineraryService(logger), recorder), resultCache)
 		itineraryHandler = handler.NewItineraryHandler(itineraryService, logger)
 		itineraryRequestValidator := customMiddleware.NewItineraryValidator(logger)
 		echoServer.POST("/api/v1/itinerary/reconstruct",
 			itineraryHandler.ReconstructItinerary,
+			customMiddleware.ContentNegotiation(render.NewItineraryRegistry(airport.NewDirectory()), logger),
+			customMiddleware.ETag(),
 			itineraryRequestValidator.Validate(),
 		)
+
+		schema, err := gql.NewSchema(itineraryService, enrichment.NewEnricher(airport.NewDirectory()), logger)
+		Expect(err).ToNot(HaveOccurred())
+		ticketCounter := customMiddleware.NewTicketCounter(ratelimit.NewLimiter(ratelimit.DefaultPolicy()), logger)
+		graphQLHandler := handler.NewGraphQLHandler(schema, gql.Limits{MaxDepth: 8, MaxComplexity: 50}, ticketCounter,
+			logger)
+		echoServer.POST("/graphql", graphQLHandler.Query)
+		echoServer.GET("/api/v1/itinerary/session", handler.NewSessionHandler(
+			customMiddleware.NewCORSPolicy(middleware.CORSConfig{AllowOrigins: []string{"https://app.example.com"}}).AllowsOrigin,
+			ticketCounter, logger).Connect)
+
+		idempotency := customMiddleware.Idempotency(customMiddleware.NewIdempotencyStore(time.Hour), logger)
+		tripHandler := handler.NewTripHandler(
+			service.NewTripService(itineraryService, storage.NewMemoryRepository(), logger), ticketCounter, logger)
+		echoServer.POST("/api/v1/itineraries", tripHandler.Create, idempotency, itineraryRequestValidator.Validate())
+		echoServer.GET("/api/v1/itineraries", tripHandler.List, customMiddleware.ETag())
+		echoServer.GET("/api/v1/itineraries/:id", tripHandler.Get)
+		echoServer.PUT("/api/v1/itineraries/:id/tickets", tripHandler.UpdateTickets, idempotency,
+			itineraryRequestValidator.Validate())
+		echoServer.PATCH("/api/v1/itineraries/:id/tickets", tripHandler.PatchTickets, idempotency)
+		echoServer.GET("/api/v1/itineraries/:id/versions", tripHandler.History)
+		echoServer.POST("/api/v1/itineraries/:id/versions/:version/revert", tripHandler.Revert, idempotency)
+		echoServer.DELETE("/api/v1/itineraries/:id", tripHandler.Delete, idempotency)
+
+		analyticsHandler := handler.NewAnalyticsHandler(recorder, logger)
+		echoServer.GET("/api/v1/analytics/routes", analyticsHandler.TopRoutes)
+		echoServer.GET("/api/v1/analytics/hubs", analyticsHandler.TopHubs)
+		echoServer.GET("/api/v1/analytics/summary", analyticsHandler.Summary)
+		echoServer.GET("/api/v1/analytics/traffic", analyticsHandler.Traffic)
+		echoServer.GET("/api/v1/analytics/export", analyticsHandler.Export)
+		echoServer.GET("/api/v1/cache/stats", handler.NewCacheHandler(resultCache).Stats)
 	})
 
 	Describe("End-to-End API Tests", func() {
@@ -152,5 +220,1134 @@ var _ = Describe("Integration Tests", func() {
 				Expect(rec.Code).To(Equal(http.StatusBadRequest))
 			})
 		})
+
+		Context("Content Negotiation", func() {
+			It("should render the itinerary in the format requested by Accept", func() {
+				reqBody, _ := json.Marshal([]model.Ticket{{"JFK", "LAX"}, {"LAX", "DXB"}})
+				req := httptest.NewRequest(http.MethodPost, "/api/v1/itinerary/reconstruct", bytes.NewReader(reqBody))
+				req.Header.Set("Content-Type", "application/json")
+				req.Header.Set("Accept", "text/plain")
+				rec := httptest.NewRecorder()
+
+				echoServer.ServeHTTP(rec, req)
+
+				Expect(rec.Code).To(Equal(http.StatusOK))
+				Expect(rec.Body.String()).To(HavePrefix("New York (JFK) → Los Angeles (LAX) → Dubai (DXB): 2 flights"))
+			})
+
+			It("should render validation errors in the format requested by the format parameter", func() {
+				req := httptest.NewRequest(http.MethodPost, "/api/v1/itinerary/reconstruct?format=yaml",
+					bytes.NewReader([]byte("[]")))
+				req.Header.Set("Content-Type", "application/json")
+				rec := httptest.NewRecorder()
+
+				echoServer.ServeHTTP(rec, req)
+
+				Expect(rec.Code).To(Equal(http.StatusBadRequest))
+				Expect(rec.Header().Get("Content-Type")).To(Equal("application/yaml"))
+				Expect(rec.Body.String()).To(ContainSubstring("type: validation_error"))
+			})
+
+			It("should return 406 for unsupported media types", func() {
+				reqBody, _ := json.Marshal([]model.Ticket{{"JFK", "LAX"}})
+				req := httptest.NewRequest(http.MethodPost, "/api/v1/itinerary/reconstruct", bytes.NewReader(reqBody))
+				req.Header.Set("Content-Type", "application/json")
+				req.Header.Set("Accept", "application/pdf")
+				rec := httptest.NewRecorder()
+
+				echoServer.ServeHTTP(rec, req)
+
+				Expect(rec.Code).To(Equal(http.StatusNotAcceptable))
+			})
+		})
+
+		Context("GraphQL Endpoint", func() {
+			query := func(body string) *httptest.ResponseRecorder {
+				req := httptest.NewRequest(http.MethodPost, "/graphql", bytes.NewReader([]byte(body)))
+				req.Header.Set("Content-Type", "application/json")
+				rec := httptest.NewRecorder()
+				echoServer.ServeHTTP(rec, req)
+				return rec
+			}
+
+			It("should return the selected enrichments", func() {
+				rec := query(`{"query": "{ reconstruct(tickets: [{source: \"JFK\", destination: \"LAX\"}]) { airports { name } } }"}`)
+
+				Expect(rec.Code).To(Equal(http.StatusOK))
+				Expect(rec.Body.String()).To(MatchJSON(`{"data": {"reconstruct": {"airports": [
+					{"name": "John F. Kennedy International Airport"},
+					{"name": "Los Angeles International Airport"}
+				]}}}`))
+			})
+
+			It("should reject queries over the complexity limit before executing them", func() {
+				tickets := `[{source: \"A\", destination: \"B\"}]`
+				rec := query(`{"query": "{ a: reconstruct(tickets: ` + tickets + `) { legs { from { name city country } to { name city country } } } ` +
+					`b: reconstruct(tickets: ` + tickets + `) { legs { from { name city country } to { name city country } } } ` +
+					`c: reconstruct(tickets: ` + tickets + `) { legs { from { name city country } to { name city country } } } }"}`)
+
+				Expect(rec.Code).To(Equal(http.StatusOK))
+				Expect(rec.Body.String()).To(ContainSubstring("query complexity"))
+				Expect(rec.Body.String()).To(ContainSubstring(`"type":"validation_error"`))
+				Expect(rec.Body.String()).To(ContainSubstring(`"data":null`))
+			})
+
+			It("should return 400 for a malformed request body", func() {
+				rec := query(`{"query": `)
+
+				Expect(rec.Code).To(Equal(http.StatusBadRequest))
+			})
+		})
+
+		Context("Incremental Ticket Session", func() {
+			It("should stream the updated itinerary after every change", func() {
+				server := httptest.NewServer(echoServer)
+				defer server.Close()
+
+				url := "ws" + strings.TrimPrefix(server.URL, "http") + "/api/v1/itinerary/session"
+				conn, _, err := websocket.DefaultDialer.Dial(url, nil)
+				Expect(err).ToNot(HaveOccurred())
+				defer conn.Close()
+
+				receive := func() session.Response {
+					var response session.Response
+					Expect(conn.ReadJSON(&response)).To(Succeed())
+					return response
+				}
+
+				Expect(receive().State.Tickets).To(Equal(0))
+
+				Expect(conn.WriteJSON(session.Message{Action: session.ActionAdd, Ticket: model.Ticket{"LAX", "DXB"}})).To(Succeed())
+				Expect(receive().State.Itinerary).To(Equal([]string{"LAX", "DXB"}))
+
+				Expect(conn.WriteJSON(session.Message{Action: session.ActionAdd, Ticket: model.Ticket{"SFO", "SJC"}})).To(Succeed())
+				response := receive()
+				Expect(response.State.Complete).To(BeFalse())
+				Expect(response.State.Fragments).To(HaveLen(2))
+
+				Expect(conn.WriteJSON(session.Message{Action: session.ActionAdd, Ticket: model.Ticket{"DXB", "SFO"}})).To(Succeed())
+				Expect(receive().State.Itinerary).To(Equal([]string{"LAX", "DXB", "SFO", "SJC"}))
+
+				Expect(conn.WriteMessage(websocket.TextMessage, []byte("not json"))).To(Succeed())
+				response = receive()
+				Expect(response.Type).To(Equal(session.ResponseError))
+				Expect(response.Error.Type).To(Equal("validation_error"))
+			})
+
+			It("should refuse sessions from origins outside the CORS policy", func() {
+				server := httptest.NewServer(echoServer)
+				defer server.Close()
+				url := "ws" + strings.TrimPrefix(server.URL, "http") + "/api/v1/itinerary/session"
+
+				_, response, err := websocket.DefaultDialer.Dial(url, http.Header{"Origin": {"https://evil.example.net"}})
+				Expect(err).To(HaveOccurred())
+				Expect(response.StatusCode).To(Equal(http.StatusForbidden))
+
+				conn, _, err := websocket.DefaultDialer.Dial(url, http.Header{"Origin": {"https://app.example.com"}})
+				Expect(err).ToNot(HaveOccurred())
+				conn.Close()
+			})
+		})
+
+		Context("Stored Itineraries", func() {
+			send := func(method, path string, body interface{}) *httptest.ResponseRecorder {
+				var reader *bytes.Reader
+				if body != nil {
+					reqBody, _ := json.Marshal(body)
+					reader = bytes.NewReader(reqBody)
+				} else {
+					reader = bytes.NewReader(nil)
+				}
+				req := httptest.NewRequest(method, path, reader)
+				req.Header.Set("Content-Type", "application/json")
+				rec := httptest.NewRecorder()
+				echoServer.ServeHTTP(rec, req)
+				return rec
+			}
+
+			It("should save, fetch, update, list and delete an itinerary", func() {
+				rec := send(http.MethodPost, "/api/v1/itineraries", []model.Ticket{{"LAX", "DXB"}, {"JFK", "LAX"}})
+				Expect(rec.Code).To(Equal(http.StatusCreated))
+				var saved storage.Itinerary
+				Expect(json.Unmarshal(rec.Body.Bytes(), &saved)).To(Succeed())
+				Expect(saved.ID).ToNot(BeEmpty())
+				Expect(saved.Route).To(Equal([]string{"JFK", "LAX", "DXB"}))
+				Expect(rec.Header().Get("Location")).To(Equal("/api/v1/itineraries/" + saved.ID))
+
+				rec = send(http.MethodGet, "/api/v1/itineraries/"+saved.ID, nil)
+				Expect(rec.Code).To(Equal(http.StatusOK))
+
+				rec = send(http.MethodPut, "/api/v1/itineraries/"+saved.ID+"/tickets",
+					[]model.Ticket{{"LAX", "DXB"}, {"JFK", "LAX"}, {"DXB", "SIN"}})
+				Expect(rec.Code).To(Equal(http.StatusOK))
+				var updated storage.Itinerary
+				Expect(json.Unmarshal(rec.Body.Bytes(), &updated)).To(Succeed())
+				Expect(updated.Route).To(Equal([]string{"JFK", "LAX", "DXB", "SIN"}))
+				Expect(updated.CreatedAt).To(Equal(saved.CreatedAt))
+
+				rec = send(http.MethodGet, "/api/v1/itineraries?limit=10", nil)
+				Expect(rec.Code).To(Equal(http.StatusOK))
+				var page handler.ItineraryPage
+				Expect(json.Unmarshal(rec.Body.Bytes(), &page)).To(Succeed())
+				Expect(page.Total).To(Equal(1))
+				Expect(page.Items[0].ID).To(Equal(saved.ID))
+
+				rec = send(http.MethodDelete, "/api/v1/itineraries/"+saved.ID, nil)
+				Expect(rec.Code).To(Equal(http.StatusNoContent))
+
+				rec = send(http.MethodGet, "/api/v1/itineraries/"+saved.ID, nil)
+				Expect(rec.Code).To(Equal(http.StatusNotFound))
+			})
+
+			It("should edit tickets, keep the version history and revert", func() {
+				rec := send(http.MethodPost, "/api/v1/itineraries", []model.Ticket{{"JFK", "LAX"}, {"LAX", "DXB"}})
+				var saved storage.Itinerary
+				Expect(json.Unmarshal(rec.Body.Bytes(), &saved)).To(Succeed())
+				Expect(saved.Version).To(Equal(1))
+
+				rec = send(http.MethodPatch, "/api/v1/itineraries/"+saved.ID+"/tickets", []model.TicketOperation{
+					{Op: model.OperationAdd, Ticket: model.Ticket{"DXB", "SIN"}},
+					{Op: model.OperationVoid, Ticket: model.Ticket{"JFK", "LAX"}},
+				})
+				Expect(rec.Code).To(Equal(http.StatusOK))
+				var patched storage.Itinerary
+				Expect(json.Unmarshal(rec.Body.Bytes(), &patched)).To(Succeed())
+				Expect(patched.Route).To(Equal([]string{"LAX", "DXB", "SIN"}))
+				Expect(patched.Version).To(Equal(2))
+
+				rec = send(http.MethodPatch, "/api/v1/itineraries/"+saved.ID+"/tickets", []model.TicketOperation{
+					{Op: model.OperationVoid, Ticket: model.Ticket{"JFK", "LAX"}},
+				})
+				Expect(rec.Code).To(Equal(http.StatusBadRequest))
+
+				rec = send(http.MethodPost, "/api/v1/itineraries/"+saved.ID+"/versions/1/revert", nil)
+				Expect(rec.Code).To(Equal(http.StatusOK))
+				var reverted storage.Itinerary
+				Expect(json.Unmarshal(rec.Body.Bytes(), &reverted)).To(Succeed())
+				Expect(reverted.Route).To(Equal([]string{"JFK", "LAX", "DXB"}))
+				Expect(reverted.Version).To(Equal(3))
+
+				rec = send(http.MethodGet, "/api/v1/itineraries/"+saved.ID+"/versions", nil)
+				Expect(rec.Code).To(Equal(http.StatusOK))
+				var history []storage.Itinerary
+				Expect(json.Unmarshal(rec.Body.Bytes(), &history)).To(Succeed())
+				Expect(history).To(HaveLen(3))
+				Expect(history[2].Change).To(Equal("reverted to version 1"))
+
+				Expect(send(http.MethodPost, "/api/v1/itineraries/"+saved.ID+"/versions/9/revert", nil).Code).
+					To(Equal(http.StatusNotFound))
+				Expect(send(http.MethodPost, "/api/v1/itineraries/"+saved.ID+"/versions/latest/revert", nil).Code).
+					To(Equal(http.StatusBadRequest))
+				Expect(send(http.MethodGet, "/api/v1/itineraries/missing/versions", nil).Code).
+					To(Equal(http.StatusNotFound))
+			})
+
+			It("should search itineraries by airport and departure", func() {
+				send(http.MethodPost, "/api/v1/itineraries?departure=2025-03-02", []model.Ticket{{"JFK", "DXB"}, {"DXB", "SIN"}})
+				send(http.MethodPost, "/api/v1/itineraries?departure=2025-03-05T08:30:00Z", []model.Ticket{{"LHR", "DXB"}})
+				send(http.MethodPost, "/api/v1/itineraries", []model.Ticket{{"JFK", "LAX"}})
+				send(http.MethodPost, "/api/v1/itineraries", []model.Ticket{{"sfo", "sea"}})
+
+				search := func(query string) handler.ItineraryPage {
+					rec := send(http.MethodGet, "/api/v1/itineraries?"+query, nil)
+					Expect(rec.Code).To(Equal(http.StatusOK))
+					var page handler.ItineraryPage
+					Expect(json.Unmarshal(rec.Body.Bytes(), &page)).To(Succeed())
+					return page
+				}
+				Expect(search("via=dxb").Total).To(Equal(2))
+				Expect(search("via=DXB&from=JFK").Items[0].Route).To(Equal([]string{"JFK", "DXB", "SIN"}))
+				Expect(search("leg=JFK-LAX").Total).To(Equal(1))
+				Expect(search("via=DXB&departing_after=2025-03-03").Items[0].Route).To(Equal([]string{"LHR", "DXB"}))
+				Expect(search("departing_before=2025-03-10").Total).To(Equal(2))
+				Expect(search("via=SEA").Total).To(Equal(1))
+				Expect(search("leg=sfo-sea").Total).To(Equal(1))
+
+				Expect(send(http.MethodGet, "/api/v1/itineraries?departing_after=tomorrow", nil).Code).To(Equal(http.StatusBadRequest))
+				Expect(send(http.MethodGet, "/api/v1/itineraries?leg=JFK", nil).Code).To(Equal(http.StatusBadRequest))
+				Expect(send(http.MethodPost, "/api/v1/itineraries?departure=soon", []model.Ticket{{"JFK", "LAX"}}).Code).
+					To(Equal(http.StatusBadRequest))
+			})
+
+			It("should replay retries carrying the same Idempotency-Key", func() {
+				remoteAddr := "192.0.2.1:1234"
+				create := func(key string, tickets []model.Ticket) *httptest.ResponseRecorder {
+					reqBody, _ := json.Marshal(tickets)
+					req := httptest.NewRequest(http.MethodPost, "/api/v1/itineraries", bytes.NewReader(reqBody))
+					req.Header.Set("Content-Type", "application/json")
+					req.Header.Set("Idempotency-Key", key)
+					req.RemoteAddr = remoteAddr
+					rec := httptest.NewRecorder()
+					echoServer.ServeHTTP(rec, req)
+					return rec
+				}
+
+				first := create("retry-1", []model.Ticket{{"JFK", "LAX"}})
+				Expect(first.Code).To(Equal(http.StatusCreated))
+				retry := create("retry-1", []model.Ticket{{"JFK", "LAX"}})
+				Expect(retry.Code).To(Equal(http.StatusCreated))
+				Expect(retry.Body.String()).To(Equal(first.Body.String()))
+				Expect(retry.Header().Get("Location")).To(Equal(first.Header().Get("Location")))
+				Expect(retry.Header().Get("Idempotent-Replayed")).To(Equal("true"))
+				Expect(send(http.MethodGet, "/api/v1/itineraries", nil).Body.String()).To(ContainSubstring(`"total":1`))
+
+				Expect(create("retry-1", []model.Ticket{{"JFK", "DXB"}}).Code).To(Equal(http.StatusUnprocessableEntity))
+
+				Expect(create("retry-2", []model.Ticket{{"JFK", "LAX"}, {"LAX", "JFK"}}).Code).To(Equal(http.StatusBadRequest))
+				Expect(create("retry-2", []model.Ticket{{"JFK", "LAX"}, {"LAX", "JFK"}}).Header().Get("Idempotent-Replayed")).
+					To(Equal("true"))
+
+				// Another caller's key of the same name is its own
+				remoteAddr = "198.51.100.7:4321"
+				other := create("retry-1", []model.Ticket{{"JFK", "DXB"}})
+				Expect(other.Code).To(Equal(http.StatusCreated))
+				Expect(other.Header().Get("Idempotent-Replayed")).To(BeEmpty())
+				Expect(other.Header().Get("Location")).ToNot(Equal(first.Header().Get("Location")))
+			})
+
+			It("should honor conditional requests against the itinerary version", func() {
+				rec := send(http.MethodPost, "/api/v1/itineraries", []model.Ticket{{"JFK", "LAX"}})
+				etag := rec.Header().Get("ETag")
+				Expect(etag).To(Equal(`"v1"`))
+				var saved storage.Itinerary
+				Expect(json.Unmarshal(rec.Body.Bytes(), &saved)).To(Succeed())
+				conditional := func(method, header, value string, body interface{}) *httptest.ResponseRecorder {
+					reqBody, _ := json.Marshal(body)
+					req := httptest.NewRequest(method, "/api/v1/itineraries/"+saved.ID+"/tickets", bytes.NewReader(reqBody))
+					if method == http.MethodGet {
+						req = httptest.NewRequest(method, "/api/v1/itineraries/"+saved.ID, nil)
+					}
+					req.Header.Set("Content-Type", "application/json")
+					req.Header.Set(header, value)
+					rec := httptest.NewRecorder()
+					echoServer.ServeHTTP(rec, req)
+					return rec
+				}
+
+				rec = conditional(http.MethodGet, "If-None-Match", etag, nil)
+				Expect(rec.Code).To(Equal(http.StatusNotModified))
+				Expect(rec.Body.Len()).To(BeZero())
+
+				rec = conditional(http.MethodPut, "If-Match", etag, []model.Ticket{{"JFK", "DXB"}})
+				Expect(rec.Code).To(Equal(http.StatusOK))
+				Expect(rec.Header().Get("ETag")).To(Equal(`"v2"`))
+
+				rec = conditional(http.MethodPut, "If-Match", etag, []model.Ticket{{"JFK", "SIN"}})
+				Expect(rec.Code).To(Equal(http.StatusPreconditionFailed))
+				rec = conditional(http.MethodPatch, "If-Match", `W/"v2"`,
+					[]model.TicketOperation{{Op: model.OperationVoid, Ticket: model.Ticket{"JFK", "DXB"}}})
+				Expect(rec.Code).To(Equal(http.StatusPreconditionFailed))
+
+				rec = conditional(http.MethodGet, "If-None-Match", etag, nil)
+				Expect(rec.Code).To(Equal(http.StatusOK))
+				Expect(rec.Header().Get("ETag")).To(Equal(`"v2"`))
+			})
+
+			It("should tag reconstructions and listings by content", func() {
+				reqBody, _ := json.Marshal([]model.Ticket{{"JFK", "LAX"}})
+				accept := ""
+				reconstruct := func(header, value string) *httptest.ResponseRecorder {
+					req := httptest.NewRequest(http.MethodPost, "/api/v1/itinerary/reconstruct", bytes.NewReader(reqBody))
+					req.Header.Set("Content-Type", "application/json")
+					if header != "" {
+						req.Header.Set(header, value)
+					}
+					if accept != "" {
+						req.Header.Set("Accept", accept)
+					}
+					rec := httptest.NewRecorder()
+					echoServer.ServeHTTP(rec, req)
+					return rec
+				}
+
+				first := reconstruct("", "")
+				etag := first.Header().Get("ETag")
+				Expect(etag).To(HavePrefix(`"`))
+				Expect(reconstruct("If-None-Match", etag).Code).To(Equal(http.StatusPreconditionFailed))
+				Expect(reconstruct("If-None-Match", `"other"`).Body.String()).To(Equal(first.Body.String()))
+				Expect(reconstruct("If-Match", `"other"`).Code).To(Equal(http.StatusPreconditionFailed))
+				Expect(reconstruct("If-Match", etag).Body.String()).To(Equal(first.Body.String()))
+
+				accept = "application/yaml"
+				failed := reconstruct("If-None-Match", "*")
+				Expect(failed.Code).To(Equal(http.StatusPreconditionFailed))
+				Expect(failed.Header().Get("Content-Type")).To(Equal("application/yaml"))
+				Expect(failed.Header().Get("ETag")).To(BeEmpty())
+				Expect(failed.Body.String()).To(ContainSubstring("If-None-Match"))
+				failed = reconstruct("If-Match", `"other"`)
+				Expect(failed.Header().Get("Content-Type")).To(Equal("application/yaml"))
+				Expect(failed.Header().Get("ETag")).To(BeEmpty())
+
+				listing := send(http.MethodGet, "/api/v1/itineraries", nil)
+				Expect(listing.Header().Get("ETag")).ToNot(BeEmpty())
+				req := httptest.NewRequest(http.MethodGet, "/api/v1/itineraries", nil)
+				req.Header.Set("If-None-Match", listing.Header().Get("ETag"))
+				unchanged := httptest.NewRecorder()
+				echoServer.ServeHTTP(unchanged, req)
+				Expect(unchanged.Code).To(Equal(http.StatusNotModified))
+				send(http.MethodPost, "/api/v1/itineraries", []model.Ticket{{"JFK", "LAX"}})
+				Expect(send(http.MethodGet, "/api/v1/itineraries", nil).Header().Get("ETag")).
+					ToNot(Equal(listing.Header().Get("ETag")))
+			})
+
+			It("should not save tickets that cannot be reconstructed", func() {
+				rec := send(http.MethodPost, "/api/v1/itineraries", []model.Ticket{{"JFK", "LAX"}, {"DXB", "SIN"}})
+
+				Expect(rec.Code).To(Equal(http.StatusBadRequest))
+				Expect(send(http.MethodGet, "/api/v1/itineraries", nil).Body.String()).To(ContainSubstring(`"total":0`))
+			})
+
+			It("should reject invalid pagination", func() {
+				Expect(send(http.MethodGet, "/api/v1/itineraries?limit=500", nil).Code).To(Equal(http.StatusBadRequest))
+				Expect(send(http.MethodGet, "/api/v1/itineraries?offset=-1", nil).Code).To(Equal(http.StatusBadRequest))
+			})
+		})
+
+		Context("Analytics", func() {
+			reconstruct := func(tickets []model.Ticket) {
+				reqBody, _ := json.Marshal(tickets)
+				req := httptest.NewRequest(http.MethodPost, "/api/v1/itinerary/reconstruct", bytes.NewReader(reqBody))
+				req.Header.Set("Content-Type", "application/json")
+				rec := httptest.NewRecorder()
+				echoServer.ServeHTTP(rec, req)
+				Expect(rec.Code).To(Equal(http.StatusOK))
+			}
+			get := func(path string) *httptest.ResponseRecorder {
+				rec := httptest.NewRecorder()
+				echoServer.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+				return rec
+			}
+
+			It("should report on reconstructed itineraries", func() {
+				reconstruct([]model.Ticket{{"JFK", "DXB"}, {"DXB", "SIN"}})
+				reconstruct([]model.Ticket{{"LHR", "DXB"}, {"DXB", "SIN"}, {"SIN", "SYD"}})
+
+				rec := get("/api/v1/analytics/routes?limit=1")
+				Expect(rec.Code).To(Equal(http.StatusOK))
+				Expect(rec.Body.String()).To(MatchJSON(`[{"source":"DXB","destination":"SIN","count":2}]`))
+
+				rec = get("/api/v1/analytics/hubs")
+				Expect(rec.Body.String()).To(MatchJSON(`[{"airport":"DXB","count":2},{"airport":"SIN","count":1}]`))
+
+				rec = get("/api/v1/analytics/summary")
+				Expect(rec.Body.String()).To(MatchJSON(`{"trips":2,"legs":5,"average_legs":2.5}`))
+
+				rec = get("/api/v1/analytics/traffic?airport=dxb&interval=hour")
+				var traffic []analytics.Traffic
+				Expect(json.Unmarshal(rec.Body.Bytes(), &traffic)).To(Succeed())
+				Expect(traffic).To(HaveLen(1))
+				Expect(traffic[0].Departures).To(Equal(2))
+				Expect(traffic[0].Arrivals).To(Equal(2))
+
+				rec = get("/api/v1/analytics/export")
+				Expect(rec.Code).To(Equal(http.StatusOK))
+				Expect(rec.Header().Get("Content-Type")).To(HavePrefix("text/csv"))
+				Expect(strings.Split(strings.TrimSpace(rec.Body.String()), "\n")).To(HaveLen(6))
+
+				Expect(get("/api/v1/analytics/summary?from=2999-01-01").Body.String()).
+					To(MatchJSON(`{"trips":0,"legs":0,"average_legs":0}`))
+			})
+
+			It("should reject invalid parameters", func() {
+				Expect(get("/api/v1/analytics/traffic?interval=week").Code).To(Equal(http.StatusBadRequest))
+				Expect(get("/api/v1/analytics/routes?limit=0").Code).To(Equal(http.StatusBadRequest))
+				Expect(get("/api/v1/analytics/summary?from=yesterday").Code).To(Equal(http.StatusBadRequest))
+			})
+		})
+
+		Context("Result Cache", func() {
+			reconstruct := func(tickets []model.Ticket, cacheControl string) *httptest.ResponseRecorder {
+				reqBody, _ := json.Marshal(tickets)
+				req := httptest.NewRequest(http.MethodPost, "/api/v1/itinerary/reconstruct", bytes.NewReader(reqBody))
+				req.Header.Set("Content-Type", "application/json")
+				if cacheControl != "" {
+					req.Header.Set("Cache-Control", cacheControl)
+				}
+				rec := httptest.NewRecorder()
+				echoServer.ServeHTTP(rec, req)
+				return rec
+			}
+
+			It("should serve reordered ticket sets from the cache", func() {
+				rec := reconstruct([]model.Ticket{{"LAX", "DXB"}, {"JFK", "LAX"}}, "")
+				Expect(rec.Header().Get("X-Cache")).To(Equal("MISS"))
+
+				rec = reconstruct([]model.Ticket{{"JFK", "LAX"}, {"LAX", "DXB"}}, "")
+				Expect(rec.Code).To(Equal(http.StatusOK))
+				Expect(rec.Header().Get("X-Cache")).To(Equal("HIT"))
+				Expect(rec.Body.String()).To(MatchJSON(`["JFK","LAX","DXB"]`))
+
+				rec = reconstruct([]model.Ticket{{"JFK", "LAX"}, {"LAX", "DXB"}}, "no-cache")
+				Expect(rec.Header().Get("X-Cache")).To(Equal("BYPASS"))
+
+				rec = httptest.NewRecorder()
+				echoServer.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/cache/stats", nil))
+				var stats cache.Stats
+				Expect(json.Unmarshal(rec.Body.Bytes(), &stats)).To(Succeed())
+				Expect(stats.Hits).To(Equal(uint64(1)))
+				Expect(stats.Misses).To(Equal(uint64(1)))
+				Expect(stats.Bypasses).To(Equal(uint64(1)))
+				Expect(stats.Entries).To(Equal(1))
+			})
+		})
+
+		Context("Authentication", func() {
+			var (
+				securedServer *echo.Echo
+				keyStore      *auth.KeyStore
+			)
+
+			BeforeEach(func() {
+				var err error
+				keyStore, err = auth.NewKeyStore(filepath.Join(GinkgoT().TempDir(), "api-keys.json"))
+				Expect(err).ToNot(HaveOccurred())
+				authenticator := customMiddleware.NewRequestAuthenticator(keyStore, logger)
+				securedServer = echo.New()
+				securedServer.POST("/api/v1/itinerary/reconstruct", itineraryHandler.ReconstructItinerary,
+					authenticator.Require(auth.ScopeReconstruct),
+					customMiddleware.NewItineraryValidator(logger).Validate())
+				securedServer.GET("/api/v1/cache/stats", handler.NewCacheHandler(
+					cache.NewCache(cache.Options{MaxBytes: 1 << 20})).Stats, authenticator.Require(auth.ScopeAdmin))
+			})
+
+			request := func(method, path, header, credential string) *httptest.ResponseRecorder {
+				req := httptest.NewRequest(method, path, strings.NewReader(`[["JFK","LAX"]]`))
+				req.Header.Set("Content-Type", "application/json")
+				if header != "" {
+					req.Header.Set(header, credential)
+				}
+				rec := httptest.NewRecorder()
+				securedServer.ServeHTTP(rec, req)
+				return rec
+			}
+
+			It("should reject requests without valid credentials", func() {
+				rec := request(http.MethodPost, "/api/v1/itinerary/reconstruct", "", "")
+				Expect(rec.Code).To(Equal(http.StatusUnauthorized))
+				Expect(rec.Header().Get("WWW-Authenticate")).To(Equal("Bearer"))
+
+				rec = request(http.MethodPost, "/api/v1/itinerary/reconstruct", "X-API-Key", "fik_0_secret")
+				Expect(rec.Code).To(Equal(http.StatusUnauthorized))
+				rec = request(http.MethodPost, "/api/v1/itinerary/reconstruct", "Authorization", "Basic dXNlcg==")
+				Expect(rec.Code).To(Equal(http.StatusUnauthorized))
+			})
+
+			It("should allow keys with the route scope and forbid others", func() {
+				plaintext, key, err := keyStore.Create("ci", []string{auth.ScopeReconstruct}, "", "")
+				Expect(err).ToNot(HaveOccurred())
+
+				rec := request(http.MethodPost, "/api/v1/itinerary/reconstruct", "X-API-Key", plaintext)
+				Expect(rec.Code).To(Equal(http.StatusOK))
+				rec = request(http.MethodPost, "/api/v1/itinerary/reconstruct", "Authorization", "Bearer "+plaintext)
+				Expect(rec.Code).To(Equal(http.StatusOK))
+				rec = request(http.MethodGet, "/api/v1/cache/stats", "X-API-Key", plaintext)
+				Expect(rec.Code).To(Equal(http.StatusForbidden))
+
+				Expect(keyStore.Revoke(key.ID)).To(Succeed())
+				rec = request(http.MethodPost, "/api/v1/itinerary/reconstruct", "X-API-Key", plaintext)
+				Expect(rec.Code).To(Equal(http.StatusUnauthorized))
+			})
+
+			It("should identify callers by their verified client certificate", func() {
+				certificateAuthenticator, err := auth.NewCertificateAuthenticator(keyStore, []string{auth.ScopeReconstruct})
+				Expect(err).ToNot(HaveOccurred())
+				var principal *auth.Principal
+				server := echo.New()
+				server.GET("/whoami", func(ctx echo.Context) error {
+					principal = ctx.Get(customMiddleware.PrincipalKey).(*auth.Principal)
+					return ctx.NoContent(http.StatusNoContent)
+				}, customMiddleware.NewRequestAuthenticator(certificateAuthenticator, logger).Require(auth.ScopeReconstruct))
+
+				client := &x509.Certificate{Subject: pkix.Name{CommonName: "billing", Organization: []string{"Example"}}}
+				req := httptest.NewRequest(http.MethodGet, "/whoami", nil)
+				req.TLS = &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{client}}}
+				rec := httptest.NewRecorder()
+				server.ServeHTTP(rec, req)
+				Expect(rec.Code).To(Equal(http.StatusNoContent))
+				Expect(principal.Subject).To(Equal("cert:CN=billing,O=Example"))
+				Expect(principal.Name).To(Equal("billing"))
+
+				rec = httptest.NewRecorder()
+				server.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/whoami", nil))
+				Expect(rec.Code).To(Equal(http.StatusUnauthorized))
+			})
+		})
+
+		Context("Rate Limiting", func() {
+			var (
+				limitedServer *echo.Echo
+				plaintext     string
+				writerKey     string
+			)
+
+			BeforeEach(func() {
+				keyStore, err := auth.NewKeyStore(filepath.Join(GinkgoT().TempDir(), "api-keys.json"))
+				Expect(err).ToNot(HaveOccurred())
+				plaintext, _, err = keyStore.Create("ci", []string{auth.ScopeReconstruct}, "trial", "")
+				Expect(err).ToNot(HaveOccurred())
+				writerKey, _, err = keyStore.Create("editor", []string{auth.ScopeWrite}, "trial", "")
+				Expect(err).ToNot(HaveOccurred())
+				limiter := ratelimit.NewLimiter(ratelimit.Policy{
+					DefaultTier: "standard",
+					Tiers: map[string]ratelimit.Tier{
+						"standard":              {Default: ratelimit.Limit{Rate: 100, Burst: 100}},
+						ratelimit.AnonymousTier: {Default: ratelimit.Limit{Rate: 0.01, Burst: 1}},
+						"trial": {
+							Default:      ratelimit.Limit{Rate: 0.01, Burst: 10},
+							DailyTickets: 3,
+						},
+					},
+				})
+				authenticator := customMiddleware.NewRequestAuthenticator(keyStore, logger)
+				limitedServer = echo.New()
+				group := limitedServer.Group("/api/v1", authenticator.Identify(), customMiddleware.RateLimit(limiter, logger))
+				group.GET("/health/status", func(ctx echo.Context) error { return ctx.NoContent(http.StatusOK) })
+				group.POST("/itinerary/reconstruct", itineraryHandler.ReconstructItinerary,
+					authenticator.Require(auth.ScopeReconstruct),
+					customMiddleware.NewItineraryValidator(logger).Validate(),
+					customMiddleware.TicketQuota(limiter, logger))
+				ticketCounter := customMiddleware.NewTicketCounter(limiter, logger)
+				schema, err := gql.NewSchema(itineraryService, enrichment.NewEnricher(airport.NewDirectory()), logger)
+				Expect(err).ToNot(HaveOccurred())
+				group.POST("/graphql", handler.NewGraphQLHandler(schema, gql.Limits{MaxDepth: 8, MaxComplexity: 50},
+					ticketCounter, logger).Query, authenticator.Require(auth.ScopeReconstruct))
+				group.GET("/itinerary/session", handler.NewSessionHandler(func(string) bool { return true },
+					ticketCounter, logger).Connect, authenticator.Require(auth.ScopeReconstruct))
+				tripHandler := handler.NewTripHandler(
+					service.NewTripService(itineraryService, storage.NewMemoryRepository(), logger), ticketCounter, logger)
+				group.POST("/itineraries", tripHandler.Create, authenticator.Require(auth.ScopeWrite),
+					customMiddleware.NewItineraryValidator(logger).Validate(), customMiddleware.TicketQuota(limiter, logger))
+				group.GET("/itineraries/:id", tripHandler.Get, authenticator.Require(auth.ScopeWrite))
+				group.PATCH("/itineraries/:id/tickets", tripHandler.PatchTickets, authenticator.Require(auth.ScopeWrite))
+				group.POST("/itineraries/:id/versions/:version/revert", tripHandler.Revert,
+					authenticator.Require(auth.ScopeWrite))
+			})
+
+			reconstruct := func(body string) *httptest.ResponseRecorder {
+				req := httptest.NewRequest(http.MethodPost, "/api/v1/itinerary/reconstruct", strings.NewReader(body))
+				req.Header.Set("Content-Type", "application/json")
+				req.Header.Set("X-API-Key", plaintext)
+				rec := httptest.NewRecorder()
+				limitedServer.ServeHTTP(rec, req)
+				return rec
+			}
+
+			It("should limit anonymous callers by IP with RateLimit headers and Retry-After", func() {
+				rec := httptest.NewRecorder()
+				limitedServer.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/health/status", nil))
+				Expect(rec.Code).To(Equal(http.StatusOK))
+				Expect(rec.Header().Get("RateLimit-Limit")).To(Equal("1"))
+				Expect(rec.Header().Get("RateLimit-Remaining")).To(Equal("0"))
+
+				rec = httptest.NewRecorder()
+				limitedServer.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/health/status", nil))
+				Expect(rec.Code).To(Equal(http.StatusTooManyRequests))
+				Expect(rec.Header().Get("Retry-After")).To(Equal("100"))
+				Expect(rec.Body.String()).To(ContainSubstring(`"type":"rate_limited"`))
+
+				// Authenticated callers have their own bucket
+				Expect(reconstruct(`[["JFK","LAX"]]`).Code).To(Equal(http.StatusOK))
+			})
+
+			It("should enforce the daily ticket quota of the key's tier", func() {
+				rec := reconstruct(`[["JFK","LAX"],["LAX","DXB"]]`)
+				Expect(rec.Code).To(Equal(http.StatusOK))
+				Expect(rec.Header().Get("RateLimit-Limit")).To(Equal("10"))
+				Expect(rec.Header().Get("X-Ticket-Quota-Limit")).To(Equal("3"))
+				Expect(rec.Header().Get("X-Ticket-Quota-Remaining")).To(Equal("1"))
+
+				rec = reconstruct(`[["JFK","LAX"],["LAX","DXB"]]`)
+				Expect(rec.Code).To(Equal(http.StatusTooManyRequests))
+				Expect(rec.Header().Get("Retry-After")).ToNot(BeEmpty())
+				Expect(rec.Body.String()).To(ContainSubstring("daily ticket quota exceeded"))
+
+				Expect(reconstruct(`[["JFK","LAX"]]`).Code).To(Equal(http.StatusOK))
+				rec = reconstruct(`[["JFK","LAX"]]`)
+				Expect(rec.Code).To(Equal(http.StatusTooManyRequests))
+				Expect(rec.Header().Get("X-Ticket-Quota-Remaining")).To(Equal("0"))
+			})
+
+			It("should count GraphQL and session tickets against the same quota", func() {
+				query := func(tickets string) *httptest.ResponseRecorder {
+					body, _ := json.Marshal(handler.GraphQLRequest{
+						Query: `{ reconstruct(tickets: ` + tickets + `) { route } }`,
+					})
+					req := httptest.NewRequest(http.MethodPost, "/api/v1/graphql", bytes.NewReader(body))
+					req.Header.Set("Content-Type", "application/json")
+					req.Header.Set("X-API-Key", plaintext)
+					rec := httptest.NewRecorder()
+					limitedServer.ServeHTTP(rec, req)
+					return rec
+				}
+				rec := query(`[{source: "JFK", destination: "LAX"}, {source: "LAX", destination: "DXB"}]`)
+				Expect(rec.Code).To(Equal(http.StatusOK))
+				Expect(rec.Header().Get("X-Ticket-Quota-Remaining")).To(Equal("1"))
+
+				server := httptest.NewServer(limitedServer)
+				defer server.Close()
+				url := "ws" + strings.TrimPrefix(server.URL, "http") + "/api/v1/itinerary/session"
+				conn, _, err := websocket.DefaultDialer.Dial(url, http.Header{"X-API-Key": {plaintext}})
+				Expect(err).ToNot(HaveOccurred())
+				defer conn.Close()
+				var response session.Response
+				Expect(conn.ReadJSON(&response)).To(Succeed())
+
+				// Rejected tickets use none of the quota
+				Expect(conn.WriteJSON(session.Message{Action: session.ActionAdd, Ticket: model.Ticket{"DXB", ""}})).To(Succeed())
+				Expect(conn.ReadJSON(&response)).To(Succeed())
+				Expect(response.Type).To(Equal(session.ResponseError))
+				Expect(conn.WriteJSON(session.Message{Action: session.ActionAdd, Ticket: model.Ticket{"DXB", "SIN"}})).To(Succeed())
+				Expect(conn.ReadJSON(&response)).To(Succeed())
+				Expect(response.State.Tickets).To(Equal(1))
+				Expect(conn.WriteJSON(session.Message{Action: session.ActionAdd, Ticket: model.Ticket{"SIN", "SYD"}})).To(Succeed())
+				Expect(conn.ReadJSON(&response)).To(Succeed())
+				Expect(response.Type).To(Equal(session.ResponseError))
+				Expect(response.Error.Message).To(ContainSubstring("daily ticket quota exceeded"))
+				Expect(conn.WriteJSON(session.Message{Action: session.ActionRemove, Ticket: model.Ticket{"SIN", "SYD"}})).To(Succeed())
+				Expect(conn.ReadJSON(&response)).To(Succeed())
+				Expect(response.Error.Message).To(ContainSubstring("is not in the session"))
+
+				rec = query(`[{source: "JFK", destination: "LAX"}]`)
+				Expect(rec.Code).To(Equal(http.StatusTooManyRequests))
+			})
+
+			It("should count tickets added by edits and reverts against the quota", func() {
+				edit := func(method, path, body string) *httptest.ResponseRecorder {
+					req := httptest.NewRequest(method, path, strings.NewReader(body))
+					req.Header.Set("Content-Type", "application/json")
+					req.Header.Set("X-API-Key", writerKey)
+					rec := httptest.NewRecorder()
+					limitedServer.ServeHTTP(rec, req)
+					return rec
+				}
+				rec := edit(http.MethodPost, "/api/v1/itineraries", `[["JFK","LAX"]]`)
+				Expect(rec.Code).To(Equal(http.StatusCreated))
+				var created storage.Itinerary
+				Expect(json.Unmarshal(rec.Body.Bytes(), &created)).To(Succeed())
+				path := "/api/v1/itineraries/" + created.ID
+
+				rec = edit(http.MethodPatch, path+"/tickets",
+					`[{"op":"add","ticket":["LAX","DXB"]},{"op":"void","ticket":["JFK","LAX"]}]`)
+				Expect(rec.Code).To(Equal(http.StatusOK))
+				Expect(rec.Header().Get("X-Ticket-Quota-Remaining")).To(Equal("1"))
+
+				rec = edit(http.MethodPost, path+"/versions/1/revert", "")
+				Expect(rec.Code).To(Equal(http.StatusOK))
+				Expect(rec.Header().Get("X-Ticket-Quota-Remaining")).To(Equal("0"))
+
+				rec = edit(http.MethodPatch, path+"/tickets", `[{"op":"add","ticket":["LAX","DXB"]}]`)
+				Expect(rec.Code).To(Equal(http.StatusTooManyRequests))
+				Expect(rec.Body.String()).To(ContainSubstring("daily ticket quota exceeded"))
+				rec = edit(http.MethodPost, path+"/versions/2/revert", "")
+				Expect(rec.Code).To(Equal(http.StatusTooManyRequests))
+
+				rec = edit(http.MethodGet, path, "")
+				Expect(rec.Body.String()).To(ContainSubstring(`"version":3`))
+			})
+		})
+
+		Context("Multi-Tenancy", func() {
+			var (
+				tenantServer *echo.Echo
+				acmeKey      string
+				operatorKey  string
+				readerKey    string
+			)
+
+			BeforeEach(func() {
+				tenants, err := tenant.NewRegistry(tenant.Dependencies{
+					ItineraryService: service.NewItineraryService(logger),
+					Cache:            cache.NewCache(cache.Options{MaxBytes: 1 << 20, TTL: time.Minute}),
+					Airports:         airport.NewDirectory(),
+					Policy:           ratelimit.DefaultPolicy(),
+					Repository: func(string) (storage.Repository, error) {
+						return storage.NewMemoryRepository(), nil
+					},
+					Logger: logger,
+				}, tenant.Config{
+					ID:         "acme",
+					Validation: tenant.Validation{MaxTickets: 3, BlockedAirports: []string{"DXB"}},
+					Airports:   []airport.Airport{{Code: "XYZ", Name: "Acme Field", Latitude: 40, Longitude: -75}},
+				}, tenant.Config{
+					ID: "globex",
+					RateLimits: &ratelimit.Policy{
+						DefaultTier: "standard",
+						Tiers:       map[string]ratelimit.Tier{"standard": {Default: ratelimit.Limit{Rate: 0.01, Burst: 1}}},
+					},
+				})
+				Expect(err).ToNot(HaveOccurred())
+				DeferCleanup(tenants.Close)
+
+				keyStore, err := auth.NewKeyStore(filepath.Join(GinkgoT().TempDir(), "api-keys.json"))
+				Expect(err).ToNot(HaveOccurred())
+				acmeKey, _, err = keyStore.Create("acme", []string{auth.ScopeReconstruct, auth.ScopeRead, auth.ScopeWrite},
+					"", "acme")
+				Expect(err).ToNot(HaveOccurred())
+				operatorKey, _, err = keyStore.Create("operator", []string{auth.ScopeAdmin}, "", "")
+				Expect(err).ToNot(HaveOccurred())
+				readerKey, _, err = keyStore.Create("reader", []string{auth.ScopeReconstruct, auth.ScopeRead}, "", "")
+				Expect(err).ToNot(HaveOccurred())
+
+				authenticator := customMiddleware.NewRequestAuthenticator(keyStore, logger)
+				tripHandler := handler.NewTripHandler(
+					service.NewTripService(itineraryService, storage.NewMemoryRepository(), logger),
+					customMiddleware.NewTicketCounter(tenants.Default().Limiter, logger), logger)
+				tenantHandler := handler.NewTenantHandler(tenants, logger)
+				tenantServer = echo.New()
+				group := tenantServer.Group("/api/v1", authenticator.Identify(),
+					customMiddleware.Tenancy(tenants, logger),
+					customMiddleware.RateLimit(tenants.Default().Limiter, logger))
+				group.POST("/itinerary/reconstruct", itineraryHandler.ReconstructItinerary,
+					authenticator.Require(auth.ScopeReconstruct),
+					customMiddleware.ItineraryContentNegotiation(render.NewItineraryRegistry(airport.NewDirectory()), logger),
+					customMiddleware.NewItineraryValidator(logger).Validate())
+				group.POST("/itineraries", tripHandler.Create, authenticator.Require(auth.ScopeWrite),
+					customMiddleware.NewItineraryValidator(logger).Validate())
+				group.GET("/itineraries", tripHandler.List, authenticator.Require(auth.ScopeRead))
+				group.GET("/tenants", tenantHandler.List, authenticator.Require(auth.ScopeAdmin))
+				group.GET("/tenants/:id", tenantHandler.Get, authenticator.Require(auth.ScopeAdmin))
+				group.GET("/itinerary/session", handler.NewSessionHandler(func(string) bool { return true },
+					customMiddleware.NewTicketCounter(tenants.Default().Limiter, logger), logger).Connect,
+					authenticator.Require(auth.ScopeReconstruct))
+			})
+
+			request := func(method, path, key, tenantID, body string) *httptest.ResponseRecorder {
+				req := httptest.NewRequest(method, path, strings.NewReader(body))
+				req.Header.Set("Content-Type", "application/json")
+				req.Header.Set("X-API-Key", key)
+				if tenantID != "" {
+					req.Header.Set("X-Tenant-ID", tenantID)
+				}
+				rec := httptest.NewRecorder()
+				tenantServer.ServeHTTP(rec, req)
+				return rec
+			}
+
+			It("should keep stored itineraries apart and bind keys to their tenant", func() {
+				rec := request(http.MethodPost, "/api/v1/itineraries", acmeKey, "", `[["JFK","LAX"]]`)
+				Expect(rec.Code).To(Equal(http.StatusCreated))
+				Expect(rec.Header().Get("X-Tenant-ID")).To(Equal("acme"))
+
+				var page handler.ItineraryPage
+				rec = request(http.MethodGet, "/api/v1/itineraries", operatorKey, "acme", "")
+				Expect(json.Unmarshal(rec.Body.Bytes(), &page)).To(Succeed())
+				Expect(page.Total).To(Equal(1))
+				rec = request(http.MethodGet, "/api/v1/itineraries", operatorKey, "", "")
+				Expect(rec.Header().Get("X-Tenant-ID")).To(Equal(tenant.DefaultID))
+				Expect(json.Unmarshal(rec.Body.Bytes(), &page)).To(Succeed())
+				Expect(page.Total).To(BeZero())
+
+				Expect(request(http.MethodGet, "/api/v1/itineraries", acmeKey, "globex", "").Code).
+					To(Equal(http.StatusForbidden))
+				Expect(request(http.MethodGet, "/api/v1/itineraries", operatorKey, "initech", "").Code).
+					To(Equal(http.StatusNotFound))
+			})
+
+			It("should only let administrators choose a tenant", func() {
+				Expect(request(http.MethodGet, "/api/v1/itineraries", readerKey, "acme", "").Code).
+					To(Equal(http.StatusForbidden))
+				Expect(request(http.MethodGet, "/api/v1/itineraries", "", "acme", "").Code).
+					To(Equal(http.StatusForbidden))
+				rec := request(http.MethodGet, "/api/v1/itineraries", readerKey, tenant.DefaultID, "")
+				Expect(rec.Code).To(Equal(http.StatusOK))
+				Expect(rec.Header().Get("X-Tenant-ID")).To(Equal(tenant.DefaultID))
+			})
+
+			It("should apply the tenant's validation rules and airport overrides", func() {
+				rec := request(http.MethodPost, "/api/v1/itinerary/reconstruct", acmeKey, "", `[["JFK","DXB"]]`)
+				Expect(rec.Code).To(Equal(http.StatusBadRequest))
+				Expect(rec.Body.String()).To(ContainSubstring("blocked airport DXB"))
+				rec = request(http.MethodPost, "/api/v1/itinerary/reconstruct", acmeKey, "",
+					`[["JFK","LAX"],["LAX","SFO"],["SFO","SEA"],["SEA","ORD"]]`)
+				Expect(rec.Code).To(Equal(http.StatusBadRequest))
+				Expect(request(http.MethodPost, "/api/v1/itinerary/reconstruct", operatorKey, "", `[["JFK","DXB"]]`).Code).
+					To(Equal(http.StatusOK))
+
+				rec = request(http.MethodPost, "/api/v1/itinerary/reconstruct?format=text", acmeKey, "", `[["JFK","XYZ"]]`)
+				Expect(rec.Code).To(Equal(http.StatusOK))
+				Expect(rec.Body.String()).To(ContainSubstring("km"))
+				rec = request(http.MethodPost, "/api/v1/itinerary/reconstruct?format=text", operatorKey, "", `[["JFK","XYZ"]]`)
+				Expect(rec.Code).To(Equal(http.StatusOK))
+				Expect(rec.Body.String()).ToNot(ContainSubstring("km"))
+			})
+
+			It("should apply the tenant's validation rules to sessions", func() {
+				server := httptest.NewServer(tenantServer)
+				defer server.Close()
+				url := "ws" + strings.TrimPrefix(server.URL, "http") + "/api/v1/itinerary/session"
+				conn, _, err := websocket.DefaultDialer.Dial(url, http.Header{"X-API-Key": {acmeKey}})
+				Expect(err).ToNot(HaveOccurred())
+				defer conn.Close()
+				var response session.Response
+				Expect(conn.ReadJSON(&response)).To(Succeed())
+
+				Expect(conn.WriteJSON(session.Message{Action: session.ActionAdd, Ticket: model.Ticket{"JFK", "DXB"}})).To(Succeed())
+				Expect(conn.ReadJSON(&response)).To(Succeed())
+				Expect(response.Type).To(Equal(session.ResponseError))
+				Expect(response.Error.Message).To(Equal("ticket JFK-DXB uses blocked airport DXB"))
+
+				for _, ticket := range []model.Ticket{{"JFK", "LAX"}, {"LAX", "SFO"}, {"SFO", "SEA"}} {
+					Expect(conn.WriteJSON(session.Message{Action: session.ActionAdd, Ticket: ticket})).To(Succeed())
+					Expect(conn.ReadJSON(&response)).To(Succeed())
+					Expect(response.Type).To(Equal(session.ResponseState))
+				}
+				Expect(conn.WriteJSON(session.Message{Action: session.ActionAdd, Ticket: model.Ticket{"SEA", "ORD"}})).To(Succeed())
+				Expect(conn.ReadJSON(&response)).To(Succeed())
+				Expect(response.Type).To(Equal(session.ResponseError))
+				Expect(response.Error.Message).To(Equal("session is limited to 3 tickets"))
+			})
+
+			It("should rate limit each tenant by its own policy", func() {
+				Expect(request(http.MethodGet, "/api/v1/itineraries", operatorKey, "globex", "").Code).To(Equal(http.StatusOK))
+				Expect(request(http.MethodGet, "/api/v1/itineraries", operatorKey, "globex", "").Code).
+					To(Equal(http.StatusTooManyRequests))
+				Expect(request(http.MethodGet, "/api/v1/itineraries", operatorKey, "acme", "").Code).To(Equal(http.StatusOK))
+			})
+
+			It("should let administrators inspect tenants", func() {
+				Expect(request(http.MethodPost, "/api/v1/itineraries", acmeKey, "", `[["JFK","LAX"]]`).Code).
+					To(Equal(http.StatusCreated))
+
+				var summaries []handler.TenantSummary
+				rec := request(http.MethodGet, "/api/v1/tenants", operatorKey, "", "")
+				Expect(rec.Code).To(Equal(http.StatusOK))
+				Expect(json.Unmarshal(rec.Body.Bytes(), &summaries)).To(Succeed())
+				Expect(summaries).To(HaveLen(3))
+				Expect(summaries[0].ID).To(Equal("acme"))
+				Expect(summaries[0].Itineraries).To(Equal(1))
+				Expect(summaries[0].Validation.BlockedAirports).To(ConsistOf("DXB"))
+
+				var summary handler.TenantSummary
+				rec = request(http.MethodGet, "/api/v1/tenants/globex", operatorKey, "", "")
+				Expect(json.Unmarshal(rec.Body.Bytes(), &summary)).To(Succeed())
+				Expect(summary.RateLimits.Tiers["standard"].Default.Burst).To(Equal(1))
+				Expect(request(http.MethodGet, "/api/v1/tenants/initech", operatorKey, "", "").Code).
+					To(Equal(http.StatusNotFound))
+				Expect(request(http.MethodGet, "/api/v1/tenants", acmeKey, "", "").Code).To(Equal(http.StatusForbidden))
+			})
+		})
+
+		Context("Audit Log", func() {
+			var (
+				auditServer *echo.Echo
+				auditDir    string
+				writerKey   string
+				tripHandler *handler.TripHandler
+			)
+
+			BeforeEach(func() {
+				auditDir = GinkgoT().TempDir()
+				auditLog, err := audit.NewLog(audit.Options{Dir: auditDir})
+				Expect(err).ToNot(HaveOccurred())
+				DeferCleanup(auditLog.Close)
+
+				keyStore, err := auth.NewKeyStore(filepath.Join(GinkgoT().TempDir(), "api-keys.json"))
+				Expect(err).ToNot(HaveOccurred())
+				writerKey, _, err = keyStore.Create("writer", []string{auth.ScopeWrite}, "", "")
+				Expect(err).ToNot(HaveOccurred())
+
+				authenticator := customMiddleware.NewRequestAuthenticator(keyStore, logger)
+				tripHandler = handler.NewTripHandler(
+					service.NewTripService(itineraryService, storage.NewMemoryRepository(), logger),
+					customMiddleware.NewTicketCounter(ratelimit.NewLimiter(ratelimit.DefaultPolicy()), logger), logger)
+				auditServer = echo.New()
+				group := auditServer.Group("/api/v1", authenticator.Identify())
+				group.POST("/itineraries", tripHandler.Create, authenticator.Require(auth.ScopeWrite),
+					customMiddleware.Idempotency(customMiddleware.NewIdempotencyStore(time.Hour), logger),
+					customMiddleware.Audit(auditLog, audit.ActionCreate, logger),
+					customMiddleware.NewItineraryValidator(logger).Validate())
+				group.DELETE("/itineraries/:id", tripHandler.Delete, authenticator.Require(auth.ScopeWrite),
+					customMiddleware.Audit(auditLog, audit.ActionDelete, logger))
+			})
+
+			request := func(method, path, key, body string) *httptest.ResponseRecorder {
+				req := httptest.NewRequest(method, path, strings.NewReader(body))
+				req.Header.Set("Content-Type", "application/json")
+				req.Header.Set("X-API-Key", key)
+				rec := httptest.NewRecorder()
+				auditServer.ServeHTTP(rec, req)
+				return rec
+			}
+
+			events := func() []audit.Event {
+				paths, err := filepath.Glob(filepath.Join(auditDir, "audit-*.log"))
+				Expect(err).ToNot(HaveOccurred())
+				var recorded []audit.Event
+				for _, path := range paths {
+					data, err := os.ReadFile(path)
+					Expect(err).ToNot(HaveOccurred())
+					for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+						var event audit.Event
+						Expect(json.Unmarshal([]byte(line), &event)).To(Succeed())
+						recorded = append(recorded, event)
+					}
+				}
+				return recorded
+			}
+
+			It("should record who stored and deleted which itinerary in a verifiable chain", func() {
+				rec := request(http.MethodPost, "/api/v1/itineraries", writerKey, `[["JFK","LAX"]]`)
+				Expect(rec.Code).To(Equal(http.StatusCreated))
+				id := filepath.Base(rec.Header().Get(echo.HeaderLocation))
+				Expect(request(http.MethodDelete, "/api/v1/itineraries/"+id, writerKey, "").Code).
+					To(Equal(http.StatusNoContent))
+				Expect(request(http.MethodDelete, "/api/v1/itineraries/"+id, writerKey, "").Code).
+					To(Equal(http.StatusNotFound))
+				Expect(request(http.MethodPost, "/api/v1/itineraries", "", `[["JFK","LAX"]]`).Code).
+					To(Equal(http.StatusUnauthorized))
+
+				recorded := events()
+				Expect(recorded).To(HaveLen(3))
+				Expect(recorded[0].Action).To(Equal(audit.ActionCreate))
+				Expect(recorded[0].Actor).To(HavePrefix("key:"))
+				Expect(recorded[0].Itinerary).To(Equal(id))
+				Expect(recorded[0].Version).To(Equal(1))
+				Expect(recorded[0].Tickets).ToNot(BeEmpty())
+				Expect(recorded[0].Status).To(Equal(http.StatusCreated))
+				Expect(recorded[1].Action).To(Equal(audit.ActionDelete))
+				Expect(recorded[1].Itinerary).To(Equal(id))
+				Expect(recorded[1].Status).To(Equal(http.StatusNoContent))
+				Expect(recorded[2].Status).To(Equal(http.StatusNotFound))
+
+				report, err := audit.Verify(auditDir)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(report.Valid()).To(BeTrue())
+				Expect(report.Events).To(Equal(3))
+			})
+
+			It("should keep the response and log the lost event when the audit event cannot be written", func() {
+				core, logs := observer.New(zapcore.ErrorLevel)
+				failingServer := echo.New()
+				failingServer.POST("/api/v1/itineraries", tripHandler.Create,
+					customMiddleware.Audit(failingRecorder{}, audit.ActionCreate, zap.New(core)),
+					customMiddleware.NewItineraryValidator(logger).Validate())
+				req := httptest.NewRequest(http.MethodPost, "/api/v1/itineraries", strings.NewReader(`[["JFK","LAX"]]`))
+				req.Header.Set("Content-Type", "application/json")
+				rec := httptest.NewRecorder()
+				failingServer.ServeHTTP(rec, req)
+
+				Expect(rec.Code).To(Equal(http.StatusCreated))
+				id := filepath.Base(rec.Header().Get(echo.HeaderLocation))
+				Expect(logs.FilterMessage("Audit event lost").All()).To(HaveLen(1))
+				Expect(logs.All()[0].ContextMap()).To(HaveKeyWithValue("itinerary", id))
+				Expect(logs.All()[0].ContextMap()).To(HaveKeyWithValue("status", int64(http.StatusCreated)))
+			})
+
+			It("should not record idempotent replays as new actions", func() {
+				post := func() *httptest.ResponseRecorder {
+					req := httptest.NewRequest(http.MethodPost, "/api/v1/itineraries", strings.NewReader(`[["JFK","LAX"]]`))
+					req.Header.Set("Content-Type", "application/json")
+					req.Header.Set("X-API-Key", writerKey)
+					req.Header.Set("Idempotency-Key", "create-1")
+					rec := httptest.NewRecorder()
+					auditServer.ServeHTTP(rec, req)
+					return rec
+				}
+
+				Expect(post().Code).To(Equal(http.StatusCreated))
+				replayed := post()
+				Expect(replayed.Code).To(Equal(http.StatusCreated))
+				Expect(replayed.Header().Get("Idempotent-Replayed")).To(Equal("true"))
+				Expect(events()).To(HaveLen(1))
+			})
+		})
+
+		Context("Live Reload", func() {
+			It("should apply new CORS settings to the next request", func() {
+				corsPolicy := customMiddleware.NewCORSPolicy(middleware.CORSConfig{AllowOrigins: []string{"https://a.example.com"}})
+				corsServer := echo.New()
+				corsServer.Use(corsPolicy.Apply())
+				corsServer.GET("/api/v1/health/status", func(ctx echo.Context) error {
+					return ctx.NoContent(http.StatusOK)
+				})
+				allowedOrigin := func(origin string) string {
+					req := httptest.NewRequest(http.MethodGet, "/api/v1/health/status", nil)
+					req.Header.Set(echo.HeaderOrigin, origin)
+					rec := httptest.NewRecorder()
+					corsServer.ServeHTTP(rec, req)
+					Expect(rec.Code).To(Equal(http.StatusOK))
+					return rec.Header().Get(echo.HeaderAccessControlAllowOrigin)
+				}
+
+				Expect(allowedOrigin("https://a.example.com")).To(Equal("https://a.example.com"))
+				Expect(allowedOrigin("https://b.example.com")).To(BeEmpty())
+				corsPolicy.Update(middleware.CORSConfig{AllowOrigins: []string{"https://b.example.com"}})
+				Expect(allowedOrigin("https://a.example.com")).To(BeEmpty())
+				Expect(allowedOrigin("https://b.example.com")).To(Equal("https://b.example.com"))
+			})
+
+			It("should serve requests with reloaded tenant rules", func() {
+				dependencies := tenant.Dependencies{
+					ItineraryService: service.NewItineraryService(logger),
+					Cache:            cache.NewCache(cache.Options{MaxBytes: 1 << 20, TTL: time.Minute}),
+					Airports:         airport.NewDirectory(),
+					Policy:           ratelimit.DefaultPolicy(),
+					Repository: func(string) (storage.Repository, error) {
+						return storage.NewMemoryRepository(), nil
+					},
+					Logger: logger,
+				}
+				tenants, err := tenant.NewRegistry(dependencies)
+				Expect(err).ToNot(HaveOccurred())
+				DeferCleanup(tenants.Close)
+				reloadServer := echo.New()
+				reloadServer.POST("/api/v1/itinerary/reconstruct", itineraryHandler.ReconstructItinerary,
+					customMiddleware.Tenancy(tenants, logger), customMiddleware.NewItineraryValidator(logger).Validate())
+				reconstruct := func() *httptest.ResponseRecorder {
+					req := httptest.NewRequest(http.MethodPost, "/api/v1/itinerary/reconstruct",
+						strings.NewReader(`[["JFK","DXB"]]`))
+					req.Header.Set("Content-Type", "application/json")
+					rec := httptest.NewRecorder()
+					reloadServer.ServeHTTP(rec, req)
+					return rec
+				}
+
+				rec := reconstruct()
+				Expect(rec.Code).To(Equal(http.StatusOK))
+				Expect(rec.Header().Get("X-Cache")).To(Equal(string(cache.StatusMiss)))
+				Expect(tenants.Reload(dependencies, tenant.Config{
+					ID:         tenant.DefaultID,
+					Validation: tenant.Validation{BlockedAirports: []string{"DXB"}},
+				})).To(Succeed())
+				Expect(reconstruct().Code).To(Equal(http.StatusBadRequest))
+			})
+		})
+
+		Context("Log Redaction", func() {
+			It("should keep itineraries and caller addresses out of handler, service and middleware logs", func() {
+				core, logs := observer.New(zapcore.DebugLevel)
+				redactedLogger := zap.New(appLogger.NewRedactingCore(core,
+					appLogger.Redaction{Policies: appLogger.DefaultPolicies(), Key: []byte("secret")}))
+				redactedHandler := handler.NewItineraryHandler(service.NewItineraryService(redactedLogger), redactedLogger)
+				redactedServer := echo.New()
+				redactedServer.Use(customMiddleware.LoggingMiddleware(redactedLogger))
+				redactedServer.POST("/api/v1/itinerary/reconstruct", redactedHandler.ReconstructItinerary,
+					customMiddleware.NewItineraryValidator(redactedLogger).Validate())
+
+				req := httptest.NewRequest(http.MethodPost, "/api/v1/itinerary/reconstruct?from=JFK&via=LAX&via=DXB",
+					strings.NewReader(`[["LAX","DXB"],["JFK","LAX"]]`))
+				req.Header.Set("Content-Type", "application/json")
+				req.RemoteAddr = "203.0.113.77:41000"
+				rec := httptest.NewRecorder()
+				redactedServer.ServeHTTP(rec, req)
+				Expect(rec.Code).To(Equal(http.StatusOK))
+
+				messages := map[string]bool{}
+				for _, entry := range logs.All() {
+					messages[entry.Message] = true
+					if entry.Message == "Request completed" {
+						Expect(entry.ContextMap()["uri"]).To(Equal("/api/v1/itinerary/reconstruct?from=&via="))
+					}
+					for key, value := range entry.ContextMap() {
+						text := fmt.Sprint(value)
+						Expect(text).ToNot(ContainSubstring("JFK"), key)
+						Expect(text).ToNot(ContainSubstring("203.0.113"), key)
+					}
+				}
+				Expect(messages).To(HaveKey("Itinerary reconstructed"))
+				Expect(messages).To(HaveKey("Successfully reconstructed itinerary"))
+				Expect(messages).To(HaveKey("Request completed"))
+			})
+		})
 	})
 })
@@ -0,0 +1,179 @@
+package airport
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/csv"
+	"fmt"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"sync/atomic"
+
+	"flight-itinerary-go/internal/geo"
+)
+
+//go:embed airports.csv
+var embeddedAirports []byte
+
+// Airport represents reference data for a single airport
+type Airport struct {
+	Code      string  `json:"code"`
+	Name      string  `json:"name"`
+	City      string  `json:"city"`
+	Country   string  `json:"country"`
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+}
+
+// Coordinate returns the airport location
+func (a Airport) Coordinate() geo.Coordinate {
+	return geo.Coordinate{Latitude: a.Latitude, Longitude: a.Longitude}
+}
+
+// Directory defines the interface for airport lookups
+type Directory interface {
+	Lookup(code string) (Airport, bool)
+}
+
+// DirectoryV1 implements the Directory interface over an in-memory index
+type DirectoryV1 struct {
+	airports map[string]Airport
+}
+
+// embedded holds the parsed embedded dataset, or the reason it could not be parsed
+var embedded, embeddedErr = parseAirports(embeddedAirports)
+
+// Embedded returns the airports of the embedded dataset; the server checks it at startup
+func Embedded() ([]Airport, error) {
+	return embedded, embeddedErr
+}
+
+// NewDirectory creates a Directory backed by the embedded airport dataset. The dataset is checked
+// by the package tests and by Embedded at startup; should it fail to parse, the directory is empty.
+func NewDirectory() Directory {
+	return NewDirectoryFromAirports(embedded)
+}
+
+// NewDirectoryFromAirports creates a Directory from the given airports
+func NewDirectoryFromAirports(airports []Airport) Directory {
+	index := make(map[string]Airport, len(airports))
+	for _, airport := range airports {
+		index[strings.ToUpper(airport.Code)] = airport
+	}
+	return &DirectoryV1{
+		airports: index,
+	}
+}
+
+// Lookup returns the airport for the given IATA code
+func (directory *DirectoryV1) Lookup(code string) (Airport, bool) {
+	airport, exists := directory.airports[strings.ToUpper(code)]
+	return airport, exists
+}
+
+// OverlayDirectory serves overriding airports before falling back to a base directory
+type OverlayDirectory struct {
+	base      Directory
+	overrides Directory
+}
+
+// NewOverlayDirectory creates a Directory where the overrides replace or extend the base airports
+func NewOverlayDirectory(base Directory, overrides []Airport) Directory {
+	if len(overrides) == 0 {
+		return base
+	}
+	return &OverlayDirectory{
+		base:      base,
+		overrides: NewDirectoryFromAirports(overrides),
+	}
+}
+
+// Lookup returns the overriding airport for the code, or the base airport
+func (directory *OverlayDirectory) Lookup(code string) (Airport, bool) {
+	if airport, exists := directory.overrides.Lookup(code); exists {
+		return airport, true
+	}
+	return directory.base.Lookup(code)
+}
+
+// ReloadableDirectory serves the airports of a directory that can be replaced while in use
+type ReloadableDirectory struct {
+	current atomic.Pointer[Directory]
+}
+
+// NewReloadableDirectory creates a ReloadableDirectory serving the directory until it is replaced
+func NewReloadableDirectory(directory Directory) *ReloadableDirectory {
+	reloadable := &ReloadableDirectory{}
+	reloadable.Set(directory)
+	return reloadable
+}
+
+// Set replaces the airports served
+func (directory *ReloadableDirectory) Set(replacement Directory) {
+	directory.current.Store(&replacement)
+}
+
+// Lookup returns the airport for the code from the current directory
+func (directory *ReloadableDirectory) Lookup(code string) (Airport, bool) {
+	return (*directory.current.Load()).Lookup(code)
+}
+
+// LoadAirports reads airports from a CSV file in the format of the embedded dataset: a header row,
+// then code, name, city, country, latitude and longitude. Coordinates must lie within ±90° of
+// latitude and ±180° of longitude.
+func LoadAirports(path string) ([]Airport, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	airports, err := parseAirports(data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid airports file %s: %w", path, err)
+	}
+	for i, airport := range airports {
+		if airport.Code == "" {
+			return nil, fmt.Errorf("invalid airports file %s: row %d has no code", path, i+2)
+		}
+		// Written as a negation so NaN coordinates are rejected too
+		if !(math.Abs(airport.Latitude) <= 90 && math.Abs(airport.Longitude) <= 180) {
+			return nil, fmt.Errorf("invalid airports file %s: row %d has coordinates %g,%g outside ±90,±180",
+				path, i+2, airport.Latitude, airport.Longitude)
+		}
+	}
+	return airports, nil
+}
+
+func parseAirports(data []byte) ([]Airport, error) {
+	reader := csv.NewReader(bytes.NewReader(data))
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 || len(records[0]) < 6 {
+		return nil, fmt.Errorf("expected a header and six columns")
+	}
+
+	airports := make([]Airport, 0, len(records))
+	// Skip header row
+	for _, record := range records[1:] {
+		latitude, err := strconv.ParseFloat(record[4], 64)
+		if err != nil {
+			return nil, err
+		}
+		longitude, err := strconv.ParseFloat(record[5], 64)
+		if err != nil {
+			return nil, err
+		}
+		airports = append(airports, Airport{
+			Code:      record[0],
+			Name:      record[1],
+			City:      record[2],
+			Country:   record[3],
+			Latitude:  latitude,
+			Longitude: longitude,
+		})
+	}
+	return airports, nil
+}
@@ -0,0 +1,71 @@
+package airport_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"flight-itinerary-go/internal/airport"
+)
+
+func TestAirport(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Airport Suite")
+}
+
+var _ = Describe("Airport", func() {
+	It("should embed a valid dataset", func() {
+		airports, err := airport.Embedded()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(airports).ToNot(BeEmpty())
+
+		codes := map[string]bool{}
+		for _, found := range airports {
+			Expect(found.Code).To(MatchRegexp(`^[A-Z]{3}$`))
+			Expect(codes).ToNot(HaveKey(found.Code))
+			codes[found.Code] = true
+			Expect(found.Latitude).To(BeNumerically(">=", -90))
+			Expect(found.Latitude).To(BeNumerically("<=", 90))
+			Expect(found.Longitude).To(BeNumerically(">=", -180))
+			Expect(found.Longitude).To(BeNumerically("<=", 180))
+		}
+		jfk, exists := airport.NewDirectory().Lookup("jfk")
+		Expect(exists).To(BeTrue())
+		Expect(jfk.City).To(Equal("New York"))
+	})
+
+	It("should load airports files and reject malformed ones", func() {
+		dir := GinkgoT().TempDir()
+		path := filepath.Join(dir, "airports.csv")
+		Expect(os.WriteFile(path, []byte("code,name,city,country,latitude,longitude\nXYZ,Acme Field,Acme,US,40,-75\n"), 0600)).To(Succeed())
+		airports, err := airport.LoadAirports(path)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(airports).To(HaveLen(1))
+
+		reloadable := airport.NewReloadableDirectory(airport.NewDirectory())
+		_, exists := reloadable.Lookup("XYZ")
+		Expect(exists).To(BeFalse())
+		reloadable.Set(airport.NewOverlayDirectory(airport.NewDirectory(), airports))
+		_, exists = reloadable.Lookup("XYZ")
+		Expect(exists).To(BeTrue())
+
+		Expect(os.WriteFile(path, []byte("code,name\nXYZ,Acme Field\n"), 0600)).To(Succeed())
+		_, err = airport.LoadAirports(path)
+		Expect(err).To(HaveOccurred())
+		Expect(os.WriteFile(path, []byte("code,name,city,country,latitude,longitude\nXYZ,Acme,Acme,US,north,-75\n"), 0600)).To(Succeed())
+		_, err = airport.LoadAirports(path)
+		Expect(err).To(HaveOccurred())
+		Expect(os.WriteFile(path, []byte("code,name,city,country,latitude,longitude\nXYZ,Acme,Acme,US,-75,40\n"), 0600)).To(Succeed())
+		_, err = airport.LoadAirports(path)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(os.WriteFile(path, []byte("code,name,city,country,latitude,longitude\nXYZ,Acme,Acme,US,91,40\n"), 0600)).To(Succeed())
+		_, err = airport.LoadAirports(path)
+		Expect(err).To(MatchError(ContainSubstring("row 2 has coordinates 91,40 outside ±90,±180")))
+		Expect(os.WriteFile(path, []byte("code,name,city,country,latitude,longitude\nXYZ,Acme,Acme,US,40,-180.5\n"), 0600)).To(Succeed())
+		_, err = airport.LoadAirports(path)
+		Expect(err).To(HaveOccurred())
+	})
+})
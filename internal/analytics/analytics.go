@@ -0,0 +1,271 @@
+package analytics
+
+import (
+	"encoding/csv"
+	"io"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// DefaultRetention is the number of trips kept when Options.Retention is not set
+const DefaultRetention = 100000
+
+// Trip is a recorded reconstruction. Anonymized trips carry no ID, so their legs cannot be
+// linked to each other, and their time is truncated to the hour.
+type Trip struct {
+	ID         string
+	RecordedAt time.Time
+	Route      []string
+}
+
+// Window selects trips recorded in [From, To); zero bounds are open
+type Window struct {
+	From time.Time
+	To   time.Time
+}
+
+// RouteCount is the number of times a leg was flown
+type RouteCount struct {
+	Source      string `json:"source"`
+	Destination string `json:"destination"`
+	Count       int    `json:"count"`
+}
+
+// AirportCount is the number of times an airport served as a layover
+type AirportCount struct {
+	Airport string `json:"airport"`
+	Count   int    `json:"count"`
+}
+
+// Summary describes the trips in a window
+type Summary struct {
+	Trips       int     `json:"trips"`
+	Legs        int     `json:"legs"`
+	AverageLegs float64 `json:"average_legs"`
+}
+
+// Traffic counts the departures and arrivals at an airport within one interval
+type Traffic struct {
+	Airport    string    `json:"airport"`
+	Start      time.Time `json:"start"`
+	Departures int       `json:"departures"`
+	Arrivals   int       `json:"arrivals"`
+}
+
+// Options configures the recorder
+type Options struct {
+	// Anonymize drops trip IDs and truncates times to the hour
+	Anonymize bool
+	// Retention is the maximum number of trips kept; the oldest are dropped first
+	Retention int
+}
+
+// Analytics defines the interface for recording reconstructions and reporting on them
+type Analytics interface {
+	Record(route []string, at time.Time)
+	TopRoutes(window Window, limit int) []RouteCount
+	TopHubs(window Window, limit int) []AirportCount
+	Summary(window Window) Summary
+	// Traffic returns per-airport counts bucketed by interval, oldest first; an empty airport
+	// includes every airport
+	Traffic(window Window, interval time.Duration, airport string) []Traffic
+	// Export writes one CSV row per recorded leg
+	Export(writer io.Writer, window Window) error
+}
+
+// AnalyticsV1 implements the Analytics interface in memory; contents are lost on restart
+type AnalyticsV1 struct {
+	mutex   sync.RWMutex
+	options Options
+	// trips is a ring buffer in recording order once full
+	trips []Trip
+	next  int
+}
+
+// NewAnalytics creates an empty recorder
+func NewAnalytics(options Options) Analytics {
+	if options.Retention <= 0 {
+		options.Retention = DefaultRetention
+	}
+	return &AnalyticsV1{
+		options: options,
+	}
+}
+
+// Record stores a reconstructed route; routes without legs are ignored
+func (analytics *AnalyticsV1) Record(route []string, at time.Time) {
+	if len(route) < 2 {
+		return
+	}
+	trip := Trip{
+		RecordedAt: at.UTC(),
+		Route:      append([]string(nil), route...),
+	}
+	if analytics.options.Anonymize {
+		trip.RecordedAt = trip.RecordedAt.Truncate(time.Hour)
+	} else {
+		trip.ID = uuid.NewString()
+	}
+
+	analytics.mutex.Lock()
+	defer analytics.mutex.Unlock()
+	if len(analytics.trips) < analytics.options.Retention {
+		analytics.trips = append(analytics.trips, trip)
+		return
+	}
+	analytics.trips[analytics.next] = trip
+	analytics.next = (analytics.next + 1) % len(analytics.trips)
+}
+
+// TopRoutes returns the most flown legs, most frequent first
+func (analytics *AnalyticsV1) TopRoutes(window Window, limit int) []RouteCount {
+	counts := make(map[[2]string]int)
+	analytics.each(window, func(trip Trip) {
+		for i := 1; i < len(trip.Route); i++ {
+			counts[[2]string{trip.Route[i-1], trip.Route[i]}]++
+		}
+	})
+
+	routes := make([]RouteCount, 0, len(counts))
+	for leg, count := range counts {
+		routes = append(routes, RouteCount{Source: leg[0], Destination: leg[1], Count: count})
+	}
+	sort.Slice(routes, func(i, j int) bool {
+		if routes[i].Count != routes[j].Count {
+			return routes[i].Count > routes[j].Count
+		}
+		if routes[i].Source != routes[j].Source {
+			return routes[i].Source < routes[j].Source
+		}
+		return routes[i].Destination < routes[j].Destination
+	})
+	if len(routes) > limit {
+		routes = routes[:limit]
+	}
+	return routes
+}
+
+// TopHubs returns the airports most used for layovers, most frequent first
+func (analytics *AnalyticsV1) TopHubs(window Window, limit int) []AirportCount {
+	counts := make(map[string]int)
+	analytics.each(window, func(trip Trip) {
+		for _, airport := range trip.Route[1 : len(trip.Route)-1] {
+			counts[airport]++
+		}
+	})
+
+	hubs := make([]AirportCount, 0, len(counts))
+	for airport, count := range counts {
+		hubs = append(hubs, AirportCount{Airport: airport, Count: count})
+	}
+	sort.Slice(hubs, func(i, j int) bool {
+		if hubs[i].Count != hubs[j].Count {
+			return hubs[i].Count > hubs[j].Count
+		}
+		return hubs[i].Airport < hubs[j].Airport
+	})
+	if len(hubs) > limit {
+		hubs = hubs[:limit]
+	}
+	return hubs
+}
+
+// Summary counts the trips and legs in the window
+func (analytics *AnalyticsV1) Summary(window Window) Summary {
+	var summary Summary
+	analytics.each(window, func(trip Trip) {
+		summary.Trips++
+		summary.Legs += len(trip.Route) - 1
+	})
+	if summary.Trips > 0 {
+		summary.AverageLegs = float64(summary.Legs) / float64(summary.Trips)
+	}
+	return summary
+}
+
+// Traffic buckets departures and arrivals by the start of their interval
+func (analytics *AnalyticsV1) Traffic(window Window, interval time.Duration, airport string) []Traffic {
+	type key struct {
+		airport string
+		start   time.Time
+	}
+	counts := make(map[key]*Traffic)
+	count := func(code string, start time.Time) *Traffic {
+		bucket := key{airport: code, start: start}
+		traffic, exists := counts[bucket]
+		if !exists {
+			traffic = &Traffic{Airport: code, Start: start}
+			counts[bucket] = traffic
+		}
+		return traffic
+	}
+	analytics.each(window, func(trip Trip) {
+		start := trip.RecordedAt.Truncate(interval)
+		for i := 1; i < len(trip.Route); i++ {
+			if airport == "" || trip.Route[i-1] == airport {
+				count(trip.Route[i-1], start).Departures++
+			}
+			if airport == "" || trip.Route[i] == airport {
+				count(trip.Route[i], start).Arrivals++
+			}
+		}
+	})
+
+	traffic := make([]Traffic, 0, len(counts))
+	for _, bucket := range counts {
+		traffic = append(traffic, *bucket)
+	}
+	sort.Slice(traffic, func(i, j int) bool {
+		if !traffic[i].Start.Equal(traffic[j].Start) {
+			return traffic[i].Start.Before(traffic[j].Start)
+		}
+		return traffic[i].Airport < traffic[j].Airport
+	})
+	return traffic
+}
+
+// Export writes the legs of the trips in the window, oldest first
+func (analytics *AnalyticsV1) Export(writer io.Writer, window Window) error {
+	csvWriter := csv.NewWriter(writer)
+	if err := csvWriter.Write([]string{"recorded_at", "trip_id", "leg", "source", "destination"}); err != nil {
+		return err
+	}
+	var err error
+	analytics.each(window, func(trip Trip) {
+		for i := 1; i < len(trip.Route) && err == nil; i++ {
+			err = csvWriter.Write([]string{
+				trip.RecordedAt.Format(time.RFC3339),
+				trip.ID,
+				strconv.Itoa(i),
+				trip.Route[i-1],
+				trip.Route[i],
+			})
+		}
+	})
+	if err != nil {
+		return err
+	}
+	csvWriter.Flush()
+	return csvWriter.Error()
+}
+
+// each visits the trips recorded within the window in recording order
+func (analytics *AnalyticsV1) each(window Window, visit func(Trip)) {
+	analytics.mutex.RLock()
+	defer analytics.mutex.RUnlock()
+
+	for i := range analytics.trips {
+		trip := analytics.trips[(analytics.next+i)%len(analytics.trips)]
+		if !window.From.IsZero() && trip.RecordedAt.Before(window.From) {
+			continue
+		}
+		if !window.To.IsZero() && !trip.RecordedAt.Before(window.To) {
+			continue
+		}
+		visit(trip)
+	}
+}
@@ -0,0 +1,88 @@
+package analytics_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.uber.org/zap"
+
+	"flight-itinerary-go/internal/analytics"
+	"flight-itinerary-go/internal/model"
+	"flight-itinerary-go/internal/service"
+)
+
+func TestAnalytics(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Analytics Suite")
+}
+
+var _ = Describe("Analytics", func() {
+	start := time.Date(2025, 3, 1, 9, 30, 0, 0, time.UTC)
+
+	It("should report only the trips within the window", func() {
+		recorder := analytics.NewAnalytics(analytics.Options{})
+		recorder.Record([]string{"JFK", "LAX"}, start)
+		recorder.Record([]string{"JFK", "LAX", "DXB"}, start.Add(time.Hour))
+		recorder.Record([]string{"LHR", "DXB"}, start.Add(48*time.Hour))
+
+		window := analytics.Window{From: start.Add(time.Minute), To: start.Add(24 * time.Hour)}
+		Expect(recorder.Summary(window)).To(Equal(analytics.Summary{Trips: 1, Legs: 2, AverageLegs: 2}))
+		Expect(recorder.TopHubs(window, 10)).To(Equal([]analytics.AirportCount{{Airport: "LAX", Count: 1}}))
+		Expect(recorder.TopRoutes(analytics.Window{}, 1)).To(Equal([]analytics.RouteCount{
+			{Source: "JFK", Destination: "LAX", Count: 2},
+		}))
+	})
+
+	It("should bucket traffic by interval", func() {
+		recorder := analytics.NewAnalytics(analytics.Options{})
+		recorder.Record([]string{"JFK", "DXB", "SIN"}, start)
+		recorder.Record([]string{"DXB", "JFK"}, start.Add(2*time.Hour))
+
+		Expect(recorder.Traffic(analytics.Window{}, time.Hour, "DXB")).To(Equal([]analytics.Traffic{
+			{Airport: "DXB", Start: start.Truncate(time.Hour), Departures: 1, Arrivals: 1},
+			{Airport: "DXB", Start: start.Add(2 * time.Hour).Truncate(time.Hour), Departures: 1},
+		}))
+		Expect(recorder.Traffic(analytics.Window{}, 24*time.Hour, "")).To(HaveLen(3))
+	})
+
+	It("should drop the oldest trips beyond the retention", func() {
+		recorder := analytics.NewAnalytics(analytics.Options{Retention: 2})
+		recorder.Record([]string{"JFK", "LAX"}, start)
+		recorder.Record([]string{"LAX", "DXB"}, start.Add(time.Minute))
+		recorder.Record([]string{"DXB", "SIN"}, start.Add(2*time.Minute))
+
+		var export bytes.Buffer
+		Expect(recorder.Export(&export, analytics.Window{})).To(Succeed())
+		lines := strings.Split(strings.TrimSpace(export.String()), "\n")
+		Expect(lines).To(HaveLen(3))
+		Expect(lines[1]).To(HaveSuffix(",1,LAX,DXB"))
+		Expect(lines[2]).To(HaveSuffix(",1,DXB,SIN"))
+	})
+
+	It("should not link anonymized legs to a trip or an exact time", func() {
+		recorder := analytics.NewAnalytics(analytics.Options{Anonymize: true})
+		recorder.Record([]string{"JFK", "LAX", "DXB"}, start)
+
+		var export bytes.Buffer
+		Expect(recorder.Export(&export, analytics.Window{})).To(Succeed())
+		Expect(export.String()).To(Equal("recorded_at,trip_id,leg,source,destination\n" +
+			"2025-03-01T09:00:00Z,,1,JFK,LAX\n" +
+			"2025-03-01T09:00:00Z,,2,LAX,DXB\n"))
+	})
+
+	It("should record only successful reconstructions", func() {
+		recorder := analytics.NewAnalytics(analytics.Options{})
+		itineraryService := analytics.NewRecordingService(service.NewItineraryService(zap.NewNop()), recorder)
+
+		_, err := itineraryService.ReconstructItinerary([]model.Ticket{{"JFK", "LAX"}, {"LAX", "DXB"}})
+		Expect(err).ToNot(HaveOccurred())
+		_, err = itineraryService.ReconstructItinerary([]model.Ticket{{"JFK", "LAX"}, {"DXB", "SIN"}})
+		Expect(err).To(HaveOccurred())
+
+		Expect(recorder.Summary(analytics.Window{}).Trips).To(Equal(1))
+	})
+})
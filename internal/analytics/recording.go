@@ -0,0 +1,32 @@
+package analytics
+
+import (
+	"time"
+
+	"flight-itinerary-go/internal/model"
+	"flight-itinerary-go/internal/service"
+)
+
+// RecordingService records every successful reconstruction of the wrapped service
+type RecordingService struct {
+	itineraryService service.ItineraryService
+	analytics        Analytics
+}
+
+// NewRecordingService wraps the itinerary service so its results feed the analytics
+func NewRecordingService(itineraryService service.ItineraryService, analytics Analytics) service.ItineraryService {
+	return &RecordingService{
+		itineraryService: itineraryService,
+		analytics:        analytics,
+	}
+}
+
+// ReconstructItinerary reconstructs the itinerary and records the route on success
+func (recordingService *RecordingService) ReconstructItinerary(tickets []model.Ticket) ([]string, error) {
+	itinerary, err := recordingService.itineraryService.ReconstructItinerary(tickets)
+	if err != nil {
+		return nil, err
+	}
+	recordingService.analytics.Record(itinerary, time.Now())
+	return itinerary, nil
+}
@@ -0,0 +1,407 @@
+package audit
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"flight-itinerary-go/internal/model"
+)
+
+// Actions recorded in the audit log
+const (
+	ActionReconstruct = "reconstruct"
+	ActionCreate      = "create"
+	ActionUpdate      = "update"
+	ActionRevert      = "revert"
+	ActionDelete      = "delete"
+	// ActionGap marks where a partly written event was cut from the log when it was reopened
+	ActionGap = "gap"
+)
+
+// Channels through which an action was requested
+const (
+	ChannelHTTP    = "http"
+	ChannelGraphQL = "graphql"
+	ChannelGRPC    = "grpc"
+	// ChannelSystem marks events the log records about itself
+	ChannelSystem = "system"
+)
+
+const (
+	filePrefix = "audit-"
+	fileSuffix = ".log"
+	// genesisHash is the previous hash of the first event
+	genesisHash = "0000000000000000000000000000000000000000000000000000000000000000"
+)
+
+// Event records who acted on which itinerary. Events are chained: each one carries the hash of the
+// one before it, so changing, removing or reordering an event breaks the chain.
+type Event struct {
+	Sequence uint64    `json:"seq"`
+	Time     time.Time `json:"time"`
+	// Actor is the principal subject, or ip:<address> for anonymous callers
+	Actor  string `json:"actor"`
+	Tenant string `json:"tenant,omitempty"`
+	Action string `json:"action"`
+	// Itinerary is the stored itinerary ID; Version is its version after the change
+	Itinerary string `json:"itinerary,omitempty"`
+	Version   int    `json:"version,omitempty"`
+	// Tickets is the order-independent digest of the submitted tickets, so reconstructions can be
+	// traced without keeping travel data in the log
+	Tickets   string `json:"tickets,omitempty"`
+	Channel   string `json:"channel"`
+	Route     string `json:"route"`
+	Status    int    `json:"status"`
+	RequestID string `json:"request_id,omitempty"`
+	Previous  string `json:"prev"`
+	Hash      string `json:"hash"`
+}
+
+// computeHash hashes the event with its hash field left empty
+func (event Event) computeHash() (string, error) {
+	event.Hash = ""
+	data, err := json.Marshal(event)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// Recorder records audit events
+type Recorder interface {
+	Record(event Event) error
+}
+
+// LogLost alerts that an event could not be written. The action has taken effect, so the event is
+// logged in full for it to be recovered.
+func LogLost(logger *zap.Logger, event Event, err error) {
+	logger.Error("Audit event lost", zap.String("actor", event.Actor), zap.String("tenant", event.Tenant),
+		zap.String("action", event.Action), zap.String("itinerary", event.Itinerary),
+		zap.Int("version", event.Version), zap.String("tickets", event.Tickets),
+		zap.String("channel", event.Channel), zap.String("route", event.Route), zap.Int("status", event.Status),
+		zap.String("request_id", event.RequestID), zap.Error(err))
+}
+
+// TicketDigest returns the order-independent digest of a ticket set recorded in events, so
+// reconstructions can be traced without keeping travel data in the log
+func TicketDigest(tickets []model.Ticket) string {
+	encoded := make([]string, 0, len(tickets))
+	for _, ticket := range tickets {
+		encoded = append(encoded, ticket.Source()+"\x00"+ticket.Destination())
+	}
+	sort.Strings(encoded)
+	hash := sha256.New()
+	for _, ticket := range encoded {
+		hash.Write([]byte(ticket))
+		hash.Write([]byte{0})
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// Options configures the audit log
+type Options struct {
+	// Dir holds the log files
+	Dir string
+	// MaxBytes rotates the current file once it reaches the size; zero disables size rotation
+	MaxBytes int64
+	// MaxAge rotates the current file once it is older; zero disables age rotation
+	MaxAge time.Duration
+}
+
+// Log is a hash-chained, append-only audit log written as JSON lines. Full files are rotated and
+// made read-only; the chain continues across files.
+type Log struct {
+	mutex    sync.Mutex
+	options  Options
+	file     *os.File
+	size     int64
+	opened   time.Time
+	sequence uint64
+	lastHash string
+	failures uint64
+	now      func() time.Time
+}
+
+// NewLog opens the audit log in the directory, resuming the chain of existing files. A partial last
+// line, left by a crash while an event was written, is cut off and a gap event records the cut.
+func NewLog(options Options) (*Log, error) {
+	if err := os.MkdirAll(options.Dir, 0700); err != nil {
+		return nil, err
+	}
+	auditLog := &Log{
+		options:  options,
+		lastHash: genesisHash,
+		now:      time.Now,
+	}
+
+	files, err := logFiles(options.Dir)
+	if err != nil {
+		return nil, err
+	}
+	var cut int64
+	if len(files) > 0 {
+		if cut, err = cutTornLine(files[len(files)-1]); err != nil {
+			return nil, fmt.Errorf("failed to recover audit log %s: %w", files[len(files)-1], err)
+		}
+	}
+	// The chain resumes from the last event, which may sit in an earlier file when the newest one
+	// is still empty
+	for i := len(files) - 1; i >= 0; i-- {
+		first, last, err := fileBounds(files[i])
+		if err != nil {
+			return nil, fmt.Errorf("failed to resume audit log %s: %w", files[i], err)
+		}
+		if last == nil {
+			continue
+		}
+		auditLog.sequence = last.Sequence
+		auditLog.lastHash = last.Hash
+		if i == len(files)-1 {
+			auditLog.opened = first.Time
+		}
+		break
+	}
+
+	// Sealed files stay closed; the next event starts a new file
+	if len(files) == 0 {
+		return auditLog, nil
+	}
+	current := files[len(files)-1]
+	info, err := os.Stat(current)
+	if err != nil {
+		return nil, err
+	}
+	if info.Mode().Perm()&0200 != 0 {
+		if err := auditLog.open(current); err != nil {
+			return nil, err
+		}
+	}
+	if cut > 0 {
+		gap := Event{
+			Actor:   "audit",
+			Action:  ActionGap,
+			Channel: ChannelSystem,
+			Route:   fmt.Sprintf("%s: %d bytes cut", filepath.Base(current), cut),
+		}
+		if err := auditLog.Record(gap); err != nil {
+			auditLog.Close()
+			return nil, err
+		}
+	}
+	return auditLog, nil
+}
+
+// Record appends the event, assigning its sequence number, time and hashes. The file is synced
+// before Record returns. Failed writes are counted for Failures.
+func (auditLog *Log) Record(event Event) error {
+	auditLog.mutex.Lock()
+	defer auditLog.mutex.Unlock()
+	if err := auditLog.record(event); err != nil {
+		auditLog.failures++
+		return err
+	}
+	return nil
+}
+
+// Failures returns the number of events that could not be written since the log was opened
+func (auditLog *Log) Failures() uint64 {
+	auditLog.mutex.Lock()
+	defer auditLog.mutex.Unlock()
+	return auditLog.failures
+}
+
+func (auditLog *Log) record(event Event) error {
+
+	if err := auditLog.rotateIfNeeded(); err != nil {
+		return err
+	}
+	event.Sequence = auditLog.sequence + 1
+	event.Time = auditLog.now().UTC()
+	event.Previous = auditLog.lastHash
+	hash, err := event.computeHash()
+	if err != nil {
+		return err
+	}
+	event.Hash = hash
+	line, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+
+	if auditLog.file == nil {
+		if err := auditLog.open(auditLog.fileFor(event.Sequence)); err != nil {
+			return err
+		}
+	}
+	if _, err := auditLog.file.Write(line); err != nil {
+		return err
+	}
+	if err := auditLog.file.Sync(); err != nil {
+		return err
+	}
+	auditLog.size += int64(len(line))
+	auditLog.sequence = event.Sequence
+	auditLog.lastHash = event.Hash
+	return nil
+}
+
+// Head returns the last event as an anchor. Keeping it elsewhere lets verification detect events
+// cut from the end of the log.
+func (auditLog *Log) Head() Anchor {
+	auditLog.mutex.Lock()
+	defer auditLog.mutex.Unlock()
+	return Anchor{Sequence: auditLog.sequence, Hash: auditLog.lastHash}
+}
+
+// Close closes the current file
+func (auditLog *Log) Close() error {
+	auditLog.mutex.Lock()
+	defer auditLog.mutex.Unlock()
+	if auditLog.file == nil {
+		return nil
+	}
+	err := auditLog.file.Close()
+	auditLog.file = nil
+	return err
+}
+
+// rotateIfNeeded seals the current file when it is full or old; the next event opens a new one
+func (auditLog *Log) rotateIfNeeded() error {
+	if auditLog.file == nil {
+		return nil
+	}
+	full := auditLog.options.MaxBytes > 0 && auditLog.size >= auditLog.options.MaxBytes
+	old := auditLog.options.MaxAge > 0 && auditLog.now().Sub(auditLog.opened) >= auditLog.options.MaxAge
+	if !full && !old {
+		return nil
+	}
+	name := auditLog.file.Name()
+	if err := auditLog.file.Close(); err != nil {
+		return err
+	}
+	auditLog.file = nil
+	return os.Chmod(name, 0400)
+}
+
+func (auditLog *Log) open(path string) error {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+	auditLog.file = file
+	auditLog.size = info.Size()
+	if info.Size() == 0 || auditLog.opened.IsZero() {
+		auditLog.opened = auditLog.now()
+	}
+	return nil
+}
+
+// fileFor names a file after its first sequence number so files sort in chain order
+func (auditLog *Log) fileFor(sequence uint64) string {
+	return filepath.Join(auditLog.options.Dir, fmt.Sprintf("%s%020d%s", filePrefix, sequence, fileSuffix))
+}
+
+// logFiles returns the audit files in the directory in chain order
+func logFiles(dir string) ([]string, error) {
+	files, err := filepath.Glob(filepath.Join(dir, filePrefix+"*"+fileSuffix))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// cutTornLine truncates a writable file after its last complete line and returns the bytes cut.
+// Sealed files were closed cleanly and are left alone.
+func cutTornLine(path string) (int64, error) {
+	info, err := os.Stat(path)
+	if err != nil || info.Mode().Perm()&0200 == 0 {
+		return 0, err
+	}
+	file, err := os.OpenFile(path, os.O_RDWR, 0)
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+
+	size := info.Size()
+	end := size
+	chunk := make([]byte, 4096)
+	for end > 0 {
+		start := max(end-int64(len(chunk)), 0)
+		data := chunk[:end-start]
+		if _, err := file.ReadAt(data, start); err != nil {
+			return 0, err
+		}
+		if i := bytes.LastIndexByte(data, '\n'); i >= 0 {
+			end = start + int64(i) + 1
+			break
+		}
+		end = start
+	}
+	if end == size {
+		return 0, nil
+	}
+	if err := file.Truncate(end); err != nil {
+		return 0, err
+	}
+	return size - end, file.Sync()
+}
+
+// fileBounds returns the first and last events of the file, or nil for an empty file
+func fileBounds(path string) (*Event, *Event, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer file.Close()
+
+	var first, last *Event
+	err = scanEvents(file, func(_ int, event Event) error {
+		if first == nil {
+			first = &event
+		}
+		last = &event
+		return nil
+	})
+	return first, last, err
+}
+
+// scanEvents decodes every line of the reader, reporting line numbers from 1
+func scanEvents(reader io.Reader, visit func(line int, event Event) error) error {
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if strings.TrimSpace(scanner.Text()) == "" {
+			continue
+		}
+		var event Event
+		if err := json.Unmarshal(scanner.Bytes(), &event); err != nil {
+			return fmt.Errorf("line %d: %w", line, err)
+		}
+		if err := visit(line, event); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
@@ -0,0 +1,224 @@
+package audit_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"flight-itinerary-go/internal/audit"
+	"flight-itinerary-go/internal/model"
+)
+
+func TestAudit(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Audit Suite")
+}
+
+var _ = Describe("Audit log", func() {
+	var dir string
+
+	record := func(auditLog *audit.Log, count int) {
+		for i := 0; i < count; i++ {
+			Expect(auditLog.Record(audit.Event{
+				Actor:     "key:abc",
+				Action:    audit.ActionCreate,
+				Itinerary: "itinerary-" + string(rune('a'+i)),
+				Version:   1,
+				Channel:   audit.ChannelHTTP,
+				Route:     "POST /api/v1/itineraries",
+				Status:    201,
+			})).To(Succeed())
+		}
+	}
+
+	files := func() []string {
+		paths, err := filepath.Glob(filepath.Join(dir, "audit-*.log"))
+		Expect(err).ToNot(HaveOccurred())
+		return paths
+	}
+
+	rewrite := func(path string, change func(lines []string) []string) {
+		Expect(os.Chmod(path, 0600)).To(Succeed())
+		data, err := os.ReadFile(path)
+		Expect(err).ToNot(HaveOccurred())
+		lines := change(strings.Split(strings.TrimSuffix(string(data), "\n"), "\n"))
+		Expect(os.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0600)).To(Succeed())
+	}
+
+	BeforeEach(func() {
+		dir = GinkgoT().TempDir()
+	})
+
+	It("should chain events and resume the chain when reopened", func() {
+		auditLog, err := audit.NewLog(audit.Options{Dir: dir})
+		Expect(err).ToNot(HaveOccurred())
+		record(auditLog, 3)
+		head := auditLog.Head()
+		Expect(head.Sequence).To(Equal(uint64(3)))
+		Expect(auditLog.Close()).To(Succeed())
+
+		auditLog, err = audit.NewLog(audit.Options{Dir: dir})
+		Expect(err).ToNot(HaveOccurred())
+		record(auditLog, 2)
+		Expect(auditLog.Close()).To(Succeed())
+
+		report, err := audit.Verify(dir, head)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(report.Valid()).To(BeTrue(), "%v", report.Problems)
+		Expect(report.Events).To(Equal(5))
+		Expect(report.Last).To(Equal(uint64(5)))
+		Expect(files()).To(HaveLen(1))
+	})
+
+	It("should cut a partly written last event and record the gap when reopened", func() {
+		auditLog, err := audit.NewLog(audit.Options{Dir: dir})
+		Expect(err).ToNot(HaveOccurred())
+		record(auditLog, 2)
+		Expect(auditLog.Close()).To(Succeed())
+		path := files()[0]
+		file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0600)
+		Expect(err).ToNot(HaveOccurred())
+		_, err = file.WriteString(`{"seq":3,"time":"2024-05-01T09:3`)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(file.Close()).To(Succeed())
+
+		auditLog, err = audit.NewLog(audit.Options{Dir: dir})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(auditLog.Head().Sequence).To(Equal(uint64(3)))
+		record(auditLog, 1)
+		Expect(auditLog.Close()).To(Succeed())
+
+		data, err := os.ReadFile(path)
+		Expect(err).ToNot(HaveOccurred())
+		lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+		Expect(lines).To(HaveLen(4))
+		Expect(lines[2]).To(ContainSubstring(`"action":"gap"`))
+		Expect(lines[2]).To(ContainSubstring("32 bytes cut"))
+		report, err := audit.Verify(dir)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(report.Valid()).To(BeTrue(), "%v", report.Problems)
+		Expect(report.Events).To(Equal(4))
+	})
+
+	It("should count events it could not write", func() {
+		logDir := filepath.Join(dir, "log")
+		auditLog, err := audit.NewLog(audit.Options{Dir: logDir})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(os.RemoveAll(logDir)).To(Succeed())
+		Expect(os.WriteFile(logDir, nil, 0600)).To(Succeed())
+
+		Expect(auditLog.Record(audit.Event{Actor: "key:abc", Action: audit.ActionCreate})).ToNot(Succeed())
+		Expect(auditLog.Failures()).To(Equal(uint64(1)))
+		Expect(auditLog.Head().Sequence).To(BeZero())
+	})
+
+	It("should digest tickets regardless of their order", func() {
+		digest := audit.TicketDigest([]model.Ticket{{"JFK", "LAX"}, {"LAX", "SFO"}})
+
+		Expect(audit.TicketDigest([]model.Ticket{{"LAX", "SFO"}, {"JFK", "LAX"}})).To(Equal(digest))
+		Expect(audit.TicketDigest([]model.Ticket{{"JFK", "LAX"}, {"LAX", "SFO"}, {"LAX", "SFO"}})).ToNot(Equal(digest))
+		Expect(digest).To(MatchRegexp(`^[0-9a-f]{64}$`))
+	})
+
+	It("should rotate full files, seal them and keep the chain across files", func() {
+		auditLog, err := audit.NewLog(audit.Options{Dir: dir, MaxBytes: 600})
+		Expect(err).ToNot(HaveOccurred())
+		record(auditLog, 6)
+		Expect(auditLog.Close()).To(Succeed())
+
+		paths := files()
+		Expect(len(paths)).To(BeNumerically(">", 1))
+		info, err := os.Stat(paths[0])
+		Expect(err).ToNot(HaveOccurred())
+		Expect(info.Mode().Perm()).To(Equal(os.FileMode(0400)))
+		Expect(filepath.Base(paths[0])).To(Equal("audit-00000000000000000001.log"))
+
+		auditLog, err = audit.NewLog(audit.Options{Dir: dir, MaxBytes: 600})
+		Expect(err).ToNot(HaveOccurred())
+		record(auditLog, 1)
+		Expect(auditLog.Close()).To(Succeed())
+
+		report, err := audit.Verify(dir)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(report.Valid()).To(BeTrue(), "%v", report.Problems)
+		Expect(report.Events).To(Equal(7))
+		Expect(report.Files).To(Equal(len(files())))
+	})
+
+	It("should detect edited, removed and reordered events", func() {
+		auditLog, err := audit.NewLog(audit.Options{Dir: dir})
+		Expect(err).ToNot(HaveOccurred())
+		record(auditLog, 4)
+		Expect(auditLog.Close()).To(Succeed())
+		path := files()[0]
+		original, err := os.ReadFile(path)
+		Expect(err).ToNot(HaveOccurred())
+
+		rewrite(path, func(lines []string) []string {
+			lines[1] = strings.Replace(lines[1], `"actor":"key:abc"`, `"actor":"key:xyz"`, 1)
+			return lines
+		})
+		report, err := audit.Verify(dir)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(report.Valid()).To(BeFalse())
+		Expect(report.Problems[0].Sequence).To(Equal(uint64(2)))
+		Expect(report.Problems[0].Message).To(ContainSubstring("does not match its hash"))
+
+		Expect(os.WriteFile(path, original, 0600)).To(Succeed())
+		rewrite(path, func(lines []string) []string {
+			return append(lines[:1], lines[2:]...)
+		})
+		report, _ = audit.Verify(dir)
+		Expect(report.Valid()).To(BeFalse())
+		Expect(report.Problems[0].String()).To(ContainSubstring("expected event 2, found 3"))
+
+		Expect(os.WriteFile(path, original, 0600)).To(Succeed())
+		rewrite(path, func(lines []string) []string {
+			lines[1], lines[2] = lines[2], lines[1]
+			return lines
+		})
+		report, _ = audit.Verify(dir)
+		Expect(report.Valid()).To(BeFalse())
+	})
+
+	It("should detect removed files and, with an anchor, a truncated log", func() {
+		auditLog, err := audit.NewLog(audit.Options{Dir: dir, MaxBytes: 600})
+		Expect(err).ToNot(HaveOccurred())
+		record(auditLog, 6)
+		head := auditLog.Head()
+		Expect(auditLog.Close()).To(Succeed())
+
+		paths := files()
+		Expect(os.Remove(paths[len(paths)-1])).To(Succeed())
+		report, err := audit.Verify(dir)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(report.Valid()).To(BeTrue())
+		report, _ = audit.Verify(dir, head)
+		Expect(report.Valid()).To(BeFalse())
+		Expect(report.Problems[0].Message).To(ContainSubstring("is missing"))
+
+		Expect(os.Remove(paths[0])).To(Succeed())
+		report, _ = audit.Verify(dir)
+		Expect(report.Valid()).To(BeFalse())
+		Expect(report.Problems[0].Message).To(ContainSubstring("expected event 1"))
+	})
+
+	It("should parse anchors", func() {
+		auditLog, err := audit.NewLog(audit.Options{Dir: dir})
+		Expect(err).ToNot(HaveOccurred())
+		record(auditLog, 1)
+		head := auditLog.Head()
+
+		parsed, err := audit.ParseAnchor(head.String())
+		Expect(err).ToNot(HaveOccurred())
+		Expect(parsed).To(Equal(head))
+		_, err = audit.ParseAnchor("7")
+		Expect(err).To(HaveOccurred())
+		_, err = audit.ParseAnchor("x:" + head.Hash)
+		Expect(err).To(HaveOccurred())
+	})
+})
@@ -0,0 +1,128 @@
+package audit
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Problem is a break in the audit chain
+type Problem struct {
+	File     string `json:"file"`
+	Line     int    `json:"line,omitempty"`
+	Sequence uint64 `json:"seq,omitempty"`
+	Message  string `json:"message"`
+}
+
+func (problem Problem) String() string {
+	location := filepath.Base(problem.File)
+	if problem.Line > 0 {
+		location += ":" + strconv.Itoa(problem.Line)
+	}
+	return location + ": " + problem.Message
+}
+
+// Anchor is an event known from elsewhere, such as the head the server logged at shutdown
+type Anchor struct {
+	Sequence uint64
+	Hash     string
+}
+
+// ParseAnchor reads an anchor written as SEQ:HASH
+func ParseAnchor(value string) (Anchor, error) {
+	sequence, hash, found := strings.Cut(value, ":")
+	parsed, err := strconv.ParseUint(sequence, 10, 64)
+	if !found || err != nil || len(hash) != len(genesisHash) {
+		return Anchor{}, fmt.Errorf("anchor %q must be written as SEQ:HASH", value)
+	}
+	return Anchor{Sequence: parsed, Hash: hash}, nil
+}
+
+func (anchor Anchor) String() string {
+	return strconv.FormatUint(anchor.Sequence, 10) + ":" + anchor.Hash
+}
+
+// Report is the outcome of verifying an audit log
+type Report struct {
+	Files  int `json:"files"`
+	Events int `json:"events"`
+	// Last and Head are the sequence number and hash of the last event
+	Last     uint64    `json:"last"`
+	Head     string    `json:"head"`
+	Problems []Problem `json:"problems,omitempty"`
+}
+
+// Valid reports whether the chain is intact
+func (report Report) Valid() bool {
+	return len(report.Problems) == 0
+}
+
+// Verify recomputes the hash chain of every file in the directory. Edited, inserted, removed or
+// reordered events and missing files are reported as problems. Events cut from the end of the log
+// leave an intact chain; they are detected through anchors, events the chain must contain.
+func Verify(dir string, anchors ...Anchor) (Report, error) {
+	files, err := logFiles(dir)
+	if err != nil {
+		return Report{}, err
+	}
+	report := Report{Files: len(files), Head: genesisHash}
+	expected := make(map[uint64]string, len(anchors))
+	for _, anchor := range anchors {
+		expected[anchor.Sequence] = anchor.Hash
+	}
+
+	for _, path := range files {
+		file, err := os.Open(path)
+		if err != nil {
+			return report, err
+		}
+		firstInFile := true
+		err = scanEvents(file, func(line int, event Event) error {
+			problem := func(format string, args ...interface{}) {
+				report.Problems = append(report.Problems, Problem{
+					File:     path,
+					Line:     line,
+					Sequence: event.Sequence,
+					Message:  fmt.Sprintf(format, args...),
+				})
+			}
+			if firstInFile && !strings.HasSuffix(path, fmt.Sprintf("%020d%s", event.Sequence, fileSuffix)) {
+				problem("file does not start with event %d", event.Sequence)
+			}
+			firstInFile = false
+
+			if event.Sequence != report.Last+1 {
+				problem("expected event %d, found %d", report.Last+1, event.Sequence)
+			}
+			if event.Previous != report.Head {
+				problem("previous hash does not match event %d", report.Last)
+			}
+			if hash, err := event.computeHash(); err != nil || hash != event.Hash {
+				problem("event content does not match its hash")
+			}
+			if hash, anchored := expected[event.Sequence]; anchored && hash != event.Hash {
+				problem("event does not match the anchor")
+			}
+			report.Events++
+			report.Last = event.Sequence
+			report.Head = event.Hash
+			return nil
+		})
+		file.Close()
+		if err != nil {
+			report.Problems = append(report.Problems, Problem{File: path, Message: err.Error()})
+		}
+	}
+	for _, anchor := range anchors {
+		if anchor.Sequence > report.Last {
+			report.Problems = append(report.Problems, Problem{
+				File:     dir,
+				Sequence: anchor.Sequence,
+				Message:  fmt.Sprintf("anchored event %d is missing; the log ends at %d", anchor.Sequence, report.Last),
+			})
+		}
+	}
+	return report, nil
+}
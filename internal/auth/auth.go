@@ -0,0 +1,59 @@
+package auth
+
+import (
+	"slices"
+
+	"flight-itinerary-go/pkg/errors"
+)
+
+// Scopes granted to credentials; ScopeAdmin grants every other scope
+const (
+	ScopeReconstruct   = "itinerary:reconstruct"
+	ScopeRead          = "itinerary:read"
+	ScopeWrite         = "itinerary:write"
+	ScopeAnalyticsRead = "analytics:read"
+	ScopeAdmin         = "admin"
+)
+
+// Scopes lists every supported scope
+var Scopes = []string{ScopeReconstruct, ScopeRead, ScopeWrite, ScopeAnalyticsRead, ScopeAdmin}
+
+var (
+	ErrMissingCredentials = errors.NewUnauthorizedError("credentials are required")
+	ErrInvalidCredentials = errors.NewUnauthorizedError("invalid credentials")
+)
+
+// Principal is the authenticated caller
+type Principal struct {
+	// Subject identifies the caller, e.g. the API key ID
+	Subject string
+	Name    string
+	Scopes  []string
+	// Tier selects the caller's rate limits; empty means the default tier
+	Tier string
+	// Tenant binds the caller to one tenant; unbound administrators may choose the tenant per request
+	Tenant string
+}
+
+// HasScope reports whether the principal was granted the scope directly or through admin
+func (principal *Principal) HasScope(scope string) bool {
+	return slices.Contains(principal.Scopes, scope) || slices.Contains(principal.Scopes, ScopeAdmin)
+}
+
+// Authenticator verifies a credential presented by a caller
+type Authenticator interface {
+	Authenticate(credential string) (*Principal, error)
+}
+
+// ValidateScopes checks that every scope is supported
+func ValidateScopes(scopes []string) error {
+	if len(scopes) == 0 {
+		return errors.NewValidationError("at least one scope is required")
+	}
+	for _, scope := range scopes {
+		if !slices.Contains(Scopes, scope) {
+			return errors.NewValidationError("unknown scope %q", scope)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,136 @@
+package auth_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.uber.org/zap"
+
+	"flight-itinerary-go/internal/auth"
+)
+
+func TestAuth(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Auth Suite")
+}
+
+var _ = Describe("Auth", func() {
+	Describe("Principal", func() {
+		It("should grant scopes directly or through admin", func() {
+			reader := &auth.Principal{Scopes: []string{auth.ScopeRead}}
+			admin := &auth.Principal{Scopes: []string{auth.ScopeAdmin}}
+
+			Expect(reader.HasScope(auth.ScopeRead)).To(BeTrue())
+			Expect(reader.HasScope(auth.ScopeWrite)).To(BeFalse())
+			Expect(admin.HasScope(auth.ScopeWrite)).To(BeTrue())
+		})
+	})
+
+	Describe("KeyStore", func() {
+		var (
+			path  string
+			store *auth.KeyStore
+		)
+
+		BeforeEach(func() {
+			path = filepath.Join(GinkgoT().TempDir(), "api-keys.json")
+			var err error
+			store, err = auth.NewKeyStore(path)
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("should authenticate a created key with its scopes and tenant", func() {
+			plaintext, key, err := store.Create("ci", []string{auth.ScopeReconstruct, auth.ScopeWrite}, "", "acme")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(auth.IsAPIKey(plaintext)).To(BeTrue())
+
+			principal, err := store.Authenticate(plaintext)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(principal.Subject).To(Equal("key:" + key.ID))
+			Expect(principal.Name).To(Equal("ci"))
+			Expect(principal.HasScope(auth.ScopeWrite)).To(BeTrue())
+			Expect(principal.HasScope(auth.ScopeAdmin)).To(BeFalse())
+			Expect(principal.Tenant).To(Equal("acme"))
+		})
+
+		It("should keep only a hash of the secret at rest", func() {
+			plaintext, _, err := store.Create("ci", []string{auth.ScopeRead}, "", "")
+			Expect(err).ToNot(HaveOccurred())
+
+			data, err := os.ReadFile(path)
+			Expect(err).ToNot(HaveOccurred())
+			secret := plaintext[strings.LastIndex(plaintext, "_")+1:]
+			Expect(string(data)).ToNot(ContainSubstring(secret))
+
+			info, err := os.Stat(path)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(info.Mode().Perm()).To(Equal(os.FileMode(0600)))
+		})
+
+		It("should reject unknown, tampered and revoked keys", func() {
+			plaintext, key, err := store.Create("ci", []string{auth.ScopeRead}, "", "")
+			Expect(err).ToNot(HaveOccurred())
+
+			_, err = store.Authenticate("not-a-key")
+			Expect(err).To(Equal(auth.ErrInvalidCredentials))
+			_, err = store.Authenticate(plaintext + "x")
+			Expect(err).To(Equal(auth.ErrInvalidCredentials))
+
+			Expect(store.Revoke(key.ID)).To(Succeed())
+			_, err = store.Authenticate(plaintext)
+			Expect(err).To(Equal(auth.ErrInvalidCredentials))
+			Expect(store.Revoke("missing")).To(Equal(auth.ErrAPIKeyNotFound))
+
+			keys, err := store.List()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(keys).To(HaveLen(1))
+			Expect(keys[0].RevokedAt).ToNot(BeNil())
+		})
+
+		It("should reject unknown scopes", func() {
+			_, _, err := store.Create("ci", []string{"itinerary:delete"}, "", "")
+			Expect(err).To(HaveOccurred())
+			_, _, err = store.Create("ci", nil, "", "")
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("should pick up keys changed by another process while watching", func() {
+			Expect(store.Watch(zap.NewNop())).To(Succeed())
+			DeferCleanup(store.Close)
+			other, err := auth.NewKeyStore(path)
+			Expect(err).ToNot(HaveOccurred())
+			plaintext, key, err := other.Create("cli", []string{auth.ScopeRead}, "", "")
+			Expect(err).ToNot(HaveOccurred())
+
+			authenticate := func() error {
+				_, err := store.Authenticate(plaintext)
+				return err
+			}
+			Eventually(authenticate).Should(Succeed())
+
+			// Ensure the revocation changes the file's modification time
+			time.Sleep(10 * time.Millisecond)
+			Expect(other.Revoke(key.ID)).To(Succeed())
+			Eventually(authenticate).Should(Equal(auth.ErrInvalidCredentials))
+		})
+
+		It("should authenticate from the keys last read without a watch", func() {
+			other, err := auth.NewKeyStore(path)
+			Expect(err).ToNot(HaveOccurred())
+			plaintext, _, err := other.Create("cli", []string{auth.ScopeRead}, "", "")
+			Expect(err).ToNot(HaveOccurred())
+
+			_, err = store.Authenticate(plaintext)
+			Expect(err).To(Equal(auth.ErrInvalidCredentials))
+			_, err = store.List()
+			Expect(err).ToNot(HaveOccurred())
+			_, err = store.Authenticate(plaintext)
+			Expect(err).ToNot(HaveOccurred())
+		})
+	})
+})
@@ -0,0 +1,39 @@
+package auth
+
+import (
+	"crypto/x509"
+)
+
+// PeerAuthenticator identifies callers by the client certificate verified during the TLS handshake
+type PeerAuthenticator interface {
+	AuthenticatePeer(certificate *x509.Certificate) (*Principal, error)
+}
+
+// CertificateAuthenticator grants fixed scopes to every caller presenting a verified client
+// certificate and passes other credentials to the wrapped authenticator
+type CertificateAuthenticator struct {
+	Authenticator
+	scopes []string
+}
+
+// NewCertificateAuthenticator accepts client certificates in addition to the authenticator's
+// credentials
+func NewCertificateAuthenticator(authenticator Authenticator, scopes []string) (*CertificateAuthenticator, error) {
+	if err := ValidateScopes(scopes); err != nil {
+		return nil, err
+	}
+	return &CertificateAuthenticator{Authenticator: authenticator, scopes: scopes}, nil
+}
+
+// AuthenticatePeer identifies the caller by the certificate's subject
+func (certificateAuthenticator *CertificateAuthenticator) AuthenticatePeer(certificate *x509.Certificate) (*Principal, error) {
+	name := certificate.Subject.CommonName
+	if name == "" {
+		name = certificate.Subject.String()
+	}
+	return &Principal{
+		Subject: "cert:" + certificate.Subject.String(),
+		Name:    name,
+		Scopes:  certificateAuthenticator.scopes,
+	}, nil
+}
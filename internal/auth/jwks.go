@@ -0,0 +1,199 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/fsnotify/fsnotify"
+	"go.uber.org/zap"
+)
+
+// jsonWebKey is a public key in JWK format (RFC 7517); only RSA and EC keys are supported
+type jsonWebKey struct {
+	KeyType string `json:"kty"`
+	KeyID   string `json:"kid"`
+	Use     string `json:"use"`
+	N       string `json:"n"`
+	E       string `json:"e"`
+	Curve   string `json:"crv"`
+	X       string `json:"x"`
+	Y       string `json:"y"`
+}
+
+// JWKS holds the token signing keys from a JWKS file. The file is watched and re-read when it
+// changes; a file that fails to parse is logged and the previous keys stay in use.
+type JWKS struct {
+	mutex   sync.RWMutex
+	path    string
+	keys    map[string]crypto.PublicKey
+	watcher *fsnotify.Watcher
+	logger  *zap.Logger
+}
+
+// NewJWKS loads the key set at path and watches it for changes
+func NewJWKS(path string, logger *zap.Logger) (*JWKS, error) {
+	keys, err := readJWKS(path)
+	if err != nil {
+		return nil, err
+	}
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+	// The directory is watched because editors and secret managers replace the file by renaming
+	if err := watcher.Add(filepath.Dir(path)); err != nil {
+		watcher.Close()
+		return nil, err
+	}
+
+	jwks := &JWKS{
+		path:    path,
+		keys:    keys,
+		watcher: watcher,
+		logger:  logger,
+	}
+	go jwks.watch()
+	return jwks, nil
+}
+
+// Key returns the key with the ID; an empty ID selects the only key of a single-key set
+func (jwks *JWKS) Key(id string) (crypto.PublicKey, bool) {
+	jwks.mutex.RLock()
+	defer jwks.mutex.RUnlock()
+	if id == "" && len(jwks.keys) == 1 {
+		for _, key := range jwks.keys {
+			return key, true
+		}
+	}
+	key, exists := jwks.keys[id]
+	return key, exists
+}
+
+// Reload re-reads the file, keeping the current keys if it is invalid
+func (jwks *JWKS) Reload() error {
+	keys, err := readJWKS(jwks.path)
+	if err != nil {
+		return err
+	}
+	jwks.mutex.Lock()
+	jwks.keys = keys
+	jwks.mutex.Unlock()
+	return nil
+}
+
+// Close stops watching the file
+func (jwks *JWKS) Close() error {
+	return jwks.watcher.Close()
+}
+
+func (jwks *JWKS) watch() {
+	name := filepath.Clean(jwks.path)
+	for {
+		select {
+		case event, ok := <-jwks.watcher.Events:
+			if !ok {
+				return
+			}
+			if filepath.Clean(event.Name) != name || !event.Has(fsnotify.Write|fsnotify.Create|fsnotify.Rename) {
+				continue
+			}
+			if err := jwks.Reload(); err != nil {
+				jwks.logger.Error("JWKS reload failed, keeping previous keys", zap.String("path", jwks.path), zap.Error(err))
+				continue
+			}
+			jwks.logger.Info("JWKS reloaded", zap.String("path", jwks.path))
+		case err, ok := <-jwks.watcher.Errors:
+			if !ok {
+				return
+			}
+			jwks.logger.Error("JWKS watch failed", zap.String("path", jwks.path), zap.Error(err))
+		}
+	}
+}
+
+func readJWKS(path string) (map[string]crypto.PublicKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var set struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, fmt.Errorf("invalid JWKS: %w", err)
+	}
+	if len(set.Keys) == 0 {
+		return nil, fmt.Errorf("JWKS has no keys")
+	}
+
+	keys := make(map[string]crypto.PublicKey, len(set.Keys))
+	for _, jwk := range set.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		key, err := jwk.publicKey()
+		if err != nil {
+			return nil, fmt.Errorf("key %q: %w", jwk.KeyID, err)
+		}
+		keys[jwk.KeyID] = key
+	}
+	return keys, nil
+}
+
+func (jwk jsonWebKey) publicKey() (crypto.PublicKey, error) {
+	switch jwk.KeyType {
+	case "RSA":
+		n, err := decodeBigInt(jwk.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeBigInt(jwk.E)
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch jwk.Curve {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve %q", jwk.Curve)
+		}
+		x, err := decodeBigInt(jwk.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeBigInt(jwk.Y)
+		if err != nil {
+			return nil, err
+		}
+		key := &ecdsa.PublicKey{Curve: curve, X: x, Y: y}
+		if _, err := key.ECDH(); err != nil {
+			return nil, fmt.Errorf("point is not on curve %s", jwk.Curve)
+		}
+		return key, nil
+	}
+	return nil, fmt.Errorf("unsupported key type %q", jwk.KeyType)
+}
+
+func decodeBigInt(value string) (*big.Int, error) {
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil || len(data) == 0 {
+		return nil, fmt.Errorf("invalid key parameter")
+	}
+	return new(big.Int).SetBytes(data), nil
+}
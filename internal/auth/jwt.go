@@ -0,0 +1,188 @@
+package auth
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"go.uber.org/zap"
+)
+
+// DefaultRoles maps the roles issued by the gateway to scopes
+var DefaultRoles = map[string][]string{
+	"viewer":  {ScopeReconstruct, ScopeRead},
+	"editor":  {ScopeReconstruct, ScopeRead, ScopeWrite},
+	"analyst": {ScopeAnalyticsRead},
+	"admin":   {ScopeAdmin},
+}
+
+// LoadRoles reads a role-to-scopes mapping from a JSON object such as {"viewer": ["itinerary:read"]}
+func LoadRoles(path string) (map[string][]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var roles map[string][]string
+	if err := json.Unmarshal(data, &roles); err != nil {
+		return nil, fmt.Errorf("invalid roles file: %w", err)
+	}
+	for role, scopes := range roles {
+		if err := ValidateScopes(scopes); err != nil {
+			return nil, fmt.Errorf("role %q: %w", role, err)
+		}
+	}
+	return roles, nil
+}
+
+// TokenOptions configures JWT validation
+type TokenOptions struct {
+	// Issuer and Audience must match the iss and aud claims when set
+	Issuer   string
+	Audience string
+	// Leeway tolerates clock skew when checking exp, nbf and iat
+	Leeway time.Duration
+	// RolesClaim names the claim listing the caller's roles; dots address nested claims such as
+	// realm_access.roles. Defaults to roles.
+	RolesClaim string
+	// Roles maps role names to scopes; defaults to DefaultRoles
+	Roles map[string][]string
+	// TierClaim names the claim holding the caller's rate limit tier. Defaults to tier.
+	TierClaim string
+	// TenantClaim names the claim binding the caller to a tenant. Defaults to tenant.
+	TenantClaim string
+}
+
+// TokenAuthenticator validates RS256 and ES256 JWTs signed by a key of the JWKS and grants the
+// scopes of the roles in their claims
+type TokenAuthenticator struct {
+	jwks    *JWKS
+	options TokenOptions
+	parser  *jwt.Parser
+	logger  *zap.Logger
+}
+
+// NewTokenAuthenticator creates an authenticator for tokens signed by the key set
+func NewTokenAuthenticator(jwks *JWKS, options TokenOptions, logger *zap.Logger) *TokenAuthenticator {
+	if options.RolesClaim == "" {
+		options.RolesClaim = "roles"
+	}
+	if options.Roles == nil {
+		options.Roles = DefaultRoles
+	}
+	if options.TierClaim == "" {
+		options.TierClaim = "tier"
+	}
+	if options.TenantClaim == "" {
+		options.TenantClaim = "tenant"
+	}
+	parserOptions := []jwt.ParserOption{
+		jwt.WithValidMethods([]string{jwt.SigningMethodRS256.Alg(), jwt.SigningMethodES256.Alg()}),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+		jwt.WithLeeway(options.Leeway),
+	}
+	if options.Issuer != "" {
+		parserOptions = append(parserOptions, jwt.WithIssuer(options.Issuer))
+	}
+	if options.Audience != "" {
+		parserOptions = append(parserOptions, jwt.WithAudience(options.Audience))
+	}
+	return &TokenAuthenticator{
+		jwks:    jwks,
+		options: options,
+		parser:  jwt.NewParser(parserOptions...),
+		logger:  logger,
+	}
+}
+
+// Authenticate validates the token and returns its subject with the scopes of its roles
+func (tokenAuthenticator *TokenAuthenticator) Authenticate(credential string) (*Principal, error) {
+	claims := jwt.MapClaims{}
+	_, err := tokenAuthenticator.parser.ParseWithClaims(credential, claims, func(token *jwt.Token) (interface{}, error) {
+		keyID, _ := token.Header["kid"].(string)
+		key, exists := tokenAuthenticator.jwks.Key(keyID)
+		if !exists {
+			return nil, jwt.ErrTokenUnverifiable
+		}
+		return key, nil
+	})
+	if err != nil {
+		tokenAuthenticator.logger.Info("Rejected token", zap.Error(err))
+		return nil, ErrInvalidCredentials
+	}
+	subject, err := claims.GetSubject()
+	if err != nil || subject == "" {
+		tokenAuthenticator.logger.Info("Rejected token without subject")
+		return nil, ErrInvalidCredentials
+	}
+
+	var scopes []string
+	for _, role := range tokenAuthenticator.roles(claims) {
+		for _, scope := range tokenAuthenticator.options.Roles[role] {
+			if !slices.Contains(scopes, scope) {
+				scopes = append(scopes, scope)
+			}
+		}
+	}
+	name, _ := claims["name"].(string)
+	if name == "" {
+		name = subject
+	}
+	tier, _ := claims[tokenAuthenticator.options.TierClaim].(string)
+	tenant, _ := claims[tokenAuthenticator.options.TenantClaim].(string)
+	return &Principal{
+		Subject: "jwt:" + subject,
+		Name:    name,
+		Scopes:  scopes,
+		Tier:    tier,
+		Tenant:  tenant,
+	}, nil
+}
+
+// roles reads the roles claim, given either as a list or as a space-separated string
+func (tokenAuthenticator *TokenAuthenticator) roles(claims jwt.MapClaims) []string {
+	var value interface{} = map[string]interface{}(claims)
+	for _, name := range strings.Split(tokenAuthenticator.options.RolesClaim, ".") {
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		value = object[name]
+	}
+
+	switch roles := value.(type) {
+	case string:
+		return strings.Fields(roles)
+	case []interface{}:
+		names := make([]string, 0, len(roles))
+		for _, role := range roles {
+			if name, ok := role.(string); ok {
+				names = append(names, name)
+			}
+		}
+		return names
+	}
+	return nil
+}
+
+// CompositeAuthenticator sends API keys to one authenticator and other bearer tokens to another
+type CompositeAuthenticator struct {
+	keys   Authenticator
+	tokens Authenticator
+}
+
+// NewCompositeAuthenticator accepts both API keys and tokens
+func NewCompositeAuthenticator(keys, tokens Authenticator) *CompositeAuthenticator {
+	return &CompositeAuthenticator{keys: keys, tokens: tokens}
+}
+
+func (compositeAuthenticator *CompositeAuthenticator) Authenticate(credential string) (*Principal, error) {
+	if IsAPIKey(credential) {
+		return compositeAuthenticator.keys.Authenticate(credential)
+	}
+	return compositeAuthenticator.tokens.Authenticate(credential)
+}
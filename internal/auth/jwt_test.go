@@ -0,0 +1,178 @@
+package auth_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.uber.org/zap"
+
+	"flight-itinerary-go/internal/auth"
+)
+
+func encodeBigInt(value *big.Int) string {
+	return base64.RawURLEncoding.EncodeToString(value.Bytes())
+}
+
+func rsaJWK(id string, key *rsa.PrivateKey) map[string]string {
+	return map[string]string{
+		"kty": "RSA", "kid": id, "use": "sig",
+		"n": encodeBigInt(key.N), "e": encodeBigInt(big.NewInt(int64(key.E))),
+	}
+}
+
+func ecJWK(id string, key *ecdsa.PrivateKey) map[string]string {
+	return map[string]string{
+		"kty": "EC", "kid": id, "crv": "P-256",
+		"x": encodeBigInt(key.X), "y": encodeBigInt(key.Y),
+	}
+}
+
+func writeJWKS(path string, keys ...map[string]string) {
+	data, err := json.Marshal(map[string]interface{}{"keys": keys})
+	Expect(err).ToNot(HaveOccurred())
+	Expect(os.WriteFile(path, data, 0600)).To(Succeed())
+}
+
+var _ = Describe("TokenAuthenticator", func() {
+	var (
+		path          string
+		rsaKey        *rsa.PrivateKey
+		ecKey         *ecdsa.PrivateKey
+		jwks          *auth.JWKS
+		authenticator *auth.TokenAuthenticator
+	)
+
+	sign := func(method jwt.SigningMethod, keyID string, key interface{}, claims jwt.MapClaims) string {
+		token := jwt.NewWithClaims(method, claims)
+		token.Header["kid"] = keyID
+		signed, err := token.SignedString(key)
+		Expect(err).ToNot(HaveOccurred())
+		return signed
+	}
+
+	validClaims := func() jwt.MapClaims {
+		return jwt.MapClaims{
+			"sub":    "alice",
+			"iss":    "https://sso.example.com",
+			"aud":    "flight-itinerary",
+			"exp":    time.Now().Add(time.Hour).Unix(),
+			"iat":    time.Now().Unix(),
+			"roles":  []string{"editor", "unknown"},
+			"tenant": "acme",
+		}
+	}
+
+	BeforeEach(func() {
+		var err error
+		rsaKey, err = rsa.GenerateKey(rand.Reader, 2048)
+		Expect(err).ToNot(HaveOccurred())
+		ecKey, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		Expect(err).ToNot(HaveOccurred())
+
+		path = filepath.Join(GinkgoT().TempDir(), "jwks.json")
+		writeJWKS(path, rsaJWK("rsa-1", rsaKey), ecJWK("ec-1", ecKey))
+		jwks, err = auth.NewJWKS(path, zap.NewNop())
+		Expect(err).ToNot(HaveOccurred())
+		DeferCleanup(jwks.Close)
+		authenticator = auth.NewTokenAuthenticator(jwks, auth.TokenOptions{
+			Issuer:   "https://sso.example.com",
+			Audience: "flight-itinerary",
+		}, zap.NewNop())
+	})
+
+	It("should accept RS256 and ES256 tokens and map their roles to scopes", func() {
+		for _, token := range []string{
+			sign(jwt.SigningMethodRS256, "rsa-1", rsaKey, validClaims()),
+			sign(jwt.SigningMethodES256, "ec-1", ecKey, validClaims()),
+		} {
+			principal, err := authenticator.Authenticate(token)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(principal.Subject).To(Equal("jwt:alice"))
+			Expect(principal.Scopes).To(ConsistOf(auth.ScopeReconstruct, auth.ScopeRead, auth.ScopeWrite))
+			Expect(principal.Tenant).To(Equal("acme"))
+		}
+	})
+
+	It("should reject tokens failing issuer, audience, expiry or signature checks", func() {
+		wrongIssuer := validClaims()
+		wrongIssuer["iss"] = "https://other.example.com"
+		wrongAudience := validClaims()
+		wrongAudience["aud"] = "other-service"
+		expired := validClaims()
+		expired["exp"] = time.Now().Add(-time.Hour).Unix()
+		noExpiry := validClaims()
+		delete(noExpiry, "exp")
+		otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+		Expect(err).ToNot(HaveOccurred())
+
+		for _, token := range []string{
+			sign(jwt.SigningMethodRS256, "rsa-1", rsaKey, wrongIssuer),
+			sign(jwt.SigningMethodRS256, "rsa-1", rsaKey, wrongAudience),
+			sign(jwt.SigningMethodRS256, "rsa-1", rsaKey, expired),
+			sign(jwt.SigningMethodRS256, "rsa-1", rsaKey, noExpiry),
+			sign(jwt.SigningMethodRS256, "rsa-1", otherKey, validClaims()),
+			sign(jwt.SigningMethodRS256, "missing", rsaKey, validClaims()),
+			sign(jwt.SigningMethodHS256, "rsa-1", []byte("secret"), validClaims()),
+			"not.a.token",
+		} {
+			_, err := authenticator.Authenticate(token)
+			Expect(err).To(Equal(auth.ErrInvalidCredentials))
+		}
+	})
+
+	It("should read roles from a nested claim", func() {
+		authenticator = auth.NewTokenAuthenticator(jwks, auth.TokenOptions{RolesClaim: "realm_access.roles"}, zap.NewNop())
+		claims := validClaims()
+		claims["realm_access"] = map[string]interface{}{"roles": []string{"analyst"}}
+
+		principal, err := authenticator.Authenticate(sign(jwt.SigningMethodRS256, "rsa-1", rsaKey, claims))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(principal.Scopes).To(ConsistOf(auth.ScopeAnalyticsRead))
+	})
+
+	It("should reload the key set when the file changes and keep it when the file is invalid", func() {
+		rotated, err := rsa.GenerateKey(rand.Reader, 2048)
+		Expect(err).ToNot(HaveOccurred())
+		token := sign(jwt.SigningMethodRS256, "rsa-2", rotated, validClaims())
+		_, err = authenticator.Authenticate(token)
+		Expect(err).To(HaveOccurred())
+
+		writeJWKS(path, rsaJWK("rsa-2", rotated))
+		Eventually(func() error {
+			_, err := authenticator.Authenticate(token)
+			return err
+		}).Should(Succeed())
+
+		Expect(os.WriteFile(path, []byte("{"), 0600)).To(Succeed())
+		Consistently(func() error {
+			_, err := authenticator.Authenticate(token)
+			return err
+		}, 200*time.Millisecond).Should(Succeed())
+	})
+
+	It("should route API keys and tokens to their authenticators", func() {
+		keyStore, err := auth.NewKeyStore(filepath.Join(GinkgoT().TempDir(), "api-keys.json"))
+		Expect(err).ToNot(HaveOccurred())
+		plaintext, _, err := keyStore.Create("ci", []string{auth.ScopeRead}, "", "")
+		Expect(err).ToNot(HaveOccurred())
+		composite := auth.NewCompositeAuthenticator(keyStore, authenticator)
+
+		principal, err := composite.Authenticate(plaintext)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(principal.Name).To(Equal("ci"))
+		principal, err = composite.Authenticate(sign(jwt.SigningMethodES256, "ec-1", ecKey, validClaims()))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(principal.Subject).To(Equal("jwt:alice"))
+	})
+})
@@ -0,0 +1,301 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"go.uber.org/zap"
+
+	"flight-itinerary-go/pkg/errors"
+)
+
+// keyPrefix marks API keys so they can be told apart from other bearer tokens
+const keyPrefix = "fik_"
+
+var ErrAPIKeyNotFound = errors.NewNotFoundError("api key not found")
+
+// APIKey is a stored API key. Only a SHA-256 hash of the secret is kept; the key itself is shown
+// once when created.
+type APIKey struct {
+	ID        string     `json:"id"`
+	Name      string     `json:"name"`
+	Hash      string     `json:"hash"`
+	Scopes    []string   `json:"scopes"`
+	Tier      string     `json:"tier,omitempty"`
+	Tenant    string     `json:"tenant,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`
+}
+
+// IsAPIKey reports whether the credential looks like an API key rather than another token
+func IsAPIKey(credential string) bool {
+	return strings.HasPrefix(credential, keyPrefix)
+}
+
+// KeyStore keeps API keys in a JSON file. Changes re-read the file first, and a watching store
+// re-reads it when it changes, so keys created or revoked by the CLI apply to a running server.
+type KeyStore struct {
+	mutex   sync.RWMutex
+	path    string
+	keys    map[string]APIKey
+	modTime time.Time
+	size    int64
+	watcher *fsnotify.Watcher
+}
+
+// NewKeyStore opens the key file at path; a missing file is an empty store
+func NewKeyStore(path string) (*KeyStore, error) {
+	store := &KeyStore{
+		path: path,
+		keys: make(map[string]APIKey),
+	}
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+	if err := store.reload(); err != nil {
+		return nil, err
+	}
+	return store, nil
+}
+
+// Watch re-reads the file whenever it changes until Close is called; a file that fails to parse
+// is logged and the previous keys stay in use
+func (store *KeyStore) Watch(logger *zap.Logger) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	// The directory is watched because the CLI replaces the file by renaming
+	if err := watcher.Add(filepath.Dir(store.path)); err != nil {
+		watcher.Close()
+		return err
+	}
+	store.watcher = watcher
+	go store.watch(logger)
+	return nil
+}
+
+// Close stops watching the file
+func (store *KeyStore) Close() error {
+	if store.watcher == nil {
+		return nil
+	}
+	return store.watcher.Close()
+}
+
+func (store *KeyStore) watch(logger *zap.Logger) {
+	name := filepath.Clean(store.path)
+	for {
+		select {
+		case event, ok := <-store.watcher.Events:
+			if !ok {
+				return
+			}
+			if filepath.Clean(event.Name) != name ||
+				!event.Has(fsnotify.Write|fsnotify.Create|fsnotify.Rename|fsnotify.Remove) {
+				continue
+			}
+			store.mutex.Lock()
+			err := store.reload()
+			store.mutex.Unlock()
+			if err != nil {
+				logger.Error("API key reload failed, keeping previous keys", zap.String("path", store.path), zap.Error(err))
+				continue
+			}
+			logger.Debug("API keys reloaded", zap.String("path", store.path))
+		case err, ok := <-store.watcher.Errors:
+			if !ok {
+				return
+			}
+			logger.Error("API key watch failed", zap.String("path", store.path), zap.Error(err))
+		}
+	}
+}
+
+// Create generates a key with the given scopes, rate limit tier and tenant and returns it with its
+// stored record
+func (store *KeyStore) Create(name string, scopes []string, tier, tenant string) (string, APIKey, error) {
+	if err := ValidateScopes(scopes); err != nil {
+		return "", APIKey{}, err
+	}
+	id := make([]byte, 6)
+	secret := make([]byte, 32)
+	if _, err := rand.Read(id); err != nil {
+		return "", APIKey{}, err
+	}
+	if _, err := rand.Read(secret); err != nil {
+		return "", APIKey{}, err
+	}
+	encodedSecret := base64.RawURLEncoding.EncodeToString(secret)
+	key := APIKey{
+		ID:        hex.EncodeToString(id),
+		Name:      name,
+		Hash:      hashSecret(encodedSecret),
+		Scopes:    scopes,
+		Tier:      tier,
+		Tenant:    tenant,
+		CreatedAt: time.Now().UTC(),
+	}
+
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+	if err := store.reload(); err != nil {
+		return "", APIKey{}, err
+	}
+	store.keys[key.ID] = key
+	if err := store.save(); err != nil {
+		delete(store.keys, key.ID)
+		return "", APIKey{}, err
+	}
+	return keyPrefix + key.ID + "_" + encodedSecret, key, nil
+}
+
+// List returns every key, revoked ones included, oldest first
+func (store *KeyStore) List() ([]APIKey, error) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+	if err := store.reload(); err != nil {
+		return nil, err
+	}
+
+	keys := make([]APIKey, 0, len(store.keys))
+	for _, key := range store.keys {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if !keys[i].CreatedAt.Equal(keys[j].CreatedAt) {
+			return keys[i].CreatedAt.Before(keys[j].CreatedAt)
+		}
+		return keys[i].ID < keys[j].ID
+	})
+	return keys, nil
+}
+
+// Revoke disables a key; revoked keys stay listed
+func (store *KeyStore) Revoke(id string) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+	if err := store.reload(); err != nil {
+		return err
+	}
+
+	key, exists := store.keys[id]
+	if !exists {
+		return ErrAPIKeyNotFound
+	}
+	if key.RevokedAt != nil {
+		return nil
+	}
+	now := time.Now().UTC()
+	key.RevokedAt = &now
+	store.keys[id] = key
+	return store.save()
+}
+
+// Authenticate returns the principal of a valid, unrevoked key from the keys last read; it never
+// touches the file, so only a watching store sees keys changed by other processes
+func (store *KeyStore) Authenticate(credential string) (*Principal, error) {
+	id, secret, found := strings.Cut(strings.TrimPrefix(credential, keyPrefix), "_")
+	if !IsAPIKey(credential) || !found {
+		return nil, ErrInvalidCredentials
+	}
+
+	store.mutex.RLock()
+	key, exists := store.keys[id]
+	store.mutex.RUnlock()
+	if !exists || key.RevokedAt != nil ||
+		subtle.ConstantTimeCompare([]byte(hashSecret(secret)), []byte(key.Hash)) != 1 {
+		return nil, ErrInvalidCredentials
+	}
+	return &Principal{
+		Subject: "key:" + key.ID,
+		Name:    key.Name,
+		Scopes:  key.Scopes,
+		Tier:    key.Tier,
+		Tenant:  key.Tenant,
+	}, nil
+}
+
+// reload re-reads the file when it changed since the last read
+func (store *KeyStore) reload() error {
+	info, err := os.Stat(store.path)
+	if os.IsNotExist(err) {
+		store.keys = make(map[string]APIKey)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if info.ModTime().Equal(store.modTime) && info.Size() == store.size {
+		return nil
+	}
+
+	data, err := os.ReadFile(store.path)
+	if err != nil {
+		return err
+	}
+	var keys []APIKey
+	if err := json.Unmarshal(data, &keys); err != nil {
+		return err
+	}
+	store.keys = make(map[string]APIKey, len(keys))
+	for _, key := range keys {
+		store.keys[key.ID] = key
+	}
+	store.modTime = info.ModTime()
+	store.size = info.Size()
+	return nil
+}
+
+// save writes the keys to a temporary file and renames it over the store, so readers never see
+// a partial file
+func (store *KeyStore) save() error {
+	keys := make([]APIKey, 0, len(store.keys))
+	for _, key := range store.keys {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].ID < keys[j].ID })
+	data, err := json.MarshalIndent(keys, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	temporary, err := os.CreateTemp(filepath.Dir(store.path), ".api-keys-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(temporary.Name())
+	if _, err := temporary.Write(data); err != nil {
+		temporary.Close()
+		return err
+	}
+	if err := temporary.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(temporary.Name(), 0600); err != nil {
+		return err
+	}
+	if err := os.Rename(temporary.Name(), store.path); err != nil {
+		return err
+	}
+	if info, err := os.Stat(store.path); err == nil {
+		store.modTime = info.ModTime()
+		store.size = info.Size()
+	}
+	return nil
+}
+
+func hashSecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
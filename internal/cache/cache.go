@@ -0,0 +1,170 @@
+package cache
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"sort"
+	"sync"
+	"time"
+
+	"flight-itinerary-go/internal/model"
+)
+
+const (
+	// entryOverhead approximates the bytes used by an entry besides its key and route strings
+	entryOverhead = 64
+	// stringOverhead is the size of a string header
+	stringOverhead = 16
+)
+
+// Options bounds the cache; entries older than TTL are never served
+type Options struct {
+	MaxBytes int64
+	TTL      time.Duration
+}
+
+// Stats describes cache usage since start
+type Stats struct {
+	Hits      uint64  `json:"hits"`
+	Misses    uint64  `json:"misses"`
+	Bypasses  uint64  `json:"bypasses"`
+	Evictions uint64  `json:"evictions"`
+	Entries   int     `json:"entries"`
+	Bytes     int64   `json:"bytes"`
+	MaxBytes  int64   `json:"max_bytes"`
+	HitRatio  float64 `json:"hit_ratio"`
+}
+
+type entry struct {
+	key     string
+	route   []string
+	size    int64
+	expires time.Time
+}
+
+// Cache is a least-recently-used cache of reconstructed routes bounded by size in bytes
+type Cache struct {
+	mutex   sync.Mutex
+	options Options
+	entries map[string]*list.Element
+	// recency holds entries from most to least recently used
+	recency *list.List
+	bytes   int64
+	stats   Stats
+}
+
+// NewCache creates an empty cache
+func NewCache(options Options) *Cache {
+	return &Cache{
+		options: options,
+		entries: make(map[string]*list.Element),
+		recency: list.New(),
+	}
+}
+
+// Key hashes the sorted ticket multiset and options, so the same tickets in any order share an
+// entry while duplicates still count
+func Key(tickets []model.Ticket, options ...string) string {
+	sorted := append([]model.Ticket(nil), tickets...)
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].Source() != sorted[j].Source() {
+			return sorted[i].Source() < sorted[j].Source()
+		}
+		return sorted[i].Destination() < sorted[j].Destination()
+	})
+
+	hash := sha256.New()
+	for _, option := range options {
+		hash.Write([]byte(option))
+		hash.Write([]byte{0})
+	}
+	// Options and tickets are separated so neither can be mistaken for the other
+	hash.Write([]byte{1})
+	for _, ticket := range sorted {
+		hash.Write([]byte(ticket.Source()))
+		hash.Write([]byte{0})
+		hash.Write([]byte(ticket.Destination()))
+		hash.Write([]byte{0})
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// Get returns a copy of the cached route, counting a hit or a miss
+func (cache *Cache) Get(key string) ([]string, bool) {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+
+	element, exists := cache.entries[key]
+	if !exists {
+		cache.stats.Misses++
+		return nil, false
+	}
+	cached := element.Value.(*entry)
+	if !time.Now().Before(cached.expires) {
+		cache.remove(element)
+		cache.stats.Misses++
+		return nil, false
+	}
+	cache.recency.MoveToFront(element)
+	cache.stats.Hits++
+	return append([]string(nil), cached.route...), true
+}
+
+// Put stores the route, evicting least recently used entries until it fits; routes larger than
+// the whole cache are not stored
+func (cache *Cache) Put(key string, route []string) {
+	size := int64(entryOverhead + len(key))
+	for _, airport := range route {
+		size += int64(len(airport) + stringOverhead)
+	}
+
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+
+	if element, exists := cache.entries[key]; exists {
+		cache.remove(element)
+	}
+	if size > cache.options.MaxBytes {
+		return
+	}
+	for cache.bytes+size > cache.options.MaxBytes {
+		cache.remove(cache.recency.Back())
+		cache.stats.Evictions++
+	}
+	cache.entries[key] = cache.recency.PushFront(&entry{
+		key:     key,
+		route:   append([]string(nil), route...),
+		size:    size,
+		expires: time.Now().Add(cache.options.TTL),
+	})
+	cache.bytes += size
+}
+
+// Bypass counts a request that skipped the cache
+func (cache *Cache) Bypass() {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+	cache.stats.Bypasses++
+}
+
+// Stats returns the current usage
+func (cache *Cache) Stats() Stats {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+
+	stats := cache.stats
+	stats.Entries = len(cache.entries)
+	stats.Bytes = cache.bytes
+	stats.MaxBytes = cache.options.MaxBytes
+	if lookups := stats.Hits + stats.Misses; lookups > 0 {
+		stats.HitRatio = float64(stats.Hits) / float64(lookups)
+	}
+	return stats
+}
+
+func (cache *Cache) remove(element *list.Element) {
+	removed := cache.recency.Remove(element).(*entry)
+	delete(cache.entries, removed.key)
+	cache.bytes -= removed.size
+}
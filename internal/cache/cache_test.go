@@ -0,0 +1,132 @@
+package cache_test
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.uber.org/zap"
+
+	"flight-itinerary-go/internal/cache"
+	"flight-itinerary-go/internal/model"
+	"flight-itinerary-go/internal/service"
+)
+
+func TestCache(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Cache Suite")
+}
+
+// countingService counts reconstructions reaching the wrapped service
+type countingService struct {
+	service.ItineraryService
+	calls int
+}
+
+func (countingService *countingService) ReconstructItinerary(tickets []model.Ticket) ([]string, error) {
+	countingService.calls++
+	return countingService.ItineraryService.ReconstructItinerary(tickets)
+}
+
+var _ = Describe("Cache", func() {
+	Describe("Key", func() {
+		It("should ignore ticket order but not duplicates or options", func() {
+			key := cache.Key([]model.Ticket{{"JFK", "LAX"}, {"LAX", "DXB"}})
+
+			Expect(cache.Key([]model.Ticket{{"LAX", "DXB"}, {"JFK", "LAX"}})).To(Equal(key))
+			Expect(cache.Key([]model.Ticket{{"LAX", "DXB"}, {"JFK", "LAX"}, {"JFK", "LAX"}})).ToNot(Equal(key))
+			Expect(cache.Key([]model.Ticket{{"JFK", "LAX"}, {"LAX", "DXB"}}, "tenant-a")).ToNot(Equal(key))
+			Expect(cache.Key([]model.Ticket{{"JF", "KLAX"}, {"LAX", "DXB"}})).ToNot(Equal(key))
+		})
+	})
+
+	It("should evict the least recently used entries to stay within the size", func() {
+		lru := cache.NewCache(cache.Options{MaxBytes: 300, TTL: time.Minute})
+		lru.Put("a", []string{"JFK", "LAX"})
+		lru.Put("b", []string{"LAX", "DXB"})
+		_, hit := lru.Get("a")
+		Expect(hit).To(BeTrue())
+		lru.Put("c", []string{"DXB", "SIN"})
+
+		_, hit = lru.Get("b")
+		Expect(hit).To(BeFalse())
+		route, hit := lru.Get("a")
+		Expect(hit).To(BeTrue())
+		Expect(route).To(Equal([]string{"JFK", "LAX"}))
+
+		stats := lru.Stats()
+		Expect(stats.Evictions).To(Equal(uint64(1)))
+		Expect(stats.Entries).To(Equal(2))
+		Expect(stats.Bytes).To(BeNumerically("<=", 300))
+	})
+
+	It("should not serve expired entries", func() {
+		lru := cache.NewCache(cache.Options{MaxBytes: 1 << 10, TTL: 10 * time.Millisecond})
+		lru.Put("a", []string{"JFK", "LAX"})
+
+		Eventually(func() bool {
+			_, hit := lru.Get("a")
+			return hit
+		}).Should(BeFalse())
+		Expect(lru.Stats().Entries).To(Equal(0))
+	})
+
+	Describe("CachingService", func() {
+		var (
+			inner          *countingService
+			lru            *cache.Cache
+			cachingService *cache.CachingService
+		)
+
+		BeforeEach(func() {
+			inner = &countingService{ItineraryService: service.NewItineraryService(zap.NewNop())}
+			lru = cache.NewCache(cache.Options{MaxBytes: 1 << 20, TTL: time.Minute})
+			cachingService = cache.NewCachingService(inner, lru)
+		})
+
+		It("should serve reordered tickets from the cache", func() {
+			itinerary, status, err := cachingService.Reconstruct([]model.Ticket{{"LAX", "DXB"}, {"JFK", "LAX"}}, false)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(status).To(Equal(cache.StatusMiss))
+
+			cached, status, err := cachingService.Reconstruct([]model.Ticket{{"JFK", "LAX"}, {"LAX", "DXB"}}, false)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(status).To(Equal(cache.StatusHit))
+			Expect(cached).To(Equal(itinerary))
+			Expect(inner.calls).To(Equal(1))
+		})
+
+		It("should skip the cache when bypassed and not cache failures", func() {
+			tickets := []model.Ticket{{"JFK", "LAX"}}
+			_, _, err := cachingService.Reconstruct(tickets, false)
+			Expect(err).ToNot(HaveOccurred())
+			_, status, _ := cachingService.Reconstruct(tickets, true)
+			Expect(status).To(Equal(cache.StatusBypass))
+
+			invalid := []model.Ticket{{"JFK", "LAX"}, {"DXB", "SIN"}}
+			_, _, err = cachingService.Reconstruct(invalid, false)
+			Expect(err).To(HaveOccurred())
+			_, status, err = cachingService.Reconstruct(invalid, false)
+			Expect(err).To(HaveOccurred())
+			Expect(status).To(Equal(cache.StatusMiss))
+
+			Expect(inner.calls).To(Equal(4))
+			Expect(lru.Stats().Bypasses).To(Equal(uint64(1)))
+		})
+
+		It("should keep namespaces apart in the shared cache", func() {
+			tickets := []model.Ticket{{"JFK", "LAX"}}
+			_, _, err := cachingService.Reconstruct(tickets, false)
+			Expect(err).ToNot(HaveOccurred())
+
+			tenant := cachingService.WithNamespace("acme")
+			_, status, err := tenant.Reconstruct(tickets, false)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(status).To(Equal(cache.StatusMiss))
+			_, status, _ = tenant.Reconstruct(tickets, false)
+			Expect(status).To(Equal(cache.StatusHit))
+			Expect(inner.calls).To(Equal(2))
+		})
+	})
+})
@@ -0,0 +1,74 @@
+package cache
+
+import (
+	"flight-itinerary-go/internal/model"
+	"flight-itinerary-go/internal/service"
+)
+
+// Status reports how a reconstruction was served
+type Status string
+
+// Cache statuses reported in the X-Cache response header
+const (
+	StatusHit    Status = "HIT"
+	StatusMiss   Status = "MISS"
+	StatusBypass Status = "BYPASS"
+)
+
+// Reconstructor is implemented by itinerary services that can report or skip caching per request
+type Reconstructor interface {
+	Reconstruct(tickets []model.Ticket, bypass bool) ([]string, Status, error)
+}
+
+// CachingService serves repeated ticket sets from the cache in front of the wrapped service.
+// Only successful reconstructions are cached.
+type CachingService struct {
+	itineraryService service.ItineraryService
+	cache            *Cache
+	namespace        []string
+}
+
+// NewCachingService wraps the itinerary service with the cache
+func NewCachingService(itineraryService service.ItineraryService, cache *Cache) *CachingService {
+	return &CachingService{
+		itineraryService: itineraryService,
+		cache:            cache,
+	}
+}
+
+// WithNamespace returns a caching service sharing the cache whose entries are kept apart from those
+// of other namespaces
+func (cachingService *CachingService) WithNamespace(namespace string) *CachingService {
+	return &CachingService{
+		itineraryService: cachingService.itineraryService,
+		cache:            cachingService.cache,
+		namespace:        []string{"namespace=" + namespace},
+	}
+}
+
+// ReconstructItinerary reconstructs the itinerary, using the cache
+func (cachingService *CachingService) ReconstructItinerary(tickets []model.Ticket) ([]string, error) {
+	itinerary, _, err := cachingService.Reconstruct(tickets, false)
+	return itinerary, err
+}
+
+// Reconstruct reconstructs the itinerary and reports whether the cache served it; bypassed
+// requests neither read nor fill the cache
+func (cachingService *CachingService) Reconstruct(tickets []model.Ticket, bypass bool) ([]string, Status, error) {
+	if bypass {
+		cachingService.cache.Bypass()
+		itinerary, err := cachingService.itineraryService.ReconstructItinerary(tickets)
+		return itinerary, StatusBypass, err
+	}
+
+	key := Key(tickets, cachingService.namespace...)
+	if itinerary, hit := cachingService.cache.Get(key); hit {
+		return itinerary, StatusHit, nil
+	}
+	itinerary, err := cachingService.itineraryService.ReconstructItinerary(tickets)
+	if err != nil {
+		return nil, StatusMiss, err
+	}
+	cachingService.cache.Put(key, itinerary)
+	return itinerary, StatusMiss, nil
+}
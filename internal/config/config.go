@@ -0,0 +1,237 @@
+package config
+
+import (
+	"fmt"
+	"net"
+	"slices"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+
+	"flight-itinerary-go/internal/auth"
+	"flight-itinerary-go/internal/logger"
+	"flight-itinerary-go/internal/tlsconfig"
+)
+
+// Config holds every server setting. Each setting has a key in the config file, an environment
+// variable and a command-line flag named after the key, e.g. server.address, HTTP_ADDRESS and
+// --server.address. Settings tagged reload take effect on reload; the others need a restart.
+type Config struct {
+	Server      Server      `json:"server"`
+	Log         Log         `json:"log"`
+	CORS        CORS        `json:"cors"`
+	Auth        Auth        `json:"auth"`
+	TLS         TLS         `json:"tls"`
+	Storage     Storage     `json:"storage"`
+	Cache       Cache       `json:"cache"`
+	RateLimits  RateLimits  `json:"rate_limits"`
+	Tenants     Tenants     `json:"tenants"`
+	Airports    Airports    `json:"airports"`
+	Audit       Audit       `json:"audit"`
+	Analytics   Analytics   `json:"analytics"`
+	Idempotency Idempotency `json:"idempotency"`
+	GraphQL     GraphQL     `json:"graphql"`
+	Reload      Reload      `json:"reload"`
+}
+
+type Server struct {
+	Address         string   `json:"address" env:"HTTP_ADDRESS" usage:"HTTP listen address"`
+	GRPCAddress     string   `json:"grpc_address" env:"GRPC_ADDRESS" usage:"gRPC listen address"`
+	ShutdownTimeout Duration `json:"shutdown_timeout" env:"SHUTDOWN_TIMEOUT" usage:"time allowed for in-flight requests at shutdown"`
+	TrustedProxies  []string `json:"trusted_proxies" env:"TRUSTED_PROXIES" usage:"CIDR ranges of proxies whose X-Forwarded-For is trusted; empty uses the connection address"`
+}
+
+type Log struct {
+	Level     string `json:"level" env:"LOG_LEVEL" usage:"debug, info, warn or error" reload:"true"`
+	Redact    string `json:"redact" env:"LOG_REDACT" usage:"redaction policies over the defaults, e.g. error=hash,user_agent=drop"`
+	RedactKey string `json:"redact_key" env:"LOG_REDACT_KEY" usage:"secret for keyed hashes of redacted fields; random per process when empty" secret:"true"`
+}
+
+type CORS struct {
+	AllowOrigins     []string `json:"allow_origins" env:"CORS_ALLOW_ORIGINS" usage:"origins allowed to call the API" reload:"true"`
+	AllowMethods     []string `json:"allow_methods" env:"CORS_ALLOW_METHODS" usage:"methods allowed in cross-origin requests" reload:"true"`
+	AllowHeaders     []string `json:"allow_headers" env:"CORS_ALLOW_HEADERS" usage:"request headers allowed; empty allows those the browser asks for" reload:"true"`
+	ExposeHeaders    []string `json:"expose_headers" env:"CORS_EXPOSE_HEADERS" usage:"response headers readable by browsers" reload:"true"`
+	AllowCredentials bool     `json:"allow_credentials" env:"CORS_ALLOW_CREDENTIALS" usage:"allow cookies and credentials" reload:"true"`
+	MaxAge           int      `json:"max_age" env:"CORS_MAX_AGE" usage:"seconds browsers may cache preflight responses" reload:"true"`
+}
+
+type Auth struct {
+	APIKeysPath string `json:"api_keys_path" env:"API_KEYS_PATH" usage:"API key file"`
+	JWKSPath    string `json:"jwks_path" env:"JWKS_PATH" usage:"JWKS file; enables JWT bearer tokens"`
+	JWT         JWT    `json:"jwt"`
+}
+
+type JWT struct {
+	Issuer      string   `json:"issuer" env:"JWT_ISSUER" usage:"required iss claim"`
+	Audience    string   `json:"audience" env:"JWT_AUDIENCE" usage:"required aud claim"`
+	Leeway      Duration `json:"leeway" env:"JWT_LEEWAY" usage:"clock skew allowed for exp and nbf"`
+	RolesClaim  string   `json:"roles_claim" env:"JWT_ROLES_CLAIM" usage:"claim holding roles"`
+	RolesPath   string   `json:"roles_path" env:"JWT_ROLES_PATH" usage:"file mapping roles to scopes"`
+	TierClaim   string   `json:"tier_claim" env:"JWT_TIER_CLAIM" usage:"claim holding the rate limit tier"`
+	TenantClaim string   `json:"tenant_claim" env:"JWT_TENANT_CLAIM" usage:"claim holding the tenant"`
+}
+
+type TLS struct {
+	CertFile     string   `json:"cert_file" env:"TLS_CERT_FILE" usage:"server certificate; enables TLS"`
+	KeyFile      string   `json:"key_file" env:"TLS_KEY_FILE" usage:"server private key"`
+	ClientCAFile string   `json:"client_ca_file" env:"TLS_CLIENT_CA_FILE" usage:"CA bundle for client certificates"`
+	ClientAuth   string   `json:"client_auth" env:"TLS_CLIENT_AUTH" usage:"none, optional or require; require when a client CA is set"`
+	ClientScopes []string `json:"client_scopes" env:"TLS_CLIENT_SCOPES" usage:"scopes granted to client certificates"`
+	MinVersion   string   `json:"min_version" env:"TLS_MIN_VERSION" usage:"1.2 or 1.3"`
+}
+
+type Storage struct {
+	ItineraryPath string `json:"itinerary_path" env:"ITINERARY_STORE_PATH" usage:"itinerary database file; empty keeps itineraries in memory"`
+}
+
+type Cache struct {
+	MaxBytes int64    `json:"max_bytes" env:"RESULT_CACHE_MAX_BYTES" usage:"result cache size; 0 disables the cache"`
+	TTL      Duration `json:"ttl" env:"RESULT_CACHE_TTL" usage:"result cache entry lifetime"`
+}
+
+type RateLimits struct {
+	Path string `json:"path" env:"RATE_LIMITS_PATH" usage:"rate limit policy file" reload:"true"`
+}
+
+type Tenants struct {
+	Dir string `json:"dir" env:"TENANTS_DIR" usage:"directory of tenant files" reload:"true"`
+}
+
+type Airports struct {
+	Path string `json:"path" env:"AIRPORTS_PATH" usage:"CSV file of airports added to or replacing the built-in reference data" reload:"true"`
+}
+
+type Audit struct {
+	Dir      string   `json:"dir" env:"AUDIT_LOG_DIR" usage:"audit log directory"`
+	MaxBytes int64    `json:"max_bytes" env:"AUDIT_LOG_MAX_BYTES" usage:"size at which an audit file is rotated; 0 disables"`
+	MaxAge   Duration `json:"max_age" env:"AUDIT_LOG_MAX_AGE" usage:"age at which an audit file is rotated; 0 disables"`
+}
+
+type Analytics struct {
+	Anonymize bool `json:"anonymize" env:"ANALYTICS_ANONYMIZE" usage:"record routes without airport codes"`
+}
+
+type Idempotency struct {
+	Retention Duration `json:"retention" env:"IDEMPOTENCY_RETENTION" usage:"how long idempotent responses are replayed"`
+}
+
+type GraphQL struct {
+	MaxDepth      int `json:"max_depth" env:"GRAPHQL_MAX_DEPTH" usage:"deepest query accepted"`
+	MaxComplexity int `json:"max_complexity" env:"GRAPHQL_MAX_COMPLEXITY" usage:"most expensive query accepted"`
+}
+
+type Reload struct {
+	Watch bool `json:"watch" env:"RELOAD_WATCH" usage:"reload when the config, rate limit, tenant or airport files change"`
+}
+
+// Default returns the settings used when no source sets them
+func Default() Config {
+	return Config{
+		Server: Server{Address: ":8080", GRPCAddress: ":9090", ShutdownTimeout: Duration(10 * time.Second)},
+		Log:    Log{Level: "info"},
+		CORS: CORS{
+			AllowOrigins: []string{"*"},
+			AllowMethods: []string{"GET", "HEAD", "PUT", "PATCH", "POST", "DELETE"},
+		},
+		Auth:        Auth{APIKeysPath: "api-keys.json", JWT: JWT{Leeway: Duration(30 * time.Second)}},
+		TLS:         TLS{ClientScopes: []string{auth.ScopeReconstruct}},
+		Cache:       Cache{MaxBytes: 16 << 20, TTL: Duration(10 * time.Minute)},
+		Audit:       Audit{Dir: "audit", MaxBytes: 10 << 20, MaxAge: Duration(24 * time.Hour)},
+		Idempotency: Idempotency{Retention: Duration(24 * time.Hour)},
+		GraphQL:     GraphQL{MaxDepth: 8, MaxComplexity: 5000},
+		Reload:      Reload{Watch: true},
+	}
+}
+
+// Validate reports every invalid setting at once
+func (config Config) Validate() error {
+	var problems []string
+	check := func(valid bool, format string, args ...interface{}) {
+		if !valid {
+			problems = append(problems, fmt.Sprintf(format, args...))
+		}
+	}
+
+	_, _, err := net.SplitHostPort(config.Server.Address)
+	check(err == nil, "server.address %q must be host:port, e.g. :8080", config.Server.Address)
+	_, _, err = net.SplitHostPort(config.Server.GRPCAddress)
+	check(err == nil, "server.grpc_address %q must be host:port, e.g. :9090", config.Server.GRPCAddress)
+	check(config.Server.ShutdownTimeout > 0, "server.shutdown_timeout must be positive")
+	for _, proxy := range config.Server.TrustedProxies {
+		_, _, err := net.ParseCIDR(proxy)
+		check(err == nil, "server.trusted_proxies %q must be a CIDR range, e.g. 10.0.0.0/8", proxy)
+	}
+
+	_, err = zapcore.ParseLevel(config.Log.Level)
+	check(err == nil, "log.level %q must be debug, info, warn or error", config.Log.Level)
+	_, err = logger.ParsePolicies(config.Log.Redact)
+	check(err == nil, "log.redact: %v", err)
+
+	check(len(config.CORS.AllowOrigins) > 0, "cors.allow_origins must not be empty")
+	for _, origin := range config.CORS.AllowOrigins {
+		check(origin != "*" || !config.CORS.AllowCredentials,
+			"cors.allow_credentials cannot be combined with the * origin")
+	}
+	check(config.CORS.MaxAge >= 0, "cors.max_age must not be negative")
+
+	check(config.Auth.APIKeysPath != "", "auth.api_keys_path must not be empty")
+	check(config.Auth.JWT.Leeway >= 0, "auth.jwt.leeway must not be negative")
+	check(config.TLS.CertFile == "" || config.TLS.KeyFile != "", "tls.key_file is required with tls.cert_file")
+	check(config.TLS.ClientCAFile == "" || config.TLS.CertFile != "", "tls.client_ca_file requires tls.cert_file")
+	check(config.TLS.ClientAuth == "" || slices.Contains([]string{tlsconfig.ClientAuthNone,
+		tlsconfig.ClientAuthOptional, tlsconfig.ClientAuthRequire}, config.TLS.ClientAuth),
+		"tls.client_auth %q must be none, optional or require", config.TLS.ClientAuth)
+	check(config.TLS.ClientCAFile != "" || config.TLS.ClientAuth == "" ||
+		config.TLS.ClientAuth == tlsconfig.ClientAuthNone, "tls.client_auth %s requires tls.client_ca_file",
+		config.TLS.ClientAuth)
+	_, err = tlsconfig.ParseMinVersion(config.TLS.MinVersion)
+	check(err == nil, "tls.min_version %q must be 1.2 or 1.3", config.TLS.MinVersion)
+
+	check(config.Cache.MaxBytes >= 0, "cache.max_bytes must not be negative")
+	check(config.Cache.TTL > 0, "cache.ttl must be positive")
+	check(config.Audit.Dir != "", "audit.dir must not be empty")
+	check(config.Audit.MaxBytes >= 0, "audit.max_bytes must not be negative")
+	check(config.Audit.MaxAge >= 0, "audit.max_age must not be negative")
+	check(config.Idempotency.Retention > 0, "idempotency.retention must be positive")
+	check(config.GraphQL.MaxDepth > 0, "graphql.max_depth must be positive")
+	check(config.GraphQL.MaxComplexity > 0, "graphql.max_complexity must be positive")
+
+	if len(problems) > 0 {
+		return fmt.Errorf("invalid configuration: %s", strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// Duration is a time.Duration written as text such as 10s or 24h
+type Duration time.Duration
+
+func (duration Duration) MarshalText() ([]byte, error) {
+	return []byte(time.Duration(duration).String()), nil
+}
+
+func (duration *Duration) UnmarshalText(text []byte) error {
+	parsed, err := time.ParseDuration(string(text))
+	if err != nil {
+		return err
+	}
+	*duration = Duration(parsed)
+	return nil
+}
+
+// ZapLevel returns the log level; the settings are valid once loaded
+func (log Log) ZapLevel() zapcore.Level {
+	level, _ := zapcore.ParseLevel(log.Level)
+	return level
+}
+
+// Options returns the logger options; the level can be changed later through Options.Level
+func (log Log) Options() logger.Options {
+	policies, _ := logger.ParsePolicies(log.Redact)
+	return logger.Options{
+		Level:     zap.NewAtomicLevelAt(log.ZapLevel()),
+		Redaction: logger.Redaction{Policies: policies, Key: []byte(log.RedactKey)},
+	}
+}
@@ -0,0 +1,251 @@
+package config_test
+
+import (
+	"flag"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"flight-itinerary-go/internal/config"
+)
+
+func TestConfig(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Config Suite")
+}
+
+var _ = Describe("Config", func() {
+	var (
+		env    map[string]string
+		loader *config.Loader
+		dir    string
+	)
+
+	lookupEnv := func(name string) (string, bool) {
+		value, ok := env[name]
+		return value, ok
+	}
+
+	parse := func(args ...string) {
+		flags := flag.NewFlagSet("test", flag.ContinueOnError)
+		flags.SetOutput(io.Discard)
+		loader.RegisterFlags(flags)
+		Expect(flags.Parse(args)).To(Succeed())
+	}
+
+	writeFile := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		Expect(os.WriteFile(path, []byte(content), 0600)).To(Succeed())
+		return path
+	}
+
+	BeforeEach(func() {
+		env = map[string]string{}
+		loader = config.NewLoader(lookupEnv)
+		dir = GinkgoT().TempDir()
+	})
+
+	It("should use valid defaults", func() {
+		loaded, sources, err := loader.Load()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(loaded).To(Equal(config.Default()))
+		Expect(sources).To(BeEmpty())
+		Expect(loaded.Server.Address).To(Equal(":8080"))
+		Expect(time.Duration(loaded.Server.ShutdownTimeout)).To(Equal(10 * time.Second))
+	})
+
+	It("should merge the file, environment variables and flags in increasing precedence", func() {
+		path := writeFile("config.yaml", `
+server:
+  address: ":8000"
+  shutdown_timeout: 30s
+log:
+  level: warn
+cors:
+  allow_origins: ["https://app.example.com"]
+  allow_credentials: true
+`)
+		env["CONFIG_FILE"] = path
+		env["LOG_LEVEL"] = "debug"
+		env["HTTP_ADDRESS"] = ":8001"
+		env["CORS_ALLOW_METHODS"] = "GET, POST"
+		parse("--server.address", ":8002", "--analytics.anonymize")
+
+		loaded, sources, err := loader.Load()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(loader.File()).To(Equal(path))
+		Expect(loaded.Server.Address).To(Equal(":8002"))
+		Expect(time.Duration(loaded.Server.ShutdownTimeout)).To(Equal(30 * time.Second))
+		Expect(loaded.Log.Level).To(Equal("debug"))
+		Expect(loaded.CORS.AllowOrigins).To(Equal([]string{"https://app.example.com"}))
+		Expect(loaded.CORS.AllowMethods).To(Equal([]string{"GET", "POST"}))
+		Expect(loaded.Analytics.Anonymize).To(BeTrue())
+		Expect(sources).To(Equal(config.Sources{
+			"server.address":          config.SourceFlag,
+			"server.shutdown_timeout": config.SourceFile,
+			"log.level":               config.SourceEnv,
+			"cors.allow_origins":      config.SourceFile,
+			"cors.allow_credentials":  config.SourceFile,
+			"cors.allow_methods":      config.SourceEnv,
+			"analytics.anonymize":     config.SourceFlag,
+		}))
+	})
+
+	It("should let empty environment variables clear defaults and file values", func() {
+		env["CONFIG_FILE"] = writeFile("config.yaml", `
+tls:
+  client_auth: optional
+cors:
+  allow_headers: [X-Request-ID]
+`)
+		env["TLS_CLIENT_AUTH"] = ""
+		env["TLS_CLIENT_SCOPES"] = ""
+		env["CORS_ALLOW_HEADERS"] = ""
+
+		loaded, sources, err := loader.Load()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(loaded.TLS.ClientAuth).To(BeEmpty())
+		Expect(loaded.TLS.ClientScopes).To(BeEmpty())
+		Expect(loaded.CORS.AllowHeaders).To(BeEmpty())
+		Expect(sources["tls.client_auth"]).To(Equal(config.SourceEnv))
+
+		env["CORS_ALLOW_ORIGINS"] = ""
+		_, _, err = loader.Load()
+		Expect(err).To(MatchError(ContainSubstring("cors.allow_origins must not be empty")))
+	})
+
+	It("should read JSON files and prefer --config over CONFIG_FILE", func() {
+		env["CONFIG_FILE"] = writeFile("ignored.json", `{"log": {"level": "error"}}`)
+		parse("--config", writeFile("config.json", `{"cache": {"ttl": "1m", "max_bytes": 1024}}`))
+
+		loaded, _, err := loader.Load()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(loaded.Log.Level).To(Equal("info"))
+		Expect(time.Duration(loaded.Cache.TTL)).To(Equal(time.Minute))
+		Expect(loaded.Cache.MaxBytes).To(Equal(int64(1024)))
+	})
+
+	It("should reject unknown keys, malformed values and unsupported files", func() {
+		env["CONFIG_FILE"] = writeFile("config.yaml", "server:\n  adress: \":8000\"\n")
+		_, _, err := loader.Load()
+		Expect(err).To(MatchError(ContainSubstring("adress")))
+
+		env["CONFIG_FILE"] = writeFile("config.toml", "")
+		_, _, err = loader.Load()
+		Expect(err).To(MatchError(ContainSubstring("must be .yaml, .yml or .json")))
+
+		delete(env, "CONFIG_FILE")
+		env["RESULT_CACHE_TTL"] = "soon"
+		_, _, err = loader.Load()
+		Expect(err).To(MatchError(ContainSubstring("RESULT_CACHE_TTL")))
+
+		delete(env, "RESULT_CACHE_TTL")
+		parse("--graphql.max_depth", "deep")
+		_, _, err = loader.Load()
+		Expect(err).To(MatchError(ContainSubstring("--graphql.max_depth")))
+	})
+
+	It("should report every invalid setting", func() {
+		env["LOG_LEVEL"] = "loud"
+		env["HTTP_ADDRESS"] = "8080"
+		env["CORS_ALLOW_CREDENTIALS"] = "true"
+		env["TLS_CERT_FILE"] = "server.crt"
+		env["LOG_REDACT"] = "start=encrypt"
+		env["TRUSTED_PROXIES"] = "10.0.0.0/8,proxy"
+		env["TLS_CLIENT_AUTH"] = "verify"
+		env["TLS_MIN_VERSION"] = "1.1"
+
+		_, _, err := loader.Load()
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(SatisfyAll(
+			ContainSubstring(`log.level "loud"`),
+			ContainSubstring("server.address"),
+			ContainSubstring("cors.allow_credentials"),
+			ContainSubstring("tls.key_file"),
+			ContainSubstring("log.redact"),
+			ContainSubstring(`server.trusted_proxies "proxy"`),
+			ContainSubstring(`tls.client_auth "verify" must be none, optional or require`),
+			ContainSubstring(`tls.min_version "1.1"`),
+		))
+	})
+
+	It("should require a client CA bundle for client certificate authentication", func() {
+		env["TLS_CERT_FILE"] = "server.crt"
+		env["TLS_KEY_FILE"] = "server.key"
+		env["TLS_CLIENT_AUTH"] = "optional"
+
+		_, _, err := loader.Load()
+		Expect(err).To(MatchError(ContainSubstring("tls.client_auth optional requires tls.client_ca_file")))
+
+		env["TLS_CLIENT_CA_FILE"] = "clients.pem"
+		_, _, err = loader.Load()
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	It("should list settings with their sources and hide secrets", func() {
+		env["LOG_REDACT_KEY"] = "secret"
+		env["GRAPHQL_MAX_DEPTH"] = "4"
+
+		loaded, sources, err := loader.Load()
+		Expect(err).ToNot(HaveOccurred())
+		settings := map[string]config.Setting{}
+		for _, setting := range loaded.Settings(sources) {
+			settings[setting.Key] = setting
+		}
+		Expect(settings["graphql.max_depth"].Value).To(Equal("4"))
+		Expect(settings["graphql.max_depth"].Source).To(Equal(config.SourceEnv))
+		Expect(settings["cors.allow_methods"].Value).To(Equal("GET,HEAD,PUT,PATCH,POST,DELETE"))
+		Expect(settings["cors.allow_methods"].Source).To(Equal(config.SourceDefault))
+		Expect(settings["log.redact_key"].Value).ToNot(ContainSubstring("secret"))
+		Expect(loaded.Redacted().Log.RedactKey).ToNot(Equal("secret"))
+		Expect(loaded.Log.RedactKey).To(Equal("secret"))
+		Expect(settings["log.level"].Reloadable).To(BeTrue())
+		Expect(settings["cors.allow_origins"].Reloadable).To(BeTrue())
+		Expect(settings["airports.path"].Reloadable).To(BeTrue())
+		Expect(settings["server.address"].Reloadable).To(BeFalse())
+		Expect(settings["storage.itinerary_path"].Reloadable).To(BeFalse())
+	})
+
+	It("should tell changed secrets apart without showing them", func() {
+		env["LOG_REDACT_KEY"] = "first"
+		loaded, sources, err := loader.Load()
+		Expect(err).ToNot(HaveOccurred())
+		previous := loaded.Settings(sources)
+
+		env["LOG_REDACT_KEY"] = "second"
+		loaded, sources, err = loader.Load()
+		Expect(err).ToNot(HaveOccurred())
+		for index, setting := range loaded.Settings(sources) {
+			Expect(setting.Key).To(Equal(previous[index].Key))
+			if setting.Key != "log.redact_key" {
+				Expect(setting.SameValue(previous[index])).To(BeTrue(), setting.Key)
+				continue
+			}
+			Expect(setting.SameValue(previous[index])).To(BeFalse())
+			Expect(setting.Value).To(Equal(previous[index].Value))
+			Expect(setting.Value).ToNot(ContainSubstring("second"))
+		}
+	})
+
+	It("should pick up a changed file on the next load and keep flags", func() {
+		path := writeFile("config.yaml", "log:\n  level: warn\n")
+		env["CONFIG_FILE"] = path
+		parse("--server.address", ":8002")
+		loaded, _, err := loader.Load()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(loaded.Log.ZapLevel().String()).To(Equal("warn"))
+
+		writeFile("config.yaml", "log:\n  level: debug\ncors:\n  max_age: 60\n")
+		loaded, sources, err := loader.Load()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(loaded.Log.Level).To(Equal("debug"))
+		Expect(loaded.CORS.MaxAge).To(Equal(60))
+		Expect(loaded.Server.Address).To(Equal(":8002"))
+		Expect(sources["server.address"]).To(Equal(config.SourceFlag))
+	})
+})
@@ -0,0 +1,327 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Sources of a setting, in increasing precedence
+const (
+	SourceDefault = "default"
+	SourceFile    = "file"
+	SourceEnv     = "env"
+	SourceFlag    = "flag"
+)
+
+// EnvFile names the config file when --config is not given
+const EnvFile = "CONFIG_FILE"
+
+// Sources maps setting keys to the source that set them; unlisted settings keep their default
+type Sources map[string]string
+
+// Setting describes one effective setting
+type Setting struct {
+	Key    string
+	Env    string
+	Value  string
+	Source string
+	Usage  string
+	Secret bool
+	// Reloadable settings take effect without a restart
+	Reloadable bool
+	// raw is the value before secrets are hidden
+	raw string
+}
+
+// SameValue reports whether both settings hold the same value, comparing secrets by their
+// actual values rather than as hidden
+func (setting Setting) SameValue(other Setting) bool {
+	return setting.raw == other.raw
+}
+
+// Loader merges defaults, a YAML or JSON config file, environment variables and command-line
+// flags, in increasing precedence. Load may be called again to pick up changed files and
+// environment variables; flag values are kept from the first parse.
+type Loader struct {
+	lookupEnv func(string) (string, bool)
+	file      string
+	flags     map[string]string
+}
+
+// NewLoader creates a loader reading environment variables with lookupEnv, usually os.LookupEnv
+func NewLoader(lookupEnv func(string) (string, bool)) *Loader {
+	return &Loader{lookupEnv: lookupEnv, flags: map[string]string{}}
+}
+
+// RegisterFlags adds --config and one flag per setting, such as --server.address, to the flag set.
+// Flag values are checked when the configuration is loaded.
+func (loader *Loader) RegisterFlags(flags *flag.FlagSet) {
+	flags.Func("config", "YAML or JSON config file (env "+EnvFile+")", func(value string) error {
+		loader.file = value
+		return nil
+	})
+	for _, field := range fields(reflect.ValueOf(&Config{}).Elem(), "") {
+		flags.Var(&flagValue{loader: loader, field: field}, field.key,
+			fmt.Sprintf("%s (env %s)", field.usage, field.env))
+	}
+}
+
+// File returns the config file in use, if any
+func (loader *Loader) File() string {
+	if loader.file != "" {
+		return loader.file
+	}
+	if value, ok := loader.lookupEnv(EnvFile); ok {
+		return value
+	}
+	return ""
+}
+
+// Load builds and validates the configuration
+func (loader *Loader) Load() (Config, Sources, error) {
+	config := Default()
+	sources := Sources{}
+	all := fields(reflect.ValueOf(&config).Elem(), "")
+
+	if path := loader.File(); path != "" {
+		keys, err := readFile(path, &config)
+		if err != nil {
+			return Config{}, nil, err
+		}
+		for _, key := range keys {
+			sources[key] = SourceFile
+		}
+	}
+	for _, field := range all {
+		// A variable set to an empty value is explicit too, so it can clear a default or a file value
+		value, ok := loader.lookupEnv(field.env)
+		if !ok {
+			continue
+		}
+		if err := field.set(value); err != nil {
+			return Config{}, nil, fmt.Errorf("invalid %s: %w", field.env, err)
+		}
+		sources[field.key] = SourceEnv
+	}
+	for _, field := range all {
+		value, ok := loader.flags[field.key]
+		if !ok {
+			continue
+		}
+		if err := field.set(value); err != nil {
+			return Config{}, nil, fmt.Errorf("invalid --%s: %w", field.key, err)
+		}
+		sources[field.key] = SourceFlag
+	}
+
+	if err := config.Validate(); err != nil {
+		return Config{}, nil, err
+	}
+	return config, sources, nil
+}
+
+// Settings lists every setting of the configuration in key order
+func (config Config) Settings(sources Sources) []Setting {
+	var settings []Setting
+	for _, field := range fields(reflect.ValueOf(&config).Elem(), "") {
+		source := sources[field.key]
+		if source == "" {
+			source = SourceDefault
+		}
+		settings = append(settings, Setting{
+			Key:        field.key,
+			Env:        field.env,
+			Value:      field.format(),
+			raw:        field.formatRaw(),
+			Source:     source,
+			Usage:      field.usage,
+			Secret:     field.secret,
+			Reloadable: field.reloadable,
+		})
+	}
+	sort.Slice(settings, func(i, j int) bool { return settings[i].Key < settings[j].Key })
+	return settings
+}
+
+// Redacted returns a copy with secrets replaced, safe to print or log
+func (config Config) Redacted() Config {
+	for _, field := range fields(reflect.ValueOf(&config).Elem(), "") {
+		if field.secret && !field.value.IsZero() {
+			field.value.SetString(redactedValue)
+		}
+	}
+	return config
+}
+
+const redactedValue = "********"
+
+// readFile decodes a YAML or JSON file over the configuration and returns the keys it sets.
+// Unknown keys are rejected so misspelt settings are not silently ignored.
+func readFile(path string, config *Config) ([]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		var document map[string]interface{}
+		if err := yaml.Unmarshal(data, &document); err != nil {
+			return nil, fmt.Errorf("failed to parse config file %s: %w", path, err)
+		}
+		if data, err = json.Marshal(document); err != nil {
+			return nil, fmt.Errorf("failed to parse config file %s: %w", path, err)
+		}
+	case ".json":
+	default:
+		return nil, fmt.Errorf("config file %s must be .yaml, .yml or .json", path)
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(config); err != nil {
+		return nil, fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+	var document map[string]interface{}
+	if err := json.Unmarshal(data, &document); err != nil {
+		return nil, fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+	return flattenKeys(document, ""), nil
+}
+
+// flattenKeys lists the dotted keys of the leaves of a decoded document
+func flattenKeys(document map[string]interface{}, prefix string) []string {
+	var keys []string
+	for key, value := range document {
+		if nested, ok := value.(map[string]interface{}); ok {
+			keys = append(keys, flattenKeys(nested, prefix+key+".")...)
+			continue
+		}
+		keys = append(keys, prefix+key)
+	}
+	return keys
+}
+
+// field is a setting located by reflection
+type field struct {
+	key        string
+	env        string
+	usage      string
+	secret     bool
+	reloadable bool
+	value      reflect.Value
+}
+
+var durationType = reflect.TypeOf(Duration(0))
+
+// fields walks the configuration structs, naming each setting by its dotted JSON path
+func fields(value reflect.Value, prefix string) []field {
+	var found []field
+	for i := 0; i < value.NumField(); i++ {
+		structField := value.Type().Field(i)
+		key := prefix + strings.Split(structField.Tag.Get("json"), ",")[0]
+		if structField.Type.Kind() == reflect.Struct {
+			found = append(found, fields(value.Field(i), key+".")...)
+			continue
+		}
+		found = append(found, field{
+			key:        key,
+			env:        structField.Tag.Get("env"),
+			usage:      structField.Tag.Get("usage"),
+			secret:     structField.Tag.Get("secret") == "true",
+			reloadable: structField.Tag.Get("reload") == "true",
+			value:      value.Field(i),
+		})
+	}
+	return found
+}
+
+// set parses a value written as in an environment variable; lists are comma-separated
+func (field field) set(text string) error {
+	if field.value.Type() == durationType {
+		parsed, err := time.ParseDuration(text)
+		if err != nil {
+			return err
+		}
+		field.value.SetInt(int64(parsed))
+		return nil
+	}
+	switch field.value.Kind() {
+	case reflect.String:
+		field.value.SetString(text)
+	case reflect.Bool:
+		parsed, err := strconv.ParseBool(text)
+		if err != nil {
+			return err
+		}
+		field.value.SetBool(parsed)
+	case reflect.Int, reflect.Int64:
+		parsed, err := strconv.ParseInt(text, 10, 64)
+		if err != nil {
+			return err
+		}
+		field.value.SetInt(parsed)
+	case reflect.Slice:
+		var items []string
+		for _, item := range strings.Split(text, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		field.value.Set(reflect.ValueOf(items))
+	default:
+		return fmt.Errorf("unsupported setting type %s", field.value.Type())
+	}
+	return nil
+}
+
+// format writes a value the way set reads it; secrets are hidden
+func (field field) format() string {
+	if field.secret && !field.value.IsZero() {
+		return redactedValue
+	}
+	return field.formatRaw()
+}
+
+// formatRaw writes a value the way set reads it, secrets included
+func (field field) formatRaw() string {
+	if field.value.Type() == durationType {
+		return time.Duration(field.value.Int()).String()
+	}
+	if field.value.Kind() == reflect.Slice {
+		return strings.Join(field.value.Interface().([]string), ",")
+	}
+	return fmt.Sprint(field.value.Interface())
+}
+
+// flagValue records a flag for Load, which applies it over the other sources
+type flagValue struct {
+	loader *Loader
+	field  field
+}
+
+func (value *flagValue) String() string {
+	if value == nil || value.loader == nil {
+		return ""
+	}
+	return value.loader.flags[value.field.key]
+}
+
+func (value *flagValue) Set(text string) error {
+	value.loader.flags[value.field.key] = text
+	return nil
+}
+
+func (value *flagValue) IsBoolFlag() bool {
+	return value != nil && value.field.value.IsValid() && value.field.value.Kind() == reflect.Bool
+}
@@ -0,0 +1,119 @@
+package enrichment
+
+import (
+	"flight-itinerary-go/internal/airport"
+	"flight-itinerary-go/internal/geo"
+)
+
+// Emission factors in kg CO2 per passenger-kilometre for an economy seat. Short flights burn a
+// larger share of their fuel on take-off and climb, so they are charged a higher rate.
+const (
+	shortHaulThresholdKm    = 1500
+	shortHaulKgPerPassenger = 0.156
+	longHaulKgPerPassenger  = 0.150
+)
+
+// Stop represents an airport in the itinerary; Airport is nil when the code is not in the
+// reference data
+type Stop struct {
+	Code    string
+	Airport *airport.Airport
+}
+
+// Leg represents a flight between two consecutive stops. Distance and emissions are nil when
+// either airport is unknown.
+type Leg struct {
+	Sequence    int
+	From        Stop
+	To          Stop
+	DistanceKm  *float64
+	EmissionsKg *float64
+}
+
+// Layover represents an intermediate stop where the traveller changes flights
+type Layover struct {
+	Sequence int
+	Stop     Stop
+}
+
+// Trip is an itinerary enriched with airport reference data, distances and emissions
+type Trip struct {
+	Route    []string
+	Stops    []Stop
+	Legs     []Leg
+	Layovers []Layover
+	// Totals are nil unless every leg could be measured
+	TotalDistanceKm  *float64
+	TotalEmissionsKg *float64
+}
+
+// Enricher defines the interface for itinerary enrichment
+type Enricher interface {
+	Enrich(itinerary []string) *Trip
+	Airport(code string) (airport.Airport, bool)
+}
+
+// EnricherV1 implements the Enricher interface using the airport directory
+type EnricherV1 struct {
+	airports airport.Directory
+}
+
+// NewEnricher creates a new itinerary enricher
+func NewEnricher(airports airport.Directory) Enricher {
+	return &EnricherV1{
+		airports: airports,
+	}
+}
+
+// Airport looks up reference data for a single airport
+func (enricher *EnricherV1) Airport(code string) (airport.Airport, bool) {
+	return enricher.airports.Lookup(code)
+}
+
+// Enrich resolves the stops of the itinerary and measures every leg
+func (enricher *EnricherV1) Enrich(itinerary []string) *Trip {
+	trip := &Trip{
+		Route:    itinerary,
+		Stops:    make([]Stop, 0, len(itinerary)),
+		Legs:     []Leg{},
+		Layovers: []Layover{},
+	}
+	for _, code := range itinerary {
+		stop := Stop{Code: code}
+		if found, exists := enricher.airports.Lookup(code); exists {
+			stop.Airport = &found
+		}
+		trip.Stops = append(trip.Stops, stop)
+	}
+
+	totalDistance, totalEmissions, measured := 0.0, 0.0, true
+	for i := 1; i < len(trip.Stops); i++ {
+		leg := Leg{Sequence: i, From: trip.Stops[i-1], To: trip.Stops[i]}
+		if leg.From.Airport != nil && leg.To.Airport != nil {
+			distance := geo.Distance(leg.From.Airport.Coordinate(), leg.To.Airport.Coordinate())
+			emissions := Emissions(distance)
+			leg.DistanceKm, leg.EmissionsKg = &distance, &emissions
+			totalDistance += distance
+			totalEmissions += emissions
+		} else {
+			measured = false
+		}
+		trip.Legs = append(trip.Legs, leg)
+	}
+	if measured {
+		trip.TotalDistanceKm, trip.TotalEmissionsKg = &totalDistance, &totalEmissions
+	}
+
+	for i := 1; i < len(trip.Stops)-1; i++ {
+		trip.Layovers = append(trip.Layovers, Layover{Sequence: i, Stop: trip.Stops[i]})
+	}
+	return trip
+}
+
+// Emissions estimates the CO2 emitted per economy passenger for a flight of the given distance
+func Emissions(distanceKm float64) float64 {
+	if distanceKm < shortHaulThresholdKm {
+		return distanceKm * shortHaulKgPerPassenger
+	}
+	return distanceKm * longHaulKgPerPassenger
+}
@@ -0,0 +1,130 @@
+package geo
+
+import "math"
+
+// EarthRadiusKm is the mean Earth radius used for distance calculations
+const EarthRadiusKm = 6371.0088
+
+// Coordinate represents a point on the Earth's surface in decimal degrees
+type Coordinate struct {
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+}
+
+// Distance returns the great-circle distance in kilometres between two coordinates
+func Distance(from, to Coordinate) float64 {
+	return centralAngle(from, to) * EarthRadiusKm
+}
+
+// GreatCircle returns the great-circle path between two coordinates, densified so that
+// no segment is longer than maxSegmentKm. The first and last points are the endpoints.
+func GreatCircle(from, to Coordinate, maxSegmentKm float64) []Coordinate {
+	angle := centralAngle(from, to)
+	if angle == 0 || maxSegmentKm <= 0 {
+		return []Coordinate{from, to}
+	}
+
+	// Every great circle through antipodal points joins them, and interpolation divides by
+	// sin(angle), which is zero; the path follows the meridian of the first point instead
+	if math.Pi-angle < antipodalTolerance {
+		waypoint := meridianWaypoint(from)
+		first := GreatCircle(from, waypoint, maxSegmentKm)
+		return append(first, GreatCircle(waypoint, to, maxSegmentKm)[1:]...)
+	}
+
+	segments := int(math.Ceil(angle * EarthRadiusKm / maxSegmentKm))
+	if segments < 1 {
+		segments = 1
+	}
+
+	path := make([]Coordinate, 0, segments+1)
+	path = append(path, from)
+	for i := 1; i < segments; i++ {
+		path = append(path, interpolate(from, to, angle, float64(i)/float64(segments)))
+	}
+	return append(path, to)
+}
+
+// SplitAntimeridian splits a path into parts wherever it crosses the 180th meridian, so that
+// map clients drawing in longitude space do not render a line across the whole world.
+func SplitAntimeridian(path []Coordinate) [][]Coordinate {
+	if len(path) == 0 {
+		return nil
+	}
+
+	parts := [][]Coordinate{}
+	current := []Coordinate{path[0]}
+	for i := 1; i < len(path); i++ {
+		previous, next := path[i-1], path[i]
+		if math.Abs(next.Longitude-previous.Longitude) <= 180 {
+			current = append(current, next)
+			continue
+		}
+
+		// Unwrap the next longitude onto the same side as the previous point
+		crossing := 180.0
+		unwrapped := next.Longitude + 360
+		if previous.Longitude < 0 {
+			crossing = -180.0
+			unwrapped = next.Longitude - 360
+		}
+		fraction := (crossing - previous.Longitude) / (unwrapped - previous.Longitude)
+		latitude := previous.Latitude + fraction*(next.Latitude-previous.Latitude)
+
+		current = append(current, Coordinate{Latitude: latitude, Longitude: crossing})
+		parts = append(parts, current)
+		current = []Coordinate{{Latitude: latitude, Longitude: -crossing}, next}
+	}
+	return append(parts, current)
+}
+
+// antipodalTolerance is the central angle, in radians, below which points count as antipodal
+const antipodalTolerance = 1e-6
+
+// meridianWaypoint returns the point a quarter circle north of the coordinate along its meridian,
+// crossing the pole when needed; it lies a quarter circle from the antipode as well
+func meridianWaypoint(from Coordinate) Coordinate {
+	if from.Latitude <= 0 {
+		return Coordinate{Latitude: from.Latitude + 90, Longitude: from.Longitude}
+	}
+	longitude := from.Longitude + 180
+	if longitude > 180 {
+		longitude -= 360
+	}
+	return Coordinate{Latitude: 90 - from.Latitude, Longitude: longitude}
+}
+
+func centralAngle(from, to Coordinate) float64 {
+	lat1, lat2 := toRadians(from.Latitude), toRadians(to.Latitude)
+	deltaLat := lat2 - lat1
+	deltaLon := toRadians(to.Longitude - from.Longitude)
+
+	h := math.Sin(deltaLat/2)*math.Sin(deltaLat/2) +
+		math.Cos(lat1)*math.Cos(lat2)*math.Sin(deltaLon/2)*math.Sin(deltaLon/2)
+	return 2 * math.Atan2(math.Sqrt(h), math.Sqrt(1-h))
+}
+
+func interpolate(from, to Coordinate, angle, fraction float64) Coordinate {
+	lat1, lon1 := toRadians(from.Latitude), toRadians(from.Longitude)
+	lat2, lon2 := toRadians(to.Latitude), toRadians(to.Longitude)
+
+	a := math.Sin((1-fraction)*angle) / math.Sin(angle)
+	b := math.Sin(fraction*angle) / math.Sin(angle)
+
+	x := a*math.Cos(lat1)*math.Cos(lon1) + b*math.Cos(lat2)*math.Cos(lon2)
+	y := a*math.Cos(lat1)*math.Sin(lon1) + b*math.Cos(lat2)*math.Sin(lon2)
+	z := a*math.Sin(lat1) + b*math.Sin(lat2)
+
+	return Coordinate{
+		Latitude:  toDegrees(math.Atan2(z, math.Sqrt(x*x+y*y))),
+		Longitude: toDegrees(math.Atan2(y, x)),
+	}
+}
+
+func toRadians(degrees float64) float64 {
+	return degrees * math.Pi / 180
+}
+
+func toDegrees(radians float64) float64 {
+	return radians * 180 / math.Pi
+}
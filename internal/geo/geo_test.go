@@ -0,0 +1,84 @@
+package geo_test
+
+import (
+	"math"
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"flight-itinerary-go/internal/geo"
+)
+
+func TestGeo(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Geo Suite")
+}
+
+var _ = Describe("Geo", func() {
+	jfk := geo.Coordinate{Latitude: 40.6413, Longitude: -73.7781}
+	lhr := geo.Coordinate{Latitude: 51.4700, Longitude: -0.4543}
+	nrt := geo.Coordinate{Latitude: 35.7720, Longitude: 140.3929}
+	hnl := geo.Coordinate{Latitude: 21.3187, Longitude: -157.9225}
+
+	Describe("Distance", func() {
+		It("should return the great-circle distance in kilometres", func() {
+			Expect(geo.Distance(jfk, lhr)).To(BeNumerically("~", 5540, 10))
+		})
+
+		It("should return zero for identical points", func() {
+			Expect(geo.Distance(jfk, jfk)).To(BeZero())
+		})
+	})
+
+	Describe("GreatCircle", func() {
+		It("should densify long arcs and keep the endpoints", func() {
+			path := geo.GreatCircle(jfk, lhr, 100)
+
+			Expect(len(path)).To(BeNumerically(">=", 56))
+			Expect(path[0]).To(Equal(jfk))
+			Expect(path[len(path)-1]).To(Equal(lhr))
+		})
+
+		It("should bend towards the pole on east-west routes", func() {
+			path := geo.GreatCircle(jfk, lhr, 100)
+
+			Expect(path[len(path)/2].Latitude).To(BeNumerically(">", lhr.Latitude))
+		})
+
+		It("should join antipodal points without NaN coordinates", func() {
+			for _, from := range []geo.Coordinate{jfk, {Latitude: -33.9, Longitude: 151.2}, {Latitude: 0, Longitude: 0}} {
+				to := geo.Coordinate{Latitude: -from.Latitude, Longitude: from.Longitude - 180}
+				path := geo.GreatCircle(from, to, 500)
+
+				Expect(path[0]).To(Equal(from))
+				Expect(path[len(path)-1]).To(Equal(to))
+				length := 0.0
+				for i, point := range path {
+					Expect(math.IsNaN(point.Latitude) || math.IsNaN(point.Longitude)).To(BeFalse())
+					if i > 0 {
+						length += geo.Distance(path[i-1], point)
+					}
+				}
+				Expect(length).To(BeNumerically("~", math.Pi*geo.EarthRadiusKm, 1))
+			}
+		})
+	})
+
+	Describe("SplitAntimeridian", func() {
+		It("should keep paths that do not cross the antimeridian intact", func() {
+			parts := geo.SplitAntimeridian(geo.GreatCircle(jfk, lhr, 100))
+
+			Expect(parts).To(HaveLen(1))
+		})
+
+		It("should split paths crossing the antimeridian", func() {
+			parts := geo.SplitAntimeridian(geo.GreatCircle(nrt, hnl, 100))
+
+			Expect(parts).To(HaveLen(2))
+			Expect(parts[0][len(parts[0])-1].Longitude).To(Equal(180.0))
+			Expect(parts[1][0].Longitude).To(Equal(-180.0))
+			Expect(parts[0][len(parts[0])-1].Latitude).To(Equal(parts[1][0].Latitude))
+		})
+	})
+})
@@ -0,0 +1,153 @@
+package gql
+
+import (
+	"github.com/graphql-go/graphql/language/ast"
+	"github.com/graphql-go/graphql/language/parser"
+	"github.com/graphql-go/graphql/language/source"
+
+	"flight-itinerary-go/pkg/errors"
+)
+
+// listFields are the fields returning one value per stop or leg of the enclosing itinerary
+var listFields = map[string]bool{
+	"route":    true,
+	"airports": true,
+	"legs":     true,
+	"layovers": true,
+}
+
+// Limits bounds the work a single query may request
+type Limits struct {
+	MaxDepth      int
+	MaxComplexity int
+}
+
+// Cost describes the work a query requests
+type Cost struct {
+	Depth      int
+	Complexity int
+	// Tickets is the number of tickets submitted across all reconstruct fields
+	Tickets int
+}
+
+// Analyze estimates the cost of the selected operation. Every resolved value costs 1; a
+// reconstruct field additionally costs one per ticket, and list fields below it multiply the
+// cost of their selections by the number of stops. Queries that fail to parse report zero
+// cost and are left for the executor to reject.
+func Analyze(query string, variables map[string]interface{}, operationName string) Cost {
+	document, err := parser.Parse(parser.ParseParams{
+		Source: source.NewSource(&source.Source{Body: []byte(query), Name: "GraphQL request"}),
+	})
+	if err != nil {
+		return Cost{}
+	}
+
+	var operation *ast.OperationDefinition
+	fragments := make(map[string]*ast.FragmentDefinition)
+	for _, definition := range document.Definitions {
+		switch definition := definition.(type) {
+		case *ast.OperationDefinition:
+			name := ""
+			if definition.Name != nil {
+				name = definition.Name.Value
+			}
+			if operation == nil && (operationName == "" || name == operationName) {
+				operation = definition
+			}
+		case *ast.FragmentDefinition:
+			fragments[definition.Name.Value] = definition
+		}
+	}
+	if operation == nil {
+		return Cost{}
+	}
+
+	analyzer := &analyzer{
+		variables: variables,
+		fragments: fragments,
+		visiting:  make(map[string]bool),
+	}
+	analyzer.selectionSet(operation.SelectionSet, 1, 1, 1)
+	return analyzer.cost
+}
+
+// Check returns a validation error when the cost exceeds the limits; zero limits are ignored
+func (limits Limits) Check(cost Cost) error {
+	if limits.MaxDepth > 0 && cost.Depth > limits.MaxDepth {
+		return appError{errors.NewValidationError("query depth %d exceeds the maximum of %d",
+			cost.Depth, limits.MaxDepth)}
+	}
+	if limits.MaxComplexity > 0 && cost.Complexity > limits.MaxComplexity {
+		return appError{errors.NewValidationError("query complexity %d exceeds the maximum of %d",
+			cost.Complexity, limits.MaxComplexity)}
+	}
+	return nil
+}
+
+type analyzer struct {
+	variables map[string]interface{}
+	fragments map[string]*ast.FragmentDefinition
+	visiting  map[string]bool
+	cost      Cost
+}
+
+func (analyzer *analyzer) selectionSet(selectionSet *ast.SelectionSet, multiplier, stops, depth int) {
+	if selectionSet == nil {
+		return
+	}
+	for _, selection := range selectionSet.Selections {
+		switch selection := selection.(type) {
+		case *ast.Field:
+			analyzer.field(selection, multiplier, stops, depth)
+		case *ast.InlineFragment:
+			analyzer.selectionSet(selection.SelectionSet, multiplier, stops, depth)
+		case *ast.FragmentSpread:
+			name := selection.Name.Value
+			fragment, exists := analyzer.fragments[name]
+			// Fragment cycles are rejected by validation; just avoid recursing forever here
+			if !exists || analyzer.visiting[name] {
+				continue
+			}
+			analyzer.visiting[name] = true
+			analyzer.selectionSet(fragment.SelectionSet, multiplier, stops, depth)
+			delete(analyzer.visiting, name)
+		}
+	}
+}
+
+func (analyzer *analyzer) field(field *ast.Field, multiplier, stops, depth int) {
+	if depth > analyzer.cost.Depth {
+		analyzer.cost.Depth = depth
+	}
+	analyzer.cost.Complexity += multiplier
+
+	name := field.Name.Value
+	switch {
+	case name == "reconstruct":
+		tickets := analyzer.ticketCount(field)
+		analyzer.cost.Complexity += multiplier * tickets
+		analyzer.cost.Tickets += multiplier * tickets
+		stops = tickets + 1
+	case listFields[name]:
+		multiplier *= stops
+	}
+	analyzer.selectionSet(field.SelectionSet, multiplier, stops, depth+1)
+}
+
+// ticketCount returns the number of tickets passed inline or through a variable
+func (analyzer *analyzer) ticketCount(field *ast.Field) int {
+	for _, argument := range field.Arguments {
+		if argument.Name.Value != "tickets" {
+			continue
+		}
+		switch value := argument.Value.(type) {
+		case *ast.ListValue:
+			return len(value.Values)
+		case *ast.Variable:
+			if tickets, ok := analyzer.variables[value.Name.Value].([]interface{}); ok {
+				return len(tickets)
+			}
+		}
+	}
+	return 1
+}
@@ -0,0 +1,157 @@
+package gql_test
+
+import (
+	"testing"
+
+	"github.com/graphql-go/graphql"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.uber.org/zap"
+
+	"flight-itinerary-go/internal/airport"
+	"flight-itinerary-go/internal/enrichment"
+	"flight-itinerary-go/internal/gql"
+	"flight-itinerary-go/internal/service"
+)
+
+func TestGQL(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "GraphQL Suite")
+}
+
+var _ = Describe("GraphQL", func() {
+	var schema graphql.Schema
+
+	BeforeEach(func() {
+		logger := zap.NewNop()
+		var err error
+		schema, err = gql.NewSchema(service.NewItineraryService(logger),
+			enrichment.NewEnricher(airport.NewDirectory()), logger)
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	execute := func(query string, variables map[string]interface{}) *graphql.Result {
+		return graphql.Do(graphql.Params{Schema: schema, RequestString: query, VariableValues: variables})
+	}
+
+	Describe("Schema", func() {
+		It("should resolve only the selected enrichments", func() {
+			result := execute(`{
+				reconstruct(tickets: [{source: "LAX", destination: "DXB"}, {source: "JFK", destination: "LAX"}]) {
+					route
+					airports { code city }
+					layovers { sequence airport { code } }
+				}
+			}`, nil)
+
+			Expect(result.Errors).To(BeEmpty())
+			itinerary := result.Data.(map[string]interface{})["reconstruct"].(map[string]interface{})
+			Expect(itinerary).To(HaveLen(3))
+			Expect(itinerary["route"]).To(Equal([]interface{}{"JFK", "LAX", "DXB"}))
+			Expect(itinerary["airports"]).To(ContainElement(map[string]interface{}{"code": "DXB", "city": "Dubai"}))
+			Expect(itinerary["layovers"]).To(Equal([]interface{}{
+				map[string]interface{}{"sequence": 1, "airport": map[string]interface{}{"code": "LAX"}},
+			}))
+		})
+
+		It("should measure legs and estimate emissions", func() {
+			result := execute(`query($tickets: [TicketInput!]!) {
+				reconstruct(tickets: $tickets) {
+					legs { from { code } to { code } distanceKm emissionsKg }
+					totalDistanceKm
+				}
+			}`, map[string]interface{}{
+				"tickets": []interface{}{
+					map[string]interface{}{"source": "JFK", "destination": "LAX"},
+				},
+			})
+
+			Expect(result.Errors).To(BeEmpty())
+			itinerary := result.Data.(map[string]interface{})["reconstruct"].(map[string]interface{})
+			leg := itinerary["legs"].([]interface{})[0].(map[string]interface{})
+			Expect(leg["distanceKm"]).To(BeNumerically("~", 3975, 10))
+			Expect(leg["emissionsKg"]).To(BeNumerically("~", 3975*0.150, 2))
+			Expect(itinerary["totalDistanceKm"]).To(Equal(leg["distanceKm"]))
+		})
+
+		It("should return null enrichments for unknown airports", func() {
+			result := execute(`{
+				reconstruct(tickets: [{source: "JFK", destination: "XYZ"}]) {
+					airports { code name }
+					totalDistanceKm
+				}
+			}`, nil)
+
+			Expect(result.Errors).To(BeEmpty())
+			itinerary := result.Data.(map[string]interface{})["reconstruct"].(map[string]interface{})
+			Expect(itinerary["airports"]).To(ContainElement(map[string]interface{}{"code": "XYZ", "name": nil}))
+			Expect(itinerary["totalDistanceKm"]).To(BeNil())
+		})
+
+		It("should expose the error type as an extension", func() {
+			result := execute(`{
+				reconstruct(tickets: [{source: "JFK", destination: "LAX"}, {source: "LAX", destination: "JFK"}]) { route }
+			}`, nil)
+
+			Expect(result.Errors).To(HaveLen(1))
+			Expect(result.Errors[0].Extensions).To(HaveKeyWithValue("type", "business_error"))
+		})
+
+		It("should look up a single airport", func() {
+			result := execute(`{ airport(code: "sin") { code country } missing: airport(code: "ZZZ") { code } }`, nil)
+
+			Expect(result.Errors).To(BeEmpty())
+			Expect(result.Data).To(Equal(map[string]interface{}{
+				"airport": map[string]interface{}{"code": "SIN", "country": "SG"},
+				"missing": nil,
+			}))
+		})
+	})
+
+	Describe("Analyze", func() {
+		It("should charge per ticket and multiply list selections by the stop count", func() {
+			cost := gql.Analyze(`{
+				reconstruct(tickets: [{source: "JFK", destination: "LAX"}, {source: "LAX", destination: "DXB"}]) {
+					legs { distanceKm }
+				}
+			}`, nil, "")
+
+			// reconstruct 1 + 2 tickets, legs 1, distanceKm once per stop
+			Expect(cost).To(Equal(gql.Cost{Depth: 3, Complexity: 7, Tickets: 2}))
+		})
+
+		It("should count tickets passed as variables and follow fragments", func() {
+			cost := gql.Analyze(`
+				query Trip($tickets: [TicketInput!]!) { reconstruct(tickets: $tickets) { ...stops } }
+				fragment stops on Itinerary { airports { code } }
+			`, map[string]interface{}{"tickets": make([]interface{}, 4)}, "Trip")
+
+			// reconstruct 1 + 4 tickets, airports 1, code once per stop
+			Expect(cost).To(Equal(gql.Cost{Depth: 3, Complexity: 11, Tickets: 4}))
+		})
+
+		It("should leave unparsable queries to the executor", func() {
+			Expect(gql.Analyze("{ reconstruct(", nil, "")).To(Equal(gql.Cost{}))
+		})
+	})
+
+	Describe("Limits", func() {
+		limits := gql.Limits{MaxDepth: 3, MaxComplexity: 10}
+
+		It("should accept queries within the limits", func() {
+			Expect(limits.Check(gql.Cost{Depth: 3, Complexity: 10})).To(Succeed())
+		})
+
+		It("should reject queries that are too deep or too complex", func() {
+			Expect(limits.Check(gql.Cost{Depth: 4})).To(MatchError(ContainSubstring("depth 4 exceeds")))
+			Expect(limits.Check(gql.Cost{Complexity: 11})).To(MatchError(ContainSubstring("complexity 11 exceeds")))
+		})
+
+		It("should report the rejection with error extensions", func() {
+			result := gql.ErrorResult(limits.Check(gql.Cost{Depth: 4}))
+
+			Expect(result.Errors).To(HaveLen(1))
+			Expect(result.Errors[0].Extensions).To(HaveKeyWithValue("type", "validation_error"))
+		})
+	})
+})
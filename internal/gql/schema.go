@@ -0,0 +1,213 @@
+package gql
+
+import (
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/gqlerrors"
+	"go.uber.org/zap"
+
+	"flight-itinerary-go/internal/airport"
+	"flight-itinerary-go/internal/enrichment"
+	"flight-itinerary-go/internal/model"
+	"flight-itinerary-go/internal/service"
+	"flight-itinerary-go/pkg/errors"
+)
+
+// appError exposes the AppError type and HTTP code as GraphQL error extensions
+type appError struct {
+	*errors.AppError
+}
+
+// Extensions returns the error metadata included in the GraphQL response
+func (err appError) Extensions() map[string]interface{} {
+	return map[string]interface{}{
+		"type": err.Type,
+		"code": err.Code,
+	}
+}
+
+// resolvers holds the dependencies used by the field resolvers
+type resolvers struct {
+	itineraryService service.ItineraryService
+	enricher         enrichment.Enricher
+	logger           *zap.Logger
+}
+
+// NewSchema builds the GraphQL schema over the itinerary service and enrichment packages
+func NewSchema(itineraryService service.ItineraryService, enricher enrichment.Enricher,
+	logger *zap.Logger) (graphql.Schema, error) {
+	fieldResolvers := &resolvers{
+		itineraryService: itineraryService,
+		enricher:         enricher,
+		logger:           logger,
+	}
+
+	airportType := graphql.NewObject(graphql.ObjectConfig{
+		Name:        "Airport",
+		Description: "An airport; reference fields are null when the code is not in the airport data",
+		Fields: graphql.Fields{
+			"code":      &graphql.Field{Type: graphql.NewNonNull(graphql.String), Resolve: stopField(func(stop enrichment.Stop) interface{} { return stop.Code })},
+			"name":      &graphql.Field{Type: graphql.String, Resolve: airportField(func(found *airport.Airport) interface{} { return found.Name })},
+			"city":      &graphql.Field{Type: graphql.String, Resolve: airportField(func(found *airport.Airport) interface{} { return found.City })},
+			"country":   &graphql.Field{Type: graphql.String, Resolve: airportField(func(found *airport.Airport) interface{} { return found.Country })},
+			"latitude":  &graphql.Field{Type: graphql.Float, Resolve: airportField(func(found *airport.Airport) interface{} { return found.Latitude })},
+			"longitude": &graphql.Field{Type: graphql.Float, Resolve: airportField(func(found *airport.Airport) interface{} { return found.Longitude })},
+		},
+	})
+
+	legType := graphql.NewObject(graphql.ObjectConfig{
+		Name:        "Leg",
+		Description: "A flight between two consecutive stops",
+		Fields: graphql.Fields{
+			"sequence":    &graphql.Field{Type: graphql.NewNonNull(graphql.Int), Resolve: legField(func(leg enrichment.Leg) interface{} { return leg.Sequence })},
+			"from":        &graphql.Field{Type: graphql.NewNonNull(airportType), Resolve: legField(func(leg enrichment.Leg) interface{} { return leg.From })},
+			"to":          &graphql.Field{Type: graphql.NewNonNull(airportType), Resolve: legField(func(leg enrichment.Leg) interface{} { return leg.To })},
+			"distanceKm":  &graphql.Field{Type: graphql.Float, Resolve: legField(func(leg enrichment.Leg) interface{} { return leg.DistanceKm })},
+			"emissionsKg": &graphql.Field{Type: graphql.Float, Description: "Estimated CO2 per economy passenger", Resolve: legField(func(leg enrichment.Leg) interface{} { return leg.EmissionsKg })},
+		},
+	})
+
+	layoverType := graphql.NewObject(graphql.ObjectConfig{
+		Name:        "Layover",
+		Description: "An intermediate stop where the traveller changes flights",
+		Fields: graphql.Fields{
+			"sequence": &graphql.Field{Type: graphql.NewNonNull(graphql.Int), Resolve: layoverField(func(layover enrichment.Layover) interface{} { return layover.Sequence })},
+			"airport":  &graphql.Field{Type: graphql.NewNonNull(airportType), Resolve: layoverField(func(layover enrichment.Layover) interface{} { return layover.Stop })},
+		},
+	})
+
+	itineraryType := graphql.NewObject(graphql.ObjectConfig{
+		Name:        "Itinerary",
+		Description: "A reconstructed itinerary with optional enrichments",
+		Fields: graphql.Fields{
+			"route":            &graphql.Field{Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(graphql.String))), Resolve: tripField(func(trip *enrichment.Trip) interface{} { return trip.Route })},
+			"airports":         &graphql.Field{Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(airportType))), Resolve: tripField(func(trip *enrichment.Trip) interface{} { return trip.Stops })},
+			"legs":             &graphql.Field{Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(legType))), Resolve: tripField(func(trip *enrichment.Trip) interface{} { return trip.Legs })},
+			"layovers":         &graphql.Field{Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(layoverType))), Resolve: tripField(func(trip *enrichment.Trip) interface{} { return trip.Layovers })},
+			"totalDistanceKm":  &graphql.Field{Type: graphql.Float, Resolve: tripField(func(trip *enrichment.Trip) interface{} { return trip.TotalDistanceKm })},
+			"totalEmissionsKg": &graphql.Field{Type: graphql.Float, Resolve: tripField(func(trip *enrichment.Trip) interface{} { return trip.TotalEmissionsKg })},
+		},
+	})
+
+	ticketInputType := graphql.NewInputObject(graphql.InputObjectConfig{
+		Name: "TicketInput",
+		Fields: graphql.InputObjectConfigFieldMap{
+			"source":      &graphql.InputObjectFieldConfig{Type: graphql.NewNonNull(graphql.String)},
+			"destination": &graphql.InputObjectFieldConfig{Type: graphql.NewNonNull(graphql.String)},
+		},
+	})
+
+	queryType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Query",
+		Fields: graphql.Fields{
+			"reconstruct": &graphql.Field{
+				Type:        graphql.NewNonNull(itineraryType),
+				Description: "Reconstructs the itinerary from a list of tickets",
+				Args: graphql.FieldConfigArgument{
+					"tickets": &graphql.ArgumentConfig{
+						Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(ticketInputType))),
+					},
+				},
+				Resolve: fieldResolvers.reconstruct,
+			},
+			"airport": &graphql.Field{
+				Type:        airportType,
+				Description: "Looks up a single airport by IATA code",
+				Args: graphql.FieldConfigArgument{
+					"code": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)},
+				},
+				Resolve: fieldResolvers.airport,
+			},
+		},
+	})
+
+	return graphql.NewSchema(graphql.SchemaConfig{Query: queryType})
+}
+
+func (fieldResolvers *resolvers) reconstruct(params graphql.ResolveParams) (interface{}, error) {
+	arguments, _ := params.Args["tickets"].([]interface{})
+	request := model.ItineraryRequest{
+		Tickets: make([]model.Ticket, 0, len(arguments)),
+	}
+	for _, argument := range arguments {
+		ticket, _ := argument.(map[string]interface{})
+		source, _ := ticket["source"].(string)
+		destination, _ := ticket["destination"].(string)
+		request.Tickets = append(request.Tickets, model.Ticket{source, destination})
+	}
+
+	if len(request.Tickets) == 0 {
+		return nil, appError{errors.NewValidationError("at least one ticket is required")}
+	}
+	tickets, err := request.ToTickets()
+	if err != nil {
+		return nil, wrapError(err)
+	}
+
+	itinerary, err := fieldResolvers.itineraryService.ReconstructItinerary(tickets)
+	if err != nil {
+		fieldResolvers.logger.Warn("Failed to reconstruct itinerary", zap.Error(err))
+		return nil, wrapError(err)
+	}
+	return fieldResolvers.enricher.Enrich(itinerary), nil
+}
+
+func (fieldResolvers *resolvers) airport(params graphql.ResolveParams) (interface{}, error) {
+	code, _ := params.Args["code"].(string)
+	found, exists := fieldResolvers.enricher.Airport(code)
+	if !exists {
+		return nil, nil
+	}
+	return enrichment.Stop{Code: found.Code, Airport: &found}, nil
+}
+
+// ErrorResult builds a GraphQL response carrying a single request-level error
+func ErrorResult(err error) *graphql.Result {
+	formatted := gqlerrors.FormatError(err)
+	if extended, ok := err.(gqlerrors.ExtendedError); ok {
+		formatted.Extensions = extended.Extensions()
+	}
+	return &graphql.Result{
+		Errors: []gqlerrors.FormattedError{formatted},
+	}
+}
+
+func wrapError(err error) error {
+	if appErr, ok := err.(*errors.AppError); ok {
+		return appError{appErr}
+	}
+	return appError{errors.NewInternalError("internal server error")}
+}
+
+func stopField(value func(enrichment.Stop) interface{}) graphql.FieldResolveFn {
+	return func(params graphql.ResolveParams) (interface{}, error) {
+		return value(params.Source.(enrichment.Stop)), nil
+	}
+}
+
+func airportField(value func(*airport.Airport) interface{}) graphql.FieldResolveFn {
+	return func(params graphql.ResolveParams) (interface{}, error) {
+		stop := params.Source.(enrichment.Stop)
+		if stop.Airport == nil {
+			return nil, nil
+		}
+		return value(stop.Airport), nil
+	}
+}
+
+func legField(value func(enrichment.Leg) interface{}) graphql.FieldResolveFn {
+	return func(params graphql.ResolveParams) (interface{}, error) {
+		return value(params.Source.(enrichment.Leg)), nil
+	}
+}
+
+func layoverField(value func(enrichment.Layover) interface{}) graphql.FieldResolveFn {
+	return func(params graphql.ResolveParams) (interface{}, error) {
+		return value(params.Source.(enrichment.Layover)), nil
+	}
+}
+
+func tripField(value func(*enrichment.Trip) interface{}) graphql.FieldResolveFn {
+	return func(params graphql.ResolveParams) (interface{}, error) {
+		return value(params.Source.(*enrichment.Trip)), nil
+	}
+}
@@ -0,0 +1,177 @@
+package graph
+
+import "flight-itinerary-go/internal/model"
+
+// Node represents an airport in the ticket graph
+type Node struct {
+	Code string
+	// Fragment identifies the weakly connected component the node belongs to
+	Fragment int
+}
+
+// Edge represents a single ticket in the ticket graph
+type Edge struct {
+	Index       int
+	Source      string
+	Destination string
+	// Duplicate marks a ticket departing from an airport that already has an outgoing ticket
+	Duplicate bool
+	// InCycle marks a ticket that is part of a circular route
+	InCycle bool
+	// OnPath marks a ticket used by the reconstructed itinerary
+	OnPath bool
+}
+
+// TicketGraph is the directed multigraph built from a ticket list, annotated with the
+// conditions that make reconstruction fail
+type TicketGraph struct {
+	Nodes     []Node
+	Edges     []Edge
+	Fragments int
+	// Outcome holds the reconstruction error message, empty on success
+	Outcome string
+}
+
+// NewTicketGraph builds and analyses the graph for the given tickets
+func NewTicketGraph(tickets []model.Ticket) *TicketGraph {
+	ticketGraph := &TicketGraph{
+		Nodes: []Node{},
+		Edges: make([]Edge, 0, len(tickets)),
+	}
+
+	nodeIndex := make(map[string]int)
+	addNode := func(code string) {
+		if _, exists := nodeIndex[code]; !exists {
+			nodeIndex[code] = len(ticketGraph.Nodes)
+			ticketGraph.Nodes = append(ticketGraph.Nodes, Node{Code: code})
+		}
+	}
+
+	departures := make(map[string]bool)
+	for i, ticket := range tickets {
+		src, dst := ticket.Source(), ticket.Destination()
+		addNode(src)
+		addNode(dst)
+		ticketGraph.Edges = append(ticketGraph.Edges, Edge{
+			Index:       i,
+			Source:      src,
+			Destination: dst,
+			Duplicate:   departures[src],
+		})
+		departures[src] = true
+	}
+
+	ticketGraph.assignFragments(nodeIndex)
+	ticketGraph.markCycles(nodeIndex)
+	return ticketGraph
+}
+
+// MarkPath flags the edges used by the reconstructed itinerary
+func (ticketGraph *TicketGraph) MarkPath(itinerary []string) {
+	for i := 1; i < len(itinerary); i++ {
+		for j := range ticketGraph.Edges {
+			edge := &ticketGraph.Edges[j]
+			if !edge.OnPath && edge.Source == itinerary[i-1] && edge.Destination == itinerary[i] {
+				edge.OnPath = true
+				break
+			}
+		}
+	}
+}
+
+// assignFragments labels weakly connected components using union-find
+func (ticketGraph *TicketGraph) assignFragments(nodeIndex map[string]int) {
+	parent := make([]int, len(ticketGraph.Nodes))
+	for i := range parent {
+		parent[i] = i
+	}
+	var find func(int) int
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+		return parent[i]
+	}
+
+	for _, edge := range ticketGraph.Edges {
+		parent[find(nodeIndex[edge.Source])] = find(nodeIndex[edge.Destination])
+	}
+
+	fragments := make(map[int]int)
+	for i := range ticketGraph.Nodes {
+		root := find(i)
+		if _, exists := fragments[root]; !exists {
+			fragments[root] = len(fragments)
+		}
+		ticketGraph.Nodes[i].Fragment = fragments[root]
+	}
+	ticketGraph.Fragments = len(fragments)
+}
+
+// markCycles flags edges whose endpoints share a strongly connected component
+func (ticketGraph *TicketGraph) markCycles(nodeIndex map[string]int) {
+	adjacency := make([][]int, len(ticketGraph.Nodes))
+	for _, edge := range ticketGraph.Edges {
+		src := nodeIndex[edge.Source]
+		adjacency[src] = append(adjacency[src], nodeIndex[edge.Destination])
+	}
+
+	component := stronglyConnectedComponents(adjacency)
+	for i := range ticketGraph.Edges {
+		edge := &ticketGraph.Edges[i]
+		src, dst := nodeIndex[edge.Source], nodeIndex[edge.Destination]
+		edge.InCycle = src == dst || component[src] == component[dst]
+	}
+}
+
+// stronglyConnectedComponents returns the component of every node using Tarjan's algorithm
+func stronglyConnectedComponents(adjacency [][]int) []int {
+	index := 0
+	indices := make([]int, len(adjacency))
+	lowLinks := make([]int, len(adjacency))
+	onStack := make([]bool, len(adjacency))
+	component := make([]int, len(adjacency))
+	for i := range indices {
+		indices[i] = -1
+	}
+	stack := []int{}
+	components := 0
+
+	var visit func(int)
+	visit = func(node int) {
+		indices[node] = index
+		lowLinks[node] = index
+		index++
+		stack = append(stack, node)
+		onStack[node] = true
+
+		for _, next := range adjacency[node] {
+			if indices[next] == -1 {
+				visit(next)
+				lowLinks[node] = min(lowLinks[node], lowLinks[next])
+			} else if onStack[next] {
+				lowLinks[node] = min(lowLinks[node], indices[next])
+			}
+		}
+
+		if lowLinks[node] == indices[node] {
+			for {
+				top := stack[len(stack)-1]
+				stack = stack[:len(stack)-1]
+				onStack[top] = false
+				component[top] = components
+				if top == node {
+					break
+				}
+			}
+			components++
+		}
+	}
+
+	for node := range adjacency {
+		if indices[node] == -1 {
+			visit(node)
+		}
+	}
+	return component
+}
@@ -0,0 +1,90 @@
+package graph_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"flight-itinerary-go/internal/graph"
+	"flight-itinerary-go/internal/model"
+)
+
+func TestTicketGraph(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "TicketGraph Suite")
+}
+
+var _ = Describe("TicketGraph", func() {
+	Describe("NewTicketGraph", func() {
+		Context("when given a linear route", func() {
+			It("should build a single fragment without cycles or duplicates", func() {
+				ticketGraph := graph.NewTicketGraph([]model.Ticket{
+					{"LAX", "DXB"},
+					{"JFK", "LAX"},
+				})
+
+				Expect(ticketGraph.Nodes).To(HaveLen(3))
+				Expect(ticketGraph.Edges).To(HaveLen(2))
+				Expect(ticketGraph.Fragments).To(Equal(1))
+				for _, edge := range ticketGraph.Edges {
+					Expect(edge.Duplicate).To(BeFalse())
+					Expect(edge.InCycle).To(BeFalse())
+				}
+			})
+		})
+
+		Context("when given disconnected routes", func() {
+			It("should assign each route its own fragment", func() {
+				ticketGraph := graph.NewTicketGraph([]model.Ticket{
+					{"JFK", "LAX"},
+					{"DXB", "SFO"},
+				})
+
+				Expect(ticketGraph.Fragments).To(Equal(2))
+				Expect(ticketGraph.Nodes[0].Fragment).To(Equal(ticketGraph.Nodes[1].Fragment))
+				Expect(ticketGraph.Nodes[0].Fragment).NotTo(Equal(ticketGraph.Nodes[2].Fragment))
+			})
+		})
+
+		Context("when given a circular route", func() {
+			It("should mark only the edges on the cycle", func() {
+				ticketGraph := graph.NewTicketGraph([]model.Ticket{
+					{"JFK", "A"},
+					{"A", "B"},
+					{"B", "A"},
+				})
+
+				Expect(ticketGraph.Edges[0].InCycle).To(BeFalse())
+				Expect(ticketGraph.Edges[1].InCycle).To(BeTrue())
+				Expect(ticketGraph.Edges[2].InCycle).To(BeTrue())
+			})
+		})
+
+		Context("when given duplicate departures", func() {
+			It("should mark the later ticket as duplicate", func() {
+				ticketGraph := graph.NewTicketGraph([]model.Ticket{
+					{"JFK", "LAX"},
+					{"JFK", "SFO"},
+				})
+
+				Expect(ticketGraph.Edges[0].Duplicate).To(BeFalse())
+				Expect(ticketGraph.Edges[1].Duplicate).To(BeTrue())
+			})
+		})
+	})
+
+	Describe("MarkPath", func() {
+		It("should flag the edges used by the itinerary", func() {
+			ticketGraph := graph.NewTicketGraph([]model.Ticket{
+				{"LAX", "DXB"},
+				{"JFK", "LAX"},
+			})
+
+			ticketGraph.MarkPath([]string{"JFK", "LAX", "DXB"})
+
+			Expect(ticketGraph.Edges[0].OnPath).To(BeTrue())
+			Expect(ticketGraph.Edges[1].OnPath).To(BeTrue())
+		})
+	})
+})
@@ -0,0 +1,178 @@
+package handler
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+
+	"flight-itinerary-go/internal/analytics"
+	"flight-itinerary-go/internal/render"
+	"flight-itinerary-go/pkg/errors"
+)
+
+const (
+	defaultTopLimit = 10
+	maxTopLimit     = 100
+)
+
+// trafficIntervals are the supported traffic bucket sizes
+var trafficIntervals = map[string]time.Duration{
+	"hour": time.Hour,
+	"day":  24 * time.Hour,
+}
+
+// AnalyticsHandler handles HTTP requests for route analytics
+type AnalyticsHandler struct {
+	analytics analytics.Analytics
+	logger    *zap.Logger
+}
+
+// NewAnalyticsHandler creates a new analytics handler
+func NewAnalyticsHandler(analytics analytics.Analytics, logger *zap.Logger) *AnalyticsHandler {
+	return &AnalyticsHandler{
+		analytics: analytics,
+		logger:    logger,
+	}
+}
+
+// @Summary Top Routes
+// @Description Returns the most frequently flown legs across reconstructed itineraries
+// @Tags Analytics
+// @Produce json
+// @Param from query string false "Recorded at or after (RFC 3339 or YYYY-MM-DD)"
+// @Param to query string false "Recorded before (RFC 3339 or YYYY-MM-DD)"
+// @Param limit query int false "Number of routes" default(10) maximum(100)
+// @Success 200 {array} analytics.RouteCount
+// @Failure 400 {object} errors.AppError
+// @Router /api/v1/analytics/routes [get]
+func (analyticsHandlerV1 *AnalyticsHandler) TopRoutes(ctx echo.Context) error {
+	window, limit, err := analyticsHandlerV1.topParams(ctx)
+	if err != nil {
+		return analyticsHandlerV1.handleError(ctx, err)
+	}
+	return ctx.JSON(http.StatusOK, analyticsHandlerV1.analytics.TopRoutes(window, limit))
+}
+
+// @Summary Top Hubs
+// @Description Returns the airports most frequently used for layovers
+// @Tags Analytics
+// @Produce json
+// @Param from query string false "Recorded at or after (RFC 3339 or YYYY-MM-DD)"
+// @Param to query string false "Recorded before (RFC 3339 or YYYY-MM-DD)"
+// @Param limit query int false "Number of hubs" default(10) maximum(100)
+// @Success 200 {array} analytics.AirportCount
+// @Failure 400 {object} errors.AppError
+// @Router /api/v1/analytics/hubs [get]
+func (analyticsHandlerV1 *AnalyticsHandler) TopHubs(ctx echo.Context) error {
+	window, limit, err := analyticsHandlerV1.topParams(ctx)
+	if err != nil {
+		return analyticsHandlerV1.handleError(ctx, err)
+	}
+	return ctx.JSON(http.StatusOK, analyticsHandlerV1.analytics.TopHubs(window, limit))
+}
+
+// @Summary Trip Summary
+// @Description Returns the number of trips and legs and the average legs per trip
+// @Tags Analytics
+// @Produce json
+// @Param from query string false "Recorded at or after (RFC 3339 or YYYY-MM-DD)"
+// @Param to query string false "Recorded before (RFC 3339 or YYYY-MM-DD)"
+// @Success 200 {object} analytics.Summary
+// @Failure 400 {object} errors.AppError
+// @Router /api/v1/analytics/summary [get]
+func (analyticsHandlerV1 *AnalyticsHandler) Summary(ctx echo.Context) error {
+	window, err := analyticsWindow(ctx)
+	if err != nil {
+		return analyticsHandlerV1.handleError(ctx, err)
+	}
+	return ctx.JSON(http.StatusOK, analyticsHandlerV1.analytics.Summary(window))
+}
+
+// @Summary Airport Traffic
+// @Description Returns departures and arrivals per airport, bucketed by hour or day
+// @Tags Analytics
+// @Produce json
+// @Param airport query string false "Only this airport"
+// @Param interval query string false "Bucket size" Enums(hour, day) default(day)
+// @Param from query string false "Recorded at or after (RFC 3339 or YYYY-MM-DD)"
+// @Param to query string false "Recorded before (RFC 3339 or YYYY-MM-DD)"
+// @Success 200 {array} analytics.Traffic
+// @Failure 400 {object} errors.AppError
+// @Router /api/v1/analytics/traffic [get]
+func (analyticsHandlerV1 *AnalyticsHandler) Traffic(ctx echo.Context) error {
+	window, err := analyticsWindow(ctx)
+	if err != nil {
+		return analyticsHandlerV1.handleError(ctx, err)
+	}
+	name := ctx.QueryParam("interval")
+	if name == "" {
+		name = "day"
+	}
+	interval, supported := trafficIntervals[name]
+	if !supported {
+		return analyticsHandlerV1.handleError(ctx, errors.NewValidationError("interval must be hour or day"))
+	}
+	airport := strings.ToUpper(strings.TrimSpace(ctx.QueryParam("airport")))
+	return ctx.JSON(http.StatusOK, analyticsHandlerV1.analytics.Traffic(window, interval, airport))
+}
+
+// @Summary Export Legs
+// @Description Exports every recorded leg as CSV. Trip IDs are empty when analytics are anonymized.
+// @Tags Analytics
+// @Produce text/csv
+// @Param from query string false "Recorded at or after (RFC 3339 or YYYY-MM-DD)"
+// @Param to query string false "Recorded before (RFC 3339 or YYYY-MM-DD)"
+// @Success 200 {string} string "recorded_at,trip_id,leg,source,destination"
+// @Failure 400 {object} errors.AppError
+// @Router /api/v1/analytics/export [get]
+func (analyticsHandlerV1 *AnalyticsHandler) Export(ctx echo.Context) error {
+	window, err := analyticsWindow(ctx)
+	if err != nil {
+		return analyticsHandlerV1.handleError(ctx, err)
+	}
+	response := ctx.Response()
+	response.Header().Set(echo.HeaderContentType, "text/csv; charset=utf-8")
+	response.Header().Set(echo.HeaderContentDisposition, `attachment; filename="legs.csv"`)
+	response.WriteHeader(http.StatusOK)
+	if err := analyticsHandlerV1.analytics.Export(response, window); err != nil {
+		analyticsHandlerV1.logger.Error("Failed to export analytics", zap.Error(err))
+	}
+	return nil
+}
+
+func (analyticsHandlerV1 *AnalyticsHandler) topParams(ctx echo.Context) (analytics.Window, int, error) {
+	window, err := analyticsWindow(ctx)
+	if err != nil {
+		return window, 0, err
+	}
+	limit, err := queryInt(ctx, "limit", defaultTopLimit)
+	if err != nil || limit < 1 || limit > maxTopLimit {
+		return window, 0, errors.NewValidationError("limit must be an integer between 1 and %d", maxTopLimit)
+	}
+	return window, limit, nil
+}
+
+func (analyticsHandlerV1 *AnalyticsHandler) handleError(ctx echo.Context, err error) error {
+	if appErr, ok := err.(*errors.AppError); ok {
+		return render.WriteError(ctx, appErr)
+	}
+
+	analyticsHandlerV1.logger.Error("Unexpected error", zap.Error(err))
+	return render.WriteError(ctx, errors.NewInternalError("internal server error"))
+}
+
+// analyticsWindow reads the from and to bounds of the reporting window
+func analyticsWindow(ctx echo.Context) (analytics.Window, error) {
+	var window analytics.Window
+	var err error
+	if window.From, err = queryTime(ctx, "from"); err != nil {
+		return window, err
+	}
+	if window.To, err = queryTime(ctx, "to"); err != nil {
+		return window, err
+	}
+	return window, nil
+}
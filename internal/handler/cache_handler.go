@@ -0,0 +1,31 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+
+	"flight-itinerary-go/internal/cache"
+)
+
+// CacheHandler handles HTTP requests for result cache metrics
+type CacheHandler struct {
+	cache *cache.Cache
+}
+
+// NewCacheHandler creates a new result cache handler
+func NewCacheHandler(cache *cache.Cache) *CacheHandler {
+	return &CacheHandler{
+		cache: cache,
+	}
+}
+
+// @Summary Result Cache Stats
+// @Description Returns hits, misses, bypasses, evictions and the size of the reconstruction result cache
+// @Tags Itinerary
+// @Produce json
+// @Success 200 {object} cache.Stats
+// @Router /api/v1/cache/stats [get]
+func (cacheHandlerV1 *CacheHandler) Stats(ctx echo.Context) error {
+	return ctx.JSON(http.StatusOK, cacheHandlerV1.cache.Stats())
+}
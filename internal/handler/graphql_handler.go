@@ -0,0 +1,99 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/graphql-go/graphql"
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+
+	"flight-itinerary-go/internal/gql"
+	customMiddleware "flight-itinerary-go/internal/middleware"
+	"flight-itinerary-go/internal/tenant"
+	"flight-itinerary-go/pkg/errors"
+)
+
+// GraphQLRequest represents a GraphQL query sent over HTTP
+type GraphQLRequest struct {
+	Query         string                 `json:"query"`
+	Variables     map[string]interface{} `json:"variables,omitempty"`
+	OperationName string                 `json:"operationName,omitempty"`
+}
+
+// GraphQLHandler handles GraphQL queries over HTTP
+type GraphQLHandler struct {
+	schema     graphql.Schema
+	limits     gql.Limits
+	useTickets customMiddleware.TicketCounter
+	logger     *zap.Logger
+}
+
+// NewGraphQLHandler creates a new GraphQL handler enforcing the given query limits and counting the
+// tickets of reconstruct fields against the caller's quota. Requests with a tenant are executed
+// against the tenant's schema instead.
+func NewGraphQLHandler(schema graphql.Schema, limits gql.Limits, useTickets customMiddleware.TicketCounter,
+	logger *zap.Logger) *GraphQLHandler {
+	return &GraphQLHandler{
+		schema:     schema,
+		limits:     limits,
+		useTickets: useTickets,
+		logger:     logger,
+	}
+}
+
+// @Summary GraphQL Query
+// @Description Executes a GraphQL query. Clients select only the enrichments they need (airport names, leg
+// @Description distances, layovers, emissions). Queries exceeding the depth or complexity limits are rejected
+// @Description before execution.
+// @Tags GraphQL
+// @Accept json
+// @Produce json
+// @Param input body handler.GraphQLRequest true "GraphQL query"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} errors.AppError
+// @Router /graphql [post]
+func (graphQLHandlerV1 *GraphQLHandler) Query(ctx echo.Context) error {
+	requestID := ctx.Response().Header().Get(echo.HeaderXRequestID)
+	logger := graphQLHandlerV1.logger.With(zap.String("request_id", requestID))
+
+	var request GraphQLRequest
+	if err := ctx.Bind(&request); err != nil {
+		logger.Warn("Failed to bind GraphQL request", zap.Error(err))
+		appErr := errors.NewValidationError("invalid GraphQL request body")
+		return ctx.JSON(appErr.Code, appErr)
+	}
+	if request.Query == "" {
+		appErr := errors.NewValidationError("query is required")
+		return ctx.JSON(appErr.Code, appErr)
+	}
+
+	cost := gql.Analyze(request.Query, request.Variables, request.OperationName)
+	if err := graphQLHandlerV1.limits.Check(cost); err != nil {
+		logger.Warn("Rejected GraphQL query", zap.Int("depth", cost.Depth),
+			zap.Int("complexity", cost.Complexity), zap.Error(err))
+		return ctx.JSON(http.StatusOK, gql.ErrorResult(err))
+	}
+	if cost.Tickets > 0 {
+		if appErr := graphQLHandlerV1.useTickets(ctx, cost.Tickets); appErr != nil {
+			return ctx.JSON(appErr.Code, appErr)
+		}
+	}
+
+	logger.Info("Executing GraphQL query", zap.String("operation", request.OperationName),
+		zap.Int("depth", cost.Depth), zap.Int("complexity", cost.Complexity))
+	schema := graphQLHandlerV1.schema
+	if requestTenant, ok := tenant.FromContext(ctx); ok {
+		schema = requestTenant.Schema
+	}
+	result := graphql.Do(graphql.Params{
+		Schema:         schema,
+		RequestString:  request.Query,
+		VariableValues: request.Variables,
+		OperationName:  request.OperationName,
+		Context:        ctx.Request().Context(),
+	})
+	if result.HasErrors() {
+		logger.Warn("GraphQL query returned errors", zap.Int("error_count", len(result.Errors)))
+	}
+	return ctx.JSON(http.StatusOK, result)
+}
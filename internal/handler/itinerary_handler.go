@@ -4,33 +4,61 @@ import (
 	"flight-itinerary-go/pkg/errors"
 	"go.uber.org/zap"
 	"net/http"
+	"strings"
 
+	"flight-itinerary-go/internal/airport"
+	"flight-itinerary-go/internal/cache"
+	"flight-itinerary-go/internal/graph"
 	"flight-itinerary-go/internal/model"
+	"flight-itinerary-go/internal/render"
 	"flight-itinerary-go/internal/service"
+	"flight-itinerary-go/internal/tenant"
 	"github.com/labstack/echo/v4"
 )
 
 // ItineraryHandler handles HTTP requests for itinerary operations
 type ItineraryHandler struct {
 	itineraryService service.ItineraryService
+	airports         airport.Directory
+	projections      map[string]render.Projection
 	logger           *zap.Logger
 }
 
-// NewItineraryHandler creates a new itinerary handler
+// NewItineraryHandler creates a new itinerary handler. Requests with a tenant use the tenant's
+// itinerary service and airports instead.
 func NewItineraryHandler(itineraryService service.ItineraryService, logger *zap.Logger) *ItineraryHandler {
 	return &ItineraryHandler{
 		itineraryService: itineraryService,
-		logger:           logger,
+		airports:         airport.NewDirectory(),
+		projections: map[string]render.Projection{
+			"robinson":        render.NewRobinsonProjection(),
+			"equirectangular": render.NewEquirectangularProjection(),
+		},
+		logger: logger,
 	}
 }
 
 // @Summary Reconstruct Itinerary
-// @Description Reconstructs the travel itinerary from a list of source-destination pairs
+// @Description Reconstructs the travel itinerary from a list of source-destination pairs. Results are cached by
+// @Description ticket set regardless of ticket order; the X-Cache response header reports HIT, MISS or BYPASS.
 // @Tags Itinerary
 // @Accept json
 // @Produce json
-// @Param input body []Ticket true "Array of ticket pairs"
+// @Produce application/x-ndjson
+// @Produce text/csv
+// @Produce text/plain
+// @Produce text/markdown
+// @Produce application/yaml
+// @Produce application/msgpack
+// @Produce application/geo+json
+// @Produce application/vnd.google-earth.kml+xml
+// @Produce image/svg+xml
+// @Param input body []model.Ticket true "Array of ticket pairs"
+// @Param format query string false "Response format, overrides the Accept header" Enums(json, ndjson, csv, text, markdown, yaml, msgpack, geojson, kml, svg)
+// @Param Cache-Control header string false "no-cache or no-store skips the result cache"
 // @Success 200 {object} []string
+// @Header 200 {string} X-Cache "HIT, MISS or BYPASS"
+// @Failure 406 {object} errors.AppError
 // @Router /api/v1/itinerary/reconstruct [post]
 func (itineraryHandlerV1 *ItineraryHandler) ReconstructItinerary(ctx echo.Context) error {
 	requestID := ctx.Response().Header().Get(echo.HeaderXRequestID)
@@ -55,7 +83,7 @@ func (itineraryHandlerV1 *ItineraryHandler) ReconstructItinerary(ctx echo.Contex
 		return itineraryHandlerV1.handleError(ctx, err)
 	}
 
-	response, err := itineraryHandlerV1.itineraryService.ReconstructItinerary(tickets)
+	response, err := itineraryHandlerV1.reconstruct(ctx, tickets)
 
 	if err != nil {
 		logger.Error("Failed to reconstruct itinerary", zap.Error(err))
@@ -63,16 +91,152 @@ func (itineraryHandlerV1 *ItineraryHandler) ReconstructItinerary(ctx echo.Contex
 	}
 	logger.Info("Successfully reconstructed itinerary",
 		zap.Strings("result", response))
-	return ctx.JSON(http.StatusOK, response)
+	return itineraryHandlerV1.respond(ctx, response)
+}
+
+// reconstruct uses the result cache when the service has one, honoring the request's Cache-Control
+func (itineraryHandlerV1 *ItineraryHandler) reconstruct(ctx echo.Context, tickets []model.Ticket) ([]string, error) {
+	itineraryService := itineraryHandlerV1.serviceFor(ctx)
+	reconstructor, cached := itineraryService.(cache.Reconstructor)
+	if !cached {
+		return itineraryService.ReconstructItinerary(tickets)
+	}
+	cacheControl := ctx.Request().Header.Get(echo.HeaderCacheControl)
+	bypass := strings.Contains(cacheControl, "no-cache") || strings.Contains(cacheControl, "no-store")
+	itinerary, status, err := reconstructor.Reconstruct(tickets, bypass)
+	ctx.Response().Header().Set("X-Cache", string(status))
+	return itinerary, err
+}
+
+// serviceFor returns the itinerary service of the request's tenant, or the handler's own
+func (itineraryHandlerV1 *ItineraryHandler) serviceFor(ctx echo.Context) service.ItineraryService {
+	if requestTenant, ok := tenant.FromContext(ctx); ok {
+		return requestTenant.ItineraryService
+	}
+	return itineraryHandlerV1.itineraryService
+}
+
+// respond writes the itinerary using the negotiated renderer, falling back to JSON
+func (itineraryHandlerV1 *ItineraryHandler) respond(ctx echo.Context, itinerary []string) error {
+	renderer := render.FromContext(ctx, render.NewJSONRenderer())
+	body, err := renderer.Render(itinerary)
+	if err != nil {
+		return itineraryHandlerV1.handleError(ctx, err)
+	}
+	return ctx.Blob(http.StatusOK, renderer.ContentType(), body)
+}
+
+// @Summary Render Ticket Graph
+// @Description Renders the ticket graph built during reconstruction as Graphviz DOT or Mermaid, highlighting
+// @Description the chosen path, duplicate tickets, cycles and disconnected fragments. Failed reconstructions
+// @Description are rendered too, with the failure reason as the graph title.
+// @Tags Itinerary
+// @Accept json
+// @Produce text/vnd.graphviz
+// @Produce text/vnd.mermaid
+// @Param input body []model.Ticket true "Array of ticket pairs"
+// @Param format query string false "Graph format, overrides the Accept header" Enums(dot, mermaid) default(dot)
+// @Success 200 {string} string
+// @Failure 406 {object} errors.AppError
+// @Router /api/v1/itinerary/graph [post]
+func (itineraryHandlerV1 *ItineraryHandler) RenderGraph(ctx echo.Context) error {
+	requestID := ctx.Response().Header().Get(echo.HeaderXRequestID)
+	logger := itineraryHandlerV1.logger.With(zap.String("request_id", requestID))
+
+	validatedRequest := ctx.Get("validated_request")
+	if validatedRequest == nil {
+		logger.Error("Validated request not found in context")
+		return itineraryHandlerV1.handleError(ctx, errors.NewInternalError("request validation failed"))
+	}
+	request := model.ItineraryRequest{
+		Tickets: validatedRequest.([]model.Ticket),
+	}
+	tickets, err := request.ToTickets()
+	if err != nil {
+		logger.Warn("Failed to convert request to tickets", zap.Error(err))
+		return itineraryHandlerV1.handleError(ctx, err)
+	}
+
+	ticketGraph := graph.NewTicketGraph(tickets)
+	itinerary, err := itineraryHandlerV1.serviceFor(ctx).ReconstructItinerary(tickets)
+	if err != nil {
+		ticketGraph.Outcome = err.Error()
+	} else {
+		ticketGraph.MarkPath(itinerary)
+	}
+
+	renderer := render.FromContext(ctx, render.NewDOTRenderer())
+	logger.Info("Rendering ticket graph", zap.String("content_type", renderer.ContentType()),
+		zap.Int("nodes", len(ticketGraph.Nodes)), zap.Int("fragments", ticketGraph.Fragments))
+	body, err := renderer.Render(ticketGraph)
+	if err != nil {
+		return itineraryHandlerV1.handleError(ctx, err)
+	}
+	return ctx.Blob(http.StatusOK, renderer.ContentType(), body)
+}
+
+// @Summary Render Route Map
+// @Description Reconstructs the itinerary and returns it as an SVG world map with numbered great-circle legs
+// @Description and labelled airports, suitable for embedding in reports and emails
+// @Tags Itinerary
+// @Accept json
+// @Produce image/svg+xml
+// @Param input body []model.Ticket true "Array of ticket pairs"
+// @Param projection query string false "Map projection" Enums(robinson, equirectangular) default(robinson)
+// @Success 200 {string} string
+// @Router /api/v1/itinerary/map [post]
+func (itineraryHandlerV1 *ItineraryHandler) RenderMap(ctx echo.Context) error {
+	requestID := ctx.Response().Header().Get(echo.HeaderXRequestID)
+	logger := itineraryHandlerV1.logger.With(zap.String("request_id", requestID))
+
+	projection := ctx.QueryParam("projection")
+	if projection == "" {
+		projection = "robinson"
+	}
+	mapProjection, exists := itineraryHandlerV1.projections[projection]
+	if !exists {
+		return itineraryHandlerV1.handleError(ctx, errors.NewValidationError("unsupported projection %q", projection))
+	}
+	airports := itineraryHandlerV1.airports
+	if requestTenant, ok := tenant.FromContext(ctx); ok {
+		airports = requestTenant.Directory
+	}
+	renderer := render.NewSVGRenderer(airports, mapProjection)
+
+	validatedRequest := ctx.Get("validated_request")
+	if validatedRequest == nil {
+		logger.Error("Validated request not found in context")
+		return itineraryHandlerV1.handleError(ctx, errors.NewInternalError("request validation failed"))
+	}
+	request := model.ItineraryRequest{
+		Tickets: validatedRequest.([]model.Ticket),
+	}
+	tickets, err := request.ToTickets()
+	if err != nil {
+		logger.Warn("Failed to convert request to tickets", zap.Error(err))
+		return itineraryHandlerV1.handleError(ctx, err)
+	}
+
+	itinerary, err := itineraryHandlerV1.serviceFor(ctx).ReconstructItinerary(tickets)
+	if err != nil {
+		logger.Error("Failed to reconstruct itinerary", zap.Error(err))
+		return itineraryHandlerV1.handleError(ctx, err)
+	}
+
+	logger.Info("Rendering route map", zap.String("projection", projection), zap.Int("stops", len(itinerary)))
+	body, err := renderer.Render(itinerary)
+	if err != nil {
+		return itineraryHandlerV1.handleError(ctx, err)
+	}
+	return ctx.Blob(http.StatusOK, renderer.ContentType(), body)
 }
 
 func (itineraryHandlerV1 *ItineraryHandler) handleError(ctx echo.Context, err error) error {
 	if appErr, ok := err.(*errors.AppError); ok {
-		return ctx.JSON(appErr.Code, appErr)
+		return render.WriteError(ctx, appErr)
 	}
 
 	// Handle unexpected errors
 	itineraryHandlerV1.logger.Error("Unexpected error", zap.Error(err))
-	internalErr := errors.NewInternalError("internal server error")
-	return ctx.JSON(internalErr.Code, internalErr)
+	return render.WriteError(ctx, errors.NewInternalError("internal server error"))
 }
@@ -12,8 +12,10 @@ import (
 	. "github.com/onsi/gomega"
 	"go.uber.org/zap"
 
+	"flight-itinerary-go/internal/airport"
 	"flight-itinerary-go/internal/handler"
 	"flight-itinerary-go/internal/model"
+	"flight-itinerary-go/internal/render"
 	"flight-itinerary-go/pkg/errors"
 )
 
@@ -82,6 +84,32 @@ var _ = Describe("ItineraryHandler", func() {
 			})
 		})
 
+		Context("when GeoJSON was negotiated", func() {
+			It("should render the itinerary as a FeatureCollection", func() {
+				mockService.reconstructFunc = func(tickets []model.Ticket) ([]string, error) {
+					return []string{"JFK", "LAX"}, nil
+				}
+
+				req := httptest.NewRequest(http.MethodPost, "/api/v1/itinerary/reconstruct", nil)
+				rec := httptest.NewRecorder()
+				ctx := echoServer.NewContext(req, rec)
+
+				ctx.Set("validated_request", []model.Ticket{{"JFK", "LAX"}})
+				ctx.Set(render.ContextKey, render.NewGeoJSONRenderer(airport.NewDirectory()))
+
+				err := handler1.ReconstructItinerary(ctx)
+
+				Expect(err).Should(BeNil())
+				Expect(rec.Code).To(Equal(http.StatusOK))
+				Expect(rec.Header().Get("Content-Type")).To(Equal("application/geo+json"))
+
+				var response map[string]interface{}
+				err = json.Unmarshal(rec.Body.Bytes(), &response)
+				Expect(err).Should(BeNil())
+				Expect(response["type"]).To(Equal("FeatureCollection"))
+			})
+		})
+
 		Context("when service returns error", func() {
 			It("should return error response", func() {
 				mockService.reconstructFunc = func(tickets []model.Ticket) ([]string, error) {
@@ -113,6 +141,28 @@ var _ = Describe("ItineraryHandler", func() {
 			})
 		})
 
+		Context("when service returns error and CSV was negotiated", func() {
+			It("should render the error body as CSV", func() {
+				mockService.reconstructFunc = func(tickets []model.Ticket) ([]string, error) {
+					return nil, errors.ErrCircularRoute
+				}
+
+				req := httptest.NewRequest(http.MethodPost, "/api/v1/itinerary/reconstruct", nil)
+				rec := httptest.NewRecorder()
+				ctx := echoServer.NewContext(req, rec)
+
+				ctx.Set("validated_request", []model.Ticket{{"A", "B"}, {"B", "A"}})
+				ctx.Set(render.ContextKey, render.NewCSVRenderer())
+
+				err := handler1.ReconstructItinerary(ctx)
+
+				Expect(err).Should(BeNil())
+				Expect(rec.Code).To(Equal(http.StatusBadRequest))
+				Expect(rec.Header().Get("Content-Type")).To(Equal("text/csv; charset=utf-8"))
+				Expect(rec.Body.String()).To(Equal("code,type,message\n400,business_error,circular route detected\n"))
+			})
+		})
+
 		Context("when validated request is missing from context", func() {
 			It("should return internal server error", func() {
 				req := httptest.NewRequest(http.MethodPost, "/api/v1/itinerary/reconstruct", nil)
@@ -148,4 +198,74 @@ var _ = Describe("ItineraryHandler", func() {
 			})
 		})
 	})
+
+	Describe("RenderGraph", func() {
+		It("should render the graph of a failed reconstruction", func() {
+			mockService.reconstructFunc = func(tickets []model.Ticket) ([]string, error) {
+				return nil, errors.ErrDisconnectedRoute
+			}
+
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/itinerary/graph", nil)
+			rec := httptest.NewRecorder()
+			ctx := echoServer.NewContext(req, rec)
+
+			ctx.Set("validated_request", []model.Ticket{{"JFK", "LAX"}, {"DXB", "SFO"}})
+			ctx.Set(render.ContextKey, render.NewMermaidRenderer())
+
+			err := handler1.RenderGraph(ctx)
+
+			Expect(err).Should(BeNil())
+			Expect(rec.Code).To(Equal(http.StatusOK))
+			Expect(rec.Header().Get("Content-Type")).To(Equal("text/vnd.mermaid; charset=utf-8"))
+			Expect(rec.Body.String()).To(ContainSubstring("reconstruction failed: disconnected route found"))
+		})
+
+		It("should default to DOT when no renderer was negotiated", func() {
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/itinerary/graph", nil)
+			rec := httptest.NewRecorder()
+			ctx := echoServer.NewContext(req, rec)
+
+			ctx.Set("validated_request", []model.Ticket{{"JFK", "LAX"}})
+
+			err := handler1.RenderGraph(ctx)
+
+			Expect(err).Should(BeNil())
+			Expect(rec.Code).To(Equal(http.StatusOK))
+			Expect(rec.Header().Get("Content-Type")).To(Equal("text/vnd.graphviz; charset=utf-8"))
+		})
+	})
+
+	Describe("RenderMap", func() {
+		It("should render the itinerary as an SVG map", func() {
+			mockService.reconstructFunc = func(tickets []model.Ticket) ([]string, error) {
+				return []string{"JFK", "LAX"}, nil
+			}
+
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/itinerary/map?projection=equirectangular", nil)
+			rec := httptest.NewRecorder()
+			ctx := echoServer.NewContext(req, rec)
+
+			ctx.Set("validated_request", []model.Ticket{{"JFK", "LAX"}})
+
+			err := handler1.RenderMap(ctx)
+
+			Expect(err).Should(BeNil())
+			Expect(rec.Code).To(Equal(http.StatusOK))
+			Expect(rec.Header().Get("Content-Type")).To(Equal("image/svg+xml"))
+			Expect(rec.Body.String()).To(HavePrefix("<svg"))
+		})
+
+		It("should reject unsupported projections", func() {
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/itinerary/map?projection=mercator", nil)
+			rec := httptest.NewRecorder()
+			ctx := echoServer.NewContext(req, rec)
+
+			ctx.Set("validated_request", []model.Ticket{{"JFK", "LAX"}})
+
+			err := handler1.RenderMap(ctx)
+
+			Expect(err).Should(BeNil())
+			Expect(rec.Code).To(Equal(http.StatusBadRequest))
+		})
+	})
 })
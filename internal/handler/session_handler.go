@@ -0,0 +1,182 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+
+	customMiddleware "flight-itinerary-go/internal/middleware"
+	"flight-itinerary-go/internal/model"
+	"flight-itinerary-go/internal/session"
+	"flight-itinerary-go/internal/tenant"
+	"flight-itinerary-go/pkg/errors"
+)
+
+const (
+	sessionMaxMessageSize = 4096
+	sessionPongWait       = 60 * time.Second
+	sessionPingInterval   = 30 * time.Second
+	sessionWriteWait      = 10 * time.Second
+)
+
+// SessionHandler serves live itinerary previews over WebSocket
+type SessionHandler struct {
+	upgrader   websocket.Upgrader
+	useTickets customMiddleware.TicketCounter
+	logger     *zap.Logger
+}
+
+// NewSessionHandler creates a new session handler. CORS does not apply to WebSocket upgrades,
+// so originAllowed decides which browser origins may open sessions. Every accepted ticket counts
+// against the caller's quota. Sessions with a tenant follow the tenant's ticket limit and airport
+// rules.
+func NewSessionHandler(originAllowed func(origin string) bool, useTickets customMiddleware.TicketCounter,
+	logger *zap.Logger) *SessionHandler {
+	return &SessionHandler{
+		useTickets: useTickets,
+		upgrader: websocket.Upgrader{
+			CheckOrigin: func(request *http.Request) bool {
+				origin := request.Header.Get(echo.HeaderOrigin)
+				if !originAllowed(origin) {
+					logger.Warn("Session origin not allowed", zap.String("origin", origin))
+					return false
+				}
+				return true
+			},
+		},
+		logger: logger,
+	}
+}
+
+// @Summary Incremental Ticket Session
+// @Description Opens a WebSocket session for entering tickets one at a time. Clients send
+// @Description {"action": "add"|"remove"|"reset", "ticket": ["JFK", "LAX"]} and receive
+// @Description {"type": "state", "state": {...}} after every change, with the itinerary once the tickets
+// @Description connect, or the current fragments and diagnostics otherwise. Invalid messages are answered
+// @Description with {"type": "error", "error": {...}} and leave the session unchanged.
+// @Tags Itinerary
+// @Success 101 {object} session.Response
+// @Router /api/v1/itinerary/session [get]
+func (sessionHandlerV1 *SessionHandler) Connect(ctx echo.Context) error {
+	requestID := ctx.Response().Header().Get(echo.HeaderXRequestID)
+	logger := sessionHandlerV1.logger.With(zap.String("request_id", requestID))
+
+	conn, err := sessionHandlerV1.upgrader.Upgrade(ctx.Response(), ctx.Request(), nil)
+	if err != nil {
+		// The upgrader has already written the error response
+		logger.Warn("Failed to upgrade session connection", zap.Error(err))
+		return nil
+	}
+	defer conn.Close()
+	logger.Info("Session opened")
+
+	var writeMutex sync.Mutex
+	write := func(messageType int, payload interface{}) error {
+		writeMutex.Lock()
+		defer writeMutex.Unlock()
+		conn.SetWriteDeadline(time.Now().Add(sessionWriteWait))
+		if messageType == websocket.PingMessage {
+			return conn.WriteMessage(websocket.PingMessage, nil)
+		}
+		return conn.WriteJSON(payload)
+	}
+
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		ticker := time.NewTicker(sessionPingInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				if err := write(websocket.PingMessage, nil); err != nil {
+					return
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	conn.SetReadLimit(sessionMaxMessageSize)
+	conn.SetReadDeadline(time.Now().Add(sessionPongWait))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(sessionPongWait))
+	})
+
+	maxTickets := model.MaxTickets
+	checkTicket := func(model.Ticket) *errors.AppError { return nil }
+	if requestTenant, ok := tenant.FromContext(ctx); ok {
+		validation := requestTenant.Validation
+		if validation.MaxTickets > 0 && validation.MaxTickets < maxTickets {
+			maxTickets = validation.MaxTickets
+		}
+		checkTicket = func(ticket model.Ticket) *errors.AppError {
+			return validation.CheckTicket(ticket, requestTenant.Directory)
+		}
+	}
+	liveSession := session.NewSession(maxTickets)
+	state := liveSession.State()
+	if err := write(websocket.TextMessage, session.Response{Type: session.ResponseState, State: &state}); err != nil {
+		return nil
+	}
+
+	for {
+		_, payload, err := conn.ReadMessage()
+		if err != nil {
+			logger.Info("Session closed", zap.Error(err))
+			return nil
+		}
+		var message session.Message
+		if err := json.Unmarshal(payload, &message); err != nil {
+			logger.Debug("Malformed session message", zap.Error(err))
+			if err := write(websocket.TextMessage, sessionError(errors.NewValidationError("malformed message"))); err != nil {
+				return nil
+			}
+			continue
+		}
+
+		if message.Action == session.ActionAdd {
+			if appErr := checkTicket(message.Ticket); appErr != nil {
+				if err := write(websocket.TextMessage, sessionError(appErr)); err != nil {
+					return nil
+				}
+				continue
+			}
+		}
+
+		state, err := liveSession.Apply(message)
+		if err == nil && message.Action == session.ActionAdd {
+			// Only accepted tickets count against the quota; a ticket over it is taken back out
+			if appErr := sessionHandlerV1.useTickets(ctx, 1); appErr != nil {
+				liveSession.Apply(session.Message{Action: session.ActionRemove, Ticket: message.Ticket})
+				err = appErr
+			}
+		}
+		response := session.Response{Type: session.ResponseState, State: &state}
+		if err != nil {
+			logger.Debug("Rejected session message", zap.String("action", message.Action), zap.Error(err))
+			response = sessionError(err)
+		} else {
+			logger.Debug("Session updated", zap.String("action", message.Action),
+				zap.Int("tickets", state.Tickets), zap.Bool("complete", state.Complete))
+		}
+		if err := write(websocket.TextMessage, response); err != nil {
+			logger.Info("Session closed", zap.Error(err))
+			return nil
+		}
+	}
+}
+
+func sessionError(err error) session.Response {
+	appErr, ok := err.(*errors.AppError)
+	if !ok {
+		appErr = errors.NewInternalError("internal server error")
+	}
+	return session.Response{Type: session.ResponseError, Error: appErr}
+}
@@ -0,0 +1,110 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+
+	"flight-itinerary-go/internal/airport"
+	"flight-itinerary-go/internal/ratelimit"
+	"flight-itinerary-go/internal/render"
+	"flight-itinerary-go/internal/storage"
+	"flight-itinerary-go/internal/tenant"
+	"flight-itinerary-go/pkg/errors"
+)
+
+// TenantSummary describes a tenant's configuration and stored itineraries
+type TenantSummary struct {
+	ID         string            `json:"id"`
+	Name       string            `json:"name"`
+	Validation tenant.Validation `json:"validation"`
+	// Airports are the tenant's overrides of the reference airports
+	Airports []airport.Airport `json:"airports"`
+	// RateLimits is the policy in force for the tenant
+	RateLimits  ratelimit.Policy `json:"rate_limits"`
+	Itineraries int              `json:"itineraries"`
+}
+
+// TenantHandler handles HTTP requests for tenant administration
+type TenantHandler struct {
+	registry *tenant.Registry
+	logger   *zap.Logger
+}
+
+// NewTenantHandler creates a new tenant handler
+func NewTenantHandler(registry *tenant.Registry, logger *zap.Logger) *TenantHandler {
+	return &TenantHandler{
+		registry: registry,
+		logger:   logger,
+	}
+}
+
+// @Summary List Tenants
+// @Description Lists the tenants served by this instance with their rules, airport overrides, rate limits
+// @Description and number of stored itineraries
+// @Tags Tenants
+// @Produce json
+// @Success 200 {array} handler.TenantSummary
+// @Router /api/v1/tenants [get]
+func (tenantHandlerV1 *TenantHandler) List(ctx echo.Context) error {
+	tenants := tenantHandlerV1.registry.List()
+	summaries := make([]TenantSummary, 0, len(tenants))
+	for _, listed := range tenants {
+		summary, err := summarize(listed)
+		if err != nil {
+			return tenantHandlerV1.handleError(ctx, err)
+		}
+		summaries = append(summaries, summary)
+	}
+	return ctx.JSON(http.StatusOK, summaries)
+}
+
+// @Summary Get Tenant
+// @Description Returns a tenant's rules, airport overrides, rate limits and number of stored itineraries
+// @Tags Tenants
+// @Produce json
+// @Param id path string true "Tenant ID"
+// @Success 200 {object} handler.TenantSummary
+// @Failure 404 {object} errors.AppError
+// @Router /api/v1/tenants/{id} [get]
+func (tenantHandlerV1 *TenantHandler) Get(ctx echo.Context) error {
+	requested, exists := tenantHandlerV1.registry.Get(ctx.Param("id"))
+	if !exists {
+		return render.WriteError(ctx, errors.NewNotFoundError("tenant not found"))
+	}
+	summary, err := summarize(requested)
+	if err != nil {
+		return tenantHandlerV1.handleError(ctx, err)
+	}
+	return ctx.JSON(http.StatusOK, summary)
+}
+
+func (tenantHandlerV1 *TenantHandler) handleError(ctx echo.Context, err error) error {
+	if appErr, ok := err.(*errors.AppError); ok {
+		return render.WriteError(ctx, appErr)
+	}
+
+	tenantHandlerV1.logger.Error("Unexpected error", zap.Error(err))
+	return render.WriteError(ctx, errors.NewInternalError("internal server error"))
+}
+
+// summarize describes the tenant, counting its stored itineraries
+func summarize(described *tenant.Tenant) (TenantSummary, error) {
+	_, total, err := described.TripService.List(storage.Query{}, 0, 1)
+	if err != nil {
+		return TenantSummary{}, err
+	}
+	airports := described.Airports
+	if airports == nil {
+		airports = []airport.Airport{}
+	}
+	return TenantSummary{
+		ID:          described.ID,
+		Name:        described.Name,
+		Validation:  described.Validation,
+		Airports:    airports,
+		RateLimits:  described.Limiter.Policy(),
+		Itineraries: total,
+	}, nil
+}
@@ -0,0 +1,436 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+
+	customMiddleware "flight-itinerary-go/internal/middleware"
+	"flight-itinerary-go/internal/model"
+	"flight-itinerary-go/internal/render"
+	"flight-itinerary-go/internal/service"
+	"flight-itinerary-go/internal/storage"
+	"flight-itinerary-go/internal/tenant"
+	"flight-itinerary-go/pkg/errors"
+)
+
+const (
+	defaultPageLimit = 20
+	maxPageLimit     = 100
+)
+
+// ItineraryPage represents a page of stored itineraries
+type ItineraryPage struct {
+	Items  []storage.Itinerary `json:"items"`
+	Total  int                 `json:"total"`
+	Offset int                 `json:"offset"`
+	Limit  int                 `json:"limit"`
+}
+
+// TripHandler handles HTTP requests for stored itineraries
+type TripHandler struct {
+	tripService service.TripService
+	useTickets  customMiddleware.TicketCounter
+	logger      *zap.Logger
+}
+
+// NewTripHandler creates a new stored itinerary handler. Requests with a tenant use the tenant's
+// itineraries instead. Tickets added by edits and reverts count against the caller's daily quota.
+func NewTripHandler(tripService service.TripService, useTickets customMiddleware.TicketCounter,
+	logger *zap.Logger) *TripHandler {
+	return &TripHandler{
+		tripService: tripService,
+		useTickets:  useTickets,
+		logger:      logger,
+	}
+}
+
+// @Summary Save Itinerary
+// @Description Reconstructs the itinerary from the tickets and stores both under a new ID
+// @Tags Stored Itineraries
+// @Accept json
+// @Produce json
+// @Param input body []model.Ticket true "Array of ticket pairs"
+// @Param departure query string false "Departure time (RFC 3339 or YYYY-MM-DD)"
+// @Param Idempotency-Key header string false "Replays the first response to retries with the same key"
+// @Success 201 {object} storage.Itinerary
+// @Failure 400 {object} errors.AppError
+// @Failure 422 {object} errors.AppError
+// @Router /api/v1/itineraries [post]
+func (tripHandlerV1 *TripHandler) Create(ctx echo.Context) error {
+	logger := tripHandlerV1.requestLogger(ctx)
+
+	departure, err := queryTime(ctx, "departure")
+	if err != nil {
+		return tripHandlerV1.handleError(ctx, err)
+	}
+	tickets, err := tripHandlerV1.tickets(ctx)
+	if err != nil {
+		return tripHandlerV1.handleError(ctx, err)
+	}
+	var departurePtr *time.Time
+	if !departure.IsZero() {
+		departurePtr = &departure
+	}
+	itinerary, err := tripHandlerV1.serviceFor(ctx).Create(tickets, departurePtr)
+	if err != nil {
+		logger.Warn("Failed to save itinerary", zap.Error(err))
+		return tripHandlerV1.handleError(ctx, err)
+	}
+
+	ctx.Response().Header().Set(echo.HeaderLocation, ctx.Path()+"/"+itinerary.ID)
+	return respondItinerary(ctx, http.StatusCreated, itinerary)
+}
+
+// @Summary Get Itinerary
+// @Description Returns a stored itinerary by ID. The ETag changes with every version.
+// @Tags Stored Itineraries
+// @Produce json
+// @Param id path string true "Itinerary ID"
+// @Param If-None-Match header string false "ETag of the copy the client holds"
+// @Success 200 {object} storage.Itinerary
+// @Success 304
+// @Failure 404 {object} errors.AppError
+// @Router /api/v1/itineraries/{id} [get]
+func (tripHandlerV1 *TripHandler) Get(ctx echo.Context) error {
+	itinerary, err := tripHandlerV1.serviceFor(ctx).Get(ctx.Param("id"))
+	if err != nil {
+		return tripHandlerV1.handleError(ctx, err)
+	}
+	etag := itineraryETag(itinerary)
+	if ifMatch := ctx.Request().Header.Get(customMiddleware.HeaderIfMatch); ifMatch != "" &&
+		!customMiddleware.ETagMatches(ifMatch, etag, false) {
+		return tripHandlerV1.handleError(ctx, errors.ErrPreconditionFailed)
+	}
+	if customMiddleware.ETagMatches(ctx.Request().Header.Get(customMiddleware.HeaderIfNoneMatch), etag, true) {
+		ctx.Response().Header().Set(customMiddleware.HeaderETag, etag)
+		return ctx.NoContent(http.StatusNotModified)
+	}
+	return respondItinerary(ctx, http.StatusOK, itinerary)
+}
+
+// @Summary List Itineraries
+// @Description Lists stored itineraries in creation order. Filters combine; date filters only match
+// @Description itineraries saved with a departure.
+// @Tags Stored Itineraries
+// @Produce json
+// @Param via query string false "Airport anywhere on the route"
+// @Param from query string false "Origin airport"
+// @Param to query string false "Final destination airport"
+// @Param leg query string false "Direct leg flown, e.g. JFK-LAX"
+// @Param departing_after query string false "Departure at or after (RFC 3339 or YYYY-MM-DD)"
+// @Param departing_before query string false "Departure before (RFC 3339 or YYYY-MM-DD)"
+// @Param offset query int false "Number of itineraries to skip" default(0)
+// @Param limit query int false "Page size" default(20) maximum(100)
+// @Success 200 {object} handler.ItineraryPage
+// @Failure 400 {object} errors.AppError
+// @Router /api/v1/itineraries [get]
+func (tripHandlerV1 *TripHandler) List(ctx echo.Context) error {
+	offset, err := queryInt(ctx, "offset", 0)
+	if err != nil || offset < 0 {
+		return tripHandlerV1.handleError(ctx, errors.NewValidationError("offset must be a non-negative integer"))
+	}
+	limit, err := queryInt(ctx, "limit", defaultPageLimit)
+	if err != nil || limit < 1 || limit > maxPageLimit {
+		return tripHandlerV1.handleError(ctx,
+			errors.NewValidationError("limit must be an integer between 1 and %d", maxPageLimit))
+	}
+	query, err := itineraryQuery(ctx)
+	if err != nil {
+		return tripHandlerV1.handleError(ctx, err)
+	}
+
+	items, total, err := tripHandlerV1.serviceFor(ctx).List(query, offset, limit)
+	if err != nil {
+		return tripHandlerV1.handleError(ctx, err)
+	}
+	return ctx.JSON(http.StatusOK, ItineraryPage{
+		Items:  items,
+		Total:  total,
+		Offset: offset,
+		Limit:  limit,
+	})
+}
+
+// @Summary Update Itinerary Tickets
+// @Description Replaces the tickets of a stored itinerary and reconstructs it
+// @Tags Stored Itineraries
+// @Accept json
+// @Produce json
+// @Param id path string true "Itinerary ID"
+// @Param input body []model.Ticket true "Array of ticket pairs"
+// @Param Idempotency-Key header string false "Replays the first response to retries with the same key"
+// @Param If-Match header string false "ETag of the version being changed"
+// @Success 200 {object} storage.Itinerary
+// @Failure 400 {object} errors.AppError
+// @Failure 404 {object} errors.AppError
+// @Failure 422 {object} errors.AppError
+// @Failure 412 {object} errors.AppError
+// @Router /api/v1/itineraries/{id}/tickets [put]
+func (tripHandlerV1 *TripHandler) UpdateTickets(ctx echo.Context) error {
+	logger := tripHandlerV1.requestLogger(ctx)
+
+	tickets, err := tripHandlerV1.tickets(ctx)
+	if err != nil {
+		return tripHandlerV1.handleError(ctx, err)
+	}
+	expected, err := expectedVersion(ctx)
+	if err != nil {
+		return tripHandlerV1.handleError(ctx, err)
+	}
+	itinerary, err := tripHandlerV1.serviceFor(ctx).UpdateTickets(ctx.Param("id"), expected, tickets)
+	if err != nil {
+		logger.Warn("Failed to update itinerary", zap.String("itinerary_id", ctx.Param("id")), zap.Error(err))
+		return tripHandlerV1.handleError(ctx, err)
+	}
+	return respondItinerary(ctx, http.StatusOK, itinerary)
+}
+
+// @Summary Edit Itinerary Tickets
+// @Description Applies ticket operations in order and reconstructs the itinerary. Operations are "add"
+// @Description (ticket), "replace" (ticket, with) and "void" (ticket); replace and void target an existing
+// @Description ticket by its source and destination. Either all operations are saved as one new version or,
+// @Description when an operation is invalid or the result cannot be reconstructed, none is.
+// @Tags Stored Itineraries
+// @Accept json
+// @Produce json
+// @Param id path string true "Itinerary ID"
+// @Param input body []model.TicketOperation true "Ticket operations"
+// @Param Idempotency-Key header string false "Replays the first response to retries with the same key"
+// @Param If-Match header string false "ETag of the version being changed"
+// @Success 200 {object} storage.Itinerary
+// @Failure 400 {object} errors.AppError
+// @Failure 404 {object} errors.AppError
+// @Failure 409 {object} errors.AppError
+// @Failure 422 {object} errors.AppError
+// @Failure 412 {object} errors.AppError
+// @Router /api/v1/itineraries/{id}/tickets [patch]
+func (tripHandlerV1 *TripHandler) PatchTickets(ctx echo.Context) error {
+	logger := tripHandlerV1.requestLogger(ctx)
+
+	var operations []model.TicketOperation
+	if err := ctx.Bind(&operations); err != nil {
+		return tripHandlerV1.handleError(ctx, errors.NewValidationError("invalid JSON format: %v", err))
+	}
+	expected, err := expectedVersion(ctx)
+	if err != nil {
+		return tripHandlerV1.handleError(ctx, err)
+	}
+	if appErr := tripHandlerV1.useTickets(ctx, addedTickets(operations)); appErr != nil {
+		return render.WriteError(ctx, appErr)
+	}
+	itinerary, err := tripHandlerV1.serviceFor(ctx).Patch(ctx.Param("id"), expected, operations)
+	if err != nil {
+		logger.Warn("Failed to edit itinerary", zap.String("itinerary_id", ctx.Param("id")), zap.Error(err))
+		return tripHandlerV1.handleError(ctx, err)
+	}
+	return respondItinerary(ctx, http.StatusOK, itinerary)
+}
+
+// @Summary Itinerary History
+// @Description Returns every version of a stored itinerary, oldest first
+// @Tags Stored Itineraries
+// @Produce json
+// @Param id path string true "Itinerary ID"
+// @Success 200 {array} storage.Itinerary
+// @Failure 404 {object} errors.AppError
+// @Router /api/v1/itineraries/{id}/versions [get]
+func (tripHandlerV1 *TripHandler) History(ctx echo.Context) error {
+	history, err := tripHandlerV1.serviceFor(ctx).History(ctx.Param("id"))
+	if err != nil {
+		return tripHandlerV1.handleError(ctx, err)
+	}
+	return ctx.JSON(http.StatusOK, history)
+}
+
+// @Summary Revert Itinerary
+// @Description Saves the tickets of an earlier version as a new version; the history is never rewritten
+// @Tags Stored Itineraries
+// @Produce json
+// @Param id path string true "Itinerary ID"
+// @Param version path int true "Version to revert to"
+// @Param Idempotency-Key header string false "Replays the first response to retries with the same key"
+// @Param If-Match header string false "ETag of the version being changed"
+// @Success 200 {object} storage.Itinerary
+// @Failure 404 {object} errors.AppError
+// @Failure 409 {object} errors.AppError
+// @Failure 422 {object} errors.AppError
+// @Failure 412 {object} errors.AppError
+// @Router /api/v1/itineraries/{id}/versions/{version}/revert [post]
+func (tripHandlerV1 *TripHandler) Revert(ctx echo.Context) error {
+	version, err := strconv.Atoi(ctx.Param("version"))
+	if err != nil {
+		return tripHandlerV1.handleError(ctx, errors.NewValidationError("version must be an integer"))
+	}
+	expected, err := expectedVersion(ctx)
+	if err != nil {
+		return tripHandlerV1.handleError(ctx, err)
+	}
+	// The reverted tickets are reconstructed again, so they count like the tickets of an update
+	history, err := tripHandlerV1.serviceFor(ctx).History(ctx.Param("id"))
+	if err != nil {
+		return tripHandlerV1.handleError(ctx, err)
+	}
+	if version >= 1 && version <= len(history) {
+		if appErr := tripHandlerV1.useTickets(ctx, len(history[version-1].Tickets)); appErr != nil {
+			return render.WriteError(ctx, appErr)
+		}
+	}
+	itinerary, err := tripHandlerV1.serviceFor(ctx).Revert(ctx.Param("id"), expected, version)
+	if err != nil {
+		tripHandlerV1.requestLogger(ctx).Warn("Failed to revert itinerary",
+			zap.String("itinerary_id", ctx.Param("id")), zap.Int("version", version), zap.Error(err))
+		return tripHandlerV1.handleError(ctx, err)
+	}
+	return respondItinerary(ctx, http.StatusOK, itinerary)
+}
+
+// @Summary Delete Itinerary
+// @Description Deletes a stored itinerary
+// @Tags Stored Itineraries
+// @Param id path string true "Itinerary ID"
+// @Param Idempotency-Key header string false "Replays the first response to retries with the same key"
+// @Param If-Match header string false "ETag of the version being changed"
+// @Success 204
+// @Failure 404 {object} errors.AppError
+// @Failure 422 {object} errors.AppError
+// @Failure 412 {object} errors.AppError
+// @Router /api/v1/itineraries/{id} [delete]
+func (tripHandlerV1 *TripHandler) Delete(ctx echo.Context) error {
+	expected, err := expectedVersion(ctx)
+	if err != nil {
+		return tripHandlerV1.handleError(ctx, err)
+	}
+	if err := tripHandlerV1.serviceFor(ctx).Delete(ctx.Param("id"), expected); err != nil {
+		return tripHandlerV1.handleError(ctx, err)
+	}
+	return ctx.NoContent(http.StatusNoContent)
+}
+
+func (tripHandlerV1 *TripHandler) requestLogger(ctx echo.Context) *zap.Logger {
+	requestID := ctx.Response().Header().Get(echo.HeaderXRequestID)
+	return tripHandlerV1.logger.With(zap.String("request_id", requestID))
+}
+
+// tickets returns the tickets parsed by the validator middleware
+func (tripHandlerV1 *TripHandler) tickets(ctx echo.Context) ([]model.Ticket, error) {
+	validatedRequest := ctx.Get("validated_request")
+	if validatedRequest == nil {
+		tripHandlerV1.requestLogger(ctx).Error("Validated request not found in context")
+		return nil, errors.NewInternalError("request validation failed")
+	}
+	request := model.ItineraryRequest{
+		Tickets: validatedRequest.([]model.Ticket),
+	}
+	return request.ToTickets()
+}
+
+// addedTickets counts the tickets the operations bring into the itinerary
+func addedTickets(operations []model.TicketOperation) int {
+	added := 0
+	for _, operation := range operations {
+		if operation.Op == model.OperationAdd || operation.Op == model.OperationReplace {
+			added++
+		}
+	}
+	return added
+}
+
+// serviceFor returns the stored itinerary service of the request's tenant, or the handler's own
+func (tripHandlerV1 *TripHandler) serviceFor(ctx echo.Context) service.TripService {
+	if requestTenant, ok := tenant.FromContext(ctx); ok {
+		return requestTenant.TripService
+	}
+	return tripHandlerV1.tripService
+}
+
+func (tripHandlerV1 *TripHandler) handleError(ctx echo.Context, err error) error {
+	if appErr, ok := err.(*errors.AppError); ok {
+		return render.WriteError(ctx, appErr)
+	}
+
+	tripHandlerV1.logger.Error("Unexpected error", zap.Error(err))
+	return render.WriteError(ctx, errors.NewInternalError("internal server error"))
+}
+
+// respondItinerary writes the itinerary with its ETag
+func respondItinerary(ctx echo.Context, status int, itinerary storage.Itinerary) error {
+	ctx.Response().Header().Set(customMiddleware.HeaderETag, itineraryETag(itinerary))
+	return ctx.JSON(status, itinerary)
+}
+
+// itineraryETag identifies a version; every change creates a new version, so it is strong
+func itineraryETag(itinerary storage.Itinerary) string {
+	return `"v` + strconv.Itoa(itinerary.Version) + `"`
+}
+
+// expectedVersion reads the version named by If-Match; 0 means any version may be changed
+func expectedVersion(ctx echo.Context) (int, error) {
+	ifMatch := strings.TrimSpace(ctx.Request().Header.Get(customMiddleware.HeaderIfMatch))
+	if ifMatch == "" || ifMatch == "*" {
+		return 0, nil
+	}
+	if strings.Contains(ifMatch, ",") {
+		return 0, errors.NewValidationError("If-Match must name a single ETag")
+	}
+	version, err := strconv.Atoi(strings.TrimSuffix(strings.TrimPrefix(ifMatch, `"v`), `"`))
+	if err != nil || version < 1 || !strings.HasPrefix(ifMatch, `"v`) {
+		// Weak or foreign ETags never match a stored version
+		return 0, errors.ErrPreconditionFailed
+	}
+	return version, nil
+}
+
+func queryInt(ctx echo.Context, name string, fallback int) (int, error) {
+	value := ctx.QueryParam(name)
+	if value == "" {
+		return fallback, nil
+	}
+	return strconv.Atoi(value)
+}
+
+// itineraryQuery reads the search filters, normalizing airport codes to upper case
+func itineraryQuery(ctx echo.Context) (storage.Query, error) {
+	airport := func(name string) string {
+		return strings.ToUpper(strings.TrimSpace(ctx.QueryParam(name)))
+	}
+	query := storage.Query{
+		Via:  airport("via"),
+		From: airport("from"),
+		To:   airport("to"),
+	}
+	if leg := airport("leg"); leg != "" {
+		source, destination, found := strings.Cut(leg, "-")
+		if !found || source == "" || destination == "" {
+			return query, errors.NewValidationError("leg must be two airport codes joined by '-', e.g. JFK-LAX")
+		}
+		query.Leg = &model.Ticket{source, destination}
+	}
+
+	var err error
+	if query.DepartingAfter, err = queryTime(ctx, "departing_after"); err != nil {
+		return query, err
+	}
+	if query.DepartingBefore, err = queryTime(ctx, "departing_before"); err != nil {
+		return query, err
+	}
+	return query, nil
+}
+
+// queryTime parses an RFC 3339 timestamp or a date, returning the zero time when absent
+func queryTime(ctx echo.Context, name string) (time.Time, error) {
+	value := ctx.QueryParam(name)
+	if value == "" {
+		return time.Time{}, nil
+	}
+	for _, layout := range []string{time.RFC3339, time.DateOnly} {
+		if parsed, err := time.Parse(layout, value); err == nil {
+			return parsed.UTC(), nil
+		}
+	}
+	return time.Time{}, errors.NewValidationError("%s must be an RFC 3339 timestamp or a YYYY-MM-DD date", name)
+}
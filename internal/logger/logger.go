@@ -5,13 +5,24 @@ import (
 	"go.uber.org/zap/zapcore"
 )
 
-func NewLogger() *zap.Logger {
+// Options configures the production logger
+type Options struct {
+	// Level may be changed while the logger is in use
+	Level     zap.AtomicLevel
+	Redaction Redaction
+}
+
+// NewLogger builds the production logger. Every logger derived from it, in handlers, services
+// and middleware alike, applies the redaction policies.
+func NewLogger(options Options) *zap.Logger {
 	config := zap.NewProductionConfig()
-	config.Level = zap.NewAtomicLevelAt(zap.InfoLevel)
+	config.Level = options.Level
 	config.EncoderConfig.TimeKey = "timestamp"
 	config.EncoderConfig.EncodeTime = zapcore.ISO8601TimeEncoder
 
-	logger, err := config.Build()
+	logger, err := config.Build(zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+		return NewRedactingCore(core, options.Redaction)
+	}))
 	if err != nil {
 		panic("Failed to create logger: " + err.Error())
 	}
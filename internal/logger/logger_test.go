@@ -0,0 +1,131 @@
+package logger_test
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+
+	"flight-itinerary-go/internal/logger"
+)
+
+func TestLogger(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Logger Suite")
+}
+
+var _ = Describe("Redaction", func() {
+	var (
+		logs      *observer.ObservedLogs
+		redaction logger.Redaction
+	)
+
+	newLogger := func() *zap.Logger {
+		var core zapcore.Core
+		core, logs = observer.New(zapcore.DebugLevel)
+		return zap.New(logger.NewRedactingCore(core, redaction))
+	}
+
+	BeforeEach(func() {
+		redaction = logger.Redaction{Policies: logger.Policies{
+			"result":     logger.PolicyHash,
+			"start":      logger.PolicyHash,
+			"remote_ip":  logger.PolicyMask,
+			"user_agent": logger.PolicyDrop,
+			"error":      logger.PolicyCodes,
+			"change":     logger.PolicyCodes,
+		}}
+	})
+
+	It("should hash, mask and drop fields by key", func() {
+		newLogger().Info("Reconstructed",
+			zap.Strings("result", []string{"JFK", "LAX"}),
+			zap.String("remote_ip", "203.0.113.12"),
+			zap.String("user_agent", "curl/8.5.0"),
+			zap.Int("stops", 2))
+
+		fields := logs.All()[0].ContextMap()
+		Expect(fields["result"]).To(MatchRegexp(`^sha256:[0-9a-f]{16}$`))
+		Expect(fields["remote_ip"]).To(Equal("*********.12"))
+		Expect(fields).ToNot(HaveKey("user_agent"))
+		Expect(fields["stops"]).To(Equal(int64(2)))
+	})
+
+	It("should hash the airport codes in errors and changes, keeping the rest of the message", func() {
+		newLogger().Warn("Failed to edit itinerary",
+			zap.String("start", "JFK"),
+			zap.Error(errors.New("operation at index 1 is invalid: ticket JFK → LAX is not part of the itinerary")),
+			zap.String("change", "add DXB → SIN; void JFK → LAX"))
+
+		fields := logs.All()[0].ContextMap()
+		digest := fields["start"].(string)
+		Expect(fields["error"]).To(MatchRegexp(`^operation at index 1 is invalid: ticket ` + digest +
+			` → sha256:[0-9a-f]{16} is not part of the itinerary$`))
+		Expect(fields["change"]).To(MatchRegexp(`^add sha256:[0-9a-f]{16} → sha256:[0-9a-f]{16}; void ` +
+			digest + ` → sha256:[0-9a-f]{16}$`))
+		Expect(fmt.Sprint(fields)).ToNot(ContainSubstring("LAX"))
+	})
+
+	It("should redact fields added with With", func() {
+		newLogger().With(zap.String("remote_ip", "203.0.113.12")).Info("Saved")
+
+		Expect(logs.All()[0].ContextMap()["remote_ip"]).To(Equal("*********.12"))
+	})
+
+	It("should hash equal values alike and depend on the key", func() {
+		log := newLogger()
+		log.Info("first", zap.Strings("result", []string{"JFK", "LAX"}))
+		log.Info("second", zap.Strings("result", []string{"JFK", "LAX"}))
+		unkeyed := logs.All()
+		Expect(unkeyed[0].ContextMap()["result"]).To(Equal(unkeyed[1].ContextMap()["result"]))
+
+		redaction.Key = []byte("secret")
+		keyed := newLogger()
+		keyed.Info("third", zap.Strings("result", []string{"JFK", "LAX"}))
+
+		Expect(logs.All()).To(HaveLen(1))
+		Expect(logs.All()[0].ContextMap()["result"]).ToNot(Equal(unkeyed[0].ContextMap()["result"]))
+	})
+
+	It("should key hashes with a per-process key when none is configured", func() {
+		newLogger().Info("first", zap.String("result", "JFK"))
+		first := logs.All()[0].ContextMap()["result"]
+		unkeyed := sha256.Sum256([]byte("JFK"))
+		Expect(first).ToNot(Equal("sha256:" + hex.EncodeToString(unkeyed[:8])))
+
+		newLogger().Info("second", zap.String("result", "JFK"))
+		Expect(logs.All()[0].ContextMap()["result"]).To(Equal(first))
+	})
+
+	Describe("ParsePolicies", func() {
+		It("should apply policies over the defaults", func() {
+			policies, err := logger.ParsePolicies("error=hash, result=keep,uri=drop")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(policies).To(HaveKeyWithValue("error", logger.PolicyHash))
+			Expect(policies).To(HaveKeyWithValue("uri", logger.PolicyDrop))
+			Expect(policies).To(HaveKeyWithValue("remote_ip", logger.PolicyMask))
+			Expect(policies).To(HaveKeyWithValue("change", logger.PolicyCodes))
+			Expect(policies).ToNot(HaveKey("result"))
+		})
+
+		It("should start from no policies with none", func() {
+			policies, err := logger.ParsePolicies("none,tenant=hash,remote_ip=codes")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(policies.String()).To(Equal("remote_ip=codes,tenant=hash"))
+		})
+
+		It("should reject malformed policies", func() {
+			_, err := logger.ParsePolicies("start")
+			Expect(err).To(HaveOccurred())
+			_, err = logger.ParsePolicies("start=encrypt")
+			Expect(err).To(MatchError(ContainSubstring("unknown redaction policy")))
+		})
+	})
+})
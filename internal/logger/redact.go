@@ -0,0 +1,236 @@
+package logger
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// Redaction policies applied to log fields by key
+const (
+	PolicyHash = "hash"
+	PolicyMask = "mask"
+	PolicyDrop = "drop"
+	// PolicyCodes hashes the airport codes inside a message and keeps the rest of it
+	PolicyCodes = "codes"
+	// PolicyKeep logs the field as is, overriding a default policy
+	PolicyKeep = "keep"
+)
+
+// Policies maps log field keys to redaction policies
+type Policies map[string]string
+
+// DefaultPolicies covers the fields that carry travel data or caller details. Errors and
+// itinerary changes name the airports of the tickets they are about.
+func DefaultPolicies() Policies {
+	return Policies{
+		"result":          PolicyHash,
+		"start":           PolicyHash,
+		"error":           PolicyCodes,
+		"change":          PolicyCodes,
+		"idempotency_key": PolicyHash,
+		"remote_ip":       PolicyMask,
+		"remote_addr":     PolicyMask,
+	}
+}
+
+// ParsePolicies reads policies written as "field=policy,field=policy" and applies them over the
+// defaults. "none" starts from no policies instead.
+func ParsePolicies(spec string) (Policies, error) {
+	policies := DefaultPolicies()
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		switch entry {
+		case "":
+			continue
+		case "none":
+			policies = Policies{}
+			continue
+		}
+		key, policy, found := strings.Cut(entry, "=")
+		key, policy = strings.TrimSpace(key), strings.TrimSpace(policy)
+		if !found || key == "" {
+			return nil, fmt.Errorf("redaction policy %q must be written as field=policy", entry)
+		}
+		switch policy {
+		case PolicyHash, PolicyMask, PolicyDrop, PolicyCodes:
+			policies[key] = policy
+		case PolicyKeep:
+			delete(policies, key)
+		default:
+			return nil, fmt.Errorf("unknown redaction policy %q for field %s", policy, key)
+		}
+	}
+	return policies, nil
+}
+
+// String lists the policies in key order, as accepted by ParsePolicies
+func (policies Policies) String() string {
+	entries := make([]string, 0, len(policies))
+	for key, policy := range policies {
+		entries = append(entries, key+"="+policy)
+	}
+	sort.Strings(entries)
+	return strings.Join(entries, ",")
+}
+
+// Redaction configures how sensitive log fields are rewritten
+type Redaction struct {
+	Policies Policies
+	// Key makes hashes keyed, so short values such as airport codes cannot be found by hashing
+	// every candidate. Hashes of equal values stay equal, so events can still be correlated.
+	// Without a key, a random one is generated per process.
+	Key []byte
+}
+
+// processKey keys hashes when none is configured, so digests correlate within one process only
+var processKey = sync.OnceValue(func() []byte {
+	key := make([]byte, 32)
+	rand.Read(key)
+	return key
+})
+
+// redactingCore rewrites fields by policy before they reach the wrapped core, covering fields
+// added with Logger.With as well as those of each entry
+type redactingCore struct {
+	zapcore.Core
+	redaction Redaction
+}
+
+// NewRedactingCore wraps a core so fields are redacted before they are encoded
+func NewRedactingCore(core zapcore.Core, redaction Redaction) zapcore.Core {
+	if len(redaction.Policies) == 0 {
+		return core
+	}
+	if len(redaction.Key) == 0 {
+		redaction.Key = processKey()
+	}
+	return &redactingCore{Core: core, redaction: redaction}
+}
+
+func (core *redactingCore) With(fields []zapcore.Field) zapcore.Core {
+	return &redactingCore{Core: core.Core.With(core.redact(fields)), redaction: core.redaction}
+}
+
+func (core *redactingCore) Check(entry zapcore.Entry, checked *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if core.Enabled(entry.Level) {
+		return checked.AddCore(entry, core)
+	}
+	return checked
+}
+
+func (core *redactingCore) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	return core.Core.Write(entry, core.redact(fields))
+}
+
+func (core *redactingCore) redact(fields []zapcore.Field) []zapcore.Field {
+	redacted := make([]zapcore.Field, 0, len(fields))
+	for _, field := range fields {
+		policy, found := core.redaction.Policies[field.Key]
+		if !found || field.Type == zapcore.NamespaceType {
+			redacted = append(redacted, field)
+			continue
+		}
+		switch policy {
+		case PolicyDrop:
+		case PolicyHash:
+			redacted = append(redacted, zap.String(field.Key, core.hash(fieldValue(field))))
+		case PolicyMask:
+			redacted = append(redacted, zap.Any(field.Key, mask(fieldValue(field))))
+		case PolicyCodes:
+			redacted = append(redacted, zap.Any(field.Key, core.hashCodes(fieldValue(field))))
+		default:
+			redacted = append(redacted, field)
+		}
+	}
+	return redacted
+}
+
+// hash replaces a value with a short keyed digest of its JSON encoding
+func (core *redactingCore) hash(value interface{}) string {
+	var encoded []byte
+	if text, ok := value.(string); ok {
+		encoded = []byte(text)
+	} else if marshalled, err := json.Marshal(value); err == nil {
+		encoded = marshalled
+	} else {
+		encoded = []byte(fmt.Sprint(value))
+	}
+	digest := hmac.New(sha256.New, core.redaction.Key)
+	digest.Write(encoded)
+	return "sha256:" + hex.EncodeToString(digest.Sum(nil)[:8])
+}
+
+// airportCode matches IATA airport codes as they appear in messages, e.g. "duplicate route from JFK"
+var airportCode = regexp.MustCompile(`\b[A-Z]{3}\b`)
+
+// hashCodes hashes every airport code in each string, keeping the structure of arrays and objects
+func (core *redactingCore) hashCodes(value interface{}) interface{} {
+	switch typed := value.(type) {
+	case string:
+		return airportCode.ReplaceAllStringFunc(typed, func(code string) string {
+			return core.hash(code)
+		})
+	case []interface{}:
+		hashed := make([]interface{}, len(typed))
+		for i, item := range typed {
+			hashed[i] = core.hashCodes(item)
+		}
+		return hashed
+	case map[string]interface{}:
+		hashed := make(map[string]interface{}, len(typed))
+		for key, item := range typed {
+			hashed[key] = core.hashCodes(item)
+		}
+		return hashed
+	default:
+		return typed
+	}
+}
+
+// fieldValue encodes a field the way the JSON encoder would see it
+func fieldValue(field zapcore.Field) interface{} {
+	encoder := zapcore.NewMapObjectEncoder()
+	field.AddTo(encoder)
+	return encoder.Fields[field.Key]
+}
+
+// mask hides all but the last quarter of each string, at most four characters, keeping the
+// structure of arrays and objects
+func mask(value interface{}) interface{} {
+	switch typed := value.(type) {
+	case string:
+		runes := []rune(typed)
+		keep := len(runes) / 4
+		if keep > 4 {
+			keep = 4
+		}
+		return strings.Repeat("*", len(runes)-keep) + string(runes[len(runes)-keep:])
+	case []interface{}:
+		masked := make([]interface{}, len(typed))
+		for i, item := range typed {
+			masked[i] = mask(item)
+		}
+		return masked
+	case map[string]interface{}:
+		masked := make(map[string]interface{}, len(typed))
+		for key, item := range typed {
+			masked[key] = mask(item)
+		}
+		return masked
+	case nil:
+		return nil
+	default:
+		return mask(fmt.Sprint(typed))
+	}
+}
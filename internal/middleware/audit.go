@@ -0,0 +1,68 @@
+package middleware
+
+import (
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+
+	"flight-itinerary-go/internal/audit"
+	"flight-itinerary-go/internal/model"
+	"flight-itinerary-go/internal/tenant"
+)
+
+// Audit records the route's action in the audit log once the handler has run, whatever its
+// outcome. The actor and tenant come from the request context, the stored itinerary from the path
+// or the Location header, its version from the ETag and the ticket digest from the validated
+// request. The action has already taken effect when the event is written, so a failed write keeps
+// the response and raises an alert instead: the event is logged as lost and the audit log reports
+// the failure. It must run after authentication and inside idempotency, so replays are not
+// recorded as new actions.
+func Audit(recorder audit.Recorder, action string, logger *zap.Logger) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(ctx echo.Context) error {
+			err := next(ctx)
+
+			actor, _ := caller(ctx)
+			response := ctx.Response()
+			event := audit.Event{
+				Actor:     actor,
+				Action:    action,
+				Itinerary: ctx.Param("id"),
+				Channel:   audit.ChannelHTTP,
+				Route:     ctx.Request().Method + " " + ctx.Path(),
+				Status:    response.Status,
+				RequestID: ctx.Request().Header.Get(echo.HeaderXRequestID),
+			}
+			if strings.HasPrefix(ctx.Path(), "/graphql") {
+				event.Channel = audit.ChannelGraphQL
+			}
+			if requestTenant, ok := tenant.FromContext(ctx); ok {
+				event.Tenant = requestTenant.ID
+			}
+			if location := response.Header().Get(echo.HeaderLocation); event.Itinerary == "" && location != "" {
+				event.Itinerary = path.Base(location)
+			}
+			if etag := response.Header().Get(HeaderETag); etag != "" {
+				event.Version, _ = strconv.Atoi(strings.TrimPrefix(strings.Trim(etag, `"`), "v"))
+			}
+			if tickets, ok := ctx.Get("validated_request").([]model.Ticket); ok {
+				event.Tickets = audit.TicketDigest(tickets)
+			}
+			if err != nil {
+				event.Status = http.StatusInternalServerError
+				if httpError, ok := err.(*echo.HTTPError); ok {
+					event.Status = httpError.Code
+				}
+			}
+
+			if recordErr := recorder.Record(event); recordErr != nil {
+				audit.LogLost(logger, event, recordErr)
+			}
+			return err
+		}
+	}
+}
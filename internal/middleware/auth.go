@@ -0,0 +1,113 @@
+package middleware
+
+import (
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+
+	"flight-itinerary-go/internal/auth"
+	"flight-itinerary-go/internal/render"
+	"flight-itinerary-go/pkg/errors"
+)
+
+const (
+	// PrincipalKey is the context key of the authenticated *auth.Principal
+	PrincipalKey = "principal"
+	// HeaderAPIKey carries an API key as an alternative to the Authorization header
+	HeaderAPIKey = "X-API-Key"
+)
+
+type RequestAuthenticator interface {
+	Identify() echo.MiddlewareFunc
+	Require(scope string) echo.MiddlewareFunc
+}
+
+type RequestAuthenticatorV1 struct {
+	authenticator auth.Authenticator
+	logger        *zap.Logger
+}
+
+// NewRequestAuthenticator creates middleware authenticating requests with the authenticator
+func NewRequestAuthenticator(authenticator auth.Authenticator, logger *zap.Logger) RequestAuthenticator {
+	return &RequestAuthenticatorV1{
+		authenticator: authenticator,
+		logger:        logger,
+	}
+}
+
+// Identify authenticates requests carrying credentials so that middleware running before Require,
+// such as rate limiting, knows the caller. Invalid credentials are rejected with 401; requests
+// without credentials continue anonymously.
+func (requestAuthenticatorV1 *RequestAuthenticatorV1) Identify() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(ctx echo.Context) error {
+			principal, err := requestAuthenticatorV1.authenticate(ctx)
+			switch {
+			case err == auth.ErrMissingCredentials:
+			case err != nil:
+				ctx.Response().Header().Set(echo.HeaderWWWAuthenticate, "Bearer")
+				return render.WriteError(ctx, err.(*errors.AppError))
+			default:
+				ctx.Set(PrincipalKey, principal)
+			}
+			return next(ctx)
+		}
+	}
+}
+
+// Require authenticates the request from its Authorization bearer token, X-API-Key header or TLS
+// client certificate and rejects callers without the scope with 401 or 403
+func (requestAuthenticatorV1 *RequestAuthenticatorV1) Require(scope string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(ctx echo.Context) error {
+			principal, ok := ctx.Get(PrincipalKey).(*auth.Principal)
+			if !ok {
+				var err error
+				principal, err = requestAuthenticatorV1.authenticate(ctx)
+				if err != nil {
+					ctx.Response().Header().Set(echo.HeaderWWWAuthenticate, "Bearer")
+					return render.WriteError(ctx, err.(*errors.AppError))
+				}
+				ctx.Set(PrincipalKey, principal)
+			}
+
+			if !principal.HasScope(scope) {
+				requestAuthenticatorV1.logger.Warn("Insufficient scope",
+					zap.String("subject", principal.Subject), zap.String("scope", scope))
+				return render.WriteError(ctx, errors.NewForbiddenError("scope %s is required", scope))
+			}
+			return next(ctx)
+		}
+	}
+}
+
+func (requestAuthenticatorV1 *RequestAuthenticatorV1) authenticate(ctx echo.Context) (*auth.Principal, error) {
+	credential := ctx.Request().Header.Get(HeaderAPIKey)
+	if authorization := ctx.Request().Header.Get(echo.HeaderAuthorization); credential == "" && authorization != "" {
+		scheme, token, found := strings.Cut(authorization, " ")
+		if !found || !strings.EqualFold(scheme, "Bearer") {
+			return nil, auth.ErrInvalidCredentials
+		}
+		credential = strings.TrimSpace(token)
+	}
+	if credential == "" {
+		// Without other credentials, a verified client certificate identifies the caller
+		peerAuthenticator, ok := requestAuthenticatorV1.authenticator.(auth.PeerAuthenticator)
+		if tlsState := ctx.Request().TLS; ok && tlsState != nil && len(tlsState.VerifiedChains) > 0 {
+			return peerAuthenticator.AuthenticatePeer(tlsState.VerifiedChains[0][0])
+		}
+		return nil, auth.ErrMissingCredentials
+	}
+
+	principal, err := requestAuthenticatorV1.authenticator.Authenticate(credential)
+	if err != nil {
+		if _, ok := err.(*errors.AppError); !ok {
+			requestAuthenticatorV1.logger.Error("Authentication failed", zap.Error(err))
+			return nil, errors.NewInternalError("authentication is unavailable")
+		}
+		requestAuthenticatorV1.logger.Info("Rejected credentials", zap.String("remote_ip", ctx.RealIP()))
+		return nil, err
+	}
+	return principal, nil
+}
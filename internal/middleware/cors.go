@@ -0,0 +1,74 @@
+package middleware
+
+import (
+	"path"
+	"strings"
+	"sync/atomic"
+
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+)
+
+// CORSPolicy answers cross-origin requests with settings that can be replaced while the server runs
+type CORSPolicy struct {
+	current atomic.Pointer[CORSRules]
+}
+
+// CORSRules is the CORS middleware built from one configuration, ready to replace a policy's
+type CORSRules struct {
+	allowOrigins []string
+	middleware   echo.MiddlewareFunc
+}
+
+// NewCORSRules builds the CORS middleware for the configuration
+func NewCORSRules(config middleware.CORSConfig) *CORSRules {
+	return &CORSRules{
+		allowOrigins: config.AllowOrigins,
+		middleware:   middleware.CORSWithConfig(config),
+	}
+}
+
+// NewCORSPolicy creates a policy applying the configuration
+func NewCORSPolicy(config middleware.CORSConfig) *CORSPolicy {
+	policy := &CORSPolicy{}
+	policy.Update(config)
+	return policy
+}
+
+// Update replaces the configuration; requests already being handled keep the previous one
+func (policy *CORSPolicy) Update(config middleware.CORSConfig) {
+	policy.Set(NewCORSRules(config))
+}
+
+// Set replaces the rules with ones built beforehand
+func (policy *CORSPolicy) Set(rules *CORSRules) {
+	policy.current.Store(rules)
+}
+
+// AllowsOrigin reports whether a browser page from the origin may call the API. It guards
+// WebSocket upgrades, which browsers make without CORS checks. Requests without an Origin header
+// do not come from browser pages and are allowed; patterns such as https://*.example.com match
+// one subdomain level.
+func (policy *CORSPolicy) AllowsOrigin(origin string) bool {
+	if origin == "" {
+		return true
+	}
+	for _, allowed := range policy.current.Load().allowOrigins {
+		if allowed == "*" || strings.EqualFold(allowed, origin) {
+			return true
+		}
+		if matched, _ := path.Match(strings.ToLower(allowed), strings.ToLower(origin)); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// Apply returns the middleware applying the current configuration to each request
+func (policy *CORSPolicy) Apply() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(ctx echo.Context) error {
+			return policy.current.Load().middleware(next)(ctx)
+		}
+	}
+}
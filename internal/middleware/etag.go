@@ -0,0 +1,119 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+
+	"flight-itinerary-go/internal/render"
+	"flight-itinerary-go/pkg/errors"
+)
+
+// Conditional request headers
+const (
+	HeaderETag        = "ETag"
+	HeaderIfMatch     = "If-Match"
+	HeaderIfNoneMatch = "If-None-Match"
+)
+
+// errNoneMatchFailed rejects requests other than GET and HEAD whose If-None-Match matches
+var errNoneMatchFailed = errors.NewPreconditionFailedError(HeaderIfNoneMatch + " matches the current representation")
+
+// bufferedWriter holds the response back until the middleware decides what reaches the client
+type bufferedWriter struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (writer *bufferedWriter) WriteHeader(status int) {
+	writer.status = status
+}
+
+func (writer *bufferedWriter) Write(data []byte) (int, error) {
+	return writer.body.Write(data)
+}
+
+// ETag adds a strong ETag derived from the body to successful responses of read endpoints and
+// evaluates the preconditions against it: If-Match fails with 412, and If-None-Match answers GET
+// and HEAD with 304 and other methods with 412. If-None-Match: * on other methods fails before the
+// handler runs, as every request produces a representation. Errors use the negotiated format when
+// the middleware runs after content negotiation.
+func ETag() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(ctx echo.Context) error {
+			request := ctx.Request()
+			safe := request.Method == http.MethodGet || request.Method == http.MethodHead
+			if !safe && strings.TrimSpace(request.Header.Get(HeaderIfNoneMatch)) == "*" {
+				return render.WriteError(ctx, errNoneMatchFailed)
+			}
+
+			response := ctx.Response()
+			writer := &bufferedWriter{ResponseWriter: response.Writer, status: http.StatusOK}
+			response.Writer = writer
+			err := next(ctx)
+			response.Writer = writer.ResponseWriter
+			if err != nil {
+				return err
+			}
+
+			if writer.status == http.StatusOK {
+				sum := sha256.Sum256(writer.body.Bytes())
+				etag := `"` + hex.EncodeToString(sum[:16]) + `"`
+				response.Header().Set(HeaderETag, etag)
+
+				if ifMatch := request.Header.Get(HeaderIfMatch); ifMatch != "" && !ETagMatches(ifMatch, etag, false) {
+					return replaceBuffered(ctx, errors.ErrPreconditionFailed)
+				}
+				if ETagMatches(request.Header.Get(HeaderIfNoneMatch), etag, true) {
+					if !safe {
+						return replaceBuffered(ctx, errNoneMatchFailed)
+					}
+					response.Header().Del(echo.HeaderContentType)
+					response.Status = http.StatusNotModified
+					writer.ResponseWriter.WriteHeader(http.StatusNotModified)
+					return nil
+				}
+			}
+			writer.ResponseWriter.WriteHeader(writer.status)
+			_, err = writer.ResponseWriter.Write(writer.body.Bytes())
+			return err
+		}
+	}
+}
+
+// replaceBuffered discards the held back response and writes the error instead. The handler has
+// already marked the response committed, though nothing reached the client yet.
+func replaceBuffered(ctx echo.Context, appErr *errors.AppError) error {
+	response := ctx.Response()
+	response.Committed = false
+	response.Size = 0
+	response.Header().Del(HeaderETag)
+	response.Header().Del(echo.HeaderContentType)
+	return render.WriteError(ctx, appErr)
+}
+
+// ETagMatches reports whether an If-Match or If-None-Match header lists the ETag. If-None-Match
+// uses weak comparison, ignoring W/ prefixes; If-Match requires a strong match.
+func ETagMatches(header, etag string, weak bool) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" {
+			return true
+		}
+		if strings.HasPrefix(candidate, "W/") {
+			if !weak {
+				continue
+			}
+			candidate = strings.TrimPrefix(candidate, "W/")
+		}
+		if candidate == etag {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,213 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+
+	"flight-itinerary-go/internal/render"
+	"flight-itinerary-go/internal/tenant"
+	"flight-itinerary-go/pkg/errors"
+)
+
+const (
+	// HeaderIdempotencyKey identifies retries of the same mutating request
+	HeaderIdempotencyKey = "Idempotency-Key"
+	// HeaderIdempotentReplayed marks responses replayed from the idempotency store
+	HeaderIdempotentReplayed = "Idempotent-Replayed"
+
+	maxIdempotencyKeyLength = 255
+)
+
+// replayedHeaders are the response headers stored and replayed with the body
+var replayedHeaders = []string{echo.HeaderContentType, echo.HeaderLocation, HeaderETag, "X-Cache"}
+
+// idempotentResponse is the first response to a request; it is pending until the handler returns
+type idempotentResponse struct {
+	fingerprint [sha256.Size]byte
+	pending     bool
+	status      int
+	header      http.Header
+	body        []byte
+	expires     time.Time
+}
+
+// storedKey names a completed response in the order responses expire
+type storedKey struct {
+	key      string
+	response *idempotentResponse
+}
+
+// IdempotencyStore keeps the first response for each Idempotency-Key for the retention period.
+// Keys are scoped to the tenant and caller, so callers cannot replay or block each other's requests.
+type IdempotencyStore struct {
+	mutex     sync.Mutex
+	retention time.Duration
+	responses map[string]*idempotentResponse
+	// expiring holds completed responses oldest first; with one retention period for every
+	// response, that is also the order in which they expire
+	expiring []storedKey
+}
+
+// NewIdempotencyStore creates an in-memory store; contents are lost on restart
+func NewIdempotencyStore(retention time.Duration) *IdempotencyStore {
+	return &IdempotencyStore{
+		retention: retention,
+		responses: make(map[string]*idempotentResponse),
+	}
+}
+
+// begin returns the stored response for the key, or reserves the key when it is new or expired
+func (store *IdempotencyStore) begin(key string, fingerprint [sha256.Size]byte) (*idempotentResponse, bool) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	now := time.Now()
+	store.expire(now)
+	if stored, exists := store.responses[key]; exists {
+		return stored, true
+	}
+	store.responses[key] = &idempotentResponse{fingerprint: fingerprint, pending: true}
+	return nil, false
+}
+
+// expire forgets the responses whose retention has ended, stopping at the first one still kept
+func (store *IdempotencyStore) expire(now time.Time) {
+	expired := 0
+	for _, stored := range store.expiring {
+		if now.Before(stored.response.expires) {
+			break
+		}
+		// The key may have been released and reserved again since this response was stored
+		if store.responses[stored.key] == stored.response {
+			delete(store.responses, stored.key)
+		}
+		expired++
+	}
+	store.expiring = store.expiring[expired:]
+}
+
+// release forgets a pending key whose handler did not complete
+func (store *IdempotencyStore) release(key string) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+	if stored, exists := store.responses[key]; exists && stored.pending {
+		delete(store.responses, key)
+	}
+}
+
+// complete saves the response for replay, or releases the key so the request can be retried
+func (store *IdempotencyStore) complete(key string, status int, header http.Header, body []byte) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	if status >= http.StatusInternalServerError {
+		delete(store.responses, key)
+		return
+	}
+	stored := store.responses[key]
+	stored.pending = false
+	stored.status = status
+	stored.header = header
+	stored.body = body
+	stored.expires = time.Now().Add(store.retention)
+	store.expiring = append(store.expiring, storedKey{key: key, response: stored})
+}
+
+// responseRecorder copies everything written to the client
+type responseRecorder struct {
+	http.ResponseWriter
+	body bytes.Buffer
+}
+
+func (recorder *responseRecorder) Write(data []byte) (int, error) {
+	recorder.body.Write(data)
+	return recorder.ResponseWriter.Write(data)
+}
+
+// Idempotency replays the first response to requests carrying an Idempotency-Key. A retry with
+// the same key but a different method, path or body is rejected with 422; a retry arriving while
+// the first request is still running is rejected with 409. Server errors are not stored.
+func Idempotency(store *IdempotencyStore, logger *zap.Logger) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(ctx echo.Context) error {
+			idempotencyKey := ctx.Request().Header.Get(HeaderIdempotencyKey)
+			if idempotencyKey == "" {
+				return next(ctx)
+			}
+			if len(idempotencyKey) > maxIdempotencyKeyLength {
+				return render.WriteError(ctx, errors.NewValidationError(
+					"%s must be at most %d characters", HeaderIdempotencyKey, maxIdempotencyKeyLength))
+			}
+			key := scopedIdempotencyKey(ctx, idempotencyKey)
+
+			body, err := io.ReadAll(ctx.Request().Body)
+			if err != nil {
+				return render.WriteError(ctx, errors.NewValidationError("failed to read request body: %v", err))
+			}
+			ctx.Request().Body = io.NopCloser(bytes.NewReader(body))
+			fingerprint := sha256.Sum256(bytes.Join(
+				[][]byte{[]byte(ctx.Request().Method), []byte(ctx.Request().URL.RequestURI()), body}, []byte{0}))
+
+			stored, exists := store.begin(key, fingerprint)
+			if exists {
+				switch {
+				case stored.fingerprint != fingerprint:
+					logger.Warn("Idempotency key reused with a different request",
+						zap.String("idempotency_key", idempotencyKey))
+					return render.WriteError(ctx, errors.NewUnprocessableError(
+						"idempotency key was already used for a different request"))
+				case stored.pending:
+					return render.WriteError(ctx, errors.NewConflictError(
+						"a request with this idempotency key is still in progress"))
+				}
+				logger.Debug("Replaying idempotent response", zap.String("idempotency_key", idempotencyKey))
+				for name, values := range stored.header {
+					ctx.Response().Header()[name] = values
+				}
+				ctx.Response().Header().Set(HeaderIdempotentReplayed, "true")
+				if len(stored.body) == 0 {
+					return ctx.NoContent(stored.status)
+				}
+				return ctx.Blob(stored.status, stored.header.Get(echo.HeaderContentType), stored.body)
+			}
+			// Releases the key if the handler panics
+			defer store.release(key)
+
+			recorder := &responseRecorder{ResponseWriter: ctx.Response().Writer}
+			ctx.Response().Writer = recorder
+			err = next(ctx)
+			ctx.Response().Writer = recorder.ResponseWriter
+
+			status := ctx.Response().Status
+			if err != nil {
+				// The error handler writes the response later, so it cannot be replayed
+				status = http.StatusInternalServerError
+			}
+			header := http.Header{}
+			for _, name := range replayedHeaders {
+				if value := ctx.Response().Header().Get(name); value != "" {
+					header.Set(name, value)
+				}
+			}
+			store.complete(key, status, header, recorder.body.Bytes())
+			return err
+		}
+	}
+}
+
+// scopedIdempotencyKey prefixes the client's key with the request's tenant and caller
+func scopedIdempotencyKey(ctx echo.Context, key string) string {
+	tenantID := ""
+	if requestTenant, ok := tenant.FromContext(ctx); ok {
+		tenantID = requestTenant.ID
+	}
+	subject, _ := caller(ctx)
+	return tenantID + "\x00" + subject + "\x00" + key
+}
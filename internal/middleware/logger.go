@@ -1,13 +1,17 @@
 package middleware
 
 import (
+	"net/url"
 	"time"
 
 	"github.com/labstack/echo/v4"
 	"go.uber.org/zap"
+
+	"flight-itinerary-go/internal/auth"
 )
 
-// LoggingMiddleware provides structured logging for requests
+// LoggingMiddleware provides structured logging for requests. Query values may carry travel data
+// such as airports, so only the parameter names of the query are logged.
 func LoggingMiddleware(logger *zap.Logger) echo.MiddlewareFunc {
 	return func(next echo.HandlerFunc) echo.HandlerFunc {
 		return func(ctx echo.Context) error {
@@ -20,7 +24,7 @@ func LoggingMiddleware(logger *zap.Logger) echo.MiddlewareFunc {
 
 			fields := []zap.Field{
 				zap.String("method", req.Method),
-				zap.String("uri", req.RequestURI),
+				zap.String("uri", redactQuery(req.URL)),
 				zap.String("remote_ip", ctx.RealIP()),
 				zap.String("user_agent", req.UserAgent()),
 				zap.Int("status", res.Status),
@@ -28,6 +32,10 @@ func LoggingMiddleware(logger *zap.Logger) echo.MiddlewareFunc {
 				zap.Duration("latency", time.Since(start)),
 			}
 
+			if principal, ok := ctx.Get(PrincipalKey).(*auth.Principal); ok {
+				fields = append(fields, zap.String("subject", principal.Subject))
+			}
+
 			if err != nil {
 				fields = append(fields, zap.Error(err))
 				logger.Error("Request failed", fields...)
@@ -39,3 +47,15 @@ func LoggingMiddleware(logger *zap.Logger) echo.MiddlewareFunc {
 		}
 	}
 }
+
+// redactQuery returns the request path with the values of its query parameters removed
+func redactQuery(requestURL *url.URL) string {
+	query := requestURL.Query()
+	if len(query) == 0 {
+		return requestURL.EscapedPath()
+	}
+	for name := range query {
+		query[name] = []string{""}
+	}
+	return requestURL.EscapedPath() + "?" + query.Encode()
+}
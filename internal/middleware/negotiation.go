@@ -0,0 +1,48 @@
+package middleware
+
+import (
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+
+	"flight-itinerary-go/internal/render"
+	"flight-itinerary-go/internal/tenant"
+	"flight-itinerary-go/pkg/errors"
+)
+
+// ContentNegotiation selects a renderer from the registry using the format query parameter
+// or the Accept header, and rejects requests for unsupported media types with 406
+func ContentNegotiation[R render.MediaTyped](registry *render.Registry[R], logger *zap.Logger) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(ctx echo.Context) error {
+			return negotiate(ctx, registry, logger, next)
+		}
+	}
+}
+
+// ItineraryContentNegotiation negotiates like ContentNegotiation using the itinerary renderers of
+// the request's tenant, so renderers showing airports use the tenant's overrides
+func ItineraryContentNegotiation(registry *render.Registry[render.Renderer], logger *zap.Logger) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(ctx echo.Context) error {
+			if requestTenant, ok := tenant.FromContext(ctx); ok {
+				return negotiate(ctx, requestTenant.Renderers, logger, next)
+			}
+			return negotiate(ctx, registry, logger, next)
+		}
+	}
+}
+
+func negotiate[R render.MediaTyped](ctx echo.Context, registry *render.Registry[R], logger *zap.Logger,
+	next echo.HandlerFunc) error {
+	accept := ctx.Request().Header.Get(echo.HeaderAccept)
+	renderer, err := registry.Negotiate(accept, ctx.QueryParam("format"))
+	if err != nil {
+		logger.Debug("Content negotiation failed", zap.String("accept", accept), zap.Error(err))
+		appErr := err.(*errors.AppError)
+		return ctx.JSON(appErr.Code, appErr)
+	}
+
+	ctx.Set(render.ContextKey, renderer)
+	ctx.Response().Header().Add(echo.HeaderVary, echo.HeaderAccept)
+	return next(ctx)
+}
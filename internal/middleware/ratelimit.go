@@ -0,0 +1,115 @@
+package middleware
+
+import (
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+
+	"flight-itinerary-go/internal/auth"
+	"flight-itinerary-go/internal/model"
+	"flight-itinerary-go/internal/ratelimit"
+	"flight-itinerary-go/internal/render"
+	"flight-itinerary-go/internal/tenant"
+	"flight-itinerary-go/pkg/errors"
+)
+
+const (
+	// HeaderRateLimitLimit, HeaderRateLimitRemaining and HeaderRateLimitReset describe the caller's
+	// bucket for the route, following the IETF RateLimit header fields draft
+	HeaderRateLimitLimit     = "RateLimit-Limit"
+	HeaderRateLimitRemaining = "RateLimit-Remaining"
+	HeaderRateLimitReset     = "RateLimit-Reset"
+	// HeaderTicketQuotaLimit and HeaderTicketQuotaRemaining describe the caller's daily ticket quota
+	HeaderTicketQuotaLimit     = "X-Ticket-Quota-Limit"
+	HeaderTicketQuotaRemaining = "X-Ticket-Quota-Remaining"
+)
+
+// RateLimit limits requests per caller and route. Authenticated callers are limited by subject and
+// tier, anonymous callers by IP address. Requests with a tenant are limited by the tenant's limiter
+// instead. Rejected requests get 429 with Retry-After.
+func RateLimit(limiter *ratelimit.Limiter, logger *zap.Logger) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(ctx echo.Context) error {
+			identity, tier := caller(ctx)
+			decision := limiterFor(ctx, limiter).Allow(identity, tier, ctx.Request().Method+" "+ctx.Path())
+
+			header := ctx.Response().Header()
+			header.Set(HeaderRateLimitLimit, strconv.Itoa(decision.Limit))
+			header.Set(HeaderRateLimitRemaining, strconv.Itoa(decision.Remaining))
+			header.Set(HeaderRateLimitReset, ceilSeconds(decision.Reset))
+			if !decision.Allowed {
+				logger.Warn("Rate limit exceeded", zap.String("caller", identity), zap.String("route", ctx.Path()))
+				header.Set(echo.HeaderRetryAfter, ceilSeconds(decision.RetryAfter))
+				return render.WriteError(ctx, errors.NewTooManyRequestsError("rate limit exceeded, retry later"))
+			}
+			return next(ctx)
+		}
+	}
+}
+
+// TicketQuota counts the validated tickets of a request against the caller's daily quota. It must
+// run after the itinerary validator.
+func TicketQuota(limiter *ratelimit.Limiter, logger *zap.Logger) echo.MiddlewareFunc {
+	useTickets := NewTicketCounter(limiter, logger)
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(ctx echo.Context) error {
+			tickets, _ := ctx.Get("validated_request").([]model.Ticket)
+			if appErr := useTickets(ctx, len(tickets)); appErr != nil {
+				return render.WriteError(ctx, appErr)
+			}
+			return next(ctx)
+		}
+	}
+}
+
+// TicketCounter counts tickets against the daily quota of the request's caller, returning a 429
+// error once the quota is used up. Handlers receiving tickets after the request starts, such as
+// GraphQL queries and sessions, count them with it.
+type TicketCounter func(ctx echo.Context, tickets int) *errors.AppError
+
+// NewTicketCounter creates a counter using the limiter of the request's tenant, or the given one.
+// It sets the quota headers on the response.
+func NewTicketCounter(limiter *ratelimit.Limiter, logger *zap.Logger) TicketCounter {
+	return func(ctx echo.Context, tickets int) *errors.AppError {
+		identity, tier := caller(ctx)
+		decision := limiterFor(ctx, limiter).UseTickets(identity, tier, tickets)
+		if decision.Limit == 0 {
+			return nil
+		}
+
+		header := ctx.Response().Header()
+		header.Set(HeaderTicketQuotaLimit, strconv.FormatInt(decision.Limit, 10))
+		header.Set(HeaderTicketQuotaRemaining, strconv.FormatInt(decision.Remaining, 10))
+		if !decision.Allowed {
+			logger.Warn("Daily ticket quota exceeded", zap.String("caller", identity), zap.Int("tickets", tickets))
+			header.Set(echo.HeaderRetryAfter, ceilSeconds(decision.Reset))
+			return errors.NewTooManyRequestsError(
+				"daily ticket quota exceeded, %d of %d tickets left today", decision.Remaining, decision.Limit)
+		}
+		return nil
+	}
+}
+
+// caller identifies the rate limited caller and its tier
+func caller(ctx echo.Context) (string, string) {
+	if principal, ok := ctx.Get(PrincipalKey).(*auth.Principal); ok {
+		return principal.Subject, principal.Tier
+	}
+	return "ip:" + ctx.RealIP(), ratelimit.AnonymousTier
+}
+
+// limiterFor returns the limiter of the request's tenant, or the given one
+func limiterFor(ctx echo.Context, limiter *ratelimit.Limiter) *ratelimit.Limiter {
+	if requestTenant, ok := tenant.FromContext(ctx); ok {
+		return requestTenant.Limiter
+	}
+	return limiter
+}
+
+// ceilSeconds formats a duration as whole seconds, rounded up
+func ceilSeconds(duration time.Duration) string {
+	return strconv.FormatInt(int64(math.Ceil(duration.Seconds())), 10)
+}
@@ -0,0 +1,49 @@
+package middleware
+
+import (
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+
+	"flight-itinerary-go/internal/auth"
+	"flight-itinerary-go/internal/render"
+	"flight-itinerary-go/internal/tenant"
+	"flight-itinerary-go/pkg/errors"
+)
+
+// HeaderTenantID selects the tenant of a request and reports the tenant that served it
+const HeaderTenantID = "X-Tenant-ID"
+
+// Tenancy selects the request's tenant. Callers bound to a tenant are served by it and may only name
+// that tenant in X-Tenant-ID. Administrators choose the tenant with the header; other callers may
+// only name the default tenant. Without the header, unbound callers get the default tenant. It must
+// run after Identify.
+func Tenancy(registry *tenant.Registry, logger *zap.Logger) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(ctx echo.Context) error {
+			id := ctx.Request().Header.Get(HeaderTenantID)
+			if principal, ok := ctx.Get(PrincipalKey).(*auth.Principal); ok && principal.Tenant != "" {
+				if id != "" && id != principal.Tenant {
+					logger.Warn("Tenant not allowed for caller", zap.String("subject", principal.Subject),
+						zap.String("tenant", id))
+					return render.WriteError(ctx, errors.NewForbiddenError("credentials are bound to another tenant"))
+				}
+				id = principal.Tenant
+			} else if id != "" && id != tenant.DefaultID && (!ok || !principal.HasScope(auth.ScopeAdmin)) {
+				logger.Warn("Tenant selection not allowed for caller", zap.String("tenant", id))
+				return render.WriteError(ctx, errors.NewForbiddenError("only administrators may choose a tenant"))
+			}
+			if id == "" {
+				id = tenant.DefaultID
+			}
+
+			requestTenant, exists := registry.Get(id)
+			if !exists {
+				return render.WriteError(ctx, errors.NewNotFoundError("tenant not found"))
+			}
+			ctx.Set(tenant.ContextKey, requestTenant)
+			ctx.Response().Header().Set(HeaderTenantID, requestTenant.ID)
+			ctx.Response().Header().Add(echo.HeaderVary, HeaderTenantID)
+			return next(ctx)
+		}
+	}
+}
@@ -5,6 +5,8 @@ import (
 	"go.uber.org/zap"
 
 	"flight-itinerary-go/internal/model"
+	"flight-itinerary-go/internal/render"
+	"flight-itinerary-go/internal/tenant"
 	"flight-itinerary-go/pkg/errors"
 )
 
@@ -31,19 +33,29 @@ func (itineraryRequestValidatorV1 *ItineraryRequestValidatorV1) Validate() echo.
 
 			if err := ctx.Bind(&tickets); err != nil {
 				appErr := errors.NewValidationError("invalid JSON format: %v", err)
-				return ctx.JSON(appErr.Code, appErr)
+				return render.WriteError(ctx, appErr)
 			}
 			//itineraryRequestValidatorV1.logger.Debug("Received request: ", zap.Any("tickets", tickets))
 
 			if len(tickets) == 0 {
 				appErr := errors.NewValidationError("at least one ticket is required")
-				return ctx.JSON(appErr.Code, appErr)
+				return render.WriteError(ctx, appErr)
+			}
+			if len(tickets) > model.MaxTickets {
+				appErr := errors.NewValidationError("at most %d tickets are allowed, got %d", model.MaxTickets, len(tickets))
+				return render.WriteError(ctx, appErr)
 			}
 
 			for i, ticket := range tickets {
 				if ticket[0] == "" || ticket[1] == "" {
 					appErr := errors.NewValidationError("ticket at index %d has empty source or destination", i)
-					return ctx.JSON(appErr.Code, appErr)
+					return render.WriteError(ctx, appErr)
+				}
+			}
+
+			if requestTenant, ok := tenant.FromContext(ctx); ok {
+				if appErr := requestTenant.Validation.Check(tickets, requestTenant.Directory); appErr != nil {
+					return render.WriteError(ctx, appErr)
 				}
 			}
 
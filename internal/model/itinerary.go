@@ -2,6 +2,9 @@ package model
 
 import "flight-itinerary-go/pkg/errors"
 
+// MaxTickets caps the tickets of a single reconstruction on every protocol
+const MaxTickets = 1000
+
 // Ticket represents a flight ticket with source and destination
 type Ticket [2]string
 
@@ -44,3 +47,18 @@ func (t Ticket) Validate() error {
 	}
 	return nil
 }
+
+// Ticket operations supported when editing a stored itinerary
+const (
+	OperationAdd     = "add"
+	OperationReplace = "replace"
+	OperationVoid    = "void"
+)
+
+// TicketOperation represents a single edit to the tickets of a stored itinerary. Replace and
+// void target an existing ticket; replace swaps it for With.
+type TicketOperation struct {
+	Op     string  `json:"op"`
+	Ticket Ticket  `json:"ticket"`
+	With   *Ticket `json:"with,omitempty"`
+}
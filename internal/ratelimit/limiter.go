@@ -0,0 +1,199 @@
+package ratelimit
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// sweepInterval is how often idle buckets and past ticket usage are dropped
+const sweepInterval = time.Minute
+
+// Decision is the outcome of a rate limit check
+type Decision struct {
+	Allowed bool
+	// Limit is the bucket size and Remaining the requests left in it
+	Limit     int
+	Remaining int
+	// Reset is the time until the bucket is full again
+	Reset time.Duration
+	// RetryAfter is the time until the next request is allowed, when denied
+	RetryAfter time.Duration
+}
+
+// QuotaDecision is the outcome of a daily ticket quota check
+type QuotaDecision struct {
+	Allowed bool
+	// Limit is the daily quota, zero when unlimited, and Remaining the tickets left today
+	Limit     int64
+	Remaining int64
+	// Reset is the time until the quota resets at midnight UTC
+	Reset time.Duration
+}
+
+type bucket struct {
+	tokens  float64
+	updated time.Time
+	// full is when the bucket will have refilled, after which it can be dropped
+	full time.Time
+}
+
+type dailyUsage struct {
+	day     string
+	tickets int64
+}
+
+// TicketUsage counts the tickets each caller submitted today. Limiters sharing it count a caller's
+// tickets once across all of them, so a caller cannot add up quotas by switching tenants.
+type TicketUsage struct {
+	mutex     sync.Mutex
+	usage     map[string]*dailyUsage
+	lastSweep time.Time
+}
+
+// NewTicketUsage creates an empty ticket count
+func NewTicketUsage() *TicketUsage {
+	return &TicketUsage{
+		usage:     make(map[string]*dailyUsage),
+		lastSweep: time.Now(),
+	}
+}
+
+// Limiter enforces a policy with one token bucket per caller and route and one ticket counter per
+// caller. State is kept in memory, so limits are per instance and reset on restart.
+type Limiter struct {
+	mutex     sync.Mutex
+	policy    Policy
+	buckets   map[string]*bucket
+	tickets   *TicketUsage
+	lastSweep time.Time
+}
+
+// NewLimiter creates a limiter enforcing the policy with its own ticket count
+func NewLimiter(policy Policy) *Limiter {
+	return NewSharedLimiter(policy, NewTicketUsage())
+}
+
+// NewSharedLimiter creates a limiter enforcing the policy that counts tickets in the given usage
+func NewSharedLimiter(policy Policy, tickets *TicketUsage) *Limiter {
+	return &Limiter{
+		policy:    policy,
+		buckets:   make(map[string]*bucket),
+		tickets:   tickets,
+		lastSweep: time.Now(),
+	}
+}
+
+// Policy returns the policy in force
+func (limiter *Limiter) Policy() Policy {
+	limiter.mutex.Lock()
+	defer limiter.mutex.Unlock()
+	return limiter.policy
+}
+
+// SetPolicy replaces the policy; callers keep their current buckets and usage
+func (limiter *Limiter) SetPolicy(policy Policy) {
+	limiter.mutex.Lock()
+	defer limiter.mutex.Unlock()
+	limiter.policy = policy
+}
+
+// Allow takes a token from the caller's bucket for the route
+func (limiter *Limiter) Allow(identity, tierName, route string) Decision {
+	limiter.mutex.Lock()
+	defer limiter.mutex.Unlock()
+	now := time.Now()
+	limiter.sweep(now)
+
+	tierName, tier := limiter.policy.tier(tierName)
+	limit := tier.limit(route)
+	key := identity + "\x00" + tierName + "\x00" + route
+	state, exists := limiter.buckets[key]
+	if !exists {
+		state = &bucket{tokens: float64(limit.Burst), updated: now}
+		limiter.buckets[key] = state
+	}
+	state.tokens = math.Min(float64(limit.Burst), state.tokens+now.Sub(state.updated).Seconds()*limit.Rate)
+	state.updated = now
+
+	decision := Decision{Limit: limit.Burst}
+	if state.tokens >= 1 {
+		state.tokens--
+		decision.Allowed = true
+	} else {
+		decision.RetryAfter = seconds((1 - state.tokens) / limit.Rate)
+	}
+	decision.Remaining = int(state.tokens)
+	decision.Reset = seconds((float64(limit.Burst) - state.tokens) / limit.Rate)
+	state.full = now.Add(decision.Reset)
+	return decision
+}
+
+// UseTickets counts tickets against the caller's daily quota; requests exceeding what is left are
+// denied without using any of it
+func (limiter *Limiter) UseTickets(identity, tierName string, tickets int) QuotaDecision {
+	limiter.mutex.Lock()
+	_, tier := limiter.policy.tier(tierName)
+	limiter.mutex.Unlock()
+	if tier.DailyTickets == 0 {
+		return QuotaDecision{Allowed: true}
+	}
+	return limiter.tickets.use(identity, tier.DailyTickets, tickets)
+}
+
+// use counts tickets against a daily quota of the given size
+func (ticketUsage *TicketUsage) use(identity string, quota int64, tickets int) QuotaDecision {
+	ticketUsage.mutex.Lock()
+	defer ticketUsage.mutex.Unlock()
+	now := time.Now().UTC()
+	day := now.Format(time.DateOnly)
+	ticketUsage.sweep(now, day)
+
+	usage, exists := ticketUsage.usage[identity]
+	if !exists || usage.day != day {
+		usage = &dailyUsage{day: day}
+		ticketUsage.usage[identity] = usage
+	}
+
+	decision := QuotaDecision{
+		Limit: quota,
+		Reset: now.Truncate(24 * time.Hour).Add(24 * time.Hour).Sub(now),
+	}
+	if usage.tickets+int64(tickets) <= quota {
+		usage.tickets += int64(tickets)
+		decision.Allowed = true
+	}
+	decision.Remaining = max(quota-usage.tickets, 0)
+	return decision
+}
+
+// sweep drops usage from past days
+func (ticketUsage *TicketUsage) sweep(now time.Time, day string) {
+	if now.Sub(ticketUsage.lastSweep) < sweepInterval {
+		return
+	}
+	ticketUsage.lastSweep = now
+	for identity, usage := range ticketUsage.usage {
+		if usage.day != day {
+			delete(ticketUsage.usage, identity)
+		}
+	}
+}
+
+// sweep drops buckets idle long enough to have refilled
+func (limiter *Limiter) sweep(now time.Time) {
+	if now.Sub(limiter.lastSweep) < sweepInterval {
+		return
+	}
+	limiter.lastSweep = now
+	for key, state := range limiter.buckets {
+		if now.After(state.full) {
+			delete(limiter.buckets, key)
+		}
+	}
+}
+
+// seconds converts fractional seconds into a duration
+func seconds(value float64) time.Duration {
+	return time.Duration(value * float64(time.Second))
+}
@@ -0,0 +1,108 @@
+package ratelimit
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// AnonymousTier applies to callers that are not authenticated, when the policy defines it
+const AnonymousTier = "anonymous"
+
+// Limit is a token bucket refilled at Rate requests per second up to Burst requests
+type Limit struct {
+	Rate  float64 `json:"rate"`
+	Burst int     `json:"burst"`
+}
+
+// Tier holds the limits of one class of callers
+type Tier struct {
+	// Default applies to routes without their own limit
+	Default Limit `json:"default"`
+	// Routes overrides the default by route, written as "METHOD /path" with the router's
+	// parameter syntax, e.g. "GET /api/v1/itineraries/:id"
+	Routes map[string]Limit `json:"routes,omitempty"`
+	// DailyTickets caps the tickets submitted per UTC day; zero means unlimited
+	DailyTickets int64 `json:"daily_tickets,omitempty"`
+}
+
+// Policy assigns limits to tiers
+type Policy struct {
+	// DefaultTier applies to callers without a tier or with an unknown one
+	DefaultTier string          `json:"default_tier"`
+	Tiers       map[string]Tier `json:"tiers"`
+}
+
+// DefaultPolicy is used when no policy file is configured
+func DefaultPolicy() Policy {
+	return Policy{
+		DefaultTier: "standard",
+		Tiers: map[string]Tier{
+			AnonymousTier: {Default: Limit{Rate: 1, Burst: 5}},
+			"standard": {
+				Default:      Limit{Rate: 10, Burst: 20},
+				DailyTickets: 100000,
+			},
+			"premium": {
+				Default: Limit{Rate: 100, Burst: 200},
+			},
+		},
+	}
+}
+
+// LoadPolicy reads a policy from a JSON file
+func LoadPolicy(path string) (Policy, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Policy{}, err
+	}
+	var policy Policy
+	if err := json.Unmarshal(data, &policy); err != nil {
+		return Policy{}, fmt.Errorf("invalid rate limit policy: %w", err)
+	}
+	return policy, policy.Validate()
+}
+
+// Validate checks that the default tier exists and every limit allows requests
+func (policy Policy) Validate() error {
+	if _, exists := policy.Tiers[policy.DefaultTier]; !exists {
+		return fmt.Errorf("default tier %q is not defined", policy.DefaultTier)
+	}
+	for name, tier := range policy.Tiers {
+		if err := tier.Default.validate(); err != nil {
+			return fmt.Errorf("tier %q: %w", name, err)
+		}
+		for route, limit := range tier.Routes {
+			if err := limit.validate(); err != nil {
+				return fmt.Errorf("tier %q route %q: %w", name, route, err)
+			}
+		}
+		if tier.DailyTickets < 0 {
+			return fmt.Errorf("tier %q: daily_tickets must not be negative", name)
+		}
+	}
+	return nil
+}
+
+func (limit Limit) validate() error {
+	if limit.Rate <= 0 || limit.Burst < 1 {
+		return fmt.Errorf("rate must be positive and burst at least 1")
+	}
+	return nil
+}
+
+// tier returns the named tier, falling back to the default tier
+func (policy Policy) tier(name string) (string, Tier) {
+	if tier, exists := policy.Tiers[name]; exists {
+		return name, tier
+	}
+	return policy.DefaultTier, policy.Tiers[policy.DefaultTier]
+}
+
+// limit returns the limit of the route in the tier
+func (tier Tier) limit(route string) Limit {
+	if limit, exists := tier.Routes[route]; exists {
+		return limit
+	}
+	return tier.Default
+}
@@ -0,0 +1,149 @@
+package ratelimit_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"flight-itinerary-go/internal/ratelimit"
+)
+
+func TestRateLimit(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Rate Limit Suite")
+}
+
+var _ = Describe("Rate limiting", func() {
+	const reconstruct = "POST /api/v1/itinerary/reconstruct"
+
+	policy := func() ratelimit.Policy {
+		return ratelimit.Policy{
+			DefaultTier: "standard",
+			Tiers: map[string]ratelimit.Tier{
+				"standard": {
+					Default:      ratelimit.Limit{Rate: 1, Burst: 2},
+					Routes:       map[string]ratelimit.Limit{reconstruct: {Rate: 1, Burst: 1}},
+					DailyTickets: 10,
+				},
+				"premium": {Default: ratelimit.Limit{Rate: 1, Burst: 5}},
+			},
+		}
+	}
+
+	Describe("Policy", func() {
+		It("should validate tiers and limits", func() {
+			Expect(ratelimit.DefaultPolicy().Validate()).To(Succeed())
+			Expect(policy().Validate()).To(Succeed())
+
+			missingDefault := policy()
+			missingDefault.DefaultTier = "gold"
+			Expect(missingDefault.Validate()).To(HaveOccurred())
+
+			zeroBurst := policy()
+			zeroBurst.Tiers["premium"] = ratelimit.Tier{Default: ratelimit.Limit{Rate: 1}}
+			Expect(zeroBurst.Validate()).To(HaveOccurred())
+
+			badRoute := policy()
+			badRoute.Tiers["standard"].Routes[reconstruct] = ratelimit.Limit{Rate: 0, Burst: 1}
+			Expect(badRoute.Validate()).To(HaveOccurred())
+		})
+
+		It("should load a policy file", func() {
+			path := filepath.Join(GinkgoT().TempDir(), "limits.json")
+			Expect(os.WriteFile(path, []byte(`{"default_tier": "free", "tiers": {
+				"free": {"default": {"rate": 0.5, "burst": 3}, "daily_tickets": 100}}}`), 0600)).To(Succeed())
+
+			loaded, err := ratelimit.LoadPolicy(path)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(loaded.Tiers["free"].Default).To(Equal(ratelimit.Limit{Rate: 0.5, Burst: 3}))
+			Expect(loaded.Tiers["free"].DailyTickets).To(Equal(int64(100)))
+
+			Expect(os.WriteFile(path, []byte(`{"default_tier": "free", "tiers": {}}`), 0600)).To(Succeed())
+			_, err = ratelimit.LoadPolicy(path)
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Describe("Limiter", func() {
+		var limiter *ratelimit.Limiter
+
+		BeforeEach(func() {
+			limiter = ratelimit.NewLimiter(policy())
+		})
+
+		It("should allow a burst and then deny with a retry delay", func() {
+			first := limiter.Allow("key:a", "", "GET /api/v1/itineraries")
+			Expect(first.Allowed).To(BeTrue())
+			Expect(first.Limit).To(Equal(2))
+			Expect(first.Remaining).To(Equal(1))
+			Expect(limiter.Allow("key:a", "", "GET /api/v1/itineraries").Allowed).To(BeTrue())
+
+			denied := limiter.Allow("key:a", "", "GET /api/v1/itineraries")
+			Expect(denied.Allowed).To(BeFalse())
+			Expect(denied.Remaining).To(Equal(0))
+			Expect(denied.RetryAfter).To(BeNumerically("~", time.Second, 100*time.Millisecond))
+		})
+
+		It("should keep separate buckets per caller, route and tier", func() {
+			Expect(limiter.Allow("key:a", "", reconstruct).Allowed).To(BeTrue())
+			Expect(limiter.Allow("key:a", "", reconstruct).Allowed).To(BeFalse())
+
+			Expect(limiter.Allow("key:b", "", reconstruct).Allowed).To(BeTrue())
+			Expect(limiter.Allow("key:a", "", "GET /api/v1/itineraries").Allowed).To(BeTrue())
+			premium := limiter.Allow("key:a", "premium", reconstruct)
+			Expect(premium.Allowed).To(BeTrue())
+			Expect(premium.Limit).To(Equal(5))
+		})
+
+		It("should treat unknown tiers as the default tier", func() {
+			Expect(limiter.Allow("key:a", "gold", reconstruct).Limit).To(Equal(1))
+			Expect(limiter.Allow("ip:10.0.0.1", ratelimit.AnonymousTier, reconstruct).Limit).To(Equal(1))
+		})
+
+		It("should refill buckets over time", func() {
+			limiter.SetPolicy(ratelimit.Policy{
+				DefaultTier: "fast",
+				Tiers:       map[string]ratelimit.Tier{"fast": {Default: ratelimit.Limit{Rate: 20, Burst: 1}}},
+			})
+			Expect(limiter.Allow("key:a", "", reconstruct).Allowed).To(BeTrue())
+			Expect(limiter.Allow("key:a", "", reconstruct).Allowed).To(BeFalse())
+			Eventually(func() bool {
+				return limiter.Allow("key:a", "", reconstruct).Allowed
+			}).WithTimeout(time.Second).Should(BeTrue())
+		})
+
+		It("should enforce daily ticket quotas without charging denied requests", func() {
+			decision := limiter.UseTickets("key:a", "", 6)
+			Expect(decision.Allowed).To(BeTrue())
+			Expect(decision.Limit).To(Equal(int64(10)))
+			Expect(decision.Remaining).To(Equal(int64(4)))
+			Expect(decision.Reset).To(BeNumerically("<=", 24*time.Hour))
+
+			decision = limiter.UseTickets("key:a", "", 5)
+			Expect(decision.Allowed).To(BeFalse())
+			Expect(decision.Remaining).To(Equal(int64(4)))
+			Expect(limiter.UseTickets("key:a", "", 4).Allowed).To(BeTrue())
+			Expect(limiter.UseTickets("key:b", "", 10).Allowed).To(BeTrue())
+
+			unlimited := limiter.UseTickets("key:a", "premium", 1000)
+			Expect(unlimited.Allowed).To(BeTrue())
+			Expect(unlimited.Limit).To(BeZero())
+		})
+
+		It("should count a caller's tickets once across limiters sharing the usage", func() {
+			usage := ratelimit.NewTicketUsage()
+			first := ratelimit.NewSharedLimiter(policy(), usage)
+			second := ratelimit.NewSharedLimiter(policy(), usage)
+
+			Expect(first.UseTickets("key:a", "", 6).Allowed).To(BeTrue())
+			decision := second.UseTickets("key:a", "", 6)
+			Expect(decision.Allowed).To(BeFalse())
+			Expect(decision.Remaining).To(Equal(int64(4)))
+			Expect(limiter.UseTickets("key:a", "", 6).Allowed).To(BeTrue())
+		})
+	})
+})
@@ -0,0 +1,184 @@
+package render
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"strconv"
+
+	"github.com/vmihailenco/msgpack/v5"
+	"gopkg.in/yaml.v3"
+
+	"flight-itinerary-go/pkg/errors"
+)
+
+// legRecord is the per-leg representation used by the line-oriented formats
+type legRecord struct {
+	Sequence int    `json:"sequence"`
+	From     string `json:"from"`
+	To       string `json:"to"`
+}
+
+func legRecords(itinerary []string) []legRecord {
+	records := make([]legRecord, 0, len(itinerary))
+	for i := 1; i < len(itinerary); i++ {
+		records = append(records, legRecord{Sequence: i, From: itinerary[i-1], To: itinerary[i]})
+	}
+	return records
+}
+
+// JSONRenderer renders itineraries as a JSON array of airport codes
+type JSONRenderer struct{}
+
+// NewJSONRenderer creates a new JSON renderer
+func NewJSONRenderer() Renderer {
+	return &JSONRenderer{}
+}
+
+// ContentType returns the JSON media type
+func (jsonRenderer *JSONRenderer) ContentType() string {
+	return MediaTypeJSON
+}
+
+// Render encodes the itinerary
+func (jsonRenderer *JSONRenderer) Render(itinerary []string) ([]byte, error) {
+	return encodeJSON(itinerary)
+}
+
+// RenderError encodes the error
+func (jsonRenderer *JSONRenderer) RenderError(appErr *errors.AppError) ([]byte, error) {
+	return encodeJSON(appErr)
+}
+
+// NDJSONRenderer renders itineraries as newline-delimited JSON, one line per leg
+type NDJSONRenderer struct{}
+
+// NewNDJSONRenderer creates a new NDJSON renderer
+func NewNDJSONRenderer() Renderer {
+	return &NDJSONRenderer{}
+}
+
+// ContentType returns the NDJSON media type
+func (ndjsonRenderer *NDJSONRenderer) ContentType() string {
+	return MediaTypeNDJSON
+}
+
+// Render encodes one JSON object per leg
+func (ndjsonRenderer *NDJSONRenderer) Render(itinerary []string) ([]byte, error) {
+	var buffer bytes.Buffer
+	encoder := json.NewEncoder(&buffer)
+	for _, record := range legRecords(itinerary) {
+		if err := encoder.Encode(record); err != nil {
+			return nil, err
+		}
+	}
+	return buffer.Bytes(), nil
+}
+
+// RenderError encodes the error as a single line
+func (ndjsonRenderer *NDJSONRenderer) RenderError(appErr *errors.AppError) ([]byte, error) {
+	return encodeJSON(appErr)
+}
+
+// CSVRenderer renders itineraries as CSV, one row per leg
+type CSVRenderer struct{}
+
+// NewCSVRenderer creates a new CSV renderer
+func NewCSVRenderer() Renderer {
+	return &CSVRenderer{}
+}
+
+// ContentType returns the CSV media type
+func (csvRenderer *CSVRenderer) ContentType() string {
+	return MediaTypeCSV + charsetUTF8
+}
+
+// Render writes a header row followed by one row per leg
+func (csvRenderer *CSVRenderer) Render(itinerary []string) ([]byte, error) {
+	rows := [][]string{{"sequence", "from", "to"}}
+	for _, record := range legRecords(itinerary) {
+		rows = append(rows, []string{strconv.Itoa(record.Sequence), record.From, record.To})
+	}
+	return encodeCSV(rows)
+}
+
+// RenderError writes the error as a single row
+func (csvRenderer *CSVRenderer) RenderError(appErr *errors.AppError) ([]byte, error) {
+	return encodeCSV([][]string{
+		{"code", "type", "message"},
+		{strconv.Itoa(appErr.Code), appErr.Type, appErr.Message},
+	})
+}
+
+// YAMLRenderer renders itineraries as a YAML sequence
+type YAMLRenderer struct{}
+
+// NewYAMLRenderer creates a new YAML renderer
+func NewYAMLRenderer() Renderer {
+	return &YAMLRenderer{}
+}
+
+// ContentType returns the YAML media type
+func (yamlRenderer *YAMLRenderer) ContentType() string {
+	return MediaTypeYAML
+}
+
+// Render encodes the itinerary
+func (yamlRenderer *YAMLRenderer) Render(itinerary []string) ([]byte, error) {
+	return yaml.Marshal(itinerary)
+}
+
+// RenderError encodes the error
+func (yamlRenderer *YAMLRenderer) RenderError(appErr *errors.AppError) ([]byte, error) {
+	return yaml.Marshal(appErr)
+}
+
+// MessagePackRenderer renders itineraries as a MessagePack array
+type MessagePackRenderer struct{}
+
+// NewMessagePackRenderer creates a new MessagePack renderer
+func NewMessagePackRenderer() Renderer {
+	return &MessagePackRenderer{}
+}
+
+// ContentType returns the MessagePack media type
+func (messagePackRenderer *MessagePackRenderer) ContentType() string {
+	return MediaTypeMessagePack
+}
+
+// Render encodes the itinerary
+func (messagePackRenderer *MessagePackRenderer) Render(itinerary []string) ([]byte, error) {
+	return encodeMessagePack(itinerary)
+}
+
+// RenderError encodes the error using its JSON field names
+func (messagePackRenderer *MessagePackRenderer) RenderError(appErr *errors.AppError) ([]byte, error) {
+	return encodeMessagePack(appErr)
+}
+
+func encodeJSON(value interface{}) ([]byte, error) {
+	var buffer bytes.Buffer
+	if err := json.NewEncoder(&buffer).Encode(value); err != nil {
+		return nil, err
+	}
+	return buffer.Bytes(), nil
+}
+
+func encodeCSV(rows [][]string) ([]byte, error) {
+	var buffer bytes.Buffer
+	writer := csv.NewWriter(&buffer)
+	if err := writer.WriteAll(rows); err != nil {
+		return nil, err
+	}
+	return buffer.Bytes(), nil
+}
+
+func encodeMessagePack(value interface{}) ([]byte, error) {
+	var buffer bytes.Buffer
+	encoder := msgpack.NewEncoder(&buffer)
+	encoder.SetCustomStructTag("json")
+	if err := encoder.Encode(value); err != nil {
+		return nil, err
+	}
+	return buffer.Bytes(), nil
+}
@@ -0,0 +1,114 @@
+package render
+
+import (
+	"encoding/json"
+	"math"
+
+	"flight-itinerary-go/internal/airport"
+	"flight-itinerary-go/internal/geo"
+)
+
+// GeoJSONRenderer renders itineraries as a GeoJSON FeatureCollection
+type GeoJSONRenderer struct {
+	airports airport.Directory
+}
+
+// NewGeoJSONRenderer creates a new GeoJSON renderer
+func NewGeoJSONRenderer(airports airport.Directory) Renderer {
+	return &GeoJSONRenderer{
+		airports: airports,
+	}
+}
+
+type featureCollection struct {
+	Type     string    `json:"type"`
+	Features []feature `json:"features"`
+}
+
+type feature struct {
+	Type       string                 `json:"type"`
+	Geometry   *geometry              `json:"geometry"`
+	Properties map[string]interface{} `json:"properties"`
+}
+
+type geometry struct {
+	Type        string      `json:"type"`
+	Coordinates interface{} `json:"coordinates"`
+}
+
+// ContentType returns the GeoJSON media type
+func (geoJSONRenderer *GeoJSONRenderer) ContentType() string {
+	return MediaTypeGeoJSON
+}
+
+// Render emits one Point feature per stop and one LineString (or MultiLineString when the
+// leg crosses the antimeridian) per leg. Unknown airports and their legs get a null geometry.
+func (geoJSONRenderer *GeoJSONRenderer) Render(itinerary []string) ([]byte, error) {
+	route := NewRoute(itinerary, geoJSONRenderer.airports)
+
+	collection := featureCollection{
+		Type:     "FeatureCollection",
+		Features: make([]feature, 0, len(route.Stops)+len(route.Legs)),
+	}
+
+	for i, stop := range route.Stops {
+		point := feature{
+			Type: "Feature",
+			Properties: map[string]interface{}{
+				"kind":     "airport",
+				"sequence": i,
+				"code":     stop.Code,
+			},
+		}
+		if stop.Airport != nil {
+			point.Geometry = &geometry{
+				Type:        "Point",
+				Coordinates: position(stop.Airport.Coordinate()),
+			}
+			point.Properties["name"] = stop.Airport.Name
+			point.Properties["city"] = stop.Airport.City
+			point.Properties["country"] = stop.Airport.Country
+		}
+		collection.Features = append(collection.Features, point)
+	}
+
+	for _, leg := range route.Legs {
+		line := feature{
+			Type: "Feature",
+			Properties: map[string]interface{}{
+				"kind":     "leg",
+				"sequence": leg.Sequence,
+				"from":     leg.From.Code,
+				"to":       leg.To.Code,
+			},
+		}
+		if leg.Located() {
+			line.Geometry = legGeometry(leg)
+			line.Properties["distance_km"] = math.Round(leg.DistanceKm*10) / 10
+		}
+		collection.Features = append(collection.Features, line)
+	}
+
+	return json.Marshal(collection)
+}
+
+func legGeometry(leg Leg) *geometry {
+	lines := make([][][2]float64, 0, len(leg.Paths))
+	for _, path := range leg.Paths {
+		line := make([][2]float64, 0, len(path))
+		for _, point := range path {
+			line = append(line, position(point))
+		}
+		lines = append(lines, line)
+	}
+
+	if len(lines) == 1 {
+		return &geometry{Type: "LineString", Coordinates: lines[0]}
+	}
+	return &geometry{Type: "MultiLineString", Coordinates: lines}
+}
+
+// position returns a GeoJSON position, which is ordered longitude first
+func position(coordinate geo.Coordinate) [2]float64 {
+	return [2]float64{coordinate.Longitude, coordinate.Latitude}
+}
@@ -0,0 +1,187 @@
+package render
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"flight-itinerary-go/internal/graph"
+)
+
+// Media types produced by the graph renderers
+const (
+	MediaTypeDOT     = "text/vnd.graphviz"
+	MediaTypeMermaid = "text/vnd.mermaid"
+)
+
+// Edge colours shared by the graph renderers
+const (
+	colorPath      = "#1f77b4"
+	colorDuplicate = "#d62728"
+	colorCycle     = "#ff7f0e"
+	colorDefault   = "#7f7f7f"
+)
+
+// GraphRenderer defines the interface for ticket graph representations
+type GraphRenderer interface {
+	ContentType() string
+	Render(ticketGraph *graph.TicketGraph) ([]byte, error)
+}
+
+// DOTRenderer renders ticket graphs in the Graphviz DOT language
+type DOTRenderer struct{}
+
+// NewDOTRenderer creates a new DOT renderer
+func NewDOTRenderer() GraphRenderer {
+	return &DOTRenderer{}
+}
+
+// ContentType returns the Graphviz media type
+func (dotRenderer *DOTRenderer) ContentType() string {
+	return MediaTypeDOT + charsetUTF8
+}
+
+// Render emits a digraph with one cluster per fragment when the graph is disconnected
+func (dotRenderer *DOTRenderer) Render(ticketGraph *graph.TicketGraph) ([]byte, error) {
+	var builder strings.Builder
+	builder.WriteString("digraph itinerary {\n")
+	builder.WriteString("  rankdir=LR;\n")
+	builder.WriteString("  node [shape=box, style=rounded];\n")
+	fmt.Fprintf(&builder, "  label=%s;\n", strconv.Quote(graphLabel(ticketGraph)))
+
+	for fragment, codes := range fragmentNodes(ticketGraph) {
+		indent := "  "
+		if ticketGraph.Fragments > 1 {
+			fmt.Fprintf(&builder, "  subgraph cluster_%d {\n", fragment)
+			fmt.Fprintf(&builder, "    label=\"fragment %d\";\n", fragment+1)
+			indent = "    "
+		}
+		for _, code := range codes {
+			fmt.Fprintf(&builder, "%s%s;\n", indent, strconv.Quote(code))
+		}
+		if ticketGraph.Fragments > 1 {
+			builder.WriteString("  }\n")
+		}
+	}
+
+	for _, edge := range ticketGraph.Edges {
+		attributes := []string{
+			fmt.Sprintf("label=%s", strconv.Quote(edgeLabel(edge))),
+			fmt.Sprintf("color=%s", strconv.Quote(edgeColor(edge))),
+		}
+		if edge.OnPath {
+			attributes = append(attributes, "penwidth=3")
+		}
+		if edge.Duplicate {
+			attributes = append(attributes, "style=dashed")
+		}
+		fmt.Fprintf(&builder, "  %s -> %s [%s];\n", strconv.Quote(edge.Source), strconv.Quote(edge.Destination),
+			strings.Join(attributes, ", "))
+	}
+
+	builder.WriteString("}\n")
+	return []byte(builder.String()), nil
+}
+
+// MermaidRenderer renders ticket graphs as Mermaid flowcharts
+type MermaidRenderer struct{}
+
+// NewMermaidRenderer creates a new Mermaid renderer
+func NewMermaidRenderer() GraphRenderer {
+	return &MermaidRenderer{}
+}
+
+// ContentType returns the Mermaid media type
+func (mermaidRenderer *MermaidRenderer) ContentType() string {
+	return MediaTypeMermaid + charsetUTF8
+}
+
+// Render emits a left-to-right flowchart with one subgraph per fragment when the graph is
+// disconnected. Node identifiers are generated because airport codes are free-form input.
+func (mermaidRenderer *MermaidRenderer) Render(ticketGraph *graph.TicketGraph) ([]byte, error) {
+	nodeIDs := make(map[string]string, len(ticketGraph.Nodes))
+	for i, node := range ticketGraph.Nodes {
+		nodeIDs[node.Code] = fmt.Sprintf("n%d", i)
+	}
+
+	var builder strings.Builder
+	fmt.Fprintf(&builder, "---\ntitle: %s\n---\n", mermaidText(graphLabel(ticketGraph)))
+	builder.WriteString("flowchart LR\n")
+
+	for fragment, codes := range fragmentNodes(ticketGraph) {
+		indent := "  "
+		if ticketGraph.Fragments > 1 {
+			fmt.Fprintf(&builder, "  subgraph fragment%d [\"fragment %d\"]\n", fragment, fragment+1)
+			indent = "    "
+		}
+		for _, code := range codes {
+			fmt.Fprintf(&builder, "%s%s[\"%s\"]\n", indent, nodeIDs[code], mermaidText(code))
+		}
+		if ticketGraph.Fragments > 1 {
+			builder.WriteString("  end\n")
+		}
+	}
+
+	for _, edge := range ticketGraph.Edges {
+		arrow := "-->"
+		if edge.Duplicate {
+			arrow = "-.->"
+		} else if edge.OnPath {
+			arrow = "==>"
+		}
+		fmt.Fprintf(&builder, "  %s %s|\"%s\"| %s\n", nodeIDs[edge.Source], arrow, mermaidText(edgeLabel(edge)),
+			nodeIDs[edge.Destination])
+	}
+
+	// Edges are numbered in declaration order, matching the ticket order
+	for i, edge := range ticketGraph.Edges {
+		fmt.Fprintf(&builder, "  linkStyle %d stroke:%s\n", i, edgeColor(edge))
+	}
+
+	return []byte(builder.String()), nil
+}
+
+func graphLabel(ticketGraph *graph.TicketGraph) string {
+	if ticketGraph.Outcome == "" {
+		return "itinerary reconstructed"
+	}
+	return "reconstruction failed: " + ticketGraph.Outcome
+}
+
+func edgeLabel(edge graph.Edge) string {
+	label := fmt.Sprintf("#%d", edge.Index)
+	if edge.Duplicate {
+		label += " duplicate"
+	}
+	if edge.InCycle {
+		label += " cycle"
+	}
+	return label
+}
+
+func edgeColor(edge graph.Edge) string {
+	switch {
+	case edge.Duplicate:
+		return colorDuplicate
+	case edge.InCycle:
+		return colorCycle
+	case edge.OnPath:
+		return colorPath
+	default:
+		return colorDefault
+	}
+}
+
+// fragmentNodes groups node codes by fragment, preserving first-seen order
+func fragmentNodes(ticketGraph *graph.TicketGraph) [][]string {
+	fragments := make([][]string, ticketGraph.Fragments)
+	for _, node := range ticketGraph.Nodes {
+		fragments[node.Fragment] = append(fragments[node.Fragment], node.Code)
+	}
+	return fragments
+}
+
+// mermaidText escapes characters that would terminate a quoted Mermaid label
+func mermaidText(text string) string {
+	return strings.ReplaceAll(text, "\"", "#quot;")
+}
@@ -0,0 +1,112 @@
+package render
+
+import (
+	"encoding/xml"
+	"fmt"
+	"strings"
+
+	"flight-itinerary-go/internal/airport"
+	"flight-itinerary-go/internal/geo"
+)
+
+// KMLRenderer renders itineraries as a KML document
+type KMLRenderer struct {
+	airports airport.Directory
+}
+
+// NewKMLRenderer creates a new KML renderer
+func NewKMLRenderer(airports airport.Directory) Renderer {
+	return &KMLRenderer{
+		airports: airports,
+	}
+}
+
+type kmlDocument struct {
+	XMLName  xml.Name `xml:"kml"`
+	Xmlns    string   `xml:"xmlns,attr"`
+	Document kmlFolder
+}
+
+type kmlFolder struct {
+	Name       string         `xml:"name"`
+	Placemarks []kmlPlacemark `xml:"Placemark"`
+}
+
+type kmlPlacemark struct {
+	Name          string            `xml:"name"`
+	Description   string            `xml:"description,omitempty"`
+	Point         *kmlPoint         `xml:"Point,omitempty"`
+	MultiGeometry *kmlMultiGeometry `xml:"MultiGeometry,omitempty"`
+}
+
+type kmlPoint struct {
+	Coordinates string `xml:"coordinates"`
+}
+
+type kmlMultiGeometry struct {
+	LineStrings []kmlLineString `xml:"LineString"`
+}
+
+type kmlLineString struct {
+	Tessellate  int    `xml:"tessellate"`
+	Coordinates string `xml:"coordinates"`
+}
+
+// ContentType returns the KML media type
+func (kmlRenderer *KMLRenderer) ContentType() string {
+	return MediaTypeKML
+}
+
+// Render emits one Placemark per stop and one Placemark per leg. Placemarks of unknown airports
+// and their legs have no geometry.
+func (kmlRenderer *KMLRenderer) Render(itinerary []string) ([]byte, error) {
+	route := NewRoute(itinerary, kmlRenderer.airports)
+
+	document := kmlDocument{
+		Xmlns: "http://www.opengis.net/kml/2.2",
+		Document: kmlFolder{
+			Name:       strings.Join(itinerary, " - "),
+			Placemarks: make([]kmlPlacemark, 0, len(route.Stops)+len(route.Legs)),
+		},
+	}
+
+	for _, stop := range route.Stops {
+		placemark := kmlPlacemark{Name: stop.Code, Description: "unknown airport"}
+		if stop.Airport != nil {
+			placemark.Description = stop.Airport.Name
+			placemark.Point = &kmlPoint{Coordinates: kmlCoordinates([]geo.Coordinate{stop.Airport.Coordinate()})}
+		}
+		document.Document.Placemarks = append(document.Document.Placemarks, placemark)
+	}
+
+	for _, leg := range route.Legs {
+		placemark := kmlPlacemark{
+			Name:        fmt.Sprintf("Leg %d: %s - %s", leg.Sequence, leg.From.Code, leg.To.Code),
+			Description: "distance unknown",
+		}
+		if leg.Located() {
+			lines := make([]kmlLineString, 0, len(leg.Paths))
+			for _, path := range leg.Paths {
+				lines = append(lines, kmlLineString{Tessellate: 1, Coordinates: kmlCoordinates(path)})
+			}
+			placemark.Description = fmt.Sprintf("%.0f km", leg.DistanceKm)
+			placemark.MultiGeometry = &kmlMultiGeometry{LineStrings: lines}
+		}
+		document.Document.Placemarks = append(document.Document.Placemarks, placemark)
+	}
+
+	body, err := xml.MarshalIndent(document, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), body...), nil
+}
+
+// kmlCoordinates formats coordinates as KML lon,lat tuples
+func kmlCoordinates(path []geo.Coordinate) string {
+	tuples := make([]string, 0, len(path))
+	for _, point := range path {
+		tuples = append(tuples, fmt.Sprintf("%.6f,%.6f", point.Longitude, point.Latitude))
+	}
+	return strings.Join(tuples, " ")
+}
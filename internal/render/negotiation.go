@@ -0,0 +1,30 @@
+package render
+
+import (
+	"github.com/labstack/echo/v4"
+
+	"flight-itinerary-go/pkg/errors"
+)
+
+// ContextKey is the echo context key holding the negotiated renderer
+const ContextKey = "renderer"
+
+// FromContext returns the negotiated renderer, or the fallback when negotiation did not run
+func FromContext[R MediaTyped](ctx echo.Context, fallback R) R {
+	if renderer, ok := ctx.Get(ContextKey).(R); ok {
+		return renderer
+	}
+	return fallback
+}
+
+// WriteError writes the error in the negotiated format when the renderer supports error
+// bodies, and as JSON otherwise
+func WriteError(ctx echo.Context, appErr *errors.AppError) error {
+	if renderer, ok := ctx.Get(ContextKey).(ErrorRenderer); ok {
+		body, err := renderer.RenderError(appErr)
+		if err == nil {
+			return ctx.Blob(appErr.Code, renderer.(MediaTyped).ContentType(), body)
+		}
+	}
+	return ctx.JSON(appErr.Code, appErr)
+}
@@ -0,0 +1,86 @@
+package render
+
+import (
+	"math"
+
+	"flight-itinerary-go/internal/geo"
+)
+
+// Projection defines the interface for map projections used by the SVG renderer
+type Projection interface {
+	Name() string
+	// Project maps a coordinate to plane coordinates centred on (0, 0), with y growing north
+	Project(coordinate geo.Coordinate) (x, y float64)
+	// Extent returns the maximum absolute x and y values produced by Project
+	Extent() (x, y float64)
+}
+
+// EquirectangularProjection maps longitude and latitude linearly to x and y
+type EquirectangularProjection struct{}
+
+// NewEquirectangularProjection creates a new equirectangular projection
+func NewEquirectangularProjection() Projection {
+	return &EquirectangularProjection{}
+}
+
+// Name returns the projection name
+func (equirectangular *EquirectangularProjection) Name() string {
+	return "equirectangular"
+}
+
+// Project maps a coordinate onto the plane
+func (equirectangular *EquirectangularProjection) Project(coordinate geo.Coordinate) (float64, float64) {
+	return coordinate.Longitude * math.Pi / 180, coordinate.Latitude * math.Pi / 180
+}
+
+// Extent returns the projected bounds
+func (equirectangular *EquirectangularProjection) Extent() (float64, float64) {
+	return math.Pi, math.Pi / 2
+}
+
+// robinsonTable holds the Robinson parallel length and distance from the equator at 5° intervals
+var robinsonTable = [][2]float64{
+	{1.0000, 0.0000}, {0.9986, 0.0620}, {0.9954, 0.1240}, {0.9900, 0.1860},
+	{0.9822, 0.2480}, {0.9730, 0.3100}, {0.9600, 0.3720}, {0.9427, 0.4340},
+	{0.9216, 0.4958}, {0.8962, 0.5571}, {0.8679, 0.6176}, {0.8350, 0.6769},
+	{0.7986, 0.7346}, {0.7597, 0.7903}, {0.7186, 0.8435}, {0.6732, 0.8936},
+	{0.6213, 0.9394}, {0.5722, 0.9761}, {0.5322, 1.0000},
+}
+
+// RobinsonProjection implements the Robinson compromise projection by table interpolation
+type RobinsonProjection struct{}
+
+// NewRobinsonProjection creates a new Robinson projection
+func NewRobinsonProjection() Projection {
+	return &RobinsonProjection{}
+}
+
+// Name returns the projection name
+func (robinson *RobinsonProjection) Name() string {
+	return "robinson"
+}
+
+// Project maps a coordinate onto the plane
+func (robinson *RobinsonProjection) Project(coordinate geo.Coordinate) (float64, float64) {
+	latitude := math.Min(math.Abs(coordinate.Latitude), 90)
+	index := int(latitude / 5)
+	if index >= len(robinsonTable)-1 {
+		index = len(robinsonTable) - 2
+	}
+	fraction := (latitude - float64(index)*5) / 5
+	lower, upper := robinsonTable[index], robinsonTable[index+1]
+	length := lower[0] + fraction*(upper[0]-lower[0])
+	distance := lower[1] + fraction*(upper[1]-lower[1])
+
+	x := 0.8487 * length * coordinate.Longitude * math.Pi / 180
+	y := 1.3523 * distance
+	if coordinate.Latitude < 0 {
+		y = -y
+	}
+	return x, y
+}
+
+// Extent returns the projected bounds
+func (robinson *RobinsonProjection) Extent() (float64, float64) {
+	return 0.8487 * math.Pi, 1.3523
+}
@@ -0,0 +1,143 @@
+package render
+
+import (
+	"mime"
+	"sort"
+	"strconv"
+	"strings"
+
+	"flight-itinerary-go/pkg/errors"
+)
+
+// MediaTyped is implemented by every renderer that can be registered
+type MediaTyped interface {
+	ContentType() string
+}
+
+// mediaTypeOf returns a content type without parameters such as charset
+func mediaTypeOf(contentType string) string {
+	mediaType, _, _ := strings.Cut(contentType, ";")
+	return strings.TrimSpace(mediaType)
+}
+
+// Registry holds renderers keyed by media type and format name, and negotiates between them
+type Registry[R MediaTyped] struct {
+	renderers   []R
+	byMediaType map[string]R
+	byFormat    map[string]R
+}
+
+// NewRegistry creates an empty registry. The first registered renderer is the default.
+func NewRegistry[R MediaTyped]() *Registry[R] {
+	return &Registry[R]{
+		byMediaType: make(map[string]R),
+		byFormat:    make(map[string]R),
+	}
+}
+
+// Register adds a renderer under its media type and the given format names
+func (registry *Registry[R]) Register(renderer R, formats ...string) {
+	registry.renderers = append(registry.renderers, renderer)
+	registry.byMediaType[mediaTypeOf(renderer.ContentType())] = renderer
+	for _, format := range formats {
+		registry.byFormat[strings.ToLower(format)] = renderer
+	}
+}
+
+// MediaTypes returns the registered media types in registration order
+func (registry *Registry[R]) MediaTypes() []string {
+	mediaTypes := make([]string, 0, len(registry.renderers))
+	for _, renderer := range registry.renderers {
+		mediaTypes = append(mediaTypes, mediaTypeOf(renderer.ContentType()))
+	}
+	return mediaTypes
+}
+
+// Default returns the renderer used when the client expresses no preference
+func (registry *Registry[R]) Default() R {
+	return registry.renderers[0]
+}
+
+// Negotiate selects a renderer from the format query parameter, which takes precedence, or
+// from the Accept header
+func (registry *Registry[R]) Negotiate(accept, format string) (R, error) {
+	var none R
+	if format != "" {
+		renderer, exists := registry.byFormat[strings.ToLower(format)]
+		if !exists {
+			return none, errors.NewNotAcceptableError("unsupported format %q", format)
+		}
+		return renderer, nil
+	}
+
+	if strings.TrimSpace(accept) == "" {
+		return registry.Default(), nil
+	}
+
+	for _, mediaRange := range parseAccept(accept) {
+		if renderer, exists := registry.match(mediaRange.mediaType); exists {
+			return renderer, nil
+		}
+	}
+	return none, errors.NewNotAcceptableError("none of the accepted media types are supported, available: %s",
+		strings.Join(registry.MediaTypes(), ", "))
+}
+
+func (registry *Registry[R]) match(mediaType string) (R, bool) {
+	if mediaType == "*/*" {
+		return registry.Default(), true
+	}
+	if prefix, found := strings.CutSuffix(mediaType, "/*"); found {
+		for _, renderer := range registry.renderers {
+			if strings.HasPrefix(renderer.ContentType(), prefix+"/") {
+				return renderer, true
+			}
+		}
+		var none R
+		return none, false
+	}
+	renderer, exists := registry.byMediaType[mediaType]
+	return renderer, exists
+}
+
+type mediaRange struct {
+	mediaType   string
+	quality     float64
+	specificity int
+}
+
+// parseAccept returns the acceptable media ranges ordered by quality, then specificity
+func parseAccept(accept string) []mediaRange {
+	ranges := []mediaRange{}
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		quality := 1.0
+		if value, exists := params["q"]; exists {
+			if parsed, err := strconv.ParseFloat(value, 64); err == nil {
+				quality = parsed
+			}
+		}
+		if quality <= 0 {
+			continue
+		}
+
+		specificity := 2
+		if mediaType == "*/*" {
+			specificity = 0
+		} else if strings.HasSuffix(mediaType, "/*") {
+			specificity = 1
+		}
+		ranges = append(ranges, mediaRange{mediaType: mediaType, quality: quality, specificity: specificity})
+	}
+
+	sort.SliceStable(ranges, func(i, j int) bool {
+		if ranges[i].quality != ranges[j].quality {
+			return ranges[i].quality > ranges[j].quality
+		}
+		return ranges[i].specificity > ranges[j].specificity
+	})
+	return ranges
+}
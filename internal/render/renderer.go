@@ -0,0 +1,119 @@
+package render
+
+import (
+	"flight-itinerary-go/internal/airport"
+	"flight-itinerary-go/internal/geo"
+	"flight-itinerary-go/pkg/errors"
+)
+
+// Media types produced by the itinerary renderers
+const (
+	MediaTypeJSON        = "application/json"
+	MediaTypeNDJSON      = "application/x-ndjson"
+	MediaTypeCSV         = "text/csv"
+	MediaTypeText        = "text/plain"
+	MediaTypeMarkdown    = "text/markdown"
+	MediaTypeYAML        = "application/yaml"
+	MediaTypeMessagePack = "application/msgpack"
+	MediaTypeGeoJSON     = "application/geo+json"
+	MediaTypeKML         = "application/vnd.google-earth.kml+xml"
+)
+
+// charsetUTF8 is appended to text media types, whose bodies may hold characters such as →
+const charsetUTF8 = "; charset=utf-8"
+
+// maxSegmentKm bounds the length of a single line segment when densifying great-circle arcs
+const maxSegmentKm = 100
+
+// Renderer defines the interface for alternative itinerary representations
+type Renderer interface {
+	ContentType() string
+	Render(itinerary []string) ([]byte, error)
+}
+
+// ErrorRenderer is implemented by renderers that can also represent error bodies
+type ErrorRenderer interface {
+	RenderError(appErr *errors.AppError) ([]byte, error)
+}
+
+// NewItineraryRegistry creates a registry with every itinerary renderer, defaulting to JSON
+func NewItineraryRegistry(airports airport.Directory) *Registry[Renderer] {
+	registry := NewRegistry[Renderer]()
+	registry.Register(NewJSONRenderer(), "json")
+	registry.Register(NewNDJSONRenderer(), "ndjson")
+	registry.Register(NewCSVRenderer(), "csv")
+	registry.Register(NewTextRenderer(airports), "text", "txt")
+	registry.Register(NewMarkdownRenderer(airports), "markdown", "md")
+	registry.Register(NewYAMLRenderer(), "yaml", "yml")
+	registry.Register(NewMessagePackRenderer(), "msgpack")
+	registry.Register(NewGeoJSONRenderer(airports), "geojson")
+	registry.Register(NewKMLRenderer(airports), "kml")
+	registry.Register(NewSVGRenderer(airports, NewRobinsonProjection()), "svg")
+	return registry
+}
+
+// NewGraphRegistry creates a registry with every ticket graph renderer, defaulting to DOT
+func NewGraphRegistry() *Registry[GraphRenderer] {
+	registry := NewRegistry[GraphRenderer]()
+	registry.Register(NewDOTRenderer(), "dot")
+	registry.Register(NewMermaidRenderer(), "mermaid")
+	return registry
+}
+
+// Stop represents a stop of the route. Airport is nil when no coordinates are known for the code.
+type Stop struct {
+	Code    string
+	Airport *airport.Airport
+}
+
+// Leg represents a single flight between two consecutive stops. Distance and paths are only set
+// when both airports are known.
+type Leg struct {
+	Sequence   int
+	From       Stop
+	To         Stop
+	DistanceKm float64
+	// Paths holds the densified great-circle arc, split at the antimeridian
+	Paths [][]geo.Coordinate
+}
+
+// Located reports whether both airports of the leg are known
+func (leg Leg) Located() bool {
+	return leg.From.Airport != nil && leg.To.Airport != nil
+}
+
+// Route represents an itinerary resolved against airport reference data
+type Route struct {
+	Stops []Stop
+	Legs  []Leg
+}
+
+// NewRoute resolves every stop of the itinerary and builds the great-circle legs between them.
+// Stops missing from the directory are kept without coordinates, like enrichment does, so maps
+// still list every stop.
+func NewRoute(itinerary []string, airports airport.Directory) *Route {
+	stops := make([]Stop, 0, len(itinerary))
+	for _, code := range itinerary {
+		stop := Stop{Code: code}
+		if found, exists := airports.Lookup(code); exists {
+			stop.Airport = &found
+		}
+		stops = append(stops, stop)
+	}
+
+	legs := make([]Leg, 0, len(stops))
+	for i := 1; i < len(stops); i++ {
+		leg := Leg{Sequence: i, From: stops[i-1], To: stops[i]}
+		if leg.Located() {
+			from, to := leg.From.Airport.Coordinate(), leg.To.Airport.Coordinate()
+			leg.DistanceKm = geo.Distance(from, to)
+			leg.Paths = geo.SplitAntimeridian(geo.GreatCircle(from, to, maxSegmentKm))
+		}
+		legs = append(legs, leg)
+	}
+
+	return &Route{
+		Stops: stops,
+		Legs:  legs,
+	}
+}
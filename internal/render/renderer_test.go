@@ -0,0 +1,339 @@
+package render_test
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"strings"
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"flight-itinerary-go/internal/airport"
+	"flight-itinerary-go/internal/geo"
+	"flight-itinerary-go/internal/graph"
+	"flight-itinerary-go/internal/model"
+	"flight-itinerary-go/internal/render"
+	"flight-itinerary-go/pkg/errors"
+)
+
+func TestRender(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Render Suite")
+}
+
+type geoJSONDocument struct {
+	Type     string `json:"type"`
+	Features []struct {
+		Geometry struct {
+			Type string `json:"type"`
+		} `json:"geometry"`
+		Properties map[string]interface{} `json:"properties"`
+	} `json:"features"`
+}
+
+var _ = Describe("Renderers", func() {
+	var airports airport.Directory
+
+	BeforeEach(func() {
+		airports = airport.NewDirectory()
+	})
+
+	Describe("GeoJSONRenderer", func() {
+		It("should emit airport points and leg lines", func() {
+			body, err := render.NewGeoJSONRenderer(airports).Render([]string{"JFK", "LAX", "DXB"})
+			Expect(err).Should(BeNil())
+
+			var document geoJSONDocument
+			Expect(json.Unmarshal(body, &document)).To(Succeed())
+			Expect(document.Type).To(Equal("FeatureCollection"))
+			Expect(document.Features).To(HaveLen(5))
+			Expect(document.Features[0].Geometry.Type).To(Equal("Point"))
+			Expect(document.Features[0].Properties["code"]).To(Equal("JFK"))
+			Expect(document.Features[3].Geometry.Type).To(Equal("LineString"))
+			Expect(document.Features[3].Properties["from"]).To(Equal("JFK"))
+			Expect(document.Features[3].Properties["to"]).To(Equal("LAX"))
+		})
+
+		It("should emit a MultiLineString for legs crossing the antimeridian", func() {
+			body, err := render.NewGeoJSONRenderer(airports).Render([]string{"NRT", "HNL"})
+			Expect(err).Should(BeNil())
+
+			var document geoJSONDocument
+			Expect(json.Unmarshal(body, &document)).To(Succeed())
+			Expect(document.Features[2].Geometry.Type).To(Equal("MultiLineString"))
+		})
+
+		It("should emit unknown airports and their legs without geometry", func() {
+			body, err := render.NewGeoJSONRenderer(airports).Render([]string{"JFK", "XXX", "LAX"})
+			Expect(err).Should(BeNil())
+
+			var document struct {
+				Features []struct {
+					Geometry   *json.RawMessage       `json:"geometry"`
+					Properties map[string]interface{} `json:"properties"`
+				} `json:"features"`
+			}
+			Expect(json.Unmarshal(body, &document)).To(Succeed())
+			Expect(document.Features).To(HaveLen(5))
+			Expect(document.Features[0].Geometry).ToNot(BeNil())
+			Expect(document.Features[1].Geometry).To(BeNil())
+			Expect(document.Features[1].Properties).To(Equal(map[string]interface{}{
+				"kind": "airport", "sequence": float64(1), "code": "XXX",
+			}))
+			Expect(document.Features[3].Geometry).To(BeNil())
+			Expect(document.Features[3].Properties).ToNot(HaveKey("distance_km"))
+			Expect(document.Features[4].Properties["from"]).To(Equal("XXX"))
+		})
+	})
+
+	Describe("KMLRenderer", func() {
+		It("should emit well-formed KML with a placemark per stop and leg", func() {
+			body, err := render.NewKMLRenderer(airports).Render([]string{"JFK", "LAX", "DXB"})
+			Expect(err).Should(BeNil())
+
+			var document struct {
+				Placemarks []struct {
+					Name string `xml:"name"`
+				} `xml:"Document>Placemark"`
+			}
+			Expect(xml.Unmarshal(body, &document)).To(Succeed())
+			Expect(document.Placemarks).To(HaveLen(5))
+			Expect(document.Placemarks[3].Name).To(Equal("Leg 1: JFK - LAX"))
+		})
+
+		It("should emit unknown airports and their legs without geometry", func() {
+			body, err := render.NewKMLRenderer(airports).Render([]string{"JFK", "XXX"})
+			Expect(err).Should(BeNil())
+
+			var document struct {
+				Placemarks []struct {
+					Name          string    `xml:"name"`
+					Description   string    `xml:"description"`
+					Point         *struct{} `xml:"Point"`
+					MultiGeometry *struct{} `xml:"MultiGeometry"`
+				} `xml:"Document>Placemark"`
+			}
+			Expect(xml.Unmarshal(body, &document)).To(Succeed())
+			Expect(document.Placemarks).To(HaveLen(3))
+			Expect(document.Placemarks[0].Point).ToNot(BeNil())
+			Expect(document.Placemarks[1].Name).To(Equal("XXX"))
+			Expect(document.Placemarks[1].Point).To(BeNil())
+			Expect(document.Placemarks[2].Description).To(Equal("distance unknown"))
+			Expect(document.Placemarks[2].MultiGeometry).To(BeNil())
+		})
+	})
+
+	Describe("Graph renderers", func() {
+		var ticketGraph *graph.TicketGraph
+
+		BeforeEach(func() {
+			ticketGraph = graph.NewTicketGraph([]model.Ticket{
+				{"JFK", "LAX"},
+				{"JFK", "SFO"},
+				{"DXB", "SIN"},
+			})
+			ticketGraph.Outcome = "duplicate route from JFK"
+		})
+
+		It("should render DOT with fragments as clusters", func() {
+			body, err := render.NewDOTRenderer().Render(ticketGraph)
+			Expect(err).Should(BeNil())
+
+			dot := string(body)
+			Expect(dot).To(HavePrefix("digraph itinerary {"))
+			Expect(dot).To(ContainSubstring(`label="reconstruction failed: duplicate route from JFK"`))
+			Expect(dot).To(ContainSubstring("subgraph cluster_1"))
+			Expect(dot).To(ContainSubstring(`"JFK" -> "SFO" [label="#1 duplicate", color="#d62728", style=dashed];`))
+		})
+
+		It("should render Mermaid with generated node identifiers", func() {
+			ticketGraph.Outcome = ""
+			ticketGraph.MarkPath([]string{"JFK", "LAX"})
+
+			body, err := render.NewMermaidRenderer().Render(ticketGraph)
+			Expect(err).Should(BeNil())
+
+			mermaid := string(body)
+			Expect(mermaid).To(ContainSubstring("flowchart LR"))
+			Expect(mermaid).To(ContainSubstring(`n0["JFK"]`))
+			Expect(mermaid).To(ContainSubstring(`n0 ==>|"#0"| n1`))
+			Expect(mermaid).To(ContainSubstring(`n0 -.->|"#1 duplicate"| n2`))
+			Expect(strings.Count(mermaid, "linkStyle")).To(Equal(3))
+		})
+	})
+
+	Describe("SVGRenderer", func() {
+		It("should render a well-formed map with numbered legs and labelled airports", func() {
+			body, err := render.NewSVGRenderer(airports, render.NewRobinsonProjection()).
+				Render([]string{"JFK", "LHR", "DXB"})
+			Expect(err).Should(BeNil())
+
+			var document struct {
+				Width string `xml:"width,attr"`
+				Texts []struct {
+					Value string `xml:",chardata"`
+				} `xml:"g>text"`
+			}
+			Expect(xml.Unmarshal(body, &document)).To(Succeed())
+			Expect(document.Width).To(Equal("1024"))
+
+			labels := []string{}
+			for _, text := range document.Texts {
+				labels = append(labels, text.Value)
+			}
+			Expect(labels).To(ContainElements("1", "2", "JFK", "LHR", "DXB"))
+		})
+
+		It("should leave unknown airports and their legs off the map", func() {
+			body, err := render.NewSVGRenderer(airports, render.NewEquirectangularProjection()).
+				Render([]string{"JFK", "XXX", "LHR", "DXB"})
+			Expect(err).Should(BeNil())
+
+			var document struct {
+				Texts []struct {
+					Value string `xml:",chardata"`
+				} `xml:"g>text"`
+			}
+			Expect(xml.Unmarshal(body, &document)).To(Succeed())
+			labels := []string{}
+			for _, text := range document.Texts {
+				labels = append(labels, text.Value)
+			}
+			Expect(labels).To(ConsistOf("3", "JFK", "LHR", "DXB"))
+		})
+	})
+
+	Describe("Projections", func() {
+		It("should map the corners of the world onto the projection extent", func() {
+			for _, projection := range []render.Projection{
+				render.NewEquirectangularProjection(),
+				render.NewRobinsonProjection(),
+			} {
+				extentX, extentY := projection.Extent()
+				x, y := projection.Project(geo.Coordinate{Latitude: -90, Longitude: 180})
+
+				Expect(y).To(BeNumerically("~", -extentY, 1e-9))
+				Expect(x).To(BeNumerically("<=", extentX))
+				x, _ = projection.Project(geo.Coordinate{Latitude: 0, Longitude: 180})
+				Expect(x).To(BeNumerically("~", extentX, 1e-9))
+			}
+		})
+	})
+
+	Describe("Registry", func() {
+		var registry *render.Registry[render.Renderer]
+
+		BeforeEach(func() {
+			registry = render.NewItineraryRegistry(airports)
+		})
+
+		DescribeTable("Negotiate",
+			func(accept, format, expected string) {
+				renderer, err := registry.Negotiate(accept, format)
+
+				Expect(err).Should(BeNil())
+				Expect(renderer.ContentType()).To(Equal(expected))
+			},
+			Entry("no preference defaults to JSON", "", "", "application/json"),
+			Entry("wildcard defaults to JSON", "*/*", "", "application/json"),
+			Entry("exact media type", "text/csv", "", "text/csv; charset=utf-8"),
+			Entry("media type parameters are ignored", "text/plain; charset=utf-8", "", "text/plain; charset=utf-8"),
+			Entry("highest quality wins", "text/csv;q=0.5, application/yaml;q=0.9", "", "application/yaml"),
+			Entry("specific type beats wildcard", "*/*, application/x-ndjson", "", "application/x-ndjson"),
+			Entry("type wildcard", "image/*", "", "image/svg+xml"),
+			Entry("unsupported types are skipped", "application/pdf, text/csv;q=0.1", "", "text/csv; charset=utf-8"),
+			Entry("format overrides Accept", "text/csv", "msgpack", "application/msgpack"),
+		)
+
+		It("should reject unsupported media types", func() {
+			_, err := registry.Negotiate("application/pdf", "")
+
+			Expect(err).Should(HaveOccurred())
+			Expect(err.(*errors.AppError).Code).To(Equal(406))
+		})
+
+		It("should reject media types with zero quality", func() {
+			_, err := registry.Negotiate("application/json;q=0", "")
+
+			Expect(err).Should(HaveOccurred())
+		})
+
+		It("should reject unknown formats", func() {
+			_, err := registry.Negotiate("", "pdf")
+
+			Expect(err).Should(HaveOccurred())
+			Expect(err.(*errors.AppError).Code).To(Equal(406))
+		})
+	})
+
+	Describe("Tabular renderers", func() {
+		itinerary := []string{"JFK", "LAX", "DXB"}
+
+		It("should render NDJSON with one line per leg", func() {
+			body, err := render.NewNDJSONRenderer().Render(itinerary)
+
+			Expect(err).Should(BeNil())
+			Expect(string(body)).To(Equal("{\"sequence\":1,\"from\":\"JFK\",\"to\":\"LAX\"}\n" +
+				"{\"sequence\":2,\"from\":\"LAX\",\"to\":\"DXB\"}\n"))
+		})
+
+		It("should render CSV with a header row", func() {
+			body, err := render.NewCSVRenderer().Render(itinerary)
+
+			Expect(err).Should(BeNil())
+			Expect(string(body)).To(Equal("sequence,from,to\n1,JFK,LAX\n2,LAX,DXB\n"))
+		})
+
+		It("should render YAML errors with lowercase keys", func() {
+			body, err := render.NewYAMLRenderer().(render.ErrorRenderer).RenderError(errors.ErrCircularRoute)
+
+			Expect(err).Should(BeNil())
+			Expect(string(body)).To(Equal("code: 400\nmessage: circular route detected\ntype: business_error\n"))
+		})
+
+		It("should render MessagePack", func() {
+			body, err := render.NewMessagePackRenderer().Render(itinerary)
+
+			Expect(err).Should(BeNil())
+			// fixarray of 3 followed by fixstr "JFK"
+			Expect(body[:5]).To(Equal([]byte{0x93, 0xa3, 'J', 'F', 'K'}))
+		})
+	})
+
+	Describe("Summary renderers", func() {
+		itinerary := []string{"JFK", "LAX", "DXB"}
+
+		It("should render a plain-text summary with city names and distances", func() {
+			body, err := render.NewTextRenderer(airports).Render(itinerary)
+
+			Expect(err).Should(BeNil())
+			Expect(string(body)).To(Equal(
+				"New York (JFK) → Los Angeles (LAX) → Dubai (DXB): 2 flights, 1 stop, 17,370 km\n" +
+					"1. New York (JFK) → Los Angeles (LAX), 3,970 km\n" +
+					"2. Los Angeles (LAX) → Dubai (DXB), 13,400 km\n"))
+		})
+
+		It("should declare UTF-8 on text media types", func() {
+			Expect(render.NewTextRenderer(airports).ContentType()).To(Equal("text/plain; charset=utf-8"))
+			Expect(render.NewMarkdownRenderer(airports).ContentType()).To(Equal("text/markdown; charset=utf-8"))
+			Expect(render.NewCSVRenderer().ContentType()).To(Equal("text/csv; charset=utf-8"))
+		})
+
+		It("should fall back to airport codes and omit distances for unknown airports", func() {
+			body, err := render.NewTextRenderer(airports).Render([]string{"JFK", "XXX"})
+
+			Expect(err).Should(BeNil())
+			Expect(string(body)).To(Equal("New York (JFK) → XXX: 1 flight, nonstop\n1. New York (JFK) → XXX\n"))
+		})
+
+		It("should render a Markdown summary with a table of legs", func() {
+			body, err := render.NewMarkdownRenderer(airports).Render(itinerary)
+
+			Expect(err).Should(BeNil())
+			Expect(string(body)).To(HavePrefix("**New York (JFK) → Los Angeles (LAX) → Dubai (DXB)**\n\n" +
+				"2 flights, 1 stop, 17,370 km\n\n| # | From | To | Distance |\n"))
+			Expect(string(body)).To(ContainSubstring("| 2 | Los Angeles (LAX) | Dubai (DXB) | 13,400 km |\n"))
+		})
+	})
+})
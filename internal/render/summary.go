@@ -0,0 +1,186 @@
+package render
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"flight-itinerary-go/internal/airport"
+	"flight-itinerary-go/internal/geo"
+	"flight-itinerary-go/pkg/errors"
+)
+
+// summary is the human-readable view of an itinerary shared by the text and Markdown renderers
+type summary struct {
+	Stops []string
+	Legs  []summaryLeg
+	// TotalKm is only meaningful when every airport is known
+	TotalKm       float64
+	DistanceKnown bool
+}
+
+type summaryLeg struct {
+	Sequence   int
+	From       string
+	To         string
+	DistanceKm float64
+}
+
+// summarize labels each stop as "City (CODE)" when the airport is known and as its bare code
+// otherwise; distances are omitted as soon as one airport is unknown
+func summarize(itinerary []string, airports airport.Directory) summary {
+	result := summary{
+		Stops:         make([]string, 0, len(itinerary)),
+		DistanceKnown: true,
+	}
+	resolved := make([]airport.Airport, 0, len(itinerary))
+	for _, code := range itinerary {
+		stop, exists := airports.Lookup(code)
+		if !exists {
+			result.Stops = append(result.Stops, code)
+			result.DistanceKnown = false
+			continue
+		}
+		result.Stops = append(result.Stops, fmt.Sprintf("%s (%s)", stop.City, stop.Code))
+		resolved = append(resolved, stop)
+	}
+
+	for i := 1; i < len(result.Stops); i++ {
+		leg := summaryLeg{Sequence: i, From: result.Stops[i-1], To: result.Stops[i]}
+		if result.DistanceKnown {
+			leg.DistanceKm = geo.Distance(resolved[i-1].Coordinate(), resolved[i].Coordinate())
+			result.TotalKm += leg.DistanceKm
+		}
+		result.Legs = append(result.Legs, leg)
+	}
+	return result
+}
+
+// headline returns the route followed by the flight and stop counts, e.g.
+// "New York (JFK) → Dubai (DXB): 1 flight, nonstop, 11,020 km"
+func (itinerarySummary summary) headline() string {
+	return strings.Join(itinerarySummary.Stops, " → ") + ": " + itinerarySummary.counts()
+}
+
+// counts returns the flight and stop counts and, when known, the total distance
+func (itinerarySummary summary) counts() string {
+	parts := []string{plural(len(itinerarySummary.Legs), "flight", "flights")}
+	if stops := len(itinerarySummary.Legs) - 1; stops > 0 {
+		parts = append(parts, plural(stops, "stop", "stops"))
+	} else {
+		parts = append(parts, "nonstop")
+	}
+	if itinerarySummary.DistanceKnown {
+		parts = append(parts, formatKm(itinerarySummary.TotalKm))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// TextRenderer renders itineraries as a plain-text summary
+type TextRenderer struct {
+	airports airport.Directory
+}
+
+// NewTextRenderer creates a new plain text renderer
+func NewTextRenderer(airports airport.Directory) Renderer {
+	return &TextRenderer{
+		airports: airports,
+	}
+}
+
+// ContentType returns the plain text media type
+func (textRenderer *TextRenderer) ContentType() string {
+	return MediaTypeText + charsetUTF8
+}
+
+// Render writes the headline followed by one numbered line per leg
+func (textRenderer *TextRenderer) Render(itinerary []string) ([]byte, error) {
+	itinerarySummary := summarize(itinerary, textRenderer.airports)
+
+	var builder strings.Builder
+	builder.WriteString(itinerarySummary.headline() + "\n")
+	for _, leg := range itinerarySummary.Legs {
+		fmt.Fprintf(&builder, "%d. %s → %s", leg.Sequence, leg.From, leg.To)
+		if itinerarySummary.DistanceKnown {
+			builder.WriteString(", " + formatKm(leg.DistanceKm))
+		}
+		builder.WriteString("\n")
+	}
+	return []byte(builder.String()), nil
+}
+
+// RenderError writes the error as a single line
+func (textRenderer *TextRenderer) RenderError(appErr *errors.AppError) ([]byte, error) {
+	return []byte(fmt.Sprintf("error %d (%s): %s\n", appErr.Code, appErr.Type, appErr.Message)), nil
+}
+
+// MarkdownRenderer renders itineraries as a Markdown summary with a table of legs
+type MarkdownRenderer struct {
+	airports airport.Directory
+}
+
+// NewMarkdownRenderer creates a new Markdown renderer
+func NewMarkdownRenderer(airports airport.Directory) Renderer {
+	return &MarkdownRenderer{
+		airports: airports,
+	}
+}
+
+// ContentType returns the Markdown media type
+func (markdownRenderer *MarkdownRenderer) ContentType() string {
+	return MediaTypeMarkdown + charsetUTF8
+}
+
+// Render writes the route in bold, the counts, and a table of legs
+func (markdownRenderer *MarkdownRenderer) Render(itinerary []string) ([]byte, error) {
+	itinerarySummary := summarize(itinerary, markdownRenderer.airports)
+
+	var builder strings.Builder
+	fmt.Fprintf(&builder, "**%s**\n\n", markdownText(strings.Join(itinerarySummary.Stops, " → ")))
+	fmt.Fprintf(&builder, "%s\n\n", itinerarySummary.counts())
+
+	if itinerarySummary.DistanceKnown {
+		builder.WriteString("| # | From | To | Distance |\n|---|------|----|----------|\n")
+	} else {
+		builder.WriteString("| # | From | To |\n|---|------|----|\n")
+	}
+	for _, leg := range itinerarySummary.Legs {
+		fmt.Fprintf(&builder, "| %d | %s | %s |", leg.Sequence, markdownText(leg.From), markdownText(leg.To))
+		if itinerarySummary.DistanceKnown {
+			fmt.Fprintf(&builder, " %s |", formatKm(leg.DistanceKm))
+		}
+		builder.WriteString("\n")
+	}
+	return []byte(builder.String()), nil
+}
+
+// RenderError writes the error as a bold line
+func (markdownRenderer *MarkdownRenderer) RenderError(appErr *errors.AppError) ([]byte, error) {
+	return []byte(fmt.Sprintf("**Error %d:** %s\n", appErr.Code, markdownText(appErr.Message))), nil
+}
+
+func plural(count int, singular, pluralForm string) string {
+	if count == 1 {
+		return "1 " + singular
+	}
+	return strconv.Itoa(count) + " " + pluralForm
+}
+
+// formatKm rounds to the nearest 10 km and adds thousands separators
+func formatKm(km float64) string {
+	digits := strconv.Itoa(int(math.Round(km/10) * 10))
+	var builder strings.Builder
+	for i, digit := range digits {
+		if i > 0 && (len(digits)-i)%3 == 0 {
+			builder.WriteByte(',')
+		}
+		builder.WriteRune(digit)
+	}
+	return builder.String() + " km"
+}
+
+// markdownText escapes characters with special meaning inside Markdown tables and emphasis
+func markdownText(text string) string {
+	return strings.NewReplacer("|", "\\|", "*", "\\*", "_", "\\_").Replace(text)
+}
@@ -0,0 +1,180 @@
+package render
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"html"
+	"strings"
+	"sync"
+
+	"flight-itinerary-go/internal/airport"
+	"flight-itinerary-go/internal/geo"
+)
+
+// MediaTypeSVG is the media type produced by the SVG renderer
+const MediaTypeSVG = "image/svg+xml"
+
+// svgWidth is the rendered map width in pixels; the height follows from the projection
+const svgWidth = 1024
+
+// graticuleStep is the spacing of meridians and parallels in degrees
+const graticuleStep = 30
+
+//go:embed data/coastlines.json
+var embeddedCoastlines []byte
+
+var (
+	coastlines     [][]geo.Coordinate
+	coastlinesOnce sync.Once
+)
+
+// loadCoastlines parses the embedded coarse land outlines, stored as [longitude, latitude] rings
+func loadCoastlines() [][]geo.Coordinate {
+	coastlinesOnce.Do(func() {
+		var shapes []struct {
+			Name        string       `json:"name"`
+			Coordinates [][2]float64 `json:"coordinates"`
+		}
+		if err := json.Unmarshal(embeddedCoastlines, &shapes); err != nil {
+			panic("Failed to load embedded coastlines: " + err.Error())
+		}
+		for _, shape := range shapes {
+			ring := make([]geo.Coordinate, 0, len(shape.Coordinates))
+			for _, position := range shape.Coordinates {
+				ring = append(ring, geo.Coordinate{Latitude: position[1], Longitude: position[0]})
+			}
+			coastlines = append(coastlines, ring)
+		}
+	})
+	return coastlines
+}
+
+// SVGRenderer renders itineraries as a static SVG world map
+type SVGRenderer struct {
+	airports   airport.Directory
+	projection Projection
+}
+
+// NewSVGRenderer creates a new SVG renderer using the given projection
+func NewSVGRenderer(airports airport.Directory, projection Projection) Renderer {
+	return &SVGRenderer{
+		airports:   airports,
+		projection: projection,
+	}
+}
+
+// ContentType returns the SVG media type
+func (svgRenderer *SVGRenderer) ContentType() string {
+	return MediaTypeSVG
+}
+
+// Render draws the coastlines, graticule, great-circle legs with their sequence numbers and
+// labelled airports. Unknown airports and their legs are left off the map.
+func (svgRenderer *SVGRenderer) Render(itinerary []string) ([]byte, error) {
+	route := NewRoute(itinerary, svgRenderer.airports)
+
+	extentX, extentY := svgRenderer.projection.Extent()
+	height := int(float64(svgWidth) * extentY / extentX)
+	scale := float64(svgWidth) / (2 * extentX)
+	point := func(coordinate geo.Coordinate) (float64, float64) {
+		x, y := svgRenderer.projection.Project(coordinate)
+		return x*scale + float64(svgWidth)/2, float64(height)/2 - y*scale
+	}
+	polyline := func(path []geo.Coordinate) string {
+		points := make([]string, 0, len(path))
+		for _, coordinate := range path {
+			x, y := point(coordinate)
+			points = append(points, fmt.Sprintf("%.1f,%.1f", x, y))
+		}
+		return strings.Join(points, " ")
+	}
+
+	var builder strings.Builder
+	fmt.Fprintf(&builder, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" font-family="sans-serif">`+"\n",
+		svgWidth, height, svgWidth, height)
+	fmt.Fprintf(&builder, "<title>%s</title>\n", html.EscapeString(strings.Join(itinerary, " - ")))
+
+	// Ocean is drawn as the projected outline of the whole globe
+	outline := make([]geo.Coordinate, 0, 2*(180/5+1))
+	for latitude := -90.0; latitude <= 90; latitude += 5 {
+		outline = append(outline, geo.Coordinate{Latitude: latitude, Longitude: -180})
+	}
+	for latitude := 90.0; latitude >= -90; latitude -= 5 {
+		outline = append(outline, geo.Coordinate{Latitude: latitude, Longitude: 180})
+	}
+	fmt.Fprintf(&builder, `<polygon points="%s" fill="#dbe9f6" stroke="#9fb7cc"/>`+"\n", polyline(outline))
+
+	builder.WriteString(`<g fill="#f2efe6" stroke="#b5ad98" stroke-width="0.6">` + "\n")
+	for _, ring := range loadCoastlines() {
+		fmt.Fprintf(&builder, `<polygon points="%s"/>`+"\n", polyline(ring))
+	}
+	builder.WriteString("</g>\n")
+
+	builder.WriteString(`<g fill="none" stroke="#9fb7cc" stroke-width="0.4" stroke-dasharray="2,3">` + "\n")
+	for longitude := -180 + graticuleStep; longitude < 180; longitude += graticuleStep {
+		meridian := []geo.Coordinate{}
+		for latitude := -90.0; latitude <= 90; latitude += 5 {
+			meridian = append(meridian, geo.Coordinate{Latitude: latitude, Longitude: float64(longitude)})
+		}
+		fmt.Fprintf(&builder, `<polyline points="%s"/>`+"\n", polyline(meridian))
+	}
+	for latitude := -90 + graticuleStep; latitude < 90; latitude += graticuleStep {
+		parallel := []geo.Coordinate{
+			{Latitude: float64(latitude), Longitude: -180},
+			{Latitude: float64(latitude), Longitude: 180},
+		}
+		fmt.Fprintf(&builder, `<polyline points="%s"/>`+"\n", polyline(parallel))
+	}
+	builder.WriteString("</g>\n")
+
+	builder.WriteString(`<g fill="none" stroke="#c0392b" stroke-width="2" stroke-linecap="round">` + "\n")
+	for _, leg := range route.Legs {
+		// Legs to unknown airports have no paths
+		for _, path := range leg.Paths {
+			fmt.Fprintf(&builder, `<polyline points="%s"/>`+"\n", polyline(path))
+		}
+	}
+	builder.WriteString("</g>\n")
+
+	builder.WriteString(`<g font-size="10" text-anchor="middle">` + "\n")
+	for _, leg := range route.Legs {
+		if !leg.Located() {
+			continue
+		}
+		arc := geo.GreatCircle(leg.From.Airport.Coordinate(), leg.To.Airport.Coordinate(), maxSegmentKm)
+		x, y := point(arc[len(arc)/2])
+		fmt.Fprintf(&builder, `<circle cx="%.1f" cy="%.1f" r="8" fill="#c0392b"/>`+"\n", x, y)
+		fmt.Fprintf(&builder, `<text x="%.1f" y="%.1f" fill="#ffffff" font-weight="bold">%d</text>`+"\n",
+			x, y+3.5, leg.Sequence)
+	}
+	builder.WriteString("</g>\n")
+
+	builder.WriteString(`<g font-size="11">` + "\n")
+	for _, stop := range uniqueStops(route.Stops) {
+		if stop.Airport == nil {
+			continue
+		}
+		x, y := point(stop.Airport.Coordinate())
+		fmt.Fprintf(&builder, `<circle cx="%.1f" cy="%.1f" r="4" fill="#2c3e50" stroke="#ffffff" stroke-width="1.5"/>`+"\n", x, y)
+		fmt.Fprintf(&builder, `<text x="%.1f" y="%.1f" fill="#2c3e50" stroke="#ffffff" stroke-width="3" paint-order="stroke">%s</text>`+"\n",
+			x+6, y-6, html.EscapeString(stop.Code))
+	}
+	builder.WriteString("</g>\n")
+
+	builder.WriteString("</svg>\n")
+	return []byte(builder.String()), nil
+}
+
+// uniqueStops removes repeated airports so that return visits are labelled once
+func uniqueStops(stops []Stop) []Stop {
+	seen := make(map[string]bool, len(stops))
+	unique := make([]Stop, 0, len(stops))
+	for _, stop := range stops {
+		if !seen[stop.Code] {
+			seen[stop.Code] = true
+			unique = append(unique, stop)
+		}
+	}
+	return unique
+}
@@ -0,0 +1,79 @@
+package rpc
+
+import (
+	"context"
+	"strings"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"flight-itinerary-go/internal/audit"
+	"flight-itinerary-go/internal/model"
+	itineraryv1 "flight-itinerary-go/pkg/api/itinerary/v1"
+)
+
+// AuditUnaryInterceptor records reconstructions in the audit log, one event per ticket set of a
+// batch. The event status is the gRPC status code. Events that cannot be written are logged as lost
+// and the call keeps its result. It must run after the auth interceptor.
+func AuditUnaryInterceptor(recorder audit.Recorder, logger *zap.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, request interface{}, info *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler) (interface{}, error) {
+		response, err := handler(ctx, request)
+
+		var ticketSets [][]*itineraryv1.Ticket
+		switch typed := request.(type) {
+		case *itineraryv1.ReconstructRequest:
+			ticketSets = append(ticketSets, typed.GetTickets())
+		case *itineraryv1.BatchReconstructRequest:
+			for _, item := range typed.GetRequests() {
+				ticketSets = append(ticketSets, item.GetTickets())
+			}
+		}
+		for _, tickets := range ticketSets {
+			recordCall(ctx, recorder, info.FullMethod, audit.TicketDigest(toModelTickets(tickets)), err, logger)
+		}
+		return response, err
+	}
+}
+
+// AuditStreamInterceptor records reconstruction streams in the audit log when they end; ticket
+// digests are not recorded for streams
+func AuditStreamInterceptor(recorder audit.Recorder, logger *zap.Logger) grpc.StreamServerInterceptor {
+	return func(server interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo,
+		handler grpc.StreamHandler) error {
+		err := handler(server, stream)
+		if !strings.HasPrefix(info.FullMethod, reflectionPrefix) {
+			recordCall(stream.Context(), recorder, info.FullMethod, "", err, logger)
+		}
+		return err
+	}
+}
+
+func recordCall(ctx context.Context, recorder audit.Recorder, method, tickets string, err error, logger *zap.Logger) {
+	actor, _ := rpcCaller(ctx)
+	event := audit.Event{
+		Actor:   actor,
+		Action:  audit.ActionReconstruct,
+		Tickets: tickets,
+		Channel: audit.ChannelGRPC,
+		Route:   method,
+		Status:  int(status.Code(err)),
+	}
+	if md, ok := metadata.FromIncomingContext(ctx); ok && len(md.Get("x-request-id")) > 0 {
+		event.RequestID = md.Get("x-request-id")[0]
+	}
+	if recordErr := recorder.Record(event); recordErr != nil {
+		audit.LogLost(logger, event, recordErr)
+	}
+}
+
+// toModelTickets converts protobuf tickets for digesting
+func toModelTickets(tickets []*itineraryv1.Ticket) []model.Ticket {
+	converted := make([]model.Ticket, 0, len(tickets))
+	for _, ticket := range tickets {
+		converted = append(converted, model.Ticket{ticket.GetSource(), ticket.GetDestination()})
+	}
+	return converted
+}
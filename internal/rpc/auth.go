@@ -0,0 +1,127 @@
+package rpc
+
+import (
+	"context"
+	"crypto/x509"
+	"strings"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+
+	"flight-itinerary-go/internal/auth"
+	"flight-itinerary-go/pkg/errors"
+)
+
+// reflectionPrefix marks the reflection service, which stays open so clients can discover the API
+const reflectionPrefix = "/grpc.reflection."
+
+type principalKey struct{}
+
+// PrincipalFromContext returns the caller authenticated by the auth interceptors
+func PrincipalFromContext(ctx context.Context) (*auth.Principal, bool) {
+	principal, ok := ctx.Value(principalKey{}).(*auth.Principal)
+	return principal, ok
+}
+
+// AuthUnaryInterceptor authenticates unary calls from the authorization or x-api-key metadata or
+// the TLS client certificate and requires the scope
+func AuthUnaryInterceptor(authenticator auth.Authenticator, scope string, logger *zap.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, request interface{}, info *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler) (interface{}, error) {
+		if strings.HasPrefix(info.FullMethod, reflectionPrefix) {
+			return handler(ctx, request)
+		}
+		ctx, err := authorize(ctx, authenticator, scope, logger)
+		if err != nil {
+			return nil, ToStatus(err).Err()
+		}
+		return handler(ctx, request)
+	}
+}
+
+// AuthStreamInterceptor authenticates streaming calls like AuthUnaryInterceptor
+func AuthStreamInterceptor(authenticator auth.Authenticator, scope string, logger *zap.Logger) grpc.StreamServerInterceptor {
+	return func(server interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo,
+		handler grpc.StreamHandler) error {
+		if strings.HasPrefix(info.FullMethod, reflectionPrefix) {
+			return handler(server, stream)
+		}
+		ctx, err := authorize(stream.Context(), authenticator, scope, logger)
+		if err != nil {
+			return ToStatus(err).Err()
+		}
+		return handler(server, &authenticatedStream{ServerStream: stream, ctx: ctx})
+	}
+}
+
+// authenticatedStream carries the principal in its context
+type authenticatedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (stream *authenticatedStream) Context() context.Context {
+	return stream.ctx
+}
+
+func authorize(ctx context.Context, authenticator auth.Authenticator, scope string,
+	logger *zap.Logger) (context.Context, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	credential := ""
+	if values := md.Get("x-api-key"); len(values) > 0 {
+		credential = values[0]
+	} else if values := md.Get("authorization"); len(values) > 0 {
+		scheme, token, found := strings.Cut(values[0], " ")
+		if !found || !strings.EqualFold(scheme, "Bearer") {
+			return ctx, auth.ErrInvalidCredentials
+		}
+		credential = strings.TrimSpace(token)
+	}
+	if credential == "" {
+		// Without other credentials, a verified client certificate identifies the caller
+		peerAuthenticator, ok := authenticator.(auth.PeerAuthenticator)
+		if certificate := peerCertificate(ctx); ok && certificate != nil {
+			principal, err := peerAuthenticator.AuthenticatePeer(certificate)
+			if err != nil {
+				return ctx, err
+			}
+			return authorizePrincipal(ctx, principal, scope, logger)
+		}
+		return ctx, auth.ErrMissingCredentials
+	}
+
+	principal, err := authenticator.Authenticate(credential)
+	if err != nil {
+		if _, ok := err.(*errors.AppError); !ok {
+			logger.Error("Authentication failed", zap.Error(err))
+			return ctx, errors.NewInternalError("authentication is unavailable")
+		}
+		return ctx, err
+	}
+	return authorizePrincipal(ctx, principal, scope, logger)
+}
+
+func authorizePrincipal(ctx context.Context, principal *auth.Principal, scope string,
+	logger *zap.Logger) (context.Context, error) {
+	if !principal.HasScope(scope) {
+		logger.Warn("Insufficient scope", zap.String("subject", principal.Subject), zap.String("scope", scope))
+		return ctx, errors.NewForbiddenError("scope %s is required", scope)
+	}
+	return context.WithValue(ctx, principalKey{}, principal), nil
+}
+
+// peerCertificate returns the client certificate verified during the TLS handshake, if any
+func peerCertificate(ctx context.Context) *x509.Certificate {
+	client, ok := peer.FromContext(ctx)
+	if !ok {
+		return nil
+	}
+	tlsInfo, ok := client.AuthInfo.(credentials.TLSInfo)
+	if !ok || len(tlsInfo.State.VerifiedChains) == 0 {
+		return nil
+	}
+	return tlsInfo.State.VerifiedChains[0][0]
+}
@@ -0,0 +1,69 @@
+package rpc
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// LoggingUnaryInterceptor provides structured logging for unary calls, mirroring the HTTP
+// logging middleware, and converts panics into internal errors
+func LoggingUnaryInterceptor(logger *zap.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, request interface{}, info *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler) (response interface{}, err error) {
+		start := time.Now()
+		defer func() {
+			if recovered := recover(); recovered != nil {
+				logger.Error("Recovered from panic", zap.Any("panic", recovered))
+				err = status.Error(codes.Internal, "internal server error")
+			}
+			logCall(ctx, logger, info.FullMethod, start, err)
+		}()
+
+		return handler(ctx, request)
+	}
+}
+
+// LoggingStreamInterceptor provides structured logging for streaming calls and converts
+// panics into internal errors
+func LoggingStreamInterceptor(logger *zap.Logger) grpc.StreamServerInterceptor {
+	return func(server interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo,
+		handler grpc.StreamHandler) (err error) {
+		start := time.Now()
+		defer func() {
+			if recovered := recover(); recovered != nil {
+				logger.Error("Recovered from panic", zap.Any("panic", recovered))
+				err = status.Error(codes.Internal, "internal server error")
+			}
+			logCall(stream.Context(), logger, info.FullMethod, start, err)
+		}()
+
+		return handler(server, stream)
+	}
+}
+
+func logCall(ctx context.Context, logger *zap.Logger, method string, start time.Time, err error) {
+	remoteAddr := ""
+	if p, ok := peer.FromContext(ctx); ok {
+		remoteAddr = p.Addr.String()
+	}
+
+	fields := []zap.Field{
+		zap.String("method", method),
+		zap.String("remote_addr", remoteAddr),
+		zap.String("code", status.Code(err).String()),
+		zap.Duration("latency", time.Since(start)),
+	}
+
+	if err != nil {
+		fields = append(fields, zap.Error(err))
+		logger.Error("RPC failed", fields...)
+	} else {
+		logger.Info("RPC completed", fields...)
+	}
+}
@@ -0,0 +1,148 @@
+package rpc
+
+import (
+	"context"
+	"io"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"flight-itinerary-go/internal/model"
+	"flight-itinerary-go/internal/service"
+	itineraryv1 "flight-itinerary-go/pkg/api/itinerary/v1"
+	"flight-itinerary-go/pkg/errors"
+)
+
+// maxBatchRequests caps the itineraries rebuilt by one BatchReconstruct call
+const maxBatchRequests = 100
+
+// ItineraryServer implements the gRPC ItineraryService on top of service.ItineraryService
+type ItineraryServer struct {
+	itineraryv1.UnimplementedItineraryServiceServer
+	itineraryService service.ItineraryService
+	logger           *zap.Logger
+}
+
+// NewItineraryServer creates a new gRPC itinerary server. Calls with a tenant use the tenant's
+// itinerary service instead.
+func NewItineraryServer(itineraryService service.ItineraryService, logger *zap.Logger) *ItineraryServer {
+	return &ItineraryServer{
+		itineraryService: itineraryService,
+		logger:           logger,
+	}
+}
+
+// Register adds the itinerary service to the gRPC server
+func (itineraryServer *ItineraryServer) Register(server *grpc.Server) {
+	itineraryv1.RegisterItineraryServiceServer(server, itineraryServer)
+}
+
+// Reconstruct rebuilds a single itinerary from a list of tickets
+func (itineraryServer *ItineraryServer) Reconstruct(ctx context.Context,
+	request *itineraryv1.ReconstructRequest) (*itineraryv1.ReconstructResponse, error) {
+	itineraryServer.logger.Info("Processing gRPC itinerary reconstruction request",
+		zap.Int("ticket_count", len(request.GetTickets())))
+
+	response, err := itineraryServer.reconstruct(ctx, request.GetTickets())
+	if err != nil {
+		return nil, ToStatus(err).Err()
+	}
+	return response, nil
+}
+
+// ReconstructStream rebuilds a single itinerary from tickets streamed one at a time
+func (itineraryServer *ItineraryServer) ReconstructStream(
+	stream grpc.ClientStreamingServer[itineraryv1.Ticket, itineraryv1.ReconstructResponse]) error {
+	tickets := []*itineraryv1.Ticket{}
+	for {
+		ticket, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			itineraryServer.logger.Warn("Failed to receive ticket from stream", zap.Error(err))
+			return err
+		}
+		if len(tickets) == model.MaxTickets {
+			itineraryServer.logger.Warn("Ticket stream exceeds the ticket limit", zap.Int("max_tickets", model.MaxTickets))
+			return status.Errorf(codes.ResourceExhausted, "at most %d tickets are allowed", model.MaxTickets)
+		}
+		tickets = append(tickets, ticket)
+	}
+
+	itineraryServer.logger.Info("Processing streamed gRPC itinerary reconstruction request",
+		zap.Int("ticket_count", len(tickets)))
+	response, err := itineraryServer.reconstruct(stream.Context(), tickets)
+	if err != nil {
+		return ToStatus(err).Err()
+	}
+	return stream.SendAndClose(response)
+}
+
+// BatchReconstruct rebuilds up to maxBatchRequests independent itineraries, reporting failures
+// per item
+func (itineraryServer *ItineraryServer) BatchReconstruct(ctx context.Context,
+	request *itineraryv1.BatchReconstructRequest) (*itineraryv1.BatchReconstructResponse, error) {
+	if len(request.GetRequests()) == 0 {
+		return nil, ToStatus(errors.NewValidationError("at least one request is required")).Err()
+	}
+	if len(request.GetRequests()) > maxBatchRequests {
+		return nil, ToStatus(errors.NewValidationError("at most %d requests are allowed, got %d",
+			maxBatchRequests, len(request.GetRequests()))).Err()
+	}
+	itineraryServer.logger.Info("Processing gRPC batch itinerary reconstruction request",
+		zap.Int("request_count", len(request.GetRequests())))
+
+	results := make([]*itineraryv1.BatchResult, 0, len(request.GetRequests()))
+	for _, item := range request.GetRequests() {
+		response, err := itineraryServer.reconstruct(ctx, item.GetTickets())
+		if err != nil {
+			results = append(results, &itineraryv1.BatchResult{
+				Result: &itineraryv1.BatchResult_Error{Error: ToStatus(err).Proto()},
+			})
+			continue
+		}
+		results = append(results, &itineraryv1.BatchResult{
+			Result: &itineraryv1.BatchResult_Itinerary{Itinerary: response},
+		})
+	}
+	return &itineraryv1.BatchReconstructResponse{Results: results}, nil
+}
+
+func (itineraryServer *ItineraryServer) reconstruct(ctx context.Context,
+	tickets []*itineraryv1.Ticket) (*itineraryv1.ReconstructResponse, error) {
+	if len(tickets) == 0 {
+		return nil, errors.NewValidationError("at least one ticket is required")
+	}
+	if len(tickets) > model.MaxTickets {
+		return nil, errors.NewValidationError("at most %d tickets are allowed, got %d", model.MaxTickets, len(tickets))
+	}
+
+	request := model.ItineraryRequest{
+		Tickets: make([]model.Ticket, 0, len(tickets)),
+	}
+	for _, ticket := range tickets {
+		request.Tickets = append(request.Tickets, model.Ticket{ticket.GetSource(), ticket.GetDestination()})
+	}
+	modelTickets, err := request.ToTickets()
+	if err != nil {
+		return nil, err
+	}
+
+	itinerary, err := itineraryServer.serviceFor(ctx).ReconstructItinerary(modelTickets)
+	if err != nil {
+		itineraryServer.logger.Warn("Failed to reconstruct itinerary", zap.Error(err))
+		return nil, err
+	}
+	return &itineraryv1.ReconstructResponse{Itinerary: itinerary}, nil
+}
+
+// serviceFor returns the itinerary service of the call's tenant, or the server's own
+func (itineraryServer *ItineraryServer) serviceFor(ctx context.Context) service.ItineraryService {
+	if callTenant, ok := TenantFromContext(ctx); ok {
+		return callTenant.ItineraryService
+	}
+	return itineraryServer.itineraryService
+}
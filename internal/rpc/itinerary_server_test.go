@@ -0,0 +1,281 @@
+package rpc_test
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sync"
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.uber.org/zap"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	"flight-itinerary-go/internal/audit"
+	"flight-itinerary-go/internal/model"
+	"flight-itinerary-go/internal/ratelimit"
+	"flight-itinerary-go/internal/rpc"
+	"flight-itinerary-go/internal/service"
+	itineraryv1 "flight-itinerary-go/pkg/api/itinerary/v1"
+)
+
+func TestItineraryServer(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "ItineraryServer Suite")
+}
+
+// eventRecorder keeps audit events in memory, or fails every write when failing is set
+type eventRecorder struct {
+	mutex   sync.Mutex
+	events  []audit.Event
+	failing bool
+}
+
+func (recorder *eventRecorder) Record(event audit.Event) error {
+	recorder.mutex.Lock()
+	defer recorder.mutex.Unlock()
+	if recorder.failing {
+		return fmt.Errorf("disk full")
+	}
+	recorder.events = append(recorder.events, event)
+	return nil
+}
+
+func (recorder *eventRecorder) Events() []audit.Event {
+	recorder.mutex.Lock()
+	defer recorder.mutex.Unlock()
+	return append([]audit.Event(nil), recorder.events...)
+}
+
+var _ = Describe("ItineraryServer", func() {
+	var (
+		grpcServer *grpc.Server
+		connection *grpc.ClientConn
+		client     itineraryv1.ItineraryServiceClient
+		ctx        context.Context
+		recorder   *eventRecorder
+		limiter    *ratelimit.Limiter
+	)
+
+	quota := func(dailyTickets int64) ratelimit.Policy {
+		return ratelimit.Policy{
+			DefaultTier: "test",
+			Tiers: map[string]ratelimit.Tier{
+				"test": {Default: ratelimit.Limit{Rate: 1000, Burst: 1000}, DailyTickets: dailyTickets},
+			},
+		}
+	}
+
+	BeforeEach(func() {
+		logger := zap.NewExample()
+		listener := bufconn.Listen(1024 * 1024)
+		recorder = &eventRecorder{}
+		limiter = ratelimit.NewLimiter(quota(10 * model.MaxTickets))
+		grpcServer = grpc.NewServer(
+			grpc.ChainUnaryInterceptor(rpc.LoggingUnaryInterceptor(logger),
+				rpc.RateLimitUnaryInterceptor(limiter, logger),
+				rpc.AuditUnaryInterceptor(recorder, logger)),
+			grpc.ChainStreamInterceptor(rpc.LoggingStreamInterceptor(logger),
+				rpc.RateLimitStreamInterceptor(limiter, logger),
+				rpc.AuditStreamInterceptor(recorder, logger)),
+		)
+		rpc.NewItineraryServer(service.NewItineraryService(logger), logger).Register(grpcServer)
+		go grpcServer.Serve(listener)
+
+		var err error
+		connection, err = grpc.NewClient("passthrough:///bufnet",
+			grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) {
+				return listener.Dial()
+			}),
+			grpc.WithTransportCredentials(insecure.NewCredentials()))
+		Expect(err).Should(BeNil())
+		client = itineraryv1.NewItineraryServiceClient(connection)
+		ctx = context.Background()
+	})
+
+	AfterEach(func() {
+		connection.Close()
+		grpcServer.Stop()
+	})
+
+	Describe("Reconstruct", func() {
+		It("should reconstruct the itinerary", func() {
+			response, err := client.Reconstruct(ctx, &itineraryv1.ReconstructRequest{
+				Tickets: []*itineraryv1.Ticket{
+					{Source: "LAX", Destination: "DXB"},
+					{Source: "JFK", Destination: "LAX"},
+				},
+			})
+
+			Expect(err).Should(BeNil())
+			Expect(response.GetItinerary()).To(Equal([]string{"JFK", "LAX", "DXB"}))
+		})
+
+		It("should map business errors to FailedPrecondition with error details", func() {
+			_, err := client.Reconstruct(ctx, &itineraryv1.ReconstructRequest{
+				Tickets: []*itineraryv1.Ticket{
+					{Source: "A", Destination: "B"},
+					{Source: "B", Destination: "A"},
+				},
+			})
+
+			grpcStatus := status.Convert(err)
+			Expect(grpcStatus.Code()).To(Equal(codes.FailedPrecondition))
+			Expect(grpcStatus.Details()).To(HaveLen(1))
+			errorInfo := grpcStatus.Details()[0].(*errdetails.ErrorInfo)
+			Expect(errorInfo.GetReason()).To(Equal("business_error"))
+			Expect(errorInfo.GetMetadata()).To(HaveKeyWithValue("http_code", "400"))
+		})
+
+		It("should map validation errors to InvalidArgument", func() {
+			_, err := client.Reconstruct(ctx, &itineraryv1.ReconstructRequest{
+				Tickets: []*itineraryv1.Ticket{{Source: "JFK"}},
+			})
+
+			Expect(status.Code(err)).To(Equal(codes.InvalidArgument))
+		})
+	})
+
+	Describe("ReconstructStream", func() {
+		It("should reconstruct the itinerary from streamed tickets", func() {
+			stream, err := client.ReconstructStream(ctx)
+			Expect(err).Should(BeNil())
+
+			for _, ticket := range []*itineraryv1.Ticket{
+				{Source: "DXB", Destination: "SFO"},
+				{Source: "JFK", Destination: "LAX"},
+				{Source: "LAX", Destination: "DXB"},
+			} {
+				Expect(stream.Send(ticket)).To(Succeed())
+			}
+			response, err := stream.CloseAndRecv()
+
+			Expect(err).Should(BeNil())
+			Expect(response.GetItinerary()).To(Equal([]string{"JFK", "LAX", "DXB", "SFO"}))
+		})
+
+		It("should reject empty streams", func() {
+			stream, err := client.ReconstructStream(ctx)
+			Expect(err).Should(BeNil())
+
+			_, err = stream.CloseAndRecv()
+
+			Expect(status.Code(err)).To(Equal(codes.InvalidArgument))
+		})
+
+		It("should stop streams exceeding the ticket limit", func() {
+			stream, err := client.ReconstructStream(ctx)
+			Expect(err).Should(BeNil())
+
+			// The server may end the stream before every ticket is sent
+			for i := 0; i <= model.MaxTickets; i++ {
+				if stream.Send(&itineraryv1.Ticket{Source: fmt.Sprint(i), Destination: fmt.Sprint(i + 1)}) != nil {
+					break
+				}
+			}
+			_, err = stream.CloseAndRecv()
+
+			Expect(status.Code(err)).To(Equal(codes.ResourceExhausted))
+		})
+
+		It("should count streamed tickets against the daily quota", func() {
+			limiter.SetPolicy(quota(3))
+			stream, err := client.ReconstructStream(ctx)
+			Expect(err).Should(BeNil())
+
+			airports := []string{"JFK", "LAX", "SFO", "SEA", "DXB"}
+			for i := 0; i+1 < len(airports); i++ {
+				if stream.Send(&itineraryv1.Ticket{Source: airports[i], Destination: airports[i+1]}) != nil {
+					break
+				}
+			}
+			_, err = stream.CloseAndRecv()
+
+			Expect(status.Code(err)).To(Equal(codes.ResourceExhausted))
+			Expect(status.Convert(err).Message()).To(ContainSubstring("daily ticket quota exceeded"))
+			_, err = client.Reconstruct(ctx, &itineraryv1.ReconstructRequest{
+				Tickets: []*itineraryv1.Ticket{{Source: "JFK", Destination: "LAX"}},
+			})
+			Expect(status.Code(err)).To(Equal(codes.ResourceExhausted))
+		})
+	})
+
+	Describe("BatchReconstruct", func() {
+		It("should report results and failures per request", func() {
+			response, err := client.BatchReconstruct(ctx, &itineraryv1.BatchReconstructRequest{
+				Requests: []*itineraryv1.ReconstructRequest{
+					{Tickets: []*itineraryv1.Ticket{{Source: "JFK", Destination: "LAX"}}},
+					{Tickets: []*itineraryv1.Ticket{
+						{Source: "JFK", Destination: "LAX"},
+						{Source: "DXB", Destination: "SFO"},
+					}},
+				},
+			})
+
+			Expect(err).Should(BeNil())
+			Expect(response.GetResults()).To(HaveLen(2))
+			Expect(response.GetResults()[0].GetItinerary().GetItinerary()).To(Equal([]string{"JFK", "LAX"}))
+			Expect(response.GetResults()[1].GetError().GetCode()).To(Equal(int32(codes.FailedPrecondition)))
+			Expect(response.GetResults()[1].GetError().GetMessage()).To(Equal("disconnected route found"))
+		})
+
+		It("should reject batches of more than 100 requests", func() {
+			requests := make([]*itineraryv1.ReconstructRequest, 101)
+			for i := range requests {
+				requests[i] = &itineraryv1.ReconstructRequest{
+					Tickets: []*itineraryv1.Ticket{{Source: "JFK", Destination: "LAX"}},
+				}
+			}
+
+			_, err := client.BatchReconstruct(ctx, &itineraryv1.BatchReconstructRequest{Requests: requests})
+
+			Expect(status.Code(err)).To(Equal(codes.InvalidArgument))
+			Expect(status.Convert(err).Message()).To(Equal("at most 100 requests are allowed, got 101"))
+
+			_, err = client.BatchReconstruct(ctx, &itineraryv1.BatchReconstructRequest{Requests: requests[:100]})
+			Expect(err).Should(BeNil())
+		})
+	})
+
+	Describe("Audit", func() {
+		It("should record one event per reconstructed ticket set", func() {
+			_, err := client.BatchReconstruct(ctx, &itineraryv1.BatchReconstructRequest{
+				Requests: []*itineraryv1.ReconstructRequest{
+					{Tickets: []*itineraryv1.Ticket{{Source: "JFK", Destination: "LAX"}}},
+					{Tickets: []*itineraryv1.Ticket{{Source: "LAX", Destination: "SFO"}}},
+				},
+			})
+			Expect(err).Should(BeNil())
+			_, err = client.Reconstruct(ctx, &itineraryv1.ReconstructRequest{
+				Tickets: []*itineraryv1.Ticket{{Source: "JFK"}},
+			})
+			Expect(err).ShouldNot(BeNil())
+
+			events := recorder.Events()
+			Expect(events).To(HaveLen(3))
+			Expect(events[0].Action).To(Equal(audit.ActionReconstruct))
+			Expect(events[0].Channel).To(Equal(audit.ChannelGRPC))
+			Expect(events[0].Route).To(HaveSuffix("/BatchReconstruct"))
+			Expect(events[0].Tickets).ToNot(Equal(events[1].Tickets))
+			Expect(events[2].Status).To(Equal(int(codes.InvalidArgument)))
+		})
+
+		It("should keep results when the audit event cannot be written", func() {
+			recorder.mutex.Lock()
+			recorder.failing = true
+			recorder.mutex.Unlock()
+
+			response, err := client.Reconstruct(ctx, &itineraryv1.ReconstructRequest{
+				Tickets: []*itineraryv1.Ticket{{Source: "JFK", Destination: "LAX"}},
+			})
+			Expect(err).Should(BeNil())
+			Expect(response.GetItinerary()).To(Equal([]string{"JFK", "LAX"}))
+		})
+	})
+})
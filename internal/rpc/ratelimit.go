@@ -0,0 +1,134 @@
+package rpc
+
+import (
+	"context"
+	"net"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/protobuf/types/known/durationpb"
+
+	"flight-itinerary-go/internal/ratelimit"
+	itineraryv1 "flight-itinerary-go/pkg/api/itinerary/v1"
+	"flight-itinerary-go/pkg/errors"
+)
+
+// RateLimitUnaryInterceptor limits calls per caller and method like the REST rate limit and counts
+// the tickets of reconstruction requests against the daily quota. Calls with a tenant are limited by
+// the tenant's limiter. It must run after the auth and tenancy interceptors.
+func RateLimitUnaryInterceptor(limiter *ratelimit.Limiter, logger *zap.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, request interface{}, info *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler) (interface{}, error) {
+		if strings.HasPrefix(info.FullMethod, reflectionPrefix) {
+			return handler(ctx, request)
+		}
+		if err := allow(ctx, limiter, info.FullMethod, logger); err != nil {
+			return nil, err
+		}
+		if err := useTickets(ctx, limiter, countTickets(request), logger); err != nil {
+			return nil, err
+		}
+		return handler(ctx, request)
+	}
+}
+
+// RateLimitStreamInterceptor limits stream openings per caller and method and counts every streamed
+// ticket against the daily quota as it arrives
+func RateLimitStreamInterceptor(limiter *ratelimit.Limiter, logger *zap.Logger) grpc.StreamServerInterceptor {
+	return func(server interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo,
+		handler grpc.StreamHandler) error {
+		if strings.HasPrefix(info.FullMethod, reflectionPrefix) {
+			return handler(server, stream)
+		}
+		if err := allow(stream.Context(), limiter, info.FullMethod, logger); err != nil {
+			return err
+		}
+		return handler(server, &quotaStream{ServerStream: stream, limiter: limiter, logger: logger})
+	}
+}
+
+// quotaStream counts the tickets received on a stream against the caller's daily quota
+type quotaStream struct {
+	grpc.ServerStream
+	limiter *ratelimit.Limiter
+	logger  *zap.Logger
+}
+
+func (stream *quotaStream) RecvMsg(message interface{}) error {
+	if err := stream.ServerStream.RecvMsg(message); err != nil {
+		return err
+	}
+	if _, ok := message.(*itineraryv1.Ticket); !ok {
+		return nil
+	}
+	return useTickets(stream.Context(), stream.limiter, 1, stream.logger)
+}
+
+func allow(ctx context.Context, limiter *ratelimit.Limiter, method string, logger *zap.Logger) error {
+	identity, tier := rpcCaller(ctx)
+	decision := limiterFor(ctx, limiter).Allow(identity, tier, method)
+	if decision.Allowed {
+		return nil
+	}
+	logger.Warn("Rate limit exceeded", zap.String("caller", identity), zap.String("method", method))
+	return retryStatus(errors.NewTooManyRequestsError("rate limit exceeded, retry later"), decision.RetryAfter)
+}
+
+// useTickets counts tickets against the caller's daily quota
+func useTickets(ctx context.Context, limiter *ratelimit.Limiter, tickets int, logger *zap.Logger) error {
+	if tickets == 0 {
+		return nil
+	}
+	identity, tier := rpcCaller(ctx)
+	decision := limiterFor(ctx, limiter).UseTickets(identity, tier, tickets)
+	if decision.Allowed {
+		return nil
+	}
+	logger.Warn("Daily ticket quota exceeded", zap.String("caller", identity), zap.Int("tickets", tickets))
+	return retryStatus(errors.NewTooManyRequestsError(
+		"daily ticket quota exceeded, %d of %d tickets left today", decision.Remaining, decision.Limit),
+		decision.Reset)
+}
+
+// rpcCaller identifies the rate limited caller and its tier
+func rpcCaller(ctx context.Context) (string, string) {
+	if principal, ok := PrincipalFromContext(ctx); ok {
+		return principal.Subject, principal.Tier
+	}
+	if client, ok := peer.FromContext(ctx); ok {
+		host, _, err := net.SplitHostPort(client.Addr.String())
+		if err != nil {
+			host = client.Addr.String()
+		}
+		return "ip:" + host, ratelimit.AnonymousTier
+	}
+	return "ip:unknown", ratelimit.AnonymousTier
+}
+
+// countTickets returns the tickets submitted by reconstruction requests
+func countTickets(request interface{}) int {
+	switch typed := request.(type) {
+	case *itineraryv1.ReconstructRequest:
+		return len(typed.GetTickets())
+	case *itineraryv1.BatchReconstructRequest:
+		tickets := 0
+		for _, item := range typed.GetRequests() {
+			tickets += len(item.GetTickets())
+		}
+		return tickets
+	}
+	return 0
+}
+
+// retryStatus converts the error into a status telling the client when to retry
+func retryStatus(appErr *errors.AppError, retryAfter time.Duration) error {
+	grpcStatus := ToStatus(appErr)
+	if detailed, err := grpcStatus.WithDetails(&errdetails.RetryInfo{RetryDelay: durationpb.New(retryAfter)}); err == nil {
+		grpcStatus = detailed
+	}
+	return grpcStatus.Err()
+}
@@ -0,0 +1,70 @@
+package rpc
+
+import (
+	"net/http"
+	"strconv"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"flight-itinerary-go/pkg/errors"
+)
+
+// ErrorDomain identifies this service in google.rpc.ErrorInfo details
+const ErrorDomain = "flight-itinerary-go"
+
+// typeCodes maps AppError types to gRPC status codes
+var typeCodes = map[string]codes.Code{
+	"validation_error": codes.InvalidArgument,
+	"business_error":   codes.FailedPrecondition,
+	"internal_error":   codes.Internal,
+}
+
+// httpCodes maps HTTP status codes to gRPC status codes for AppError types without a mapping
+var httpCodes = map[int]codes.Code{
+	http.StatusBadRequest:          codes.InvalidArgument,
+	http.StatusUnauthorized:        codes.Unauthenticated,
+	http.StatusForbidden:           codes.PermissionDenied,
+	http.StatusNotFound:            codes.NotFound,
+	http.StatusNotAcceptable:       codes.InvalidArgument,
+	http.StatusConflict:            codes.Aborted,
+	http.StatusPreconditionFailed:  codes.FailedPrecondition,
+	http.StatusUnprocessableEntity: codes.InvalidArgument,
+	http.StatusTooManyRequests:     codes.ResourceExhausted,
+	http.StatusInternalServerError: codes.Internal,
+	http.StatusServiceUnavailable:  codes.Unavailable,
+}
+
+// ToStatus converts an error into a gRPC status. AppErrors carry their type and HTTP code in a
+// google.rpc.ErrorInfo detail; other errors become an opaque internal error.
+func ToStatus(err error) *status.Status {
+	appErr, ok := err.(*errors.AppError)
+	if !ok {
+		if grpcStatus, isStatus := status.FromError(err); isStatus {
+			return grpcStatus
+		}
+		appErr = errors.NewInternalError("internal server error")
+	}
+
+	code, exists := typeCodes[appErr.Type]
+	if !exists {
+		code, exists = httpCodes[appErr.Code]
+		if !exists {
+			code = codes.Unknown
+		}
+	}
+
+	grpcStatus := status.New(code, appErr.Message)
+	detailed, detailErr := grpcStatus.WithDetails(&errdetails.ErrorInfo{
+		Reason: appErr.Type,
+		Domain: ErrorDomain,
+		Metadata: map[string]string{
+			"http_code": strconv.Itoa(appErr.Code),
+		},
+	})
+	if detailErr != nil {
+		return grpcStatus
+	}
+	return detailed
+}
@@ -0,0 +1,95 @@
+package rpc
+
+import (
+	"context"
+	"strings"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+
+	"flight-itinerary-go/internal/auth"
+	"flight-itinerary-go/internal/ratelimit"
+	"flight-itinerary-go/internal/tenant"
+	"flight-itinerary-go/pkg/errors"
+)
+
+// tenantMetadata selects the tenant of a call like the X-Tenant-ID header
+const tenantMetadata = "x-tenant-id"
+
+type tenantKey struct{}
+
+// TenantFromContext returns the tenant selected by the tenancy interceptors
+func TenantFromContext(ctx context.Context) (*tenant.Tenant, bool) {
+	callTenant, ok := ctx.Value(tenantKey{}).(*tenant.Tenant)
+	return callTenant, ok
+}
+
+// TenancyUnaryInterceptor selects the call's tenant like the REST tenancy middleware: callers bound
+// to a tenant are served by it, administrators choose the tenant with x-tenant-id metadata and other
+// callers get the default tenant. It must run after the auth interceptor.
+func TenancyUnaryInterceptor(registry *tenant.Registry, logger *zap.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, request interface{}, info *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler) (interface{}, error) {
+		if strings.HasPrefix(info.FullMethod, reflectionPrefix) {
+			return handler(ctx, request)
+		}
+		ctx, err := selectTenant(ctx, registry, logger)
+		if err != nil {
+			return nil, ToStatus(err).Err()
+		}
+		return handler(ctx, request)
+	}
+}
+
+// TenancyStreamInterceptor selects the tenant of streaming calls like TenancyUnaryInterceptor
+func TenancyStreamInterceptor(registry *tenant.Registry, logger *zap.Logger) grpc.StreamServerInterceptor {
+	return func(server interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo,
+		handler grpc.StreamHandler) error {
+		if strings.HasPrefix(info.FullMethod, reflectionPrefix) {
+			return handler(server, stream)
+		}
+		ctx, err := selectTenant(stream.Context(), registry, logger)
+		if err != nil {
+			return ToStatus(err).Err()
+		}
+		return handler(server, &authenticatedStream{ServerStream: stream, ctx: ctx})
+	}
+}
+
+func selectTenant(ctx context.Context, registry *tenant.Registry, logger *zap.Logger) (context.Context, error) {
+	id := ""
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get(tenantMetadata); len(values) > 0 {
+			id = values[0]
+		}
+	}
+	if principal, ok := PrincipalFromContext(ctx); ok && principal.Tenant != "" {
+		if id != "" && id != principal.Tenant {
+			logger.Warn("Tenant not allowed for caller", zap.String("subject", principal.Subject),
+				zap.String("tenant", id))
+			return ctx, errors.NewForbiddenError("credentials are bound to another tenant")
+		}
+		id = principal.Tenant
+	} else if id != "" && id != tenant.DefaultID && (!ok || !principal.HasScope(auth.ScopeAdmin)) {
+		logger.Warn("Tenant selection not allowed for caller", zap.String("tenant", id))
+		return ctx, errors.NewForbiddenError("only administrators may choose a tenant")
+	}
+	if id == "" {
+		id = tenant.DefaultID
+	}
+
+	callTenant, exists := registry.Get(id)
+	if !exists {
+		return ctx, errors.NewNotFoundError("tenant not found")
+	}
+	return context.WithValue(ctx, tenantKey{}, callTenant), nil
+}
+
+// limiterFor returns the limiter of the call's tenant, or the given one
+func limiterFor(ctx context.Context, limiter *ratelimit.Limiter) *ratelimit.Limiter {
+	if callTenant, ok := TenantFromContext(ctx); ok {
+		return callTenant.Limiter
+	}
+	return limiter
+}
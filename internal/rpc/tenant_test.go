@@ -0,0 +1,138 @@
+package rpc_test
+
+import (
+	"context"
+	"net"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	"flight-itinerary-go/internal/airport"
+	"flight-itinerary-go/internal/auth"
+	"flight-itinerary-go/internal/cache"
+	"flight-itinerary-go/internal/ratelimit"
+	"flight-itinerary-go/internal/rpc"
+	"flight-itinerary-go/internal/service"
+	"flight-itinerary-go/internal/storage"
+	"flight-itinerary-go/internal/tenant"
+	itineraryv1 "flight-itinerary-go/pkg/api/itinerary/v1"
+)
+
+// principals authenticates fixed credentials
+type principals map[string]*auth.Principal
+
+func (principals principals) Authenticate(credential string) (*auth.Principal, error) {
+	if principal, ok := principals[credential]; ok {
+		return principal, nil
+	}
+	return nil, auth.ErrInvalidCredentials
+}
+
+var _ = Describe("Tenancy", func() {
+	var (
+		grpcServer *grpc.Server
+		connection *grpc.ClientConn
+		client     itineraryv1.ItineraryServiceClient
+		registry   *tenant.Registry
+	)
+
+	BeforeEach(func() {
+		logger := zap.NewNop()
+		var err error
+		registry, err = tenant.NewRegistry(tenant.Dependencies{
+			ItineraryService: service.NewItineraryService(logger),
+			Cache:            cache.NewCache(cache.Options{MaxBytes: 1 << 20, TTL: time.Minute}),
+			Airports:         airport.NewDirectory(),
+			Policy:           ratelimit.DefaultPolicy(),
+			Repository: func(string) (storage.Repository, error) {
+				return storage.NewMemoryRepository(), nil
+			},
+			Logger: logger,
+		}, tenant.Config{ID: "acme", Name: "Acme", Validation: tenant.Validation{BlockedAirports: []string{"DXB"}}})
+		Expect(err).Should(BeNil())
+
+		authenticator := principals{
+			"admin":  {Subject: "admin", Scopes: []string{auth.ScopeAdmin}},
+			"reader": {Subject: "reader", Scopes: []string{auth.ScopeReconstruct}},
+			"bound":  {Subject: "bound", Scopes: []string{auth.ScopeReconstruct}, Tenant: "acme"},
+		}
+		limiter := ratelimit.NewLimiter(ratelimit.DefaultPolicy())
+		listener := bufconn.Listen(1024 * 1024)
+		grpcServer = grpc.NewServer(
+			grpc.ChainUnaryInterceptor(rpc.AuthUnaryInterceptor(authenticator, auth.ScopeReconstruct, logger),
+				rpc.TenancyUnaryInterceptor(registry, logger),
+				rpc.RateLimitUnaryInterceptor(limiter, logger)),
+			grpc.ChainStreamInterceptor(rpc.AuthStreamInterceptor(authenticator, auth.ScopeReconstruct, logger),
+				rpc.TenancyStreamInterceptor(registry, logger),
+				rpc.RateLimitStreamInterceptor(limiter, logger)),
+		)
+		rpc.NewItineraryServer(registry.DefaultService(), logger).Register(grpcServer)
+		go grpcServer.Serve(listener)
+
+		connection, err = grpc.NewClient("passthrough:///bufnet",
+			grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) {
+				return listener.Dial()
+			}),
+			grpc.WithTransportCredentials(insecure.NewCredentials()))
+		Expect(err).Should(BeNil())
+		client = itineraryv1.NewItineraryServiceClient(connection)
+	})
+
+	AfterEach(func() {
+		connection.Close()
+		grpcServer.Stop()
+		registry.Close()
+	})
+
+	callAs := func(key string, pairs ...string) context.Context {
+		return metadata.NewOutgoingContext(context.Background(),
+			metadata.Pairs(append([]string{"x-api-key", key}, pairs...)...))
+	}
+
+	reconstruct := func(ctx context.Context) error {
+		_, err := client.Reconstruct(ctx, &itineraryv1.ReconstructRequest{
+			Tickets: []*itineraryv1.Ticket{{Source: "JFK", Destination: "DXB"}},
+		})
+		return err
+	}
+
+	It("should serve callers bound to a tenant by their tenant", func() {
+		err := reconstruct(callAs("bound"))
+
+		Expect(status.Code(err)).To(Equal(codes.InvalidArgument))
+		Expect(status.Convert(err).Message()).To(ContainSubstring("blocked airport DXB"))
+		Expect(status.Code(reconstruct(callAs("bound", "x-tenant-id", tenant.DefaultID)))).
+			To(Equal(codes.PermissionDenied))
+	})
+
+	It("should let administrators choose the tenant with x-tenant-id metadata", func() {
+		Expect(reconstruct(callAs("admin"))).To(Succeed())
+		Expect(status.Code(reconstruct(callAs("admin", "x-tenant-id", "acme")))).To(Equal(codes.InvalidArgument))
+		Expect(status.Code(reconstruct(callAs("admin", "x-tenant-id", "missing")))).To(Equal(codes.NotFound))
+	})
+
+	It("should not let other callers choose a tenant", func() {
+		err := reconstruct(callAs("reader", "x-tenant-id", "acme"))
+
+		Expect(status.Code(err)).To(Equal(codes.PermissionDenied))
+		Expect(reconstruct(callAs("reader", "x-tenant-id", tenant.DefaultID))).To(Succeed())
+	})
+
+	It("should apply the tenant's rules to streamed tickets", func() {
+		stream, err := client.ReconstructStream(callAs("admin", "x-tenant-id", "acme"))
+		Expect(err).Should(BeNil())
+
+		Expect(stream.Send(&itineraryv1.Ticket{Source: "JFK", Destination: "DXB"})).To(Succeed())
+		_, err = stream.CloseAndRecv()
+
+		Expect(status.Code(err)).To(Equal(codes.InvalidArgument))
+	})
+})
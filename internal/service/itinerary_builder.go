@@ -0,0 +1,503 @@
+package service
+
+import (
+	"container/heap"
+	"fmt"
+	"sort"
+
+	"flight-itinerary-go/internal/model"
+	"flight-itinerary-go/pkg/errors"
+)
+
+// Conflict types describing why the tickets do not form a single itinerary
+const (
+	ConflictDuplicateDeparture = "duplicate_departure"
+	ConflictDuplicateArrival   = "duplicate_arrival"
+	ConflictCircularRoute      = "circular_route"
+	ConflictDisconnected       = "disconnected"
+)
+
+// Conflict describes a single problem preventing reconstruction
+type Conflict struct {
+	Type    string
+	Airport string
+	// Ticket is the ticket that could not be linked, for duplicate departures and arrivals
+	Ticket  *model.Ticket
+	Message string
+}
+
+// ItineraryState is a snapshot of the tickets held by an ItineraryBuilder
+type ItineraryState struct {
+	Tickets int
+	// Itinerary is set once the tickets form a single connected route
+	Itinerary []string
+	// Fragments lists every chain of connecting tickets, ordered by departure airport
+	Fragments [][]string
+	Conflicts []Conflict
+}
+
+// link is a ticket placed in a chain of connecting tickets
+type link struct {
+	ticket     model.Ticket
+	sequence   int
+	prev, next *link
+}
+
+// fragment is the route of one chain as of the last State, and whether the chain is closed
+type fragment struct {
+	route    []string
+	circular bool
+}
+
+// ItineraryBuilder keeps tickets as linked chains indexed by their endpoints, so adding or
+// removing a ticket only relinks its neighbours instead of rebuilding the whole itinerary.
+// A ticket is linked exactly when no earlier linked ticket departs from its source or arrives at
+// its destination, as if the tickets were added again in their order, so the state never
+// depends on the order of edits. Parked tickets are indexed by their endpoints too, so a removal
+// only rechecks the tickets waiting for the airports it frees. State keeps a snapshot of every
+// chain and only walks the chains changed since it was last called.
+// An ItineraryBuilder is not safe for concurrent use.
+type ItineraryBuilder struct {
+	// departures and arrivals index every linked ticket by its source and destination
+	departures map[string]*link
+	arrivals   map[string]*link
+	// heads and tails index the first and last link of every open chain
+	heads map[string]*link
+	tails map[string]*link
+	// conflicts holds tickets that cannot be linked because an earlier ticket already departs
+	// from their source or arrives at their destination, by the order they were added in;
+	// parkedDepartures and parkedArrivals index them by their source and destination
+	conflicts        map[int]model.Ticket
+	parkedDepartures map[string]map[int]bool
+	parkedArrivals   map[string]map[int]bool
+	// added counts the tickets added, ordering them as a ticket list would
+	added int
+	// fragments holds the snapshot of every chain by key, the source of its first ticket or, for a
+	// closed chain, its smallest source; keys records the key of each link's chain in the snapshot
+	fragments map[string]fragment
+	keys      map[*link]string
+	// dirty holds links whose chain changed since the last State
+	dirty map[*link]bool
+}
+
+// NewItineraryBuilder creates an empty ItineraryBuilder
+func NewItineraryBuilder() *ItineraryBuilder {
+	return &ItineraryBuilder{
+		departures: make(map[string]*link),
+		arrivals:   make(map[string]*link),
+		heads:      make(map[string]*link),
+		tails:      make(map[string]*link),
+
+		conflicts:        make(map[int]model.Ticket),
+		parkedDepartures: make(map[string]map[int]bool),
+		parkedArrivals:   make(map[string]map[int]bool),
+
+		fragments: make(map[string]fragment),
+		keys:      make(map[*link]string),
+		dirty:     make(map[*link]bool),
+	}
+}
+
+// Len returns the number of tickets held, linked or conflicting
+func (builder *ItineraryBuilder) Len() int {
+	return len(builder.departures) + len(builder.conflicts)
+}
+
+// Add links the ticket between the chain arriving at its source and the chain departing from
+// its destination; it is parked as a conflict when either endpoint is already taken
+func (builder *ItineraryBuilder) Add(ticket model.Ticket) {
+	builder.added++
+	if !builder.link(ticket, builder.added) {
+		builder.park(ticket, builder.added)
+	}
+}
+
+// Remove unlinks the ticket, splitting its chain in two, and reports whether it was held. Of
+// several equal tickets the one added last is removed.
+func (builder *ItineraryBuilder) Remove(ticket model.Ticket) bool {
+	// Equal tickets share both endpoints, so a linked copy is always the earliest one
+	last := 0
+	for sequence := range builder.parkedDepartures[ticket.Source()] {
+		if builder.conflicts[sequence] == ticket && sequence > last {
+			last = sequence
+		}
+	}
+	if last != 0 {
+		builder.unpark(last)
+		return true
+	}
+
+	removed, exists := builder.departures[ticket.Source()]
+	if !exists || removed.ticket != ticket {
+		return false
+	}
+	builder.unlink(removed)
+
+	// Parked tickets waiting for the freed departure or arrival may link now
+	candidates := &sequences{}
+	builder.wake(candidates, builder.parkedDepartures[ticket.Source()])
+	builder.wake(candidates, builder.parkedArrivals[ticket.Destination()])
+	builder.relink(candidates)
+	return true
+}
+
+// relink links the candidate parked tickets in the order they were added, when no earlier ticket
+// holds their endpoints. Later tickets holding them are parked in turn, which frees their other
+// endpoint for the tickets waiting on it; those were added later still, so every ticket is
+// decided once everything added before it is.
+func (builder *ItineraryBuilder) relink(candidates *sequences) {
+	for candidates.Len() > 0 {
+		sequence := heap.Pop(candidates).(int)
+		ticket, parked := builder.conflicts[sequence]
+		if !parked {
+			continue
+		}
+		departure := builder.departures[ticket.Source()]
+		arrival := builder.arrivals[ticket.Destination()]
+		if (departure != nil && departure.sequence < sequence) || (arrival != nil && arrival.sequence < sequence) {
+			continue
+		}
+
+		if departure != nil {
+			builder.unlink(departure)
+			builder.park(departure.ticket, departure.sequence)
+			builder.wake(candidates, builder.parkedArrivals[departure.ticket.Destination()])
+		}
+		if arrival != nil && arrival != departure {
+			builder.unlink(arrival)
+			builder.park(arrival.ticket, arrival.sequence)
+			builder.wake(candidates, builder.parkedDepartures[arrival.ticket.Source()])
+		}
+		builder.unpark(sequence)
+		builder.link(ticket, sequence)
+	}
+}
+
+// wake adds the parked tickets to the candidates
+func (builder *ItineraryBuilder) wake(candidates *sequences, parked map[int]bool) {
+	for sequence := range parked {
+		heap.Push(candidates, sequence)
+	}
+}
+
+// park records a ticket that cannot be linked
+func (builder *ItineraryBuilder) park(ticket model.Ticket, sequence int) {
+	builder.conflicts[sequence] = ticket
+	index := func(byAirport map[string]map[int]bool, airport string) {
+		if byAirport[airport] == nil {
+			byAirport[airport] = make(map[int]bool)
+		}
+		byAirport[airport][sequence] = true
+	}
+	index(builder.parkedDepartures, ticket.Source())
+	index(builder.parkedArrivals, ticket.Destination())
+}
+
+// unpark drops a parked ticket
+func (builder *ItineraryBuilder) unpark(sequence int) {
+	ticket := builder.conflicts[sequence]
+	delete(builder.conflicts, sequence)
+	unindex := func(byAirport map[string]map[int]bool, airport string) {
+		delete(byAirport[airport], sequence)
+		if len(byAirport[airport]) == 0 {
+			delete(byAirport, airport)
+		}
+	}
+	unindex(builder.parkedDepartures, ticket.Source())
+	unindex(builder.parkedArrivals, ticket.Destination())
+}
+
+// unlink takes a linked ticket out of its chain, splitting the chain in two
+func (builder *ItineraryBuilder) unlink(removed *link) {
+	src, dst := removed.ticket.Source(), removed.ticket.Destination()
+	delete(builder.departures, src)
+	delete(builder.arrivals, dst)
+	builder.touch(removed)
+
+	// A ticket looping back to its own source is its own neighbour on both sides
+	if removed.prev != nil && removed.prev != removed {
+		removed.prev.next = nil
+		builder.tails[src] = removed.prev
+	} else {
+		delete(builder.heads, src)
+	}
+	if removed.next != nil && removed.next != removed {
+		removed.next.prev = nil
+		builder.heads[dst] = removed.next
+	} else {
+		delete(builder.tails, dst)
+	}
+	removed.prev, removed.next = nil, nil
+}
+
+// Itinerary returns the current itinerary, or the error explaining why the tickets do not
+// form one. Results and errors are those of reconstructing the tickets as a list in the order
+// they were added: the first repeated departure is reported, the route starts at the first
+// ticket departing from an airport no ticket arrives at, and it must use every ticket.
+func (builder *ItineraryBuilder) Itinerary() ([]string, error) {
+	if builder.Len() == 0 {
+		return nil, errors.NewValidationError("no tickets provided")
+	}
+	if airport, duplicated := builder.firstDuplicateDeparture(); duplicated {
+		return nil, errors.NewValidationError("duplicate route from %s", airport)
+	}
+
+	// Departures are unique from here on, so parked tickets only repeat an arrival
+	parkedDepartures := make(map[string]string, len(builder.conflicts))
+	start, first := "", 0
+	for sequence, ticket := range builder.conflicts {
+		parkedDepartures[ticket.Source()] = ticket.Destination()
+		if _, arrived := builder.arrivals[ticket.Source()]; !arrived && (first == 0 || sequence < first) {
+			start, first = ticket.Source(), sequence
+		}
+	}
+	for src, head := range builder.heads {
+		if first == 0 || head.sequence < first {
+			start, first = src, head.sequence
+		}
+	}
+	if first == 0 {
+		return nil, errors.ErrNoStartingPoint
+	}
+
+	route := []string{start}
+	visited := make(map[string]bool, builder.Len())
+	for current := start; len(route) <= builder.Len(); {
+		if visited[current] {
+			return nil, errors.ErrCircularRoute
+		}
+		visited[current] = true
+		next, exists := parkedDepartures[current]
+		if departure, linked := builder.departures[current]; linked {
+			next, exists = departure.ticket.Destination(), true
+		}
+		if !exists {
+			break
+		}
+		route = append(route, next)
+		current = next
+	}
+	if len(route) != builder.Len()+1 {
+		return nil, errors.ErrDisconnectedRoute
+	}
+	return route, nil
+}
+
+// firstDuplicateDeparture returns the source of the first ticket, in the order added, that
+// departs from an airport an earlier ticket departs from
+func (builder *ItineraryBuilder) firstDuplicateDeparture() (string, bool) {
+	// Only parked tickets can repeat a departure; the earliest ticket from an airport is kept as
+	// the first and every later one is a repeat
+	earliest := make(map[string]int, len(builder.conflicts))
+	for src, departure := range builder.departures {
+		earliest[src] = departure.sequence
+	}
+	airport, first := "", 0
+	for sequence, ticket := range builder.conflicts {
+		src := ticket.Source()
+		if seen, exists := earliest[src]; !exists || sequence < seen {
+			earliest[src] = sequence
+		}
+	}
+	for sequence, ticket := range builder.conflicts {
+		src := ticket.Source()
+		if sequence > earliest[src] && (first == 0 || sequence < first) {
+			airport, first = src, sequence
+		}
+	}
+	for src, departure := range builder.departures {
+		if departure.sequence > earliest[src] && (first == 0 || departure.sequence < first) {
+			airport, first = src, departure.sequence
+		}
+	}
+	return airport, first != 0
+}
+
+// State reports the fragments and conflicts, along with the itinerary when the tickets form a
+// single route. Open chains come first, then closed ones, each ordered by key, and parked tickets
+// follow in the order they were added. The fragments are shared with the builder's snapshot and
+// must not be modified.
+func (builder *ItineraryBuilder) State() ItineraryState {
+	builder.refresh()
+	state := ItineraryState{
+		Tickets:   builder.Len(),
+		Fragments: make([][]string, 0, len(builder.fragments)),
+		Conflicts: []Conflict{},
+	}
+
+	var closed []string
+	for _, key := range sortedKeys(builder.fragments) {
+		if builder.fragments[key].circular {
+			closed = append(closed, key)
+			continue
+		}
+		state.Fragments = append(state.Fragments, builder.fragments[key].route)
+	}
+	for _, key := range closed {
+		state.Fragments = append(state.Fragments, builder.fragments[key].route)
+		state.Conflicts = append(state.Conflicts, Conflict{
+			Type:    ConflictCircularRoute,
+			Airport: key,
+			Message: fmt.Sprintf("circular route through %s", key),
+		})
+	}
+
+	parkedOrder := make([]int, 0, len(builder.conflicts))
+	for sequence := range builder.conflicts {
+		parkedOrder = append(parkedOrder, sequence)
+	}
+	sort.Ints(parkedOrder)
+	for _, sequence := range parkedOrder {
+		ticket := builder.conflicts[sequence]
+		parked := Conflict{
+			Type:    ConflictDuplicateDeparture,
+			Airport: ticket.Source(),
+			Ticket:  &ticket,
+			Message: fmt.Sprintf("duplicate route from %s", ticket.Source()),
+		}
+		if _, taken := builder.departures[ticket.Source()]; !taken {
+			parked.Type = ConflictDuplicateArrival
+			parked.Airport = ticket.Destination()
+			parked.Message = fmt.Sprintf("multiple tickets arriving at %s", ticket.Destination())
+		}
+		state.Conflicts = append(state.Conflicts, parked)
+	}
+
+	if len(state.Fragments) > 1 {
+		state.Conflicts = append(state.Conflicts, Conflict{
+			Type:    ConflictDisconnected,
+			Message: fmt.Sprintf("tickets form %d disconnected fragments", len(state.Fragments)),
+		})
+	}
+
+	if len(state.Fragments) == 1 && len(state.Conflicts) == 0 {
+		state.Itinerary = state.Fragments[0]
+	}
+	return state
+}
+
+func (builder *ItineraryBuilder) link(ticket model.Ticket, sequence int) bool {
+	src, dst := ticket.Source(), ticket.Destination()
+	if _, taken := builder.departures[src]; taken {
+		return false
+	}
+	if _, taken := builder.arrivals[dst]; taken {
+		return false
+	}
+
+	added := &link{ticket: ticket, sequence: sequence}
+	builder.departures[src] = added
+	builder.arrivals[dst] = added
+	defer builder.touch(added)
+
+	if previous, exists := builder.arrivals[src]; exists {
+		previous.next = added
+		added.prev = previous
+		delete(builder.tails, src)
+	} else {
+		builder.heads[src] = added
+	}
+	if next, exists := builder.departures[dst]; exists {
+		next.prev = added
+		added.next = next
+		delete(builder.heads, dst)
+	} else {
+		builder.tails[dst] = added
+	}
+	return true
+}
+
+// touch marks a link and its neighbours for the next State
+func (builder *ItineraryBuilder) touch(changed *link) {
+	for _, touched := range []*link{changed, changed.prev, changed.next} {
+		if touched != nil {
+			builder.dirty[touched] = true
+		}
+	}
+}
+
+// refresh drops the snapshot of every changed chain, then walks each changed chain still held
+// once, so unchanged chains are not walked again
+func (builder *ItineraryBuilder) refresh() {
+	for touched := range builder.dirty {
+		if key, exists := builder.keys[touched]; exists {
+			delete(builder.fragments, key)
+		}
+	}
+	refreshed := make(map[*link]bool)
+	for touched := range builder.dirty {
+		if builder.departures[touched.ticket.Source()] != touched {
+			delete(builder.keys, touched)
+			continue
+		}
+		if !refreshed[touched] {
+			builder.snapshot(touched, refreshed)
+		}
+	}
+	clear(builder.dirty)
+}
+
+// snapshot walks the chain holding the link, records it under its key and adds its links to
+// visited
+func (builder *ItineraryBuilder) snapshot(member *link, visited map[*link]bool) {
+	start, circular := member, false
+	for start.prev != nil {
+		start = start.prev
+		if start == member {
+			circular = true
+			break
+		}
+	}
+	if circular {
+		// Closed chains start at their smallest source
+		for current := member.next; current != member; current = current.next {
+			if current.ticket.Source() < start.ticket.Source() {
+				start = current
+			}
+		}
+	}
+
+	key := start.ticket.Source()
+	builder.fragments[key] = fragment{route: walk(start, visited), circular: circular}
+	for current := start; current != nil; current = current.next {
+		builder.keys[current] = key
+		if current.next == start {
+			break
+		}
+	}
+}
+
+// walk follows the chain from start, stopping at its end or when it loops back
+func walk(start *link, visited map[*link]bool) []string {
+	route := []string{start.ticket.Source()}
+	for current := start; current != nil && !visited[current]; current = current.next {
+		visited[current] = true
+		route = append(route, current.ticket.Destination())
+	}
+	return route
+}
+
+// sequences is a min-heap of ticket sequences
+type sequences []int
+
+func (queue sequences) Len() int           { return len(queue) }
+func (queue sequences) Less(i, j int) bool { return queue[i] < queue[j] }
+func (queue sequences) Swap(i, j int)      { queue[i], queue[j] = queue[j], queue[i] }
+
+func (queue *sequences) Push(sequence any) { *queue = append(*queue, sequence.(int)) }
+
+func (queue *sequences) Pop() any {
+	old := *queue
+	last := old[len(old)-1]
+	*queue = old[:len(old)-1]
+	return last
+}
+
+func sortedKeys[V any](index map[string]V) []string {
+	keys := make([]string, 0, len(index))
+	for key := range index {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,316 @@
+package service_test
+
+import (
+	"fmt"
+	"math/rand"
+	"slices"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"flight-itinerary-go/internal/model"
+	"flight-itinerary-go/internal/service"
+	"flight-itinerary-go/pkg/errors"
+)
+
+var _ = Describe("ItineraryBuilder", func() {
+	var builder *service.ItineraryBuilder
+
+	BeforeEach(func() {
+		builder = service.NewItineraryBuilder()
+	})
+
+	add := func(tickets ...model.Ticket) {
+		for _, ticket := range tickets {
+			builder.Add(ticket)
+		}
+	}
+
+	conflictTypes := func() []string {
+		types := []string{}
+		for _, conflict := range builder.State().Conflicts {
+			types = append(types, conflict.Type)
+		}
+		return types
+	}
+
+	It("should reject an empty builder", func() {
+		_, err := builder.Itinerary()
+
+		Expect(err).To(MatchError(ContainSubstring("no tickets provided")))
+		Expect(builder.State().Fragments).To(BeEmpty())
+	})
+
+	It("should join chains regardless of the order tickets arrive in", func() {
+		add(model.Ticket{"SFO", "SJC"}, model.Ticket{"JFK", "LAX"})
+
+		Expect(builder.State().Fragments).To(Equal([][]string{{"JFK", "LAX"}, {"SFO", "SJC"}}))
+		Expect(conflictTypes()).To(Equal([]string{service.ConflictDisconnected}))
+		_, err := builder.Itinerary()
+		Expect(err).To(Equal(errors.ErrDisconnectedRoute))
+
+		add(model.Ticket{"DXB", "SFO"}, model.Ticket{"LAX", "DXB"})
+
+		itinerary, err := builder.Itinerary()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(itinerary).To(Equal([]string{"JFK", "LAX", "DXB", "SFO", "SJC"}))
+		Expect(builder.State().Itinerary).To(Equal(itinerary))
+	})
+
+	It("should split the chain when a ticket in the middle is removed", func() {
+		add(model.Ticket{"JFK", "LAX"}, model.Ticket{"LAX", "DXB"}, model.Ticket{"DXB", "SFO"})
+
+		Expect(builder.Remove(model.Ticket{"LAX", "DXB"})).To(BeTrue())
+		Expect(builder.Len()).To(Equal(2))
+		Expect(builder.State().Fragments).To(Equal([][]string{{"DXB", "SFO"}, {"JFK", "LAX"}}))
+	})
+
+	It("should report tickets it does not hold", func() {
+		add(model.Ticket{"JFK", "LAX"})
+
+		Expect(builder.Remove(model.Ticket{"JFK", "SFO"})).To(BeFalse())
+		Expect(builder.Len()).To(Equal(1))
+	})
+
+	It("should park duplicate departures and link them once the original is removed", func() {
+		add(model.Ticket{"JFK", "LAX"}, model.Ticket{"JFK", "SFO"})
+
+		state := builder.State()
+		Expect(state.Conflicts).To(HaveLen(1))
+		Expect(state.Conflicts[0].Type).To(Equal(service.ConflictDuplicateDeparture))
+		Expect(state.Conflicts[0].Ticket).To(Equal(&model.Ticket{"JFK", "SFO"}))
+		_, err := builder.Itinerary()
+		Expect(err).To(MatchError("duplicate route from JFK"))
+
+		builder.Remove(model.Ticket{"JFK", "LAX"})
+
+		itinerary, err := builder.Itinerary()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(itinerary).To(Equal([]string{"JFK", "SFO"}))
+	})
+
+	It("should park duplicate arrivals", func() {
+		add(model.Ticket{"JFK", "LAX"}, model.Ticket{"SFO", "LAX"})
+
+		Expect(conflictTypes()).To(Equal([]string{service.ConflictDuplicateArrival}))
+		_, err := builder.Itinerary()
+		Expect(err).To(Equal(errors.ErrDisconnectedRoute))
+	})
+
+	It("should detect closed loops and reopen them on removal", func() {
+		add(model.Ticket{"JFK", "LAX"}, model.Ticket{"LAX", "DXB"}, model.Ticket{"DXB", "JFK"})
+
+		Expect(builder.State().Fragments).To(Equal([][]string{{"DXB", "JFK", "LAX", "DXB"}}))
+		Expect(conflictTypes()).To(Equal([]string{service.ConflictCircularRoute}))
+		_, err := builder.Itinerary()
+		Expect(err).To(Equal(errors.ErrNoStartingPoint))
+
+		builder.Remove(model.Ticket{"DXB", "JFK"})
+
+		itinerary, err := builder.Itinerary()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(itinerary).To(Equal([]string{"JFK", "LAX", "DXB"}))
+	})
+
+	It("should report a loop beside the route as disconnected, as the route never reaches it", func() {
+		add(model.Ticket{"JFK", "LAX"}, model.Ticket{"DXB", "SFO"}, model.Ticket{"SFO", "DXB"})
+
+		Expect(conflictTypes()).To(ContainElement(service.ConflictCircularRoute))
+		_, err := builder.Itinerary()
+		Expect(err).To(Equal(errors.ErrDisconnectedRoute))
+	})
+
+	It("should relink every parked ticket the removed ticket was blocking", func() {
+		add(model.Ticket{"S", "A"}, model.Ticket{"A", "B"}, model.Ticket{"A", "C"}, model.Ticket{"C", "Q"},
+			model.Ticket{"Q", "B"})
+
+		Expect(builder.Remove(model.Ticket{"A", "B"})).To(BeTrue())
+
+		itinerary, err := builder.Itinerary()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(itinerary).To(Equal([]string{"S", "A", "C", "Q", "B"}))
+		Expect(builder.State().Conflicts).To(BeEmpty())
+	})
+
+	It("should treat a ticket back to its own source as circular", func() {
+		add(model.Ticket{"JFK", "JFK"})
+
+		Expect(conflictTypes()).To(Equal([]string{service.ConflictCircularRoute}))
+
+		Expect(builder.Remove(model.Ticket{"JFK", "JFK"})).To(BeTrue())
+		Expect(builder.State().Fragments).To(BeEmpty())
+	})
+
+	DescribeTable("should reconstruct like the batch algorithm",
+		func(tickets []model.Ticket, expected []string, expectedErr error) {
+			add(tickets...)
+
+			itinerary, err := builder.Itinerary()
+			batchItinerary, batchErr := batchReconstruct(tickets)
+			Expect(fmt.Sprint(itinerary, err)).To(Equal(fmt.Sprint(batchItinerary, batchErr)))
+			if expectedErr != nil {
+				Expect(err).To(Equal(expectedErr))
+				return
+			}
+			Expect(err).ToNot(HaveOccurred())
+			Expect(itinerary).To(Equal(expected))
+		},
+		Entry("revisiting an airport", []model.Ticket{{"A", "B"}, {"B", "C"}, {"C", "B"}},
+			[]string{"A", "B", "C", "B"}, nil),
+		Entry("a loop with a separate ticket", []model.Ticket{{"A", "B"}, {"B", "C"}, {"C", "A"}, {"X", "Y"}},
+			nil, errors.ErrDisconnectedRoute),
+		Entry("a closed loop", []model.Ticket{{"A", "B"}, {"B", "A"}}, nil, errors.ErrNoStartingPoint),
+		Entry("a duplicate departure", []model.Ticket{{"A", "B"}, {"A", "C"}}, nil,
+			errors.NewValidationError("duplicate route from %s", "A")),
+	)
+
+	It("should reconstruct like the batch algorithm for any ticket list", func() {
+		airports := []string{"A", "B", "C", "D", "E"}
+		random := rand.New(rand.NewSource(GinkgoRandomSeed()))
+		for round := 0; round < 2000; round++ {
+			tickets := make([]model.Ticket, 1+random.Intn(6))
+			for i := range tickets {
+				tickets[i] = model.Ticket{airports[random.Intn(len(airports))], airports[random.Intn(len(airports))]}
+			}
+			rebuilt := service.NewItineraryBuilder()
+			for _, ticket := range tickets {
+				rebuilt.Add(ticket)
+			}
+
+			itinerary, err := rebuilt.Itinerary()
+			expected, expectedErr := batchReconstruct(tickets)
+			Expect(fmt.Sprint(itinerary, err)).To(Equal(fmt.Sprint(expected, expectedErr)), "%v", tickets)
+		}
+	})
+
+	It("should keep its snapshot equal to a builder rebuilt from the same tickets", func() {
+		airports := []string{"A", "B", "C", "D", "E", "F"}
+		random := rand.New(rand.NewSource(GinkgoRandomSeed()))
+		var held []model.Ticket
+		for step := 0; step < 2000; step++ {
+			if len(held) > 0 && random.Intn(3) == 0 {
+				i := random.Intn(len(held))
+				Expect(builder.Remove(held[i])).To(BeTrue())
+				held = append(held[:i], held[i+1:]...)
+			} else {
+				ticket := model.Ticket{airports[random.Intn(len(airports))], airports[random.Intn(len(airports))]}
+				builder.Add(ticket)
+				held = append(held, ticket)
+			}
+			if random.Intn(4) != 0 {
+				continue
+			}
+
+			// Linking the same tickets first and parking the same conflicts yields the same chains
+			state := builder.State()
+			rebuilt := service.NewItineraryBuilder()
+			linked := append([]model.Ticket{}, held...)
+			for _, conflict := range state.Conflicts {
+				if conflict.Ticket != nil {
+					i := slices.Index(linked, *conflict.Ticket)
+					linked = append(linked[:i], linked[i+1:]...)
+				}
+			}
+			for _, ticket := range linked {
+				rebuilt.Add(ticket)
+			}
+			Expect(rebuilt.State().Conflicts).To(HaveLen(len(state.Conflicts)-(len(held)-len(linked))),
+				"linked tickets must not conflict")
+			Expect(rebuilt.State().Fragments).To(Equal(state.Fragments))
+		}
+	})
+
+	It("should report the same state whatever order the tickets were edited in", func() {
+		held := []model.Ticket{{"B", "D"}, {"B", "C"}, {"B", "E"}, {"C", "B"}, {"D", "B"}, {"B", "D"}, {"A", "C"}}
+		fresh := service.NewItineraryBuilder()
+		for _, ticket := range held {
+			fresh.Add(ticket)
+		}
+
+		// C-F keeps C-B parked until it is removed, when D-B already arrives at B
+		add(model.Ticket{"C", "F"})
+		add(held...)
+		Expect(builder.Remove(model.Ticket{"C", "F"})).To(BeTrue())
+
+		Expect(builder.State()).To(Equal(fresh.State()))
+		Expect(builder.State().Fragments).To(Equal([][]string{{"A", "C", "B", "D"}}))
+	})
+
+	It("should keep its state equal to a builder given the held tickets in order", func() {
+		airports := []string{"A", "B", "C", "D", "E", "F"}
+		random := rand.New(rand.NewSource(GinkgoRandomSeed()))
+		var held []model.Ticket
+		for step := 0; step < 2000; step++ {
+			if len(held) > 0 && random.Intn(3) == 0 {
+				// Of equal tickets the one added last is removed
+				ticket := held[random.Intn(len(held))]
+				Expect(builder.Remove(ticket)).To(BeTrue())
+				for i := len(held) - 1; i >= 0; i-- {
+					if held[i] == ticket {
+						held = slices.Delete(held, i, i+1)
+						break
+					}
+				}
+			} else {
+				ticket := model.Ticket{airports[random.Intn(len(airports))], airports[random.Intn(len(airports))]}
+				builder.Add(ticket)
+				held = append(held, ticket)
+			}
+			if random.Intn(4) != 0 {
+				continue
+			}
+
+			rebuilt := service.NewItineraryBuilder()
+			for _, ticket := range held {
+				rebuilt.Add(ticket)
+			}
+			Expect(builder.State()).To(Equal(rebuilt.State()), "%v", held)
+		}
+	})
+})
+
+// batchReconstruct is the reconstruction the builder replaced, kept as the reference for its results
+// and errors
+func batchReconstruct(tickets []model.Ticket) ([]string, error) {
+	graph := make(map[string]string)
+	for _, ticket := range tickets {
+		if _, exists := graph[ticket.Source()]; exists {
+			return nil, errors.NewValidationError("duplicate route from %s", ticket.Source())
+		}
+		graph[ticket.Source()] = ticket.Destination()
+	}
+
+	destinations := make(map[string]bool)
+	for _, ticket := range tickets {
+		destinations[ticket.Destination()] = true
+	}
+	start := ""
+	for _, ticket := range tickets {
+		if !destinations[ticket.Source()] {
+			start = ticket.Source()
+			break
+		}
+	}
+	if start == "" {
+		return nil, errors.ErrNoStartingPoint
+	}
+
+	itinerary := []string{start}
+	visited := make(map[string]bool)
+	for current, i := start, 0; i < len(tickets); i++ {
+		if visited[current] {
+			return nil, errors.ErrCircularRoute
+		}
+		visited[current] = true
+		next, exists := graph[current]
+		if !exists {
+			break
+		}
+		itinerary = append(itinerary, next)
+		current = next
+	}
+	if len(itinerary) != len(tickets)+1 {
+		return nil, errors.ErrDisconnectedRoute
+	}
+	return itinerary, nil
+}
@@ -30,79 +30,18 @@ func (itineraryService *ItineraryServiceV1) ReconstructItinerary(tickets []model
 		return nil, errors.NewValidationError("no tickets provided")
 	}
 
-	// Build adjacencyGraph and track destinations
-	adjacencyGraph := make(map[string]string)
-
+	builder := NewItineraryBuilder()
 	for _, ticket := range tickets {
-		src, dst := ticket.Source(), ticket.Destination()
-		// Check for duplicate routes
-		if existing, exists := adjacencyGraph[src]; exists {
-			itineraryService.logger.Warn("Duplicate route found", zap.String("source", src),
-				zap.String("existing_dest", existing), zap.String("new_dest", dst))
-			return nil, errors.NewValidationError("duplicate route from %s", src)
-		}
-		adjacencyGraph[src] = dst
+		builder.Add(ticket)
 	}
-	itineraryService.logger.Debug("Graph built", zap.Int("nodes", len(adjacencyGraph)))
-	// Find starting point
-	startingPoint, err := itineraryService.findStartingPoint(tickets)
-	if err != nil {
-		itineraryService.logger.Error("Failed to find starting point", zap.Error(err))
-		return nil, err
-	}
-	itineraryService.logger.Info("Starting point found", zap.String("start", startingPoint))
+	itineraryService.logger.Debug("Chains built", zap.Int("tickets", builder.Len()))
 
-	// Reconstruct itinerary
-	itinerary, err := itineraryService.buildItinerary(adjacencyGraph, startingPoint, len(tickets))
+	itinerary, err := builder.Itinerary()
 	if err != nil {
+		itineraryService.logger.Warn("Failed to reconstruct itinerary", zap.Error(err))
 		return nil, err
 	}
-
-	// Validate completeness
-	if len(itinerary) != len(tickets)+1 {
-		itineraryService.logger.Error("Failed to build itinerary as itinerary route disconnected!!")
-		return nil, errors.ErrDisconnectedRoute
-	}
-
+	itineraryService.logger.Info("Itinerary reconstructed", zap.String("start", itinerary[0]),
+		zap.Int("stops", len(itinerary)))
 	return itinerary, nil
 }
-
-func (itineraryService *ItineraryServiceV1) buildItinerary(graph map[string]string, startingPoint string,
-	expectedHops int) ([]string, error) {
-	itinerary := []string{startingPoint}
-	current := startingPoint
-	visited := make(map[string]bool)
-
-	for i := 0; i < expectedHops; i++ {
-		if visited[current] {
-			itineraryService.logger.Warn("Circular route detected", zap.String("city", current),
-				zap.Int("step", i))
-			return nil, errors.ErrCircularRoute
-		}
-		visited[current] = true
-		next, exists := graph[current]
-		if !exists {
-			itineraryService.logger.Warn("Route ends prematurely", zap.String("city", current),
-				zap.Int("step", i))
-			break
-		}
-		itinerary = append(itinerary, next)
-		current = next
-	}
-	return itinerary, nil
-}
-
-func (itineraryService *ItineraryServiceV1) findStartingPoint(tickets []model.Ticket) (string, error) {
-	destinationSet := make(map[string]bool)
-	for _, ticket := range tickets {
-		_, dst := ticket.Source(), ticket.Destination()
-		destinationSet[dst] = true
-	}
-
-	for _, ticket := range tickets {
-		if !destinationSet[ticket.Source()] {
-			return ticket.Source(), nil
-		}
-	}
-	return "", errors.ErrNoStartingPoint
-}
@@ -0,0 +1,224 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+
+	"flight-itinerary-go/internal/model"
+	"flight-itinerary-go/internal/storage"
+	"flight-itinerary-go/pkg/errors"
+)
+
+// TripService defines the interface for stored itinerary operations
+type TripService interface {
+	Create(tickets []model.Ticket, departure *time.Time) (storage.Itinerary, error)
+	Get(id string) (storage.Itinerary, error)
+	List(query storage.Query, offset, limit int) ([]storage.Itinerary, int, error)
+	UpdateTickets(id string, expected int, tickets []model.Ticket) (storage.Itinerary, error)
+	Patch(id string, expected int, operations []model.TicketOperation) (storage.Itinerary, error)
+	History(id string) ([]storage.Itinerary, error)
+	Revert(id string, expected int, version int) (storage.Itinerary, error)
+	Delete(id string, expected int) error
+}
+
+// TripServiceV1 implements the TripService interface, reconstructing the itinerary whenever
+// its tickets change and saving every change as a new version. Changes take the version the
+// caller expects to modify and fail with errors.ErrPreconditionFailed when the stored one
+// differs; an expected version of 0 skips the check.
+type TripServiceV1 struct {
+	itineraryService ItineraryService
+	repository       storage.Repository
+	logger           *zap.Logger
+}
+
+// NewTripService creates a new instance of TripService
+func NewTripService(itineraryService ItineraryService, repository storage.Repository, logger *zap.Logger) TripService {
+	return &TripServiceV1{
+		itineraryService: itineraryService,
+		repository:       repository,
+		logger:           logger,
+	}
+}
+
+// Create reconstructs and saves a new itinerary; the departure is optional
+func (tripService *TripServiceV1) Create(tickets []model.Ticket, departure *time.Time) (storage.Itinerary, error) {
+	route, err := tripService.itineraryService.ReconstructItinerary(tickets)
+	if err != nil {
+		return storage.Itinerary{}, err
+	}
+
+	now := time.Now().UTC()
+	itinerary := storage.Itinerary{
+		ID:        uuid.NewString(),
+		Tickets:   tickets,
+		Route:     route,
+		Version:   1,
+		Change:    "created",
+		Departure: departure,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if err := tripService.repository.Create(itinerary); err != nil {
+		tripService.logger.Error("Failed to save itinerary", zap.Error(err))
+		return storage.Itinerary{}, err
+	}
+	tripService.logger.Info("Itinerary saved", zap.String("itinerary_id", itinerary.ID))
+	return itinerary, nil
+}
+
+// Get returns a stored itinerary
+func (tripService *TripServiceV1) Get(id string) (storage.Itinerary, error) {
+	return tripService.repository.Get(id)
+}
+
+// List returns a page of the stored itineraries matching the query and the total match count
+func (tripService *TripServiceV1) List(query storage.Query, offset, limit int) ([]storage.Itinerary, int, error) {
+	return tripService.repository.List(query, offset, limit)
+}
+
+// UpdateTickets replaces the tickets of a stored itinerary and reconstructs it
+func (tripService *TripServiceV1) UpdateTickets(id string, expected int, tickets []model.Ticket) (storage.Itinerary, error) {
+	return tripService.revise(id, expected, func(storage.Itinerary) ([]model.Ticket, string, error) {
+		return tickets, "replaced tickets", nil
+	})
+}
+
+// Patch applies the ticket operations in order and reconstructs the result; either every
+// operation is saved as one new version or none is
+func (tripService *TripServiceV1) Patch(id string, expected int,
+	operations []model.TicketOperation) (storage.Itinerary, error) {
+	if len(operations) == 0 {
+		return storage.Itinerary{}, errors.NewValidationError("at least one operation is required")
+	}
+	if len(operations) > model.MaxTickets {
+		return storage.Itinerary{}, errors.NewValidationError("at most %d operations are allowed, got %d",
+			model.MaxTickets, len(operations))
+	}
+	return tripService.revise(id, expected, func(current storage.Itinerary) ([]model.Ticket, string, error) {
+		tickets := append([]model.Ticket(nil), current.Tickets...)
+		changes := make([]string, 0, len(operations))
+		for i, operation := range operations {
+			var err error
+			tickets, err = applyOperation(tickets, operation)
+			if err != nil {
+				return nil, "", errors.NewValidationError("operation at index %d is invalid: %v", i, err)
+			}
+			changes = append(changes, describeOperation(operation))
+		}
+		return tickets, strings.Join(changes, "; "), nil
+	})
+}
+
+// History returns every version of a stored itinerary, oldest first
+func (tripService *TripServiceV1) History(id string) ([]storage.Itinerary, error) {
+	return tripService.repository.History(id)
+}
+
+// Revert saves the tickets of an earlier version as a new version, keeping the history intact
+func (tripService *TripServiceV1) Revert(id string, expected int, version int) (storage.Itinerary, error) {
+	history, err := tripService.repository.History(id)
+	if err != nil {
+		return storage.Itinerary{}, err
+	}
+	if version < 1 || version > len(history) {
+		return storage.Itinerary{}, errors.NewNotFoundError(fmt.Sprintf("version %d not found", version))
+	}
+	target := history[version-1]
+	return tripService.revise(id, expected, func(storage.Itinerary) ([]model.Ticket, string, error) {
+		return target.Tickets, fmt.Sprintf("reverted to version %d", version), nil
+	})
+}
+
+// revise loads the itinerary, applies the edit, reconstructs it and stores the next version. The
+// edited tickets follow the ticket limit of every other request.
+func (tripService *TripServiceV1) revise(id string, expected int,
+	edit func(storage.Itinerary) ([]model.Ticket, string, error)) (storage.Itinerary, error) {
+	itinerary, err := tripService.repository.Get(id)
+	if err != nil {
+		return storage.Itinerary{}, err
+	}
+	if expected != 0 && expected != itinerary.Version {
+		return storage.Itinerary{}, errors.ErrPreconditionFailed
+	}
+	tickets, change, err := edit(itinerary)
+	if err != nil {
+		return storage.Itinerary{}, err
+	}
+	if len(tickets) > model.MaxTickets {
+		return storage.Itinerary{}, errors.NewValidationError("at most %d tickets are allowed, got %d",
+			model.MaxTickets, len(tickets))
+	}
+	route, err := tripService.itineraryService.ReconstructItinerary(tickets)
+	if err != nil {
+		return storage.Itinerary{}, err
+	}
+
+	itinerary.Tickets = tickets
+	itinerary.Route = route
+	itinerary.Version++
+	itinerary.Change = change
+	itinerary.UpdatedAt = time.Now().UTC()
+	if err := tripService.repository.Update(itinerary); err != nil {
+		tripService.logger.Warn("Failed to update itinerary", zap.String("itinerary_id", id), zap.Error(err))
+		return storage.Itinerary{}, err
+	}
+	tripService.logger.Info("Itinerary updated", zap.String("itinerary_id", id),
+		zap.Int("version", itinerary.Version), zap.String("change", change))
+	return itinerary, nil
+}
+
+// Delete removes a stored itinerary
+func (tripService *TripServiceV1) Delete(id string, expected int) error {
+	if err := tripService.repository.Delete(id, expected); err != nil {
+		return err
+	}
+	tripService.logger.Info("Itinerary deleted", zap.String("itinerary_id", id))
+	return nil
+}
+
+func applyOperation(tickets []model.Ticket, operation model.TicketOperation) ([]model.Ticket, error) {
+	switch operation.Op {
+	case model.OperationAdd:
+		if err := operation.Ticket.Validate(); err != nil {
+			return nil, err
+		}
+		return append(tickets, operation.Ticket), nil
+	case model.OperationReplace, model.OperationVoid:
+		position := -1
+		for i, ticket := range tickets {
+			if ticket == operation.Ticket {
+				position = i
+				break
+			}
+		}
+		if position < 0 {
+			return nil, fmt.Errorf("ticket %s → %s is not part of the itinerary",
+				operation.Ticket.Source(), operation.Ticket.Destination())
+		}
+		if operation.Op == model.OperationVoid {
+			return append(tickets[:position], tickets[position+1:]...), nil
+		}
+		if operation.With == nil {
+			return nil, fmt.Errorf("replace requires a replacement ticket in \"with\"")
+		}
+		if err := operation.With.Validate(); err != nil {
+			return nil, err
+		}
+		tickets[position] = *operation.With
+		return tickets, nil
+	default:
+		return nil, fmt.Errorf("unsupported operation %q", operation.Op)
+	}
+}
+
+func describeOperation(operation model.TicketOperation) string {
+	description := fmt.Sprintf("%s %s → %s", operation.Op, operation.Ticket.Source(), operation.Ticket.Destination())
+	if operation.With != nil && operation.Op == model.OperationReplace {
+		description += fmt.Sprintf(" with %s → %s", operation.With.Source(), operation.With.Destination())
+	}
+	return description
+}
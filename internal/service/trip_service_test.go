@@ -0,0 +1,157 @@
+package service_test
+
+import (
+	"fmt"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.uber.org/zap"
+
+	"flight-itinerary-go/internal/model"
+	"flight-itinerary-go/internal/service"
+	"flight-itinerary-go/internal/storage"
+	"flight-itinerary-go/pkg/errors"
+)
+
+var _ = Describe("TripService", func() {
+	var tripService service.TripService
+
+	BeforeEach(func() {
+		logger := zap.NewNop()
+		tripService = service.NewTripService(service.NewItineraryService(logger), storage.NewMemoryRepository(), logger)
+	})
+
+	It("should save the reconstructed route with the tickets", func() {
+		saved, err := tripService.Create([]model.Ticket{{"LAX", "DXB"}, {"JFK", "LAX"}}, nil)
+
+		Expect(err).ToNot(HaveOccurred())
+		Expect(saved.ID).ToNot(BeEmpty())
+		Expect(saved.Route).To(Equal([]string{"JFK", "LAX", "DXB"}))
+		Expect(saved.CreatedAt).To(Equal(saved.UpdatedAt))
+
+		stored, err := tripService.Get(saved.ID)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(stored).To(Equal(saved))
+	})
+
+	It("should keep the stored itinerary when updated tickets cannot be reconstructed", func() {
+		saved, err := tripService.Create([]model.Ticket{{"JFK", "LAX"}}, nil)
+		Expect(err).ToNot(HaveOccurred())
+
+		_, err = tripService.UpdateTickets(saved.ID, 0, []model.Ticket{{"JFK", "LAX"}, {"LAX", "JFK"}})
+		Expect(err).To(Equal(errors.ErrNoStartingPoint))
+
+		stored, _ := tripService.Get(saved.ID)
+		Expect(stored.Route).To(Equal([]string{"JFK", "LAX"}))
+	})
+
+	It("should apply add, replace and void operations as one version", func() {
+		saved, err := tripService.Create([]model.Ticket{{"JFK", "LAX"}, {"LAX", "DXB"}}, nil)
+		Expect(err).ToNot(HaveOccurred())
+
+		patched, err := tripService.Patch(saved.ID, 0, []model.TicketOperation{
+			{Op: model.OperationAdd, Ticket: model.Ticket{"DXB", "SIN"}},
+			{Op: model.OperationReplace, Ticket: model.Ticket{"LAX", "DXB"}, With: &model.Ticket{"LAX", "DOH"}},
+			{Op: model.OperationReplace, Ticket: model.Ticket{"DXB", "SIN"}, With: &model.Ticket{"DOH", "SIN"}},
+			{Op: model.OperationVoid, Ticket: model.Ticket{"DOH", "SIN"}},
+		})
+
+		Expect(err).ToNot(HaveOccurred())
+		Expect(patched.Route).To(Equal([]string{"JFK", "LAX", "DOH"}))
+		Expect(patched.Version).To(Equal(2))
+		Expect(patched.Change).To(Equal("add DXB → SIN; replace LAX → DXB with LAX → DOH; " +
+			"replace DXB → SIN with DOH → SIN; void DOH → SIN"))
+	})
+
+	It("should save nothing when any operation fails", func() {
+		saved, err := tripService.Create([]model.Ticket{{"JFK", "LAX"}}, nil)
+		Expect(err).ToNot(HaveOccurred())
+
+		_, err = tripService.Patch(saved.ID, 0, []model.TicketOperation{
+			{Op: model.OperationAdd, Ticket: model.Ticket{"LAX", "DXB"}},
+			{Op: model.OperationVoid, Ticket: model.Ticket{"SIN", "HKG"}},
+		})
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("operation at index 1 is invalid"))
+
+		_, err = tripService.Patch(saved.ID, 0, []model.TicketOperation{
+			{Op: model.OperationAdd, Ticket: model.Ticket{"LAX", "JFK"}},
+		})
+		Expect(err).To(Equal(errors.ErrNoStartingPoint))
+
+		stored, _ := tripService.Get(saved.ID)
+		Expect(stored.Version).To(Equal(1))
+		Expect(stored.Tickets).To(Equal([]model.Ticket{{"JFK", "LAX"}}))
+	})
+
+	It("should reject unsupported operations and replacements without a ticket", func() {
+		saved, _ := tripService.Create([]model.Ticket{{"JFK", "LAX"}}, nil)
+
+		_, err := tripService.Patch(saved.ID, 0, []model.TicketOperation{{Op: "move", Ticket: model.Ticket{"JFK", "LAX"}}})
+		Expect(err).To(HaveOccurred())
+		_, err = tripService.Patch(saved.ID, 0, []model.TicketOperation{{Op: model.OperationReplace, Ticket: model.Ticket{"JFK", "LAX"}}})
+		Expect(err).To(HaveOccurred())
+		_, err = tripService.Patch(saved.ID, 0, nil)
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("should keep patched itineraries within the ticket limit", func() {
+		tickets := make([]model.Ticket, 0, model.MaxTickets)
+		for i := 0; i < model.MaxTickets; i++ {
+			tickets = append(tickets, model.Ticket{fmt.Sprintf("%04d", i), fmt.Sprintf("%04d", i+1)})
+		}
+		saved, err := tripService.Create(tickets, nil)
+		Expect(err).ToNot(HaveOccurred())
+
+		_, err = tripService.Patch(saved.ID, 0, []model.TicketOperation{
+			{Op: model.OperationAdd, Ticket: model.Ticket{fmt.Sprintf("%04d", model.MaxTickets), "XXXX"}},
+		})
+		Expect(err).To(MatchError(ContainSubstring(fmt.Sprintf("at most %d tickets", model.MaxTickets))))
+
+		operations := make([]model.TicketOperation, model.MaxTickets+1)
+		for i := range operations {
+			operations[i] = model.TicketOperation{Op: model.OperationVoid, Ticket: tickets[0]}
+		}
+		_, err = tripService.Patch(saved.ID, 0, operations)
+		Expect(err).To(MatchError(ContainSubstring(fmt.Sprintf("at most %d operations", model.MaxTickets))))
+	})
+
+	It("should revert to an earlier version as a new version", func() {
+		saved, _ := tripService.Create([]model.Ticket{{"JFK", "LAX"}}, nil)
+		_, err := tripService.UpdateTickets(saved.ID, 0, []model.Ticket{{"JFK", "LAX"}, {"LAX", "DXB"}})
+		Expect(err).ToNot(HaveOccurred())
+
+		reverted, err := tripService.Revert(saved.ID, 0, 1)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(reverted.Version).To(Equal(3))
+		Expect(reverted.Route).To(Equal([]string{"JFK", "LAX"}))
+		Expect(reverted.Change).To(Equal("reverted to version 1"))
+
+		history, err := tripService.History(saved.ID)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(history).To(HaveLen(3))
+		Expect(history[1].Route).To(Equal([]string{"JFK", "LAX", "DXB"}))
+		Expect(history[1].Change).To(Equal("replaced tickets"))
+
+		_, err = tripService.Revert(saved.ID, 0, 4)
+		Expect(err).To(HaveOccurred())
+		Expect(err.(*errors.AppError).Code).To(Equal(404))
+	})
+
+	It("should only change the version the caller expects", func() {
+		saved, _ := tripService.Create([]model.Ticket{{"JFK", "LAX"}}, nil)
+		_, err := tripService.UpdateTickets(saved.ID, 2, []model.Ticket{{"JFK", "DXB"}})
+		Expect(err).To(Equal(errors.ErrPreconditionFailed))
+		Expect(tripService.Delete(saved.ID, 2)).To(Equal(errors.ErrPreconditionFailed))
+
+		updated, err := tripService.UpdateTickets(saved.ID, 1, []model.Ticket{{"JFK", "DXB"}})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(tripService.Delete(saved.ID, updated.Version)).To(Succeed())
+	})
+
+	It("should report unknown itineraries", func() {
+		_, err := tripService.UpdateTickets("missing", 0, []model.Ticket{{"JFK", "LAX"}})
+		Expect(err).To(Equal(errors.ErrItineraryNotFound))
+		Expect(tripService.Delete("missing", 0)).To(Equal(errors.ErrItineraryNotFound))
+	})
+})
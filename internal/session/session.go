@@ -0,0 +1,119 @@
+package session
+
+import (
+	"flight-itinerary-go/internal/model"
+	"flight-itinerary-go/internal/service"
+	"flight-itinerary-go/pkg/errors"
+)
+
+// Actions accepted from clients
+const (
+	ActionAdd    = "add"
+	ActionRemove = "remove"
+	ActionReset  = "reset"
+)
+
+// Response types sent to clients
+const (
+	ResponseState = "state"
+	ResponseError = "error"
+)
+
+// Diagnostic types describing why the tickets do not form a single itinerary yet
+const (
+	DiagnosticDuplicateDeparture = service.ConflictDuplicateDeparture
+	DiagnosticDuplicateArrival   = service.ConflictDuplicateArrival
+	DiagnosticCircularRoute      = service.ConflictCircularRoute
+	DiagnosticDisconnected       = service.ConflictDisconnected
+)
+
+// Message is a change pushed by the client
+type Message struct {
+	Action string       `json:"action"`
+	Ticket model.Ticket `json:"ticket"`
+}
+
+// Response is sent to the client after every message
+type Response struct {
+	Type  string           `json:"type"`
+	State *State           `json:"state,omitempty"`
+	Error *errors.AppError `json:"error,omitempty"`
+}
+
+// Diagnostic describes a single problem preventing reconstruction
+type Diagnostic struct {
+	Type    string        `json:"type"`
+	Airport string        `json:"airport,omitempty"`
+	Ticket  *model.Ticket `json:"ticket,omitempty"`
+	Message string        `json:"message"`
+}
+
+// State is the live preview of the tickets entered so far
+type State struct {
+	Tickets  int  `json:"tickets"`
+	Complete bool `json:"complete"`
+	// Itinerary is set once the tickets form a single connected route
+	Itinerary []string `json:"itinerary,omitempty"`
+	// Fragments lists every chain of connecting tickets, ordered by departure airport
+	Fragments   [][]string   `json:"fragments"`
+	Diagnostics []Diagnostic `json:"diagnostics"`
+}
+
+// Session holds the tickets entered over a single connection
+type Session struct {
+	builder    *service.ItineraryBuilder
+	maxTickets int
+}
+
+// NewSession creates an empty session accepting up to maxTickets tickets
+func NewSession(maxTickets int) *Session {
+	return &Session{
+		builder:    service.NewItineraryBuilder(),
+		maxTickets: maxTickets,
+	}
+}
+
+// State returns the current preview
+func (session *Session) State() State {
+	builderState := session.builder.State()
+	state := State{
+		Tickets:     builderState.Tickets,
+		Complete:    builderState.Itinerary != nil,
+		Itinerary:   builderState.Itinerary,
+		Fragments:   builderState.Fragments,
+		Diagnostics: make([]Diagnostic, 0, len(builderState.Conflicts)),
+	}
+	for _, conflict := range builderState.Conflicts {
+		state.Diagnostics = append(state.Diagnostics, Diagnostic{
+			Type:    conflict.Type,
+			Airport: conflict.Airport,
+			Ticket:  conflict.Ticket,
+			Message: conflict.Message,
+		})
+	}
+	return state
+}
+
+// Apply applies a client message and returns the updated preview
+func (session *Session) Apply(message Message) (State, error) {
+	switch message.Action {
+	case ActionAdd:
+		if err := message.Ticket.Validate(); err != nil {
+			return State{}, err
+		}
+		if session.builder.Len() >= session.maxTickets {
+			return State{}, errors.NewValidationError("session is limited to %d tickets", session.maxTickets)
+		}
+		session.builder.Add(message.Ticket)
+	case ActionRemove:
+		if !session.builder.Remove(message.Ticket) {
+			return State{}, errors.NewValidationError("ticket %s → %s is not in the session",
+				message.Ticket.Source(), message.Ticket.Destination())
+		}
+	case ActionReset:
+		session.builder = service.NewItineraryBuilder()
+	default:
+		return State{}, errors.NewValidationError("unsupported action %q", message.Action)
+	}
+	return session.State(), nil
+}
@@ -0,0 +1,99 @@
+package session_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"flight-itinerary-go/internal/model"
+	"flight-itinerary-go/internal/session"
+)
+
+func TestSession(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Session Suite")
+}
+
+var _ = Describe("Session", func() {
+	var liveSession *session.Session
+
+	BeforeEach(func() {
+		liveSession = session.NewSession(5)
+	})
+
+	apply := func(action string, src, dst string) session.State {
+		state, err := liveSession.Apply(session.Message{Action: action, Ticket: model.Ticket{src, dst}})
+		Expect(err).ToNot(HaveOccurred())
+		return state
+	}
+
+	It("should start empty", func() {
+		state := liveSession.State()
+
+		Expect(state.Tickets).To(Equal(0))
+		Expect(state.Complete).To(BeFalse())
+		Expect(state.Fragments).To(BeEmpty())
+		Expect(state.Diagnostics).To(BeEmpty())
+	})
+
+	It("should report fragments and diagnostics until the tickets connect", func() {
+		apply(session.ActionAdd, "SFO", "SJC")
+		state := apply(session.ActionAdd, "JFK", "LAX")
+
+		Expect(state.Complete).To(BeFalse())
+		Expect(state.Fragments).To(Equal([][]string{{"JFK", "LAX"}, {"SFO", "SJC"}}))
+		Expect(state.Diagnostics).To(HaveLen(1))
+		Expect(state.Diagnostics[0].Type).To(Equal(session.DiagnosticDisconnected))
+
+		apply(session.ActionAdd, "DXB", "SFO")
+		state = apply(session.ActionAdd, "LAX", "DXB")
+
+		Expect(state.Complete).To(BeTrue())
+		Expect(state.Itinerary).To(Equal([]string{"JFK", "LAX", "DXB", "SFO", "SJC"}))
+		Expect(state.Diagnostics).To(BeEmpty())
+	})
+
+	It("should describe the ticket behind a duplicate departure", func() {
+		apply(session.ActionAdd, "JFK", "LAX")
+		state := apply(session.ActionAdd, "JFK", "SFO")
+
+		Expect(state.Diagnostics).To(HaveLen(1))
+		Expect(state.Diagnostics[0].Type).To(Equal(session.DiagnosticDuplicateDeparture))
+		Expect(state.Diagnostics[0].Airport).To(Equal("JFK"))
+		Expect(state.Diagnostics[0].Ticket).To(Equal(&model.Ticket{"JFK", "SFO"}))
+
+		state = apply(session.ActionRemove, "JFK", "LAX")
+
+		Expect(state.Itinerary).To(Equal([]string{"JFK", "SFO"}))
+	})
+
+	It("should clear all tickets on reset", func() {
+		apply(session.ActionAdd, "JFK", "LAX")
+		state := apply(session.ActionReset, "", "")
+
+		Expect(state.Tickets).To(Equal(0))
+	})
+
+	It("should reject invalid messages without changing the session", func() {
+		apply(session.ActionAdd, "JFK", "LAX")
+
+		_, err := liveSession.Apply(session.Message{Action: session.ActionAdd, Ticket: model.Ticket{"", "LAX"}})
+		Expect(err).To(HaveOccurred())
+		_, err = liveSession.Apply(session.Message{Action: session.ActionRemove, Ticket: model.Ticket{"SFO", "SJC"}})
+		Expect(err).To(MatchError(ContainSubstring("not in the session")))
+		_, err = liveSession.Apply(session.Message{Action: "replace"})
+		Expect(err).To(MatchError(ContainSubstring("unsupported action")))
+
+		Expect(liveSession.State().Itinerary).To(Equal([]string{"JFK", "LAX"}))
+	})
+
+	It("should limit the number of tickets", func() {
+		for i := 0; i < 5; i++ {
+			apply(session.ActionAdd, string(rune('A'+i)), string(rune('B'+i)))
+		}
+
+		_, err := liveSession.Apply(session.Message{Action: session.ActionAdd, Ticket: model.Ticket{"X", "Y"}})
+		Expect(err).To(MatchError(ContainSubstring("limited to 5 tickets")))
+	})
+})
@@ -0,0 +1,378 @@
+package storage
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+
+	"flight-itinerary-go/pkg/errors"
+)
+
+var (
+	itinerariesBucket = []byte("itineraries")
+	// createdBucket indexes itinerary IDs by creation time for pagination
+	createdBucket = []byte("created")
+	// historyBucket holds every version keyed by itinerary ID and version number
+	historyBucket = []byte("history")
+	// indexBucket maps airport and leg terms to itinerary IDs, keyed by term and ID
+	indexBucket = []byte("index")
+	// metaBucket holds counters kept up to date with the itineraries
+	metaBucket = []byte("meta")
+	// countKey holds the number of stored itineraries, so pages report a total without a scan
+	countKey = []byte("count")
+	// indexVersionKey holds the format of the index terms; files indexed in an older format are reindexed
+	indexVersionKey = []byte("index_version")
+)
+
+// indexVersion is the current index term format; version 2 upper-cases airport codes
+const indexVersion = 2
+
+// BoltRepository implements the Repository interface over an embedded bbolt database file
+type BoltRepository struct {
+	db *bolt.DB
+}
+
+// NewBoltRepository opens or creates the database file at path
+func NewBoltRepository(path string) (Repository, error) {
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, err
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		for _, bucket := range [][]byte{itinerariesBucket, createdBucket, historyBucket} {
+			if _, err := tx.CreateBucketIfNotExists(bucket); err != nil {
+				return err
+			}
+		}
+		if tx.Bucket(metaBucket) == nil {
+			// Files written before the counter existed are counted once on open
+			meta, err := tx.CreateBucket(metaBucket)
+			if err != nil {
+				return err
+			}
+			if err := putCount(meta, tx.Bucket(createdBucket).Stats().KeyN); err != nil {
+				return err
+			}
+		}
+		meta := tx.Bucket(metaBucket)
+		if version := meta.Get(indexVersionKey); tx.Bucket(indexBucket) != nil && len(version) == 8 &&
+			binary.BigEndian.Uint64(version) == indexVersion {
+			return nil
+		}
+		// Files written before the index existed, or in an older format, are indexed once on open
+		if tx.Bucket(indexBucket) != nil {
+			if err := tx.DeleteBucket(indexBucket); err != nil {
+				return err
+			}
+		}
+		if err := meta.Put(indexVersionKey, binary.BigEndian.AppendUint64(nil, indexVersion)); err != nil {
+			return err
+		}
+		index, err := tx.CreateBucket(indexBucket)
+		if err != nil {
+			return err
+		}
+		return tx.Bucket(itinerariesBucket).ForEach(func(_, data []byte) error {
+			var itinerary Itinerary
+			if err := json.Unmarshal(data, &itinerary); err != nil {
+				return err
+			}
+			return addTerms(index, itinerary)
+		})
+	})
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &BoltRepository{
+		db: db,
+	}, nil
+}
+
+// Create stores a new itinerary
+func (repository *BoltRepository) Create(itinerary Itinerary) error {
+	return repository.db.Update(func(tx *bolt.Tx) error {
+		itineraries := tx.Bucket(itinerariesBucket)
+		if itineraries.Get([]byte(itinerary.ID)) != nil {
+			return errors.NewInternalError("itinerary " + itinerary.ID + " already exists")
+		}
+		if err := put(itineraries, []byte(itinerary.ID), itinerary); err != nil {
+			return err
+		}
+		if err := put(tx.Bucket(historyBucket), historyKey(itinerary.ID, itinerary.Version), itinerary); err != nil {
+			return err
+		}
+		if err := addTerms(tx.Bucket(indexBucket), itinerary); err != nil {
+			return err
+		}
+		if err := addCount(tx.Bucket(metaBucket), 1); err != nil {
+			return err
+		}
+		return tx.Bucket(createdBucket).Put(createdKey(itinerary), []byte(itinerary.ID))
+	})
+}
+
+// Get returns the itinerary with the given ID
+func (repository *BoltRepository) Get(id string) (Itinerary, error) {
+	var itinerary Itinerary
+	err := repository.db.View(func(tx *bolt.Tx) error {
+		var err error
+		itinerary, err = get(tx.Bucket(itinerariesBucket), id)
+		return err
+	})
+	return itinerary, err
+}
+
+// List returns a page of the matching itineraries in creation order
+func (repository *BoltRepository) List(query Query, offset, limit int) ([]Itinerary, int, error) {
+	if len(query.terms()) > 0 || query.filtersDeparture() {
+		return repository.search(query, offset, limit)
+	}
+
+	page := []Itinerary{}
+	total := 0
+	err := repository.db.View(func(tx *bolt.Tx) error {
+		itineraries := tx.Bucket(itinerariesBucket)
+		created := tx.Bucket(createdBucket)
+		total = count(tx.Bucket(metaBucket))
+
+		cursor := created.Cursor()
+		position := 0
+		for key, id := cursor.First(); key != nil && len(page) < limit; key, id = cursor.Next() {
+			if position++; position <= offset {
+				continue
+			}
+			itinerary, err := get(itineraries, string(id))
+			if err != nil {
+				return err
+			}
+			page = append(page, itinerary)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	return page, total, nil
+}
+
+// search loads the itineraries carrying every index term and filters them by departure
+func (repository *BoltRepository) search(query Query, offset, limit int) ([]Itinerary, int, error) {
+	var matches []Itinerary
+	err := repository.db.View(func(tx *bolt.Tx) error {
+		itineraries := tx.Bucket(itinerariesBucket)
+		collect := func(id string) error {
+			itinerary, err := get(itineraries, id)
+			if err != nil {
+				return err
+			}
+			if query.matchesDeparture(itinerary) {
+				matches = append(matches, itinerary)
+			}
+			return nil
+		}
+
+		terms := query.terms()
+		if len(terms) == 0 {
+			return itineraries.ForEach(func(id, _ []byte) error {
+				return collect(string(id))
+			})
+		}
+		index := tx.Bucket(indexBucket)
+		ids := termIDs(index, terms[0])
+		for _, term := range terms[1:] {
+			if len(ids) == 0 {
+				break
+			}
+			other := termIDs(index, term)
+			for id := range ids {
+				if !other[id] {
+					delete(ids, id)
+				}
+			}
+		}
+		for id := range ids {
+			if err := collect(id); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	page, total := paginate(matches, offset, limit)
+	return page, total, nil
+}
+
+// Update replaces an existing itinerary
+func (repository *BoltRepository) Update(itinerary Itinerary) error {
+	return repository.db.Update(func(tx *bolt.Tx) error {
+		itineraries := tx.Bucket(itinerariesBucket)
+		stored, err := get(itineraries, itinerary.ID)
+		if err != nil {
+			return err
+		}
+		if itinerary.Version != stored.Version+1 {
+			return errors.ErrVersionConflict
+		}
+		// The creation index is keyed by the stored creation time
+		itinerary.CreatedAt = stored.CreatedAt
+		if err := put(itineraries, []byte(itinerary.ID), itinerary); err != nil {
+			return err
+		}
+		index := tx.Bucket(indexBucket)
+		if err := removeTerms(index, stored); err != nil {
+			return err
+		}
+		if err := addTerms(index, itinerary); err != nil {
+			return err
+		}
+		return put(tx.Bucket(historyBucket), historyKey(itinerary.ID, itinerary.Version), itinerary)
+	})
+}
+
+// History returns every version of an itinerary, oldest first
+func (repository *BoltRepository) History(id string) ([]Itinerary, error) {
+	var history []Itinerary
+	err := repository.db.View(func(tx *bolt.Tx) error {
+		prefix := historyPrefix(id)
+		cursor := tx.Bucket(historyBucket).Cursor()
+		for key, data := cursor.Seek(prefix); key != nil && bytes.HasPrefix(key, prefix); key, data = cursor.Next() {
+			var version Itinerary
+			if err := json.Unmarshal(data, &version); err != nil {
+				return err
+			}
+			history = append(history, version)
+		}
+		if len(history) == 0 {
+			return errors.ErrItineraryNotFound
+		}
+		return nil
+	})
+	return history, err
+}
+
+// Delete removes an itinerary
+func (repository *BoltRepository) Delete(id string, version int) error {
+	return repository.db.Update(func(tx *bolt.Tx) error {
+		itineraries := tx.Bucket(itinerariesBucket)
+		stored, err := get(itineraries, id)
+		if err != nil {
+			return err
+		}
+		if version != 0 && version != stored.Version {
+			return errors.ErrPreconditionFailed
+		}
+		if err := tx.Bucket(createdBucket).Delete(createdKey(stored)); err != nil {
+			return err
+		}
+		if err := removeTerms(tx.Bucket(indexBucket), stored); err != nil {
+			return err
+		}
+		history := tx.Bucket(historyBucket)
+		for version := 1; version <= stored.Version; version++ {
+			if err := history.Delete(historyKey(id, version)); err != nil {
+				return err
+			}
+		}
+		if err := addCount(tx.Bucket(metaBucket), -1); err != nil {
+			return err
+		}
+		return itineraries.Delete([]byte(id))
+	})
+}
+
+// Close closes the database file
+func (repository *BoltRepository) Close() error {
+	return repository.db.Close()
+}
+
+func get(bucket *bolt.Bucket, id string) (Itinerary, error) {
+	var itinerary Itinerary
+	data := bucket.Get([]byte(id))
+	if data == nil {
+		return itinerary, errors.ErrItineraryNotFound
+	}
+	err := json.Unmarshal(data, &itinerary)
+	return itinerary, err
+}
+
+func put(bucket *bolt.Bucket, key []byte, itinerary Itinerary) error {
+	data, err := json.Marshal(itinerary)
+	if err != nil {
+		return err
+	}
+	return bucket.Put(key, data)
+}
+
+// createdKey orders itineraries by creation time, breaking ties by ID
+func createdKey(itinerary Itinerary) []byte {
+	key := make([]byte, 8, 8+len(itinerary.ID))
+	binary.BigEndian.PutUint64(key, uint64(itinerary.CreatedAt.UnixNano()))
+	return append(key, itinerary.ID...)
+}
+
+// historyKey orders the versions of an itinerary; the separator keeps IDs from prefixing each other
+func historyKey(id string, version int) []byte {
+	return binary.BigEndian.AppendUint32(historyPrefix(id), uint32(version))
+}
+
+func historyPrefix(id string) []byte {
+	return append([]byte(id), 0)
+}
+
+// count returns the number of stored itineraries
+func count(meta *bolt.Bucket) int {
+	data := meta.Get(countKey)
+	if len(data) != 8 {
+		return 0
+	}
+	return int(binary.BigEndian.Uint64(data))
+}
+
+func putCount(meta *bolt.Bucket, count int) error {
+	return meta.Put(countKey, binary.BigEndian.AppendUint64(nil, uint64(count)))
+}
+
+// addCount changes the number of stored itineraries in the same transaction as the change itself
+func addCount(meta *bolt.Bucket, delta int) error {
+	return putCount(meta, count(meta)+delta)
+}
+
+func addTerms(index *bolt.Bucket, itinerary Itinerary) error {
+	for _, term := range indexTerms(itinerary) {
+		if err := index.Put(indexKey(term, itinerary.ID), nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func removeTerms(index *bolt.Bucket, itinerary Itinerary) error {
+	for _, term := range indexTerms(itinerary) {
+		if err := index.Delete(indexKey(term, itinerary.ID)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// termIDs returns the IDs of the itineraries carrying the term
+func termIDs(index *bolt.Bucket, term string) map[string]bool {
+	ids := make(map[string]bool)
+	prefix := append([]byte(term), 0)
+	cursor := index.Cursor()
+	for key, _ := cursor.Seek(prefix); key != nil && bytes.HasPrefix(key, prefix); key, _ = cursor.Next() {
+		ids[string(key[len(prefix):])] = true
+	}
+	return ids
+}
+
+func indexKey(term, id string) []byte {
+	key := append([]byte(term), 0)
+	return append(key, id...)
+}
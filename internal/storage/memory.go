@@ -0,0 +1,203 @@
+package storage
+
+import (
+	"sort"
+	"sync"
+
+	"flight-itinerary-go/pkg/errors"
+)
+
+// MemoryRepository implements the Repository interface in memory; contents are lost on restart
+type MemoryRepository struct {
+	mutex       sync.RWMutex
+	itineraries map[string]Itinerary
+	history     map[string][]Itinerary
+	// order holds IDs in creation order for pagination
+	order []string
+	// index maps airport and leg terms to the IDs of the itineraries carrying them
+	index map[string]map[string]struct{}
+}
+
+// NewMemoryRepository creates an empty in-memory repository
+func NewMemoryRepository() Repository {
+	return &MemoryRepository{
+		itineraries: make(map[string]Itinerary),
+		history:     make(map[string][]Itinerary),
+		index:       make(map[string]map[string]struct{}),
+	}
+}
+
+// Create stores a new itinerary
+func (repository *MemoryRepository) Create(itinerary Itinerary) error {
+	repository.mutex.Lock()
+	defer repository.mutex.Unlock()
+
+	if _, exists := repository.itineraries[itinerary.ID]; exists {
+		return errors.NewInternalError("itinerary " + itinerary.ID + " already exists")
+	}
+	repository.itineraries[itinerary.ID] = clone(itinerary)
+	repository.history[itinerary.ID] = []Itinerary{clone(itinerary)}
+	repository.addTerms(itinerary)
+
+	// Keep order sorted by creation time, breaking ties by ID
+	position := sort.Search(len(repository.order), func(i int) bool {
+		stored := repository.itineraries[repository.order[i]]
+		if !stored.CreatedAt.Equal(itinerary.CreatedAt) {
+			return stored.CreatedAt.After(itinerary.CreatedAt)
+		}
+		return stored.ID > itinerary.ID
+	})
+	repository.order = append(repository.order, "")
+	copy(repository.order[position+1:], repository.order[position:])
+	repository.order[position] = itinerary.ID
+	return nil
+}
+
+// Get returns the itinerary with the given ID
+func (repository *MemoryRepository) Get(id string) (Itinerary, error) {
+	repository.mutex.RLock()
+	defer repository.mutex.RUnlock()
+
+	itinerary, exists := repository.itineraries[id]
+	if !exists {
+		return Itinerary{}, errors.ErrItineraryNotFound
+	}
+	return clone(itinerary), nil
+}
+
+// List returns a page of the matching itineraries in creation order
+func (repository *MemoryRepository) List(query Query, offset, limit int) ([]Itinerary, int, error) {
+	repository.mutex.RLock()
+	defer repository.mutex.RUnlock()
+
+	terms := query.terms()
+	if len(terms) == 0 && !query.filtersDeparture() {
+		total := len(repository.order)
+		page := []Itinerary{}
+		for i := offset; i < total && len(page) < limit; i++ {
+			page = append(page, clone(repository.itineraries[repository.order[i]]))
+		}
+		return page, total, nil
+	}
+
+	var matches []Itinerary
+	for _, id := range repository.candidates(terms) {
+		itinerary := repository.itineraries[id]
+		if query.matchesDeparture(itinerary) {
+			matches = append(matches, clone(itinerary))
+		}
+	}
+	page, total := paginate(matches, offset, limit)
+	return page, total, nil
+}
+
+// candidates returns the IDs carrying every term, scanning the smallest index entry
+func (repository *MemoryRepository) candidates(terms []string) []string {
+	if len(terms) == 0 {
+		return repository.order
+	}
+	smallest := repository.index[terms[0]]
+	for _, term := range terms[1:] {
+		if len(repository.index[term]) < len(smallest) {
+			smallest = repository.index[term]
+		}
+	}
+	ids := make([]string, 0, len(smallest))
+	for id := range smallest {
+		matched := true
+		for _, term := range terms {
+			if _, exists := repository.index[term][id]; !exists {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+func (repository *MemoryRepository) addTerms(itinerary Itinerary) {
+	for _, term := range indexTerms(itinerary) {
+		ids, exists := repository.index[term]
+		if !exists {
+			ids = make(map[string]struct{})
+			repository.index[term] = ids
+		}
+		ids[itinerary.ID] = struct{}{}
+	}
+}
+
+func (repository *MemoryRepository) removeTerms(itinerary Itinerary) {
+	for _, term := range indexTerms(itinerary) {
+		delete(repository.index[term], itinerary.ID)
+		if len(repository.index[term]) == 0 {
+			delete(repository.index, term)
+		}
+	}
+}
+
+// Update replaces an existing itinerary
+func (repository *MemoryRepository) Update(itinerary Itinerary) error {
+	repository.mutex.Lock()
+	defer repository.mutex.Unlock()
+
+	stored, exists := repository.itineraries[itinerary.ID]
+	if !exists {
+		return errors.ErrItineraryNotFound
+	}
+	if itinerary.Version != stored.Version+1 {
+		return errors.ErrVersionConflict
+	}
+	repository.removeTerms(stored)
+	repository.addTerms(itinerary)
+	repository.itineraries[itinerary.ID] = clone(itinerary)
+	repository.history[itinerary.ID] = append(repository.history[itinerary.ID], clone(itinerary))
+	return nil
+}
+
+// History returns every version of an itinerary, oldest first
+func (repository *MemoryRepository) History(id string) ([]Itinerary, error) {
+	repository.mutex.RLock()
+	defer repository.mutex.RUnlock()
+
+	versions, exists := repository.history[id]
+	if !exists {
+		return nil, errors.ErrItineraryNotFound
+	}
+	history := make([]Itinerary, 0, len(versions))
+	for _, version := range versions {
+		history = append(history, clone(version))
+	}
+	return history, nil
+}
+
+// Delete removes an itinerary
+func (repository *MemoryRepository) Delete(id string, version int) error {
+	repository.mutex.Lock()
+	defer repository.mutex.Unlock()
+
+	stored, exists := repository.itineraries[id]
+	if !exists {
+		return errors.ErrItineraryNotFound
+	}
+	if version != 0 && version != stored.Version {
+		return errors.ErrPreconditionFailed
+	}
+	repository.removeTerms(stored)
+	delete(repository.itineraries, id)
+	delete(repository.history, id)
+	for i, stored := range repository.order {
+		if stored == id {
+			repository.order = append(repository.order[:i], repository.order[i+1:]...)
+			break
+		}
+	}
+	return nil
+}
+
+// Close is a no-op for the in-memory repository
+func (repository *MemoryRepository) Close() error {
+	return nil
+}
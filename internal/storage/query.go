@@ -0,0 +1,121 @@
+package storage
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"flight-itinerary-go/internal/model"
+)
+
+// Query filters stored itineraries; empty fields match everything and set fields must all match
+type Query struct {
+	// Via matches itineraries that touch the airport anywhere on the route, including its ends
+	Via  string
+	From string
+	To   string
+	// Leg matches itineraries that fly the leg directly
+	Leg *model.Ticket
+	// DepartingAfter and DepartingBefore only match itineraries with a departure in [after, before)
+	DepartingAfter  time.Time
+	DepartingBefore time.Time
+}
+
+// terms returns the index terms an itinerary must carry to match the query
+func (query Query) terms() []string {
+	var terms []string
+	if query.Via != "" {
+		terms = append(terms, viaTerm(query.Via))
+	}
+	if query.From != "" {
+		terms = append(terms, fromTerm(query.From))
+	}
+	if query.To != "" {
+		terms = append(terms, toTerm(query.To))
+	}
+	if query.Leg != nil {
+		terms = append(terms, legTerm(*query.Leg))
+	}
+	return terms
+}
+
+// filtersDeparture reports whether the query restricts departure dates
+func (query Query) filtersDeparture() bool {
+	return !query.DepartingAfter.IsZero() || !query.DepartingBefore.IsZero()
+}
+
+// matchesDeparture checks the date range; itineraries without a departure never match a range
+func (query Query) matchesDeparture(itinerary Itinerary) bool {
+	if !query.filtersDeparture() {
+		return true
+	}
+	if itinerary.Departure == nil {
+		return false
+	}
+	if !query.DepartingAfter.IsZero() && itinerary.Departure.Before(query.DepartingAfter) {
+		return false
+	}
+	return query.DepartingBefore.IsZero() || itinerary.Departure.Before(query.DepartingBefore)
+}
+
+// indexTerms returns the secondary index terms of an itinerary's airports and legs
+func indexTerms(itinerary Itinerary) []string {
+	if len(itinerary.Route) == 0 {
+		return nil
+	}
+	seen := make(map[string]bool, len(itinerary.Route))
+	terms := []string{
+		fromTerm(itinerary.Route[0]),
+		toTerm(itinerary.Route[len(itinerary.Route)-1]),
+	}
+	for i, airport := range itinerary.Route {
+		if via := viaTerm(airport); !seen[via] {
+			seen[via] = true
+			terms = append(terms, via)
+		}
+		if i > 0 {
+			leg := legTerm(model.Ticket{itinerary.Route[i-1], airport})
+			if !seen[leg] {
+				seen[leg] = true
+				terms = append(terms, leg)
+			}
+		}
+	}
+	return terms
+}
+
+func viaTerm(airport string) string {
+	return "via:" + airportTerm(airport)
+}
+
+func fromTerm(airport string) string {
+	return "from:" + airportTerm(airport)
+}
+
+func toTerm(airport string) string {
+	return "to:" + airportTerm(airport)
+}
+
+func legTerm(leg model.Ticket) string {
+	return "leg:" + airportTerm(leg.Source()) + ">" + airportTerm(leg.Destination())
+}
+
+// airportTerm normalizes an airport code, so routes stored as ticketed match searches in any case
+func airportTerm(airport string) string {
+	return strings.ToUpper(airport)
+}
+
+// paginate sorts matches into creation order and returns the requested page with the total count
+func paginate(matches []Itinerary, offset, limit int) ([]Itinerary, int) {
+	sort.Slice(matches, func(i, j int) bool {
+		if !matches[i].CreatedAt.Equal(matches[j].CreatedAt) {
+			return matches[i].CreatedAt.Before(matches[j].CreatedAt)
+		}
+		return matches[i].ID < matches[j].ID
+	})
+	page := []Itinerary{}
+	for i := offset; i < len(matches) && len(page) < limit; i++ {
+		page = append(page, matches[i])
+	}
+	return page, len(matches)
+}
@@ -0,0 +1,52 @@
+package storage
+
+import (
+	"time"
+
+	"flight-itinerary-go/internal/model"
+)
+
+// Itinerary is a reconstructed itinerary saved for later reference
+type Itinerary struct {
+	ID      string         `json:"id"`
+	Tickets []model.Ticket `json:"tickets"`
+	Route   []string       `json:"route"`
+	// Version starts at 1 and increases with every change
+	Version int `json:"version"`
+	// Change describes the edit that produced this version
+	Change string `json:"change"`
+	// Departure is optional; tickets carry no dates, so it is only known when provided
+	Departure *time.Time `json:"departure,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+}
+
+// Repository defines the interface for itinerary persistence. Every stored version is kept in
+// an append-only history. Lookups of unknown IDs return errors.ErrItineraryNotFound.
+type Repository interface {
+	Create(itinerary Itinerary) error
+	Get(id string) (Itinerary, error)
+	// List returns a page of the itineraries matching the query in creation order along with
+	// the total number of matches
+	List(query Query, offset, limit int) ([]Itinerary, int, error)
+	// Update stores the next version of an itinerary; it fails with errors.ErrVersionConflict
+	// unless the version directly follows the stored one
+	Update(itinerary Itinerary) error
+	// History returns every version of an itinerary, oldest first
+	History(id string) ([]Itinerary, error)
+	// Delete removes an itinerary along with its history. A non-zero version must match the stored
+	// one, otherwise it fails with errors.ErrPreconditionFailed.
+	Delete(id string, version int) error
+	Close() error
+}
+
+// clone copies the slices so callers cannot modify stored itineraries
+func clone(itinerary Itinerary) Itinerary {
+	itinerary.Tickets = append([]model.Ticket(nil), itinerary.Tickets...)
+	itinerary.Route = append([]string(nil), itinerary.Route...)
+	if itinerary.Departure != nil {
+		departure := *itinerary.Departure
+		itinerary.Departure = &departure
+	}
+	return itinerary
+}
@@ -0,0 +1,249 @@
+package storage_test
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"flight-itinerary-go/internal/model"
+	"flight-itinerary-go/internal/storage"
+	"flight-itinerary-go/pkg/errors"
+)
+
+func TestStorage(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Storage Suite")
+}
+
+var _ = Describe("Repository", func() {
+	created := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	itinerary := func(id string, offset time.Duration) storage.Itinerary {
+		return storage.Itinerary{
+			ID:        id,
+			Tickets:   []model.Ticket{{"JFK", "LAX"}},
+			Route:     []string{"JFK", "LAX"},
+			Version:   1,
+			Change:    "created",
+			CreatedAt: created.Add(offset),
+			UpdatedAt: created.Add(offset),
+		}
+	}
+
+	repositorySpecs := func(newRepository func() storage.Repository) {
+		var repository storage.Repository
+
+		BeforeEach(func() {
+			repository = newRepository()
+			DeferCleanup(repository.Close)
+		})
+
+		It("should store and return itineraries", func() {
+			Expect(repository.Create(itinerary("a", 0))).To(Succeed())
+
+			stored, err := repository.Get("a")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(stored).To(Equal(itinerary("a", 0)))
+		})
+
+		It("should reject duplicate IDs", func() {
+			Expect(repository.Create(itinerary("a", 0))).To(Succeed())
+			Expect(repository.Create(itinerary("a", time.Second))).ToNot(Succeed())
+		})
+
+		It("should report unknown IDs", func() {
+			_, err := repository.Get("missing")
+			Expect(err).To(Equal(errors.ErrItineraryNotFound))
+			Expect(repository.Update(itinerary("missing", 0))).To(Equal(errors.ErrItineraryNotFound))
+			Expect(repository.Delete("missing", 0)).To(Equal(errors.ErrItineraryNotFound))
+		})
+
+		It("should page through itineraries in creation order", func() {
+			Expect(repository.Create(itinerary("c", 2*time.Second))).To(Succeed())
+			Expect(repository.Create(itinerary("a", 0))).To(Succeed())
+			Expect(repository.Create(itinerary("b", time.Second))).To(Succeed())
+
+			page, total, err := repository.List(storage.Query{}, 1, 5)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(total).To(Equal(3))
+			Expect(page).To(HaveLen(2))
+			Expect(page[0].ID).To(Equal("b"))
+			Expect(page[1].ID).To(Equal("c"))
+
+			page, _, err = repository.List(storage.Query{}, 3, 5)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(page).To(BeEmpty())
+		})
+
+		It("should update and delete itineraries", func() {
+			Expect(repository.Create(itinerary("a", 0))).To(Succeed())
+			updated := itinerary("a", 0)
+			updated.Tickets = []model.Ticket{{"JFK", "LAX"}, {"LAX", "DXB"}}
+			updated.Route = []string{"JFK", "LAX", "DXB"}
+			updated.Version = 2
+			Expect(repository.Update(updated)).To(Succeed())
+
+			stored, err := repository.Get("a")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(stored.Route).To(Equal([]string{"JFK", "LAX", "DXB"}))
+
+			Expect(repository.Delete("a", 1)).To(Equal(errors.ErrPreconditionFailed))
+			Expect(repository.Delete("a", 2)).To(Succeed())
+			_, total, err := repository.List(storage.Query{}, 0, 10)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(total).To(Equal(0))
+			_, err = repository.History("a")
+			Expect(err).To(Equal(errors.ErrItineraryNotFound))
+		})
+
+		It("should keep every version in the history", func() {
+			Expect(repository.Create(itinerary("a", 0))).To(Succeed())
+			Expect(repository.Create(itinerary("ab", 0))).To(Succeed())
+			second := itinerary("a", 0)
+			second.Version = 2
+			second.Change = "add LAX → DXB"
+			Expect(repository.Update(second)).To(Succeed())
+
+			history, err := repository.History("a")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(history).To(HaveLen(2))
+			Expect(history[0].Change).To(Equal("created"))
+			Expect(history[1].Change).To(Equal("add LAX → DXB"))
+		})
+
+		It("should reject updates that skip or repeat a version", func() {
+			Expect(repository.Create(itinerary("a", 0))).To(Succeed())
+			stale := itinerary("a", 0)
+
+			Expect(repository.Update(stale)).To(Equal(errors.ErrVersionConflict))
+			stale.Version = 3
+			Expect(repository.Update(stale)).To(Equal(errors.ErrVersionConflict))
+		})
+
+		It("should find itineraries by airport, leg and departure", func() {
+			routed := func(id string, offset time.Duration, departure *time.Time, route ...string) storage.Itinerary {
+				stored := itinerary(id, offset)
+				stored.Route = route
+				stored.Departure = departure
+				return stored
+			}
+			early, late := created.Add(24*time.Hour), created.Add(72*time.Hour)
+			Expect(repository.Create(routed("a", 0, &early, "JFK", "DXB", "SIN"))).To(Succeed())
+			Expect(repository.Create(routed("b", time.Second, &late, "LHR", "DXB"))).To(Succeed())
+			Expect(repository.Create(routed("c", 2*time.Second, nil, "JFK", "LAX"))).To(Succeed())
+
+			ids := func(query storage.Query) []string {
+				page, total, err := repository.List(query, 0, 10)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(page).To(HaveLen(total))
+				found := []string{}
+				for _, stored := range page {
+					found = append(found, stored.ID)
+				}
+				return found
+			}
+			Expect(ids(storage.Query{Via: "DXB"})).To(Equal([]string{"a", "b"}))
+			Expect(ids(storage.Query{Via: "DXB", From: "JFK"})).To(Equal([]string{"a"}))
+			Expect(ids(storage.Query{To: "DXB"})).To(Equal([]string{"b"}))
+			Expect(ids(storage.Query{Leg: &model.Ticket{"JFK", "DXB"}})).To(Equal([]string{"a"}))
+			Expect(ids(storage.Query{Leg: &model.Ticket{"JFK", "SIN"}})).To(BeEmpty())
+			Expect(ids(storage.Query{DepartingAfter: created.Add(48 * time.Hour)})).To(Equal([]string{"b"}))
+			Expect(ids(storage.Query{DepartingBefore: late})).To(Equal([]string{"a"}))
+
+			page, total, err := repository.List(storage.Query{Via: "DXB"}, 1, 10)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(total).To(Equal(2))
+			Expect(page).To(HaveLen(1))
+			Expect(page[0].ID).To(Equal("b"))
+
+			rerouted := routed("a", 0, &early, "JFK", "DOH")
+			rerouted.Version = 2
+			Expect(repository.Update(rerouted)).To(Succeed())
+			Expect(repository.Delete("b", 0)).To(Succeed())
+			Expect(ids(storage.Query{Via: "DXB"})).To(BeEmpty())
+			Expect(ids(storage.Query{Via: "DOH"})).To(Equal([]string{"a"}))
+		})
+
+		It("should find itineraries ticketed in lower case", func() {
+			lower := itinerary("a", 0)
+			lower.Tickets = []model.Ticket{{"jfk", "dxb"}, {"dxb", "sin"}}
+			lower.Route = []string{"jfk", "dxb", "sin"}
+			Expect(repository.Create(lower)).To(Succeed())
+
+			for _, query := range []storage.Query{
+				{Via: "DXB"}, {From: "JFK"}, {To: "sin"}, {Leg: &model.Ticket{"JFK", "dxb"}},
+			} {
+				_, total, err := repository.List(query, 0, 10)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(total).To(Equal(1), "%+v", query)
+			}
+		})
+	}
+
+	Describe("MemoryRepository", func() {
+		Context("contract", func() {
+			repositorySpecs(storage.NewMemoryRepository)
+		})
+
+		It("should not share slices with callers", func() {
+			repository := storage.NewMemoryRepository()
+			original := itinerary("a", 0)
+			Expect(repository.Create(original)).To(Succeed())
+			original.Route[0] = "SFO"
+
+			stored, _ := repository.Get("a")
+			Expect(stored.Route[0]).To(Equal("JFK"))
+		})
+	})
+
+	Describe("BoltRepository", func() {
+		var path string
+
+		BeforeEach(func() {
+			path = filepath.Join(GinkgoT().TempDir(), "itineraries.db")
+		})
+
+		Context("contract", func() {
+			repositorySpecs(func() storage.Repository {
+				repository, err := storage.NewBoltRepository(path)
+				Expect(err).ToNot(HaveOccurred())
+				return repository
+			})
+		})
+
+		It("should keep itineraries across restarts", func() {
+			repository, err := storage.NewBoltRepository(path)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(repository.Create(itinerary("a", 0))).To(Succeed())
+			Expect(repository.Close()).To(Succeed())
+
+			reopened, err := storage.NewBoltRepository(path)
+			Expect(err).ToNot(HaveOccurred())
+			defer reopened.Close()
+			stored, err := reopened.Get("a")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(stored.Route).To(Equal([]string{"JFK", "LAX"}))
+		})
+
+		It("should keep the itinerary count across restarts", func() {
+			repository, err := storage.NewBoltRepository(path)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(repository.Create(itinerary("a", 0))).To(Succeed())
+			Expect(repository.Create(itinerary("b", time.Second))).To(Succeed())
+			Expect(repository.Create(itinerary("c", 2*time.Second))).To(Succeed())
+			Expect(repository.Delete("b", 0)).To(Succeed())
+			Expect(repository.Create(itinerary("a", 3*time.Second))).ToNot(Succeed())
+			Expect(repository.Close()).To(Succeed())
+
+			reopened, err := storage.NewBoltRepository(path)
+			Expect(err).ToNot(HaveOccurred())
+			defer reopened.Close()
+			page, total, err := reopened.List(storage.Query{}, 0, 1)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(page).To(HaveLen(1))
+			Expect(total).To(Equal(2))
+		})
+	})
+})
@@ -0,0 +1,237 @@
+package tenant
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"flight-itinerary-go/internal/cache"
+	"flight-itinerary-go/internal/model"
+	"flight-itinerary-go/internal/service"
+)
+
+// LoadConfigs reads every *.json tenant file in the directory. A file without an id is named after
+// the file.
+func LoadConfigs(dir string) ([]Config, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(paths)
+
+	configs := make([]Config, 0, len(paths))
+	seen := make(map[string]string, len(paths))
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		var config Config
+		if err := json.Unmarshal(data, &config); err != nil {
+			return nil, fmt.Errorf("invalid tenant file %s: %w", path, err)
+		}
+		if config.ID == "" {
+			config.ID = strings.TrimSuffix(filepath.Base(path), ".json")
+		}
+		if err := config.Validate(); err != nil {
+			return nil, fmt.Errorf("invalid tenant file %s: %w", path, err)
+		}
+		if other, exists := seen[config.ID]; exists {
+			return nil, fmt.Errorf("tenant %s is defined by both %s and %s", config.ID, other, path)
+		}
+		seen[config.ID] = path
+		configs = append(configs, config)
+	}
+	return configs, nil
+}
+
+// Registry holds the tenants served by this instance, always including the default tenant. A
+// request keeps the tenant it was given even if the registry is reloaded meanwhile.
+type Registry struct {
+	mutex     sync.RWMutex
+	reloading sync.Mutex
+	tenants   map[string]*Tenant
+}
+
+// NewRegistry builds a tenant per configuration, plus the default tenant unless one is configured
+func NewRegistry(dependencies Dependencies, configs ...Config) (*Registry, error) {
+	registry := &Registry{
+		tenants: make(map[string]*Tenant, len(configs)+1),
+	}
+	for _, config := range withDefault(configs) {
+		if _, exists := registry.tenants[config.ID]; exists {
+			registry.Close()
+			return nil, fmt.Errorf("tenant %s is configured twice", config.ID)
+		}
+		tenant, err := New(config, dependencies)
+		if err != nil {
+			registry.Close()
+			return nil, err
+		}
+		registry.tenants[config.ID] = tenant
+	}
+	return registry, nil
+}
+
+// Reload rebuilds every tenant from new configurations and dependencies, then swaps them all in at
+// once. Tenants keep their itinerary store and their callers' rate limit usage; new tenants are
+// opened. Removing a tenant needs a restart. On error the current tenants stay in place.
+func (registry *Registry) Reload(dependencies Dependencies, configs ...Config) error {
+	staged, err := registry.Stage(dependencies, configs...)
+	if err != nil {
+		return err
+	}
+	staged.Apply()
+	return nil
+}
+
+// StagedTenants holds the tenants rebuilt by Stage until they are applied or discarded
+type StagedTenants struct {
+	registry     *Registry
+	next         map[string]*Tenant
+	opened       []*Tenant
+	dependencies Dependencies
+}
+
+// Stage rebuilds every tenant like Reload without serving them yet, so callers can prepare other
+// changes and apply them together. Other reloads wait until the staged tenants are applied or
+// discarded.
+func (registry *Registry) Stage(dependencies Dependencies, configs ...Config) (*StagedTenants, error) {
+	registry.reloading.Lock()
+	registry.mutex.RLock()
+	current := registry.tenants
+	registry.mutex.RUnlock()
+
+	staged := &StagedTenants{
+		registry:     registry,
+		next:         make(map[string]*Tenant, len(configs)+1),
+		dependencies: dependencies,
+	}
+	fail := func(err error) (*StagedTenants, error) {
+		staged.Discard()
+		return nil, err
+	}
+	for _, config := range withDefault(configs) {
+		if _, exists := staged.next[config.ID]; exists {
+			return fail(fmt.Errorf("tenant %s is configured twice", config.ID))
+		}
+		existing, exists := current[config.ID]
+		if !exists {
+			tenant, err := New(config, dependencies)
+			if err != nil {
+				return fail(err)
+			}
+			staged.opened = append(staged.opened, tenant)
+			staged.next[config.ID] = tenant
+			continue
+		}
+		tenant, err := build(config, dependencies, existing.repository, existing.Limiter)
+		if err != nil {
+			return fail(err)
+		}
+		staged.next[config.ID] = tenant
+	}
+	for id := range current {
+		if _, exists := staged.next[id]; !exists {
+			return fail(fmt.Errorf("tenant %s cannot be removed without a restart", id))
+		}
+	}
+	return staged, nil
+}
+
+// Apply serves the staged tenants in place of the current ones
+func (staged *StagedTenants) Apply() {
+	registry := staged.registry
+	defer registry.reloading.Unlock()
+	registry.mutex.Lock()
+	defer registry.mutex.Unlock()
+	registry.tenants = staged.next
+	for _, tenant := range staged.next {
+		tenant.Limiter.SetPolicy(policyFor(tenant.Config, staged.dependencies))
+	}
+}
+
+// Discard closes the tenants opened for the stage and keeps the current ones
+func (staged *StagedTenants) Discard() {
+	defer staged.registry.reloading.Unlock()
+	for _, tenant := range staged.opened {
+		tenant.Close()
+	}
+}
+
+// Get returns the tenant with the ID
+func (registry *Registry) Get(id string) (*Tenant, bool) {
+	registry.mutex.RLock()
+	defer registry.mutex.RUnlock()
+	tenant, exists := registry.tenants[id]
+	return tenant, exists
+}
+
+// Default returns the tenant serving requests that name no tenant
+func (registry *Registry) Default() *Tenant {
+	registry.mutex.RLock()
+	defer registry.mutex.RUnlock()
+	return registry.tenants[DefaultID]
+}
+
+// DefaultService reconstructs with the default tenant current at each call, for callers such as
+// the gRPC server that hold on to a service across reloads
+func (registry *Registry) DefaultService() service.ItineraryService {
+	return &defaultService{registry: registry}
+}
+
+// List returns every tenant ordered by ID
+func (registry *Registry) List() []*Tenant {
+	registry.mutex.RLock()
+	defer registry.mutex.RUnlock()
+	tenants := make([]*Tenant, 0, len(registry.tenants))
+	for _, tenant := range registry.tenants {
+		tenants = append(tenants, tenant)
+	}
+	sort.Slice(tenants, func(i, j int) bool {
+		return tenants[i].ID < tenants[j].ID
+	})
+	return tenants
+}
+
+// Close releases the itinerary stores of every tenant
+func (registry *Registry) Close() error {
+	registry.mutex.RLock()
+	defer registry.mutex.RUnlock()
+	var firstErr error
+	for _, tenant := range registry.tenants {
+		if err := tenant.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// withDefault adds the default tenant unless it is configured
+func withDefault(configs []Config) []Config {
+	for _, config := range configs {
+		if config.ID == DefaultID {
+			return configs
+		}
+	}
+	return append([]Config{{ID: DefaultID, Name: "Default"}}, configs...)
+}
+
+// defaultService delegates to the current default tenant
+type defaultService struct {
+	registry *Registry
+}
+
+// ReconstructItinerary reconstructs the itinerary with the current default tenant
+func (defaultService *defaultService) ReconstructItinerary(tickets []model.Ticket) ([]string, error) {
+	return defaultService.registry.Default().ItineraryService.ReconstructItinerary(tickets)
+}
+
+// Reconstruct reconstructs the itinerary with the current default tenant, reporting the cache status
+func (defaultService *defaultService) Reconstruct(tickets []model.Ticket, bypass bool) ([]string, cache.Status, error) {
+	return defaultService.registry.Default().ItineraryService.(cache.Reconstructor).Reconstruct(tickets, bypass)
+}
@@ -0,0 +1,243 @@
+package tenant
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/graphql-go/graphql"
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+
+	"flight-itinerary-go/internal/airport"
+	"flight-itinerary-go/internal/cache"
+	"flight-itinerary-go/internal/enrichment"
+	"flight-itinerary-go/internal/gql"
+	"flight-itinerary-go/internal/model"
+	"flight-itinerary-go/internal/ratelimit"
+	"flight-itinerary-go/internal/render"
+	"flight-itinerary-go/internal/service"
+	"flight-itinerary-go/internal/storage"
+	"flight-itinerary-go/pkg/errors"
+)
+
+const (
+	// DefaultID identifies the tenant serving requests that name no tenant
+	DefaultID = "default"
+	// ContextKey is the echo context key holding the request's tenant
+	ContextKey = "tenant"
+)
+
+var idPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,62}$`)
+
+// Validation holds a tenant's rules for submitted tickets, on top of the rules every request follows
+type Validation struct {
+	// MaxTickets caps the tickets per request; zero means unlimited
+	MaxTickets int `json:"max_tickets,omitempty"`
+	// RequireKnownAirports rejects airports missing from the tenant's airport directory
+	RequireKnownAirports bool `json:"require_known_airports,omitempty"`
+	// BlockedAirports are rejected as source or destination
+	BlockedAirports []string `json:"blocked_airports,omitempty"`
+}
+
+// Check returns a validation error for the first ticket breaking a rule
+func (validation Validation) Check(tickets []model.Ticket, airports airport.Directory) *errors.AppError {
+	if validation.MaxTickets > 0 && len(tickets) > validation.MaxTickets {
+		return errors.NewValidationError("at most %d tickets are allowed, got %d", validation.MaxTickets, len(tickets))
+	}
+	for i, ticket := range tickets {
+		if problem := validation.airportProblem(ticket, airports); problem != "" {
+			return errors.NewValidationError("ticket at index %d %s", i, problem)
+		}
+	}
+	return nil
+}
+
+// CheckTicket returns a validation error when a single ticket uses a blocked or unknown airport
+func (validation Validation) CheckTicket(ticket model.Ticket, airports airport.Directory) *errors.AppError {
+	if problem := validation.airportProblem(ticket, airports); problem != "" {
+		return errors.NewValidationError("ticket %s-%s %s", ticket.Source(), ticket.Destination(), problem)
+	}
+	return nil
+}
+
+// airportProblem describes the first airport of the ticket breaking a rule, or returns ""
+func (validation Validation) airportProblem(ticket model.Ticket, airports airport.Directory) string {
+	for _, code := range ticket {
+		if slices.ContainsFunc(validation.BlockedAirports, func(blocked string) bool {
+			return strings.EqualFold(blocked, code)
+		}) {
+			return "uses blocked airport " + code
+		}
+		if _, known := airports.Lookup(code); validation.RequireKnownAirports && !known {
+			return "uses unknown airport " + code
+		}
+	}
+	return ""
+}
+
+// Config is a tenant's configuration file
+type Config struct {
+	ID         string     `json:"id"`
+	Name       string     `json:"name"`
+	Validation Validation `json:"validation"`
+	// Airports replace or extend the reference airports for this tenant
+	Airports []airport.Airport `json:"airports,omitempty"`
+	// RateLimits replaces the server's rate limit policy for this tenant
+	RateLimits *ratelimit.Policy `json:"rate_limits,omitempty"`
+}
+
+// Validate checks the tenant ID, rules and rate limits
+func (config Config) Validate() error {
+	if !idPattern.MatchString(config.ID) {
+		return fmt.Errorf("tenant id %q must be lowercase letters, digits, - or _", config.ID)
+	}
+	if config.Validation.MaxTickets < 0 {
+		return fmt.Errorf("tenant %s: max_tickets must not be negative", config.ID)
+	}
+	for _, override := range config.Airports {
+		if override.Code == "" {
+			return fmt.Errorf("tenant %s: airport overrides need a code", config.ID)
+		}
+	}
+	if config.RateLimits != nil {
+		if err := config.RateLimits.Validate(); err != nil {
+			return fmt.Errorf("tenant %s: %w", config.ID, err)
+		}
+	}
+	return nil
+}
+
+// Dependencies are the shared components tenants are built from
+type Dependencies struct {
+	// ItineraryService reconstructs itineraries; tenants put their rules and cache namespace in front
+	ItineraryService service.ItineraryService
+	// Cache is shared by all tenants, each in its own namespace
+	Cache *cache.Cache
+	// Airports are the reference airports tenant overrides apply to
+	Airports airport.Directory
+	// Policy is the rate limit policy of tenants without their own
+	Policy ratelimit.Policy
+	// TicketUsage counts callers' tickets against their daily quotas across all tenants; without it
+	// each tenant counts its own
+	TicketUsage *ratelimit.TicketUsage
+	// Repository opens the itinerary store of a tenant
+	Repository func(id string) (storage.Repository, error)
+	Logger     *zap.Logger
+}
+
+// Tenant bundles the components serving one tenant
+type Tenant struct {
+	Config
+	Directory        airport.Directory
+	Limiter          *ratelimit.Limiter
+	ItineraryService service.ItineraryService
+	TripService      service.TripService
+	Renderers        *render.Registry[render.Renderer]
+	Schema           graphql.Schema
+	repository       storage.Repository
+}
+
+// New builds a tenant from its configuration. The default tenant shares the cache namespace of
+// requests made before tenancy was introduced.
+func New(config Config, dependencies Dependencies) (*Tenant, error) {
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	repository, err := dependencies.Repository(config.ID)
+	if err != nil {
+		return nil, err
+	}
+	tenant, err := build(config, dependencies, repository, newLimiter(config, dependencies))
+	if err != nil {
+		repository.Close()
+		return nil, err
+	}
+	return tenant, nil
+}
+
+// build assembles a tenant around an opened itinerary store and a limiter
+func build(config Config, dependencies Dependencies, repository storage.Repository,
+	limiter *ratelimit.Limiter) (*Tenant, error) {
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	logger := dependencies.Logger.With(zap.String("tenant", config.ID))
+	directory := airport.NewOverlayDirectory(dependencies.Airports, config.Airports)
+
+	cachingService := cache.NewCachingService(dependencies.ItineraryService, dependencies.Cache)
+	if config.ID != DefaultID {
+		cachingService = cachingService.WithNamespace(config.ID)
+	}
+	itineraryService := &ruledService{
+		cachingService: cachingService,
+		validation:     config.Validation,
+		airports:       directory,
+	}
+	schema, err := gql.NewSchema(itineraryService, enrichment.NewEnricher(directory), logger)
+	if err != nil {
+		return nil, err
+	}
+	return &Tenant{
+		Config:           config,
+		Directory:        directory,
+		Limiter:          limiter,
+		ItineraryService: itineraryService,
+		TripService:      service.NewTripService(itineraryService, repository, logger),
+		Renderers:        render.NewItineraryRegistry(directory),
+		Schema:           schema,
+		repository:       repository,
+	}, nil
+}
+
+// newLimiter creates the tenant's limiter, counting tickets in the shared usage when there is one
+func newLimiter(config Config, dependencies Dependencies) *ratelimit.Limiter {
+	if dependencies.TicketUsage == nil {
+		return ratelimit.NewLimiter(policyFor(config, dependencies))
+	}
+	return ratelimit.NewSharedLimiter(policyFor(config, dependencies), dependencies.TicketUsage)
+}
+
+// policyFor returns the tenant's own rate limit policy, or the server's
+func policyFor(config Config, dependencies Dependencies) ratelimit.Policy {
+	if config.RateLimits != nil {
+		return *config.RateLimits
+	}
+	return dependencies.Policy
+}
+
+// Close releases the tenant's itinerary store
+func (tenant *Tenant) Close() error {
+	return tenant.repository.Close()
+}
+
+// FromContext returns the tenant selected for the request
+func FromContext(ctx echo.Context) (*Tenant, bool) {
+	tenant, ok := ctx.Get(ContextKey).(*Tenant)
+	return tenant, ok
+}
+
+// ruledService enforces the tenant's validation rules before the cached reconstruction, so stored
+// itinerary changes and GraphQL queries follow them too
+type ruledService struct {
+	cachingService *cache.CachingService
+	validation     Validation
+	airports       airport.Directory
+}
+
+// ReconstructItinerary checks the rules and reconstructs the itinerary
+func (ruledService *ruledService) ReconstructItinerary(tickets []model.Ticket) ([]string, error) {
+	if appErr := ruledService.validation.Check(tickets, ruledService.airports); appErr != nil {
+		return nil, appErr
+	}
+	return ruledService.cachingService.ReconstructItinerary(tickets)
+}
+
+// Reconstruct checks the rules and reconstructs the itinerary, reporting the cache status
+func (ruledService *ruledService) Reconstruct(tickets []model.Ticket, bypass bool) ([]string, cache.Status, error) {
+	if appErr := ruledService.validation.Check(tickets, ruledService.airports); appErr != nil {
+		return nil, cache.StatusBypass, appErr
+	}
+	return ruledService.cachingService.Reconstruct(tickets, bypass)
+}
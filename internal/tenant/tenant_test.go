@@ -0,0 +1,265 @@
+package tenant_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.uber.org/zap"
+
+	"flight-itinerary-go/internal/airport"
+	"flight-itinerary-go/internal/cache"
+	"flight-itinerary-go/internal/model"
+	"flight-itinerary-go/internal/ratelimit"
+	"flight-itinerary-go/internal/service"
+	"flight-itinerary-go/internal/storage"
+	"flight-itinerary-go/internal/tenant"
+)
+
+func TestTenant(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Tenant Suite")
+}
+
+var _ = Describe("Tenants", func() {
+	var (
+		dir          string
+		opened       []string
+		dependencies tenant.Dependencies
+	)
+
+	writeConfig := func(name, content string) {
+		Expect(os.WriteFile(filepath.Join(dir, name), []byte(content), 0600)).To(Succeed())
+	}
+
+	BeforeEach(func() {
+		dir = GinkgoT().TempDir()
+		opened = nil
+		dependencies = tenant.Dependencies{
+			ItineraryService: service.NewItineraryService(zap.NewNop()),
+			Cache:            cache.NewCache(cache.Options{MaxBytes: 1 << 20, TTL: time.Minute}),
+			Airports:         airport.NewDirectory(),
+			Policy:           ratelimit.DefaultPolicy(),
+			Repository: func(id string) (storage.Repository, error) {
+				opened = append(opened, id)
+				return storage.NewMemoryRepository(), nil
+			},
+			Logger: zap.NewNop(),
+		}
+	})
+
+	Describe("LoadConfigs", func() {
+		It("should load tenant files, naming tenants after files without an id", func() {
+			writeConfig("acme.json", `{"name": "Acme", "validation": {"max_tickets": 10},
+				"airports": [{"code": "XYZ", "name": "Acme Field", "latitude": 40, "longitude": -75}]}`)
+			writeConfig("globex.json", `{"id": "globex-eu", "rate_limits": {"default_tier": "basic",
+				"tiers": {"basic": {"default": {"rate": 1, "burst": 2}}}}}`)
+			writeConfig("notes.txt", "ignored")
+
+			configs, err := tenant.LoadConfigs(dir)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(configs).To(HaveLen(2))
+			Expect(configs[0].ID).To(Equal("acme"))
+			Expect(configs[0].Validation.MaxTickets).To(Equal(10))
+			Expect(configs[0].Airports[0].Name).To(Equal("Acme Field"))
+			Expect(configs[1].ID).To(Equal("globex-eu"))
+			Expect(configs[1].RateLimits.DefaultTier).To(Equal("basic"))
+		})
+
+		It("should reject invalid IDs, rate limits and duplicate tenants", func() {
+			writeConfig("Bad Name.json", `{}`)
+			_, err := tenant.LoadConfigs(dir)
+			Expect(err).To(MatchError(ContainSubstring("tenant id")))
+
+			dir = GinkgoT().TempDir()
+			writeConfig("acme.json", `{"rate_limits": {"default_tier": "missing", "tiers": {}}}`)
+			_, err = tenant.LoadConfigs(dir)
+			Expect(err).To(MatchError(ContainSubstring("default tier")))
+
+			dir = GinkgoT().TempDir()
+			writeConfig("acme.json", `{}`)
+			writeConfig("other.json", `{"id": "acme"}`)
+			_, err = tenant.LoadConfigs(dir)
+			Expect(err).To(MatchError(ContainSubstring("defined by both")))
+		})
+	})
+
+	Describe("Validation", func() {
+		It("should enforce ticket limits, blocked airports and known airports", func() {
+			airports := airport.NewDirectory()
+			validation := tenant.Validation{MaxTickets: 2, BlockedAirports: []string{"dxb"}, RequireKnownAirports: true}
+
+			Expect(validation.Check([]model.Ticket{{"JFK", "LAX"}}, airports)).To(BeNil())
+			Expect(validation.Check([]model.Ticket{{"JFK", "LAX"}, {"LAX", "SFO"}, {"SFO", "SEA"}}, airports).Message).
+				To(ContainSubstring("at most 2 tickets"))
+			Expect(validation.Check([]model.Ticket{{"JFK", "DXB"}}, airports).Message).To(ContainSubstring("blocked airport"))
+			Expect(validation.Check([]model.Ticket{{"JFK", "QQQ"}}, airports).Message).To(ContainSubstring("unknown airport"))
+			Expect(tenant.Validation{}.Check([]model.Ticket{{"JFK", "QQQ"}}, airports)).To(BeNil())
+		})
+
+		It("should check a single ticket against the airport rules", func() {
+			airports := airport.NewDirectory()
+			validation := tenant.Validation{BlockedAirports: []string{"DXB"}, RequireKnownAirports: true}
+
+			Expect(validation.CheckTicket(model.Ticket{"JFK", "LAX"}, airports)).To(BeNil())
+			Expect(validation.CheckTicket(model.Ticket{"JFK", "DXB"}, airports).Message).
+				To(Equal("ticket JFK-DXB uses blocked airport DXB"))
+			Expect(validation.CheckTicket(model.Ticket{"QQQ", "LAX"}, airports).Message).
+				To(Equal("ticket QQQ-LAX uses unknown airport QQQ"))
+		})
+	})
+
+	Describe("Registry", func() {
+		It("should add the default tenant and isolate stores, limiters and cache entries", func() {
+			registry, err := tenant.NewRegistry(dependencies, tenant.Config{ID: "acme", Name: "Acme"})
+			Expect(err).ToNot(HaveOccurred())
+			DeferCleanup(registry.Close)
+
+			Expect(registry.List()).To(HaveLen(2))
+			Expect(registry.Default().ID).To(Equal(tenant.DefaultID))
+			Expect(opened).To(ConsistOf(tenant.DefaultID, "acme"))
+			acme, exists := registry.Get("acme")
+			Expect(exists).To(BeTrue())
+			_, exists = registry.Get("globex")
+			Expect(exists).To(BeFalse())
+
+			_, err = acme.TripService.Create([]model.Ticket{{"JFK", "LAX"}}, nil)
+			Expect(err).ToNot(HaveOccurred())
+			_, total, err := registry.Default().TripService.List(storage.Query{}, 0, 10)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(total).To(BeZero())
+			Expect(acme.Limiter).ToNot(BeIdenticalTo(registry.Default().Limiter))
+
+			tickets := []model.Ticket{{"SFO", "SEA"}}
+			_, status, err := registry.Default().ItineraryService.(cache.Reconstructor).Reconstruct(tickets, false)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(status).To(Equal(cache.StatusMiss))
+			acmeReconstructor := acme.ItineraryService.(cache.Reconstructor)
+			_, status, _ = acmeReconstructor.Reconstruct(tickets, false)
+			Expect(status).To(Equal(cache.StatusMiss))
+			_, status, _ = acmeReconstructor.Reconstruct(tickets, false)
+			Expect(status).To(Equal(cache.StatusHit))
+		})
+
+		It("should apply the tenant's airports, rules and rate limits", func() {
+			registry, err := tenant.NewRegistry(dependencies, tenant.Config{
+				ID:         "acme",
+				Validation: tenant.Validation{BlockedAirports: []string{"DXB"}},
+				Airports:   []airport.Airport{{Code: "JFK", Name: "Acme Kennedy"}},
+				RateLimits: &ratelimit.Policy{
+					DefaultTier: "basic",
+					Tiers:       map[string]ratelimit.Tier{"basic": {Default: ratelimit.Limit{Rate: 1, Burst: 1}}},
+				},
+			})
+			Expect(err).ToNot(HaveOccurred())
+			DeferCleanup(registry.Close)
+			acme, _ := registry.Get("acme")
+
+			overridden, _ := acme.Directory.Lookup("jfk")
+			Expect(overridden.Name).To(Equal("Acme Kennedy"))
+			inherited, exists := acme.Directory.Lookup("LAX")
+			Expect(exists).To(BeTrue())
+			Expect(inherited.City).ToNot(BeEmpty())
+
+			_, err = acme.ItineraryService.ReconstructItinerary([]model.Ticket{{"JFK", "DXB"}})
+			Expect(err).To(MatchError(ContainSubstring("blocked airport")))
+			_, err = acme.TripService.Create([]model.Ticket{{"JFK", "DXB"}}, nil)
+			Expect(err).To(HaveOccurred())
+			_, err = registry.Default().ItineraryService.ReconstructItinerary([]model.Ticket{{"JFK", "DXB"}})
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(acme.Limiter.Policy().DefaultTier).To(Equal("basic"))
+			Expect(registry.Default().Limiter.Policy().DefaultTier).To(Equal("standard"))
+		})
+
+		It("should count a caller's tickets across tenants sharing the ticket usage", func() {
+			dependencies.TicketUsage = ratelimit.NewTicketUsage()
+			quota := &ratelimit.Policy{
+				DefaultTier: "basic",
+				Tiers: map[string]ratelimit.Tier{
+					"basic": {Default: ratelimit.Limit{Rate: 1, Burst: 1}, DailyTickets: 5},
+				},
+			}
+			registry, err := tenant.NewRegistry(dependencies,
+				tenant.Config{ID: "acme", RateLimits: quota}, tenant.Config{ID: "globex", RateLimits: quota})
+			Expect(err).ToNot(HaveOccurred())
+			DeferCleanup(registry.Close)
+			acme, _ := registry.Get("acme")
+			globex, _ := registry.Get("globex")
+
+			Expect(acme.Limiter.UseTickets("key:a", "", 4).Allowed).To(BeTrue())
+			Expect(globex.Limiter.UseTickets("key:a", "", 4).Allowed).To(BeFalse())
+			Expect(globex.Limiter.UseTickets("key:b", "", 4).Allowed).To(BeTrue())
+		})
+
+		It("should reload rules and policies, keeping stores and limiters, and keep tenants on failure", func() {
+			registry, err := tenant.NewRegistry(dependencies, tenant.Config{ID: "acme"})
+			Expect(err).ToNot(HaveOccurred())
+			DeferCleanup(registry.Close)
+			acme, _ := registry.Get("acme")
+			_, err = acme.TripService.Create([]model.Ticket{{"JFK", "LAX"}}, nil)
+			Expect(err).ToNot(HaveOccurred())
+			defaultService := registry.DefaultService()
+
+			basic := ratelimit.Policy{
+				DefaultTier: "basic",
+				Tiers:       map[string]ratelimit.Tier{"basic": {Default: ratelimit.Limit{Rate: 1, Burst: 1}}},
+			}
+			dependencies.Policy = basic
+			Expect(registry.Reload(dependencies,
+				tenant.Config{ID: "acme", Validation: tenant.Validation{BlockedAirports: []string{"DXB"}}},
+				tenant.Config{ID: tenant.DefaultID, Validation: tenant.Validation{MaxTickets: 1}},
+				tenant.Config{ID: "globex"},
+			)).To(Succeed())
+			Expect(registry.List()).To(HaveLen(3))
+			Expect(opened).To(ConsistOf(tenant.DefaultID, "acme", "globex"))
+
+			reloaded, _ := registry.Get("acme")
+			Expect(reloaded.Limiter).To(BeIdenticalTo(acme.Limiter))
+			Expect(acme.Limiter.Policy()).To(Equal(basic))
+			_, total, err := reloaded.TripService.List(storage.Query{}, 0, 10)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(total).To(Equal(1))
+			_, err = reloaded.ItineraryService.ReconstructItinerary([]model.Ticket{{"JFK", "DXB"}})
+			Expect(err).To(MatchError(ContainSubstring("blocked airport")))
+			_, err = defaultService.ReconstructItinerary([]model.Ticket{{"JFK", "LAX"}, {"LAX", "SFO"}})
+			Expect(err).To(HaveOccurred())
+
+			Expect(registry.Reload(dependencies, tenant.Config{ID: "acme"})).To(
+				MatchError(ContainSubstring("globex cannot be removed")))
+			Expect(registry.Reload(dependencies, tenant.Config{ID: "acme"}, tenant.Config{ID: "globex"},
+				tenant.Config{ID: "Bad ID"})).ToNot(Succeed())
+			current, _ := registry.Get("acme")
+			Expect(current).To(BeIdenticalTo(reloaded))
+			Expect(registry.List()).To(HaveLen(3))
+		})
+
+		It("should serve staged tenants only once they are applied", func() {
+			registry, err := tenant.NewRegistry(dependencies, tenant.Config{ID: "acme"})
+			Expect(err).ToNot(HaveOccurred())
+			DeferCleanup(registry.Close)
+			acme, _ := registry.Get("acme")
+
+			staged, err := registry.Stage(dependencies, tenant.Config{ID: "acme"}, tenant.Config{ID: "globex"})
+			Expect(err).ToNot(HaveOccurred())
+			_, exists := registry.Get("globex")
+			Expect(exists).To(BeFalse())
+			staged.Discard()
+			current, _ := registry.Get("acme")
+			Expect(current).To(BeIdenticalTo(acme))
+			Expect(registry.List()).To(HaveLen(2))
+
+			staged, err = registry.Stage(dependencies, tenant.Config{ID: "acme"}, tenant.Config{ID: "globex"})
+			Expect(err).ToNot(HaveOccurred())
+			staged.Apply()
+			_, exists = registry.Get("globex")
+			Expect(exists).To(BeTrue())
+			current, _ = registry.Get("acme")
+			Expect(current).ToNot(BeIdenticalTo(acme))
+			Expect(current.Limiter).To(BeIdenticalTo(acme.Limiter))
+		})
+	})
+})
@@ -0,0 +1,213 @@
+package tlsconfig
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"go.uber.org/zap"
+)
+
+// Client certificate policies
+const (
+	ClientAuthNone     = "none"
+	ClientAuthOptional = "optional"
+	ClientAuthRequire  = "require"
+)
+
+// reloadDelay is how long the files must be unchanged before they are reloaded
+const reloadDelay = 100 * time.Millisecond
+
+var versions = map[string]uint16{
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// Options configures the TLS listener shared by the HTTP and gRPC servers
+type Options struct {
+	CertFile string
+	KeyFile  string
+	// ClientCAFile is a PEM bundle of the CAs trusted to issue client certificates
+	ClientCAFile string
+	// ClientAuth is none, optional or require; it defaults to require when ClientCAFile is set
+	ClientAuth string
+	// MinVersion is 1.2 (default) or 1.3
+	MinVersion string
+}
+
+// NewConfig creates a TLS configuration serving the certificate from the options, reloaded
+// whenever the files change, and offering HTTP/2. Close the returned reloader on shutdown.
+func NewConfig(options Options, logger *zap.Logger) (*tls.Config, *CertificateReloader, error) {
+	minVersion, err := ParseMinVersion(options.MinVersion)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	config := &tls.Config{
+		MinVersion: minVersion,
+		NextProtos: []string{"h2", "http/1.1"},
+	}
+	clientAuth := options.ClientAuth
+	if clientAuth == "" {
+		clientAuth = ClientAuthNone
+		if options.ClientCAFile != "" {
+			clientAuth = ClientAuthRequire
+		}
+	}
+	switch clientAuth {
+	case ClientAuthNone:
+	case ClientAuthOptional, ClientAuthRequire:
+		if options.ClientCAFile == "" {
+			return nil, nil, fmt.Errorf("client certificate authentication requires a CA bundle")
+		}
+		bundle, err := os.ReadFile(options.ClientCAFile)
+		if err != nil {
+			return nil, nil, err
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(bundle) {
+			return nil, nil, fmt.Errorf("no certificates found in %s", options.ClientCAFile)
+		}
+		config.ClientCAs = pool
+		config.ClientAuth = tls.VerifyClientCertIfGiven
+		if clientAuth == ClientAuthRequire {
+			config.ClientAuth = tls.RequireAndVerifyClientCert
+		}
+	default:
+		return nil, nil, fmt.Errorf("unsupported client authentication %q, use none, optional or require", clientAuth)
+	}
+
+	reloader, err := NewCertificateReloader(options.CertFile, options.KeyFile, logger)
+	if err != nil {
+		return nil, nil, err
+	}
+	config.GetCertificate = reloader.GetCertificate
+	return config, reloader, nil
+}
+
+// ParseMinVersion returns the TLS version for 1.2 or 1.3, defaulting to 1.2 when empty
+func ParseMinVersion(version string) (uint16, error) {
+	if version == "" {
+		return tls.VersionTLS12, nil
+	}
+	parsed, supported := versions[version]
+	if !supported {
+		return 0, fmt.Errorf("unsupported minimum TLS version %q, use 1.2 or 1.3", version)
+	}
+	return parsed, nil
+}
+
+// CertificateReloader serves a certificate and key pair, reloading it when either file changes.
+// A pair that fails to load is logged and the previous certificate stays in use.
+type CertificateReloader struct {
+	mutex       sync.RWMutex
+	certFile    string
+	keyFile     string
+	certificate *tls.Certificate
+	watcher     *fsnotify.Watcher
+	logger      *zap.Logger
+}
+
+// NewCertificateReloader loads the pair and watches the directories containing it
+func NewCertificateReloader(certFile, keyFile string, logger *zap.Logger) (*CertificateReloader, error) {
+	certificate, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, err
+	}
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+	// Directories are watched because certificate managers replace files by renaming or by
+	// swapping symlinks
+	for _, directory := range []string{filepath.Dir(certFile), filepath.Dir(keyFile)} {
+		if err := watcher.Add(directory); err != nil {
+			watcher.Close()
+			return nil, err
+		}
+	}
+
+	reloader := &CertificateReloader{
+		certFile:    certFile,
+		keyFile:     keyFile,
+		certificate: &certificate,
+		watcher:     watcher,
+		logger:      logger,
+	}
+	go reloader.watch()
+	return reloader, nil
+}
+
+// GetCertificate returns the current certificate; it is used as tls.Config.GetCertificate
+func (reloader *CertificateReloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	reloader.mutex.RLock()
+	defer reloader.mutex.RUnlock()
+	return reloader.certificate, nil
+}
+
+// Reload loads the pair again, returning whether the certificate changed
+func (reloader *CertificateReloader) Reload() (bool, error) {
+	certificate, err := tls.LoadX509KeyPair(reloader.certFile, reloader.keyFile)
+	if err != nil {
+		return false, err
+	}
+	reloader.mutex.Lock()
+	defer reloader.mutex.Unlock()
+	if bytes.Equal(certificate.Certificate[0], reloader.certificate.Certificate[0]) {
+		return false, nil
+	}
+	reloader.certificate = &certificate
+	return true, nil
+}
+
+// Close stops watching the files
+func (reloader *CertificateReloader) Close() error {
+	return reloader.watcher.Close()
+}
+
+func (reloader *CertificateReloader) watch() {
+	// Kubernetes mounts secrets through a ..data symlink that is swapped on update
+	names := map[string]bool{
+		filepath.Clean(reloader.certFile):                        true,
+		filepath.Clean(reloader.keyFile):                         true,
+		filepath.Join(filepath.Dir(reloader.certFile), "..data"): true,
+		filepath.Join(filepath.Dir(reloader.keyFile), "..data"):  true,
+	}
+	// Rotations write the certificate and key separately, so reloading waits for writes to settle
+	settle := time.NewTimer(time.Hour)
+	settle.Stop()
+	for {
+		select {
+		case event, ok := <-reloader.watcher.Events:
+			if !ok {
+				settle.Stop()
+				return
+			}
+			if names[filepath.Clean(event.Name)] {
+				settle.Reset(reloadDelay)
+			}
+		case <-settle.C:
+			changed, err := reloader.Reload()
+			if err != nil {
+				reloader.logger.Warn("TLS certificate reload failed, keeping previous certificate",
+					zap.String("cert_file", reloader.certFile), zap.Error(err))
+				continue
+			}
+			if changed {
+				reloader.logger.Info("TLS certificate reloaded", zap.String("cert_file", reloader.certFile))
+			}
+		case err, ok := <-reloader.watcher.Errors:
+			if !ok {
+				settle.Stop()
+				return
+			}
+			reloader.logger.Error("TLS certificate watch failed", zap.Error(err))
+		}
+	}
+}
@@ -0,0 +1,181 @@
+package tlsconfig_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.uber.org/zap"
+
+	"flight-itinerary-go/internal/tlsconfig"
+)
+
+func TestTLSConfig(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "TLS Config Suite")
+}
+
+// issue creates a certificate for the name signed by the parent, or self-signed without one
+func issue(name string, isCA bool, parent *x509.Certificate, parentKey *ecdsa.PrivateKey) (*x509.Certificate, *ecdsa.PrivateKey, tls.Certificate) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	Expect(err).ToNot(HaveOccurred())
+	serial, err := rand.Int(rand.Reader, big.NewInt(1<<62))
+	Expect(err).ToNot(HaveOccurred())
+	template := &x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{CommonName: name},
+		DNSNames:              []string{name},
+		NotBefore:             time.Now().Add(-time.Minute),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  isCA,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+	if parent == nil {
+		parent, parentKey = template, key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, parentKey)
+	Expect(err).ToNot(HaveOccurred())
+	certificate, err := x509.ParseCertificate(der)
+	Expect(err).ToNot(HaveOccurred())
+	return certificate, key, tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key, Leaf: certificate}
+}
+
+func writePair(directory string, certificate *x509.Certificate, key *ecdsa.PrivateKey) (string, string) {
+	certFile := filepath.Join(directory, "tls.crt")
+	keyFile := filepath.Join(directory, "tls.key")
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	Expect(err).ToNot(HaveOccurred())
+	Expect(os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certificate.Raw}), 0600)).To(Succeed())
+	Expect(os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0600)).To(Succeed())
+	return certFile, keyFile
+}
+
+var _ = Describe("TLS configuration", func() {
+	var (
+		directory        string
+		certFile         string
+		keyFile          string
+		caFile           string
+		ca               *x509.Certificate
+		caKey            *ecdsa.PrivateKey
+		serverCert       *x509.Certificate
+		clientCredential tls.Certificate
+	)
+
+	BeforeEach(func() {
+		directory = GinkgoT().TempDir()
+		ca, caKey, _ = issue("Test CA", true, nil, nil)
+		var serverKey *ecdsa.PrivateKey
+		serverCert, serverKey, _ = issue("localhost", false, ca, caKey)
+		certFile, keyFile = writePair(directory, serverCert, serverKey)
+		_, _, clientCredential = issue("billing", false, ca, caKey)
+		caFile = filepath.Join(directory, "ca.pem")
+		Expect(os.WriteFile(caFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ca.Raw}), 0600)).To(Succeed())
+	})
+
+	serve := func(config *tls.Config) *httptest.Server {
+		server := httptest.NewUnstartedServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+			subject := "-"
+			if len(request.TLS.VerifiedChains) > 0 {
+				subject = request.TLS.VerifiedChains[0][0].Subject.CommonName
+			}
+			writer.Header().Set("X-Client", subject)
+			writer.Header().Set("X-Protocol", request.Proto)
+		}))
+		server.TLS = config
+		server.EnableHTTP2 = true
+		server.StartTLS()
+		DeferCleanup(server.Close)
+		return server
+	}
+
+	client := func(certificates ...tls.Certificate) *http.Client {
+		roots := x509.NewCertPool()
+		roots.AddCert(ca)
+		return &http.Client{Transport: &http.Transport{
+			ForceAttemptHTTP2: true,
+			TLSClientConfig:   &tls.Config{RootCAs: roots, ServerName: "localhost", Certificates: certificates},
+		}}
+	}
+
+	It("should reject invalid options", func() {
+		_, _, err := tlsconfig.NewConfig(tlsconfig.Options{CertFile: certFile, KeyFile: keyFile, MinVersion: "1.1"}, zap.NewNop())
+		Expect(err).To(HaveOccurred())
+		_, _, err = tlsconfig.NewConfig(tlsconfig.Options{CertFile: certFile, KeyFile: keyFile, ClientAuth: "require"}, zap.NewNop())
+		Expect(err).To(HaveOccurred())
+		_, _, err = tlsconfig.NewConfig(tlsconfig.Options{CertFile: certFile, KeyFile: caFile}, zap.NewNop())
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("should serve HTTP/2 and identify clients by their certificates", func() {
+		config, reloader, err := tlsconfig.NewConfig(tlsconfig.Options{
+			CertFile: certFile, KeyFile: keyFile, ClientCAFile: caFile, ClientAuth: tlsconfig.ClientAuthOptional,
+		}, zap.NewNop())
+		Expect(err).ToNot(HaveOccurred())
+		DeferCleanup(reloader.Close)
+		server := serve(config)
+
+		response, err := client(clientCredential).Get(server.URL)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(response.Header.Get("X-Protocol")).To(Equal("HTTP/2.0"))
+		Expect(response.Header.Get("X-Client")).To(Equal("billing"))
+
+		response, err = client().Get(server.URL)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(response.Header.Get("X-Client")).To(Equal("-"))
+	})
+
+	It("should require client certificates from the CA bundle", func() {
+		config, reloader, err := tlsconfig.NewConfig(tlsconfig.Options{
+			CertFile: certFile, KeyFile: keyFile, ClientCAFile: caFile, MinVersion: "1.3",
+		}, zap.NewNop())
+		Expect(err).ToNot(HaveOccurred())
+		DeferCleanup(reloader.Close)
+		server := serve(config)
+
+		_, err = client().Get(server.URL)
+		Expect(err).To(HaveOccurred())
+		otherCA, otherKey, _ := issue("Other CA", true, nil, nil)
+		_, _, foreign := issue("intruder", false, otherCA, otherKey)
+		_, err = client(foreign).Get(server.URL)
+		Expect(err).To(HaveOccurred())
+		_, err = client(clientCredential).Get(server.URL)
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	It("should reload a replaced certificate and keep the previous one when the pair is invalid", func() {
+		reloader, err := tlsconfig.NewCertificateReloader(certFile, keyFile, zap.NewNop())
+		Expect(err).ToNot(HaveOccurred())
+		DeferCleanup(reloader.Close)
+		servedSerial := func() *big.Int {
+			certificate, err := reloader.GetCertificate(nil)
+			Expect(err).ToNot(HaveOccurred())
+			leaf, err := x509.ParseCertificate(certificate.Certificate[0])
+			Expect(err).ToNot(HaveOccurred())
+			return leaf.SerialNumber
+		}
+		Expect(servedSerial()).To(Equal(serverCert.SerialNumber))
+
+		rotated, rotatedKey, _ := issue("localhost", false, ca, caKey)
+		writePair(directory, rotated, rotatedKey)
+		Eventually(servedSerial).Should(Equal(rotated.SerialNumber))
+
+		Expect(os.WriteFile(keyFile, []byte("not a key"), 0600)).To(Succeed())
+		Consistently(servedSerial, 300*time.Millisecond).Should(Equal(rotated.SerialNumber))
+	})
+})
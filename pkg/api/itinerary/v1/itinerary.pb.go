@@ -0,0 +1,431 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.6
+// 	protoc        (unknown)
+// source: itinerary/v1/itinerary.proto
+
+package itineraryv1
+
+import (
+	status "google.golang.org/genproto/googleapis/rpc/status"
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// Ticket represents a flight ticket with source and destination airport codes
+type Ticket struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Source        string                 `protobuf:"bytes,1,opt,name=source,proto3" json:"source,omitempty"`
+	Destination   string                 `protobuf:"bytes,2,opt,name=destination,proto3" json:"destination,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Ticket) Reset() {
+	*x = Ticket{}
+	mi := &file_itinerary_v1_itinerary_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Ticket) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Ticket) ProtoMessage() {}
+
+func (x *Ticket) ProtoReflect() protoreflect.Message {
+	mi := &file_itinerary_v1_itinerary_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Ticket.ProtoReflect.Descriptor instead.
+func (*Ticket) Descriptor() ([]byte, []int) {
+	return file_itinerary_v1_itinerary_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Ticket) GetSource() string {
+	if x != nil {
+		return x.Source
+	}
+	return ""
+}
+
+func (x *Ticket) GetDestination() string {
+	if x != nil {
+		return x.Destination
+	}
+	return ""
+}
+
+type ReconstructRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Tickets       []*Ticket              `protobuf:"bytes,1,rep,name=tickets,proto3" json:"tickets,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ReconstructRequest) Reset() {
+	*x = ReconstructRequest{}
+	mi := &file_itinerary_v1_itinerary_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReconstructRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReconstructRequest) ProtoMessage() {}
+
+func (x *ReconstructRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_itinerary_v1_itinerary_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReconstructRequest.ProtoReflect.Descriptor instead.
+func (*ReconstructRequest) Descriptor() ([]byte, []int) {
+	return file_itinerary_v1_itinerary_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *ReconstructRequest) GetTickets() []*Ticket {
+	if x != nil {
+		return x.Tickets
+	}
+	return nil
+}
+
+type ReconstructResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Airport codes in travel order, from first departure to final destination
+	Itinerary     []string `protobuf:"bytes,1,rep,name=itinerary,proto3" json:"itinerary,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ReconstructResponse) Reset() {
+	*x = ReconstructResponse{}
+	mi := &file_itinerary_v1_itinerary_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReconstructResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReconstructResponse) ProtoMessage() {}
+
+func (x *ReconstructResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_itinerary_v1_itinerary_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReconstructResponse.ProtoReflect.Descriptor instead.
+func (*ReconstructResponse) Descriptor() ([]byte, []int) {
+	return file_itinerary_v1_itinerary_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *ReconstructResponse) GetItinerary() []string {
+	if x != nil {
+		return x.Itinerary
+	}
+	return nil
+}
+
+type BatchReconstructRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Requests      []*ReconstructRequest  `protobuf:"bytes,1,rep,name=requests,proto3" json:"requests,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BatchReconstructRequest) Reset() {
+	*x = BatchReconstructRequest{}
+	mi := &file_itinerary_v1_itinerary_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BatchReconstructRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BatchReconstructRequest) ProtoMessage() {}
+
+func (x *BatchReconstructRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_itinerary_v1_itinerary_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BatchReconstructRequest.ProtoReflect.Descriptor instead.
+func (*BatchReconstructRequest) Descriptor() ([]byte, []int) {
+	return file_itinerary_v1_itinerary_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *BatchReconstructRequest) GetRequests() []*ReconstructRequest {
+	if x != nil {
+		return x.Requests
+	}
+	return nil
+}
+
+type BatchReconstructResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Results in the same order as the requests
+	Results       []*BatchResult `protobuf:"bytes,1,rep,name=results,proto3" json:"results,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BatchReconstructResponse) Reset() {
+	*x = BatchReconstructResponse{}
+	mi := &file_itinerary_v1_itinerary_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BatchReconstructResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BatchReconstructResponse) ProtoMessage() {}
+
+func (x *BatchReconstructResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_itinerary_v1_itinerary_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BatchReconstructResponse.ProtoReflect.Descriptor instead.
+func (*BatchReconstructResponse) Descriptor() ([]byte, []int) {
+	return file_itinerary_v1_itinerary_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *BatchReconstructResponse) GetResults() []*BatchResult {
+	if x != nil {
+		return x.Results
+	}
+	return nil
+}
+
+type BatchResult struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Types that are valid to be assigned to Result:
+	//
+	//	*BatchResult_Itinerary
+	//	*BatchResult_Error
+	Result        isBatchResult_Result `protobuf_oneof:"result"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BatchResult) Reset() {
+	*x = BatchResult{}
+	mi := &file_itinerary_v1_itinerary_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BatchResult) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BatchResult) ProtoMessage() {}
+
+func (x *BatchResult) ProtoReflect() protoreflect.Message {
+	mi := &file_itinerary_v1_itinerary_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BatchResult.ProtoReflect.Descriptor instead.
+func (*BatchResult) Descriptor() ([]byte, []int) {
+	return file_itinerary_v1_itinerary_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *BatchResult) GetResult() isBatchResult_Result {
+	if x != nil {
+		return x.Result
+	}
+	return nil
+}
+
+func (x *BatchResult) GetItinerary() *ReconstructResponse {
+	if x != nil {
+		if x, ok := x.Result.(*BatchResult_Itinerary); ok {
+			return x.Itinerary
+		}
+	}
+	return nil
+}
+
+func (x *BatchResult) GetError() *status.Status {
+	if x != nil {
+		if x, ok := x.Result.(*BatchResult_Error); ok {
+			return x.Error
+		}
+	}
+	return nil
+}
+
+type isBatchResult_Result interface {
+	isBatchResult_Result()
+}
+
+type BatchResult_Itinerary struct {
+	Itinerary *ReconstructResponse `protobuf:"bytes,1,opt,name=itinerary,proto3,oneof"`
+}
+
+type BatchResult_Error struct {
+	// The same status the unary call would have failed with, including error details
+	Error *status.Status `protobuf:"bytes,2,opt,name=error,proto3,oneof"`
+}
+
+func (*BatchResult_Itinerary) isBatchResult_Result() {}
+
+func (*BatchResult_Error) isBatchResult_Result() {}
+
+var File_itinerary_v1_itinerary_proto protoreflect.FileDescriptor
+
+const file_itinerary_v1_itinerary_proto_rawDesc = "" +
+	"\n" +
+	"\x1citinerary/v1/itinerary.proto\x12\fitinerary.v1\x1a\x17google/rpc/status.proto\"B\n" +
+	"\x06Ticket\x12\x16\n" +
+	"\x06source\x18\x01 \x01(\tR\x06source\x12 \n" +
+	"\vdestination\x18\x02 \x01(\tR\vdestination\"D\n" +
+	"\x12ReconstructRequest\x12.\n" +
+	"\atickets\x18\x01 \x03(\v2\x14.itinerary.v1.TicketR\atickets\"3\n" +
+	"\x13ReconstructResponse\x12\x1c\n" +
+	"\titinerary\x18\x01 \x03(\tR\titinerary\"W\n" +
+	"\x17BatchReconstructRequest\x12<\n" +
+	"\brequests\x18\x01 \x03(\v2 .itinerary.v1.ReconstructRequestR\brequests\"O\n" +
+	"\x18BatchReconstructResponse\x123\n" +
+	"\aresults\x18\x01 \x03(\v2\x19.itinerary.v1.BatchResultR\aresults\"\x86\x01\n" +
+	"\vBatchResult\x12A\n" +
+	"\titinerary\x18\x01 \x01(\v2!.itinerary.v1.ReconstructResponseH\x00R\titinerary\x12*\n" +
+	"\x05error\x18\x02 \x01(\v2\x12.google.rpc.StatusH\x00R\x05errorB\b\n" +
+	"\x06result2\x99\x02\n" +
+	"\x10ItineraryService\x12R\n" +
+	"\vReconstruct\x12 .itinerary.v1.ReconstructRequest\x1a!.itinerary.v1.ReconstructResponse\x12N\n" +
+	"\x11ReconstructStream\x12\x14.itinerary.v1.Ticket\x1a!.itinerary.v1.ReconstructResponse(\x01\x12a\n" +
+	"\x10BatchReconstruct\x12%.itinerary.v1.BatchReconstructRequest\x1a&.itinerary.v1.BatchReconstructResponseB6Z4flight-itinerary-go/pkg/api/itinerary/v1;itineraryv1b\x06proto3"
+
+var (
+	file_itinerary_v1_itinerary_proto_rawDescOnce sync.Once
+	file_itinerary_v1_itinerary_proto_rawDescData []byte
+)
+
+func file_itinerary_v1_itinerary_proto_rawDescGZIP() []byte {
+	file_itinerary_v1_itinerary_proto_rawDescOnce.Do(func() {
+		file_itinerary_v1_itinerary_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_itinerary_v1_itinerary_proto_rawDesc), len(file_itinerary_v1_itinerary_proto_rawDesc)))
+	})
+	return file_itinerary_v1_itinerary_proto_rawDescData
+}
+
+var file_itinerary_v1_itinerary_proto_msgTypes = make([]protoimpl.MessageInfo, 6)
+var file_itinerary_v1_itinerary_proto_goTypes = []any{
+	(*Ticket)(nil),                   // 0: itinerary.v1.Ticket
+	(*ReconstructRequest)(nil),       // 1: itinerary.v1.ReconstructRequest
+	(*ReconstructResponse)(nil),      // 2: itinerary.v1.ReconstructResponse
+	(*BatchReconstructRequest)(nil),  // 3: itinerary.v1.BatchReconstructRequest
+	(*BatchReconstructResponse)(nil), // 4: itinerary.v1.BatchReconstructResponse
+	(*BatchResult)(nil),              // 5: itinerary.v1.BatchResult
+	(*status.Status)(nil),            // 6: google.rpc.Status
+}
+var file_itinerary_v1_itinerary_proto_depIdxs = []int32{
+	0, // 0: itinerary.v1.ReconstructRequest.tickets:type_name -> itinerary.v1.Ticket
+	1, // 1: itinerary.v1.BatchReconstructRequest.requests:type_name -> itinerary.v1.ReconstructRequest
+	5, // 2: itinerary.v1.BatchReconstructResponse.results:type_name -> itinerary.v1.BatchResult
+	2, // 3: itinerary.v1.BatchResult.itinerary:type_name -> itinerary.v1.ReconstructResponse
+	6, // 4: itinerary.v1.BatchResult.error:type_name -> google.rpc.Status
+	1, // 5: itinerary.v1.ItineraryService.Reconstruct:input_type -> itinerary.v1.ReconstructRequest
+	0, // 6: itinerary.v1.ItineraryService.ReconstructStream:input_type -> itinerary.v1.Ticket
+	3, // 7: itinerary.v1.ItineraryService.BatchReconstruct:input_type -> itinerary.v1.BatchReconstructRequest
+	2, // 8: itinerary.v1.ItineraryService.Reconstruct:output_type -> itinerary.v1.ReconstructResponse
+	2, // 9: itinerary.v1.ItineraryService.ReconstructStream:output_type -> itinerary.v1.ReconstructResponse
+	4, // 10: itinerary.v1.ItineraryService.BatchReconstruct:output_type -> itinerary.v1.BatchReconstructResponse
+	8, // [8:11] is the sub-list for method output_type
+	5, // [5:8] is the sub-list for method input_type
+	5, // [5:5] is the sub-list for extension type_name
+	5, // [5:5] is the sub-list for extension extendee
+	0, // [0:5] is the sub-list for field type_name
+}
+
+func init() { file_itinerary_v1_itinerary_proto_init() }
+func file_itinerary_v1_itinerary_proto_init() {
+	if File_itinerary_v1_itinerary_proto != nil {
+		return
+	}
+	file_itinerary_v1_itinerary_proto_msgTypes[5].OneofWrappers = []any{
+		(*BatchResult_Itinerary)(nil),
+		(*BatchResult_Error)(nil),
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_itinerary_v1_itinerary_proto_rawDesc), len(file_itinerary_v1_itinerary_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   6,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_itinerary_v1_itinerary_proto_goTypes,
+		DependencyIndexes: file_itinerary_v1_itinerary_proto_depIdxs,
+		MessageInfos:      file_itinerary_v1_itinerary_proto_msgTypes,
+	}.Build()
+	File_itinerary_v1_itinerary_proto = out.File
+	file_itinerary_v1_itinerary_proto_goTypes = nil
+	file_itinerary_v1_itinerary_proto_depIdxs = nil
+}
@@ -0,0 +1,203 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             (unknown)
+// source: itinerary/v1/itinerary.proto
+
+package itineraryv1
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	ItineraryService_Reconstruct_FullMethodName       = "/itinerary.v1.ItineraryService/Reconstruct"
+	ItineraryService_ReconstructStream_FullMethodName = "/itinerary.v1.ItineraryService/ReconstructStream"
+	ItineraryService_BatchReconstruct_FullMethodName  = "/itinerary.v1.ItineraryService/BatchReconstruct"
+)
+
+// ItineraryServiceClient is the client API for ItineraryService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// ItineraryService reconstructs travel itineraries from unordered flight tickets
+type ItineraryServiceClient interface {
+	// Reconstruct rebuilds a single itinerary from a list of tickets
+	Reconstruct(ctx context.Context, in *ReconstructRequest, opts ...grpc.CallOption) (*ReconstructResponse, error)
+	// ReconstructStream rebuilds a single itinerary from tickets streamed one at a time,
+	// for ticket sets too large to send in one message
+	ReconstructStream(ctx context.Context, opts ...grpc.CallOption) (grpc.ClientStreamingClient[Ticket, ReconstructResponse], error)
+	// BatchReconstruct rebuilds several independent itineraries, reporting failures per item
+	BatchReconstruct(ctx context.Context, in *BatchReconstructRequest, opts ...grpc.CallOption) (*BatchReconstructResponse, error)
+}
+
+type itineraryServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewItineraryServiceClient(cc grpc.ClientConnInterface) ItineraryServiceClient {
+	return &itineraryServiceClient{cc}
+}
+
+func (c *itineraryServiceClient) Reconstruct(ctx context.Context, in *ReconstructRequest, opts ...grpc.CallOption) (*ReconstructResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ReconstructResponse)
+	err := c.cc.Invoke(ctx, ItineraryService_Reconstruct_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *itineraryServiceClient) ReconstructStream(ctx context.Context, opts ...grpc.CallOption) (grpc.ClientStreamingClient[Ticket, ReconstructResponse], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &ItineraryService_ServiceDesc.Streams[0], ItineraryService_ReconstructStream_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[Ticket, ReconstructResponse]{ClientStream: stream}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type ItineraryService_ReconstructStreamClient = grpc.ClientStreamingClient[Ticket, ReconstructResponse]
+
+func (c *itineraryServiceClient) BatchReconstruct(ctx context.Context, in *BatchReconstructRequest, opts ...grpc.CallOption) (*BatchReconstructResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(BatchReconstructResponse)
+	err := c.cc.Invoke(ctx, ItineraryService_BatchReconstruct_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// ItineraryServiceServer is the server API for ItineraryService service.
+// All implementations must embed UnimplementedItineraryServiceServer
+// for forward compatibility.
+//
+// ItineraryService reconstructs travel itineraries from unordered flight tickets
+type ItineraryServiceServer interface {
+	// Reconstruct rebuilds a single itinerary from a list of tickets
+	Reconstruct(context.Context, *ReconstructRequest) (*ReconstructResponse, error)
+	// ReconstructStream rebuilds a single itinerary from tickets streamed one at a time,
+	// for ticket sets too large to send in one message
+	ReconstructStream(grpc.ClientStreamingServer[Ticket, ReconstructResponse]) error
+	// BatchReconstruct rebuilds several independent itineraries, reporting failures per item
+	BatchReconstruct(context.Context, *BatchReconstructRequest) (*BatchReconstructResponse, error)
+	mustEmbedUnimplementedItineraryServiceServer()
+}
+
+// UnimplementedItineraryServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedItineraryServiceServer struct{}
+
+func (UnimplementedItineraryServiceServer) Reconstruct(context.Context, *ReconstructRequest) (*ReconstructResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Reconstruct not implemented")
+}
+func (UnimplementedItineraryServiceServer) ReconstructStream(grpc.ClientStreamingServer[Ticket, ReconstructResponse]) error {
+	return status.Errorf(codes.Unimplemented, "method ReconstructStream not implemented")
+}
+func (UnimplementedItineraryServiceServer) BatchReconstruct(context.Context, *BatchReconstructRequest) (*BatchReconstructResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method BatchReconstruct not implemented")
+}
+func (UnimplementedItineraryServiceServer) mustEmbedUnimplementedItineraryServiceServer() {}
+func (UnimplementedItineraryServiceServer) testEmbeddedByValue()                          {}
+
+// UnsafeItineraryServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to ItineraryServiceServer will
+// result in compilation errors.
+type UnsafeItineraryServiceServer interface {
+	mustEmbedUnimplementedItineraryServiceServer()
+}
+
+func RegisterItineraryServiceServer(s grpc.ServiceRegistrar, srv ItineraryServiceServer) {
+	// If the following call pancis, it indicates UnimplementedItineraryServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&ItineraryService_ServiceDesc, srv)
+}
+
+func _ItineraryService_Reconstruct_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ReconstructRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ItineraryServiceServer).Reconstruct(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ItineraryService_Reconstruct_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ItineraryServiceServer).Reconstruct(ctx, req.(*ReconstructRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ItineraryService_ReconstructStream_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(ItineraryServiceServer).ReconstructStream(&grpc.GenericServerStream[Ticket, ReconstructResponse]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type ItineraryService_ReconstructStreamServer = grpc.ClientStreamingServer[Ticket, ReconstructResponse]
+
+func _ItineraryService_BatchReconstruct_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BatchReconstructRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ItineraryServiceServer).BatchReconstruct(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ItineraryService_BatchReconstruct_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ItineraryServiceServer).BatchReconstruct(ctx, req.(*BatchReconstructRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// ItineraryService_ServiceDesc is the grpc.ServiceDesc for ItineraryService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var ItineraryService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "itinerary.v1.ItineraryService",
+	HandlerType: (*ItineraryServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Reconstruct",
+			Handler:    _ItineraryService_Reconstruct_Handler,
+		},
+		{
+			MethodName: "BatchReconstruct",
+			Handler:    _ItineraryService_BatchReconstruct_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "ReconstructStream",
+			Handler:       _ItineraryService_ReconstructStream_Handler,
+			ClientStreams: true,
+		},
+	},
+	Metadata: "itinerary/v1/itinerary.proto",
+}
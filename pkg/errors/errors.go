@@ -7,10 +7,13 @@ import (
 
 // Custom error types
 var (
-	ErrNoStartingPoint   = NewBusinessError("no valid starting point found")
-	ErrCircularRoute     = NewBusinessError("circular route detected")
-	ErrDisconnectedRoute = NewBusinessError("disconnected route found")
-	ErrInvalidTicket     = NewBusinessError("invalid ticket: source and destination cannot be empty")
+	ErrNoStartingPoint    = NewBusinessError("no valid starting point found")
+	ErrCircularRoute      = NewBusinessError("circular route detected")
+	ErrDisconnectedRoute  = NewBusinessError("disconnected route found")
+	ErrInvalidTicket      = NewBusinessError("invalid ticket: source and destination cannot be empty")
+	ErrItineraryNotFound  = NewNotFoundError("itinerary not found")
+	ErrVersionConflict    = NewConflictError("itinerary was modified by another request")
+	ErrPreconditionFailed = NewPreconditionFailedError("itinerary has changed since it was read")
 )
 
 // AppError represents application-specific errors
@@ -50,3 +53,75 @@ func NewInternalError(message string) *AppError {
 		Type:    "internal_error",
 	}
 }
+
+// NewNotAcceptableError creates a new error for unsupported response formats
+func NewNotAcceptableError(format string, args ...interface{}) *AppError {
+	return &AppError{
+		Code:    http.StatusNotAcceptable,
+		Message: fmt.Sprintf(format, args...),
+		Type:    "not_acceptable",
+	}
+}
+
+// NewNotFoundError creates a new error for missing resources
+func NewNotFoundError(message string) *AppError {
+	return &AppError{
+		Code:    http.StatusNotFound,
+		Message: message,
+		Type:    "not_found",
+	}
+}
+
+// NewConflictError creates a new error for requests conflicting with the current resource state
+func NewConflictError(message string) *AppError {
+	return &AppError{
+		Code:    http.StatusConflict,
+		Message: message,
+		Type:    "conflict",
+	}
+}
+
+// NewUnprocessableError creates a new error for well-formed requests that cannot be processed
+func NewUnprocessableError(message string) *AppError {
+	return &AppError{
+		Code:    http.StatusUnprocessableEntity,
+		Message: message,
+		Type:    "unprocessable",
+	}
+}
+
+// NewPreconditionFailedError creates a new error for failed conditional requests
+func NewPreconditionFailedError(message string) *AppError {
+	return &AppError{
+		Code:    http.StatusPreconditionFailed,
+		Message: message,
+		Type:    "precondition_failed",
+	}
+}
+
+// NewUnauthorizedError creates a new error for requests without valid credentials
+func NewUnauthorizedError(message string) *AppError {
+	return &AppError{
+		Code:    http.StatusUnauthorized,
+		Message: message,
+		Type:    "unauthorized",
+	}
+}
+
+// NewForbiddenError creates a new error for callers lacking a required permission
+func NewForbiddenError(format string, args ...interface{}) *AppError {
+	return &AppError{
+		Code:    http.StatusForbidden,
+		Message: fmt.Sprintf(format, args...),
+		Type:    "forbidden",
+	}
+}
+
+// NewTooManyRequestsError creates a new error for callers exceeding a rate limit or quota
+func NewTooManyRequestsError(format string, args ...interface{}) *AppError {
+	return &AppError{
+		Code:    http.StatusTooManyRequests,
+		Message: fmt.Sprintf(format, args...),
+		Type:    "rate_limited",
+	}
+}